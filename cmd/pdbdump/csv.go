@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jtang613/gopdb/pkg/pdb"
+)
+
+// csvSections lists the result keys written in CSV mode, in output order.
+var csvSections = []string{"info", "modules", "functions", "variables", "types", "public_symbols", "lines", "streams"}
+
+// writeCSV writes each listing of result as a CSV table with a header row.
+// Tables are separated by a blank line.
+func writeCSV(out io.Writer, result map[string]interface{}) error {
+	first := true
+	for _, key := range csvSections {
+		v, ok := result[key]
+		if !ok {
+			continue
+		}
+
+		if !first {
+			if _, err := io.WriteString(out, "\n"); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		w := csv.NewWriter(out)
+		if err := w.WriteAll(csvRows(v)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// csvRows converts a listing into CSV rows, starting with the header.
+// Types are flattened to one row per member.
+func csvRows(v interface{}) [][]string {
+	hex := func(n uint32) string { return fmt.Sprintf("0x%x", n) }
+	itoa := func(n uint64) string { return strconv.FormatUint(n, 10) }
+
+	switch v := v.(type) {
+	case *pdb.PDBInfo:
+		return [][]string{
+			{"guid", "age", "version", "machine", "toolset", "streams"},
+			{v.GUID, itoa(uint64(v.Age)), itoa(uint64(v.Version)), v.Machine, v.Toolset, strconv.Itoa(v.Streams)},
+		}
+
+	case []pdb.ModuleInfo:
+		rows := [][]string{{"name", "object_file", "symbol_stream", "symbol_size", "source_files"}}
+		for _, mod := range v {
+			rows = append(rows, []string{mod.Name, mod.ObjectFile, itoa(uint64(mod.SymbolStream)), itoa(uint64(mod.SymbolSize)), itoa(uint64(mod.SourceFiles))})
+		}
+		return rows
+
+	case []pdb.Function:
+		rows := [][]string{{"name", "demangled", "rva", "length", "module", "signature"}}
+		for _, fn := range v {
+			rows = append(rows, []string{fn.Name, fn.DemangledName, hex(fn.RVA), itoa(uint64(fn.Length)), fn.Module, fn.Signature})
+		}
+		return rows
+
+	case []pdb.Variable:
+		rows := [][]string{{"name", "demangled", "rva", "type", "module"}}
+		for _, va := range v {
+			rows = append(rows, []string{va.Name, va.DemangledName, hex(va.RVA), va.TypeName, va.Module})
+		}
+		return rows
+
+	case []pdb.TypeInfo:
+		rows := [][]string{{"index", "kind", "name", "size", "member", "member_type", "member_offset"}}
+		for _, ti := range v {
+			base := []string{hex(ti.Index), ti.Kind, ti.Name, itoa(ti.Size)}
+			if len(ti.Members) == 0 {
+				rows = append(rows, append(base, "", "", ""))
+				continue
+			}
+			for _, m := range ti.Members {
+				row := append(append([]string(nil), base...), m.Name, m.TypeName, itoa(m.Offset))
+				rows = append(rows, row)
+			}
+		}
+		return rows
+
+	case []pdb.PublicSymbol:
+		rows := [][]string{{"name", "demangled", "rva", "is_function"}}
+		for _, pub := range v {
+			rows = append(rows, []string{pub.Name, pub.DemangledName, hex(pub.RVA), strconv.FormatBool(pub.IsFunction)})
+		}
+		return rows
+
+	case []pdb.LineInfo:
+		rows := [][]string{{"rva", "file", "line", "module"}}
+		for _, line := range v {
+			rows = append(rows, []string{hex(line.RVA), line.FileName, itoa(uint64(line.LineNumber)), line.Module})
+		}
+		return rows
+
+	case []pdb.StreamInfo:
+		rows := [][]string{{"index", "size", "blocks", "name", "unused"}}
+		for _, st := range v {
+			rows = append(rows, []string{strconv.Itoa(st.Index), itoa(uint64(st.Size)), strconv.Itoa(st.Blocks), st.Name, strconv.FormatBool(st.Unused)})
+		}
+		return rows
+	}
+
+	return nil
+}
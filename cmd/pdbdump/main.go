@@ -6,8 +6,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/jtang613/gopdb/pkg/pdb"
+	"github.com/jtang613/gopdb/pkg/pdb/msf"
 )
 
 func main() {
@@ -18,9 +25,22 @@ func main() {
 	showTypes := flag.Bool("types", false, "List all named types")
 	showPublics := flag.Bool("publics", false, "List all public symbols")
 	showModules := flag.Bool("modules", false, "List all modules")
+	showLines := flag.Bool("lines", false, "List all line-number entries")
+	showStreams := flag.Bool("streams", false, "List the MSF streams with their sizes and block counts")
 	showAll := flag.Bool("all", false, "Show all information")
 	prettyPrint := flag.Bool("pretty", false, "Pretty-print JSON output")
+	csvOutput := flag.Bool("csv", false, "Write listings as CSV instead of JSON")
+	stream := flag.Bool("stream", false, "Write JSON listings incrementally instead of building them in memory first")
 	typeIndex := flag.Uint("type", 0, "Show details for a specific type index")
+	addr := flag.String("addr", "", "Show the function and source line at an RVA (hex or decimal)")
+	filter := flag.String("filter", "", "Only list entries whose name matches the regex")
+	lazyTypes := flag.Bool("lazy", false, "Read type records on demand (faster -type lookups in large PDBs)")
+	strict := flag.Bool("strict", false, "Reject PDBs whose MSF block layout is inconsistent")
+	rawTypes := flag.Bool("raw", false, "Include the undecoded record (raw_kind, base64 raw) of each type")
+	extractDir := flag.String("extract-sources", "", "Write the source files embedded in the PDB to this directory")
+	dumpStream := flag.String("dump-stream", "", "Write the raw contents of a stream, by index or name (e.g. /names), to -o")
+	outFile := flag.String("o", "", "Output file for -dump-stream (default stdout)")
+	printSchema := flag.Bool("schema", false, "Print the JSON Schema of the output and exit")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] <pdb-file>\n\n", os.Args[0])
@@ -31,25 +51,72 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -functions -pretty file.pdb\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -all file.pdb\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -type 0x1000 file.pdb\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -addr 0x1234 file.pdb\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -functions -filter 'MyClass::' file.pdb\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -functions -csv file.pdb > functions.csv\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -functions -stream file.pdb | jq .\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -extract-sources ./src file.pdb\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -dump-stream /names -o names.bin file.pdb\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -schema > pdbdump.schema.json\n", os.Args[0])
 	}
 
 	flag.Parse()
 
+	if *printSchema {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(outputSchema()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if flag.NArg() < 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	if *stream && (*prettyPrint || *csvOutput) {
+		fmt.Fprintf(os.Stderr, "-stream cannot be combined with -pretty or -csv\n")
+		os.Exit(1)
+	}
+
 	pdbPath := flag.Arg(0)
 
+	var filterRe *regexp.Regexp
+	if *filter != "" {
+		re, err := regexp.Compile(*filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid filter: %v\n", err)
+			os.Exit(1)
+		}
+		filterRe = re
+	}
+
 	// Open PDB
-	p, err := pdb.Open(pdbPath)
+	var opts []pdb.Option
+	if *lazyTypes {
+		opts = append(opts, pdb.WithLazyTypes())
+	}
+	if *strict {
+		opts = append(opts, pdb.WithMSFValidation())
+	}
+	if *rawTypes {
+		opts = append(opts, pdb.WithRawTypes())
+	}
+	p, err := pdb.Open(pdbPath, opts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening PDB: %v\n", err)
 		os.Exit(1)
 	}
 	defer p.Close()
 
+	for _, w := range p.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", w)
+	}
+
 	// Helper for JSON output
 	outputJSON := func(v interface{}) {
 		encoder := json.NewEncoder(os.Stdout)
@@ -63,6 +130,14 @@ func main() {
 		}
 	}
 
+	// Helper for CSV output
+	outputCSV := func(result map[string]interface{}) {
+		if err := writeCSV(os.Stdout, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Handle type lookup
 	if *typeIndex > 0 {
 		ti := p.ResolveType(uint32(*typeIndex))
@@ -70,41 +145,255 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Type 0x%x not found\n", *typeIndex)
 			os.Exit(1)
 		}
-		outputJSON(ti)
+		if *csvOutput {
+			outputCSV(map[string]interface{}{"types": []pdb.TypeInfo{*ti}})
+		} else {
+			outputJSON(ti)
+		}
+		return
+	}
+
+	// Handle source extraction
+	if *extractDir != "" {
+		n, err := extractSources(p, *extractDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error extracting sources: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Extracted %d source files to %s\n", n, *extractDir)
+		return
+	}
+
+	// Handle raw stream dumps
+	if *dumpStream != "" {
+		n, err := dumpRawStream(p, *dumpStream, *outFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error dumping stream: %v\n", err)
+			os.Exit(1)
+		}
+		if *outFile != "" && *outFile != "-" {
+			fmt.Fprintf(os.Stderr, "Wrote %d bytes of stream %s to %s\n", n, *dumpStream, *outFile)
+		}
+		return
+	}
+
+	// Handle address lookup
+	if *addr != "" {
+		rva, err := strconv.ParseUint(*addr, 0, 32)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid address %q: %v\n", *addr, err)
+			os.Exit(1)
+		}
+		loc := lookupAddr(p, uint32(rva))
+		if *prettyPrint {
+			outputJSON(loc)
+		} else {
+			fmt.Println(loc)
+		}
 		return
 	}
 
 	// Default to showing info if no flags specified
-	if !*showInfo && !*showFunctions && !*showVariables && !*showTypes && !*showPublics && !*showModules && !*showAll {
+	if !*showInfo && !*showFunctions && !*showVariables && !*showTypes && !*showPublics && !*showModules && !*showLines && !*showStreams && !*showAll {
 		*showInfo = true
 	}
 
+	if *stream {
+		sections := map[string]bool{
+			"info":           *showInfo || *showAll,
+			"modules":        *showModules || *showAll,
+			"functions":      *showFunctions || *showAll,
+			"variables":      *showVariables || *showAll,
+			"types":          *showTypes || *showAll,
+			"public_symbols": *showPublics || *showAll,
+			"lines":          *showLines || *showAll,
+			"streams":        *showStreams || *showAll,
+		}
+		if err := writeStream(os.Stdout, p, sections, filterRe); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Build output
-	result := make(map[string]interface{})
+	result := map[string]interface{}{"schema_version": schemaVersion}
 
 	if *showInfo || *showAll {
 		result["info"] = p.Info()
 	}
 
 	if *showModules || *showAll {
-		result["modules"] = p.Modules()
+		modules := p.Modules()
+		if filterRe != nil {
+			matches := make([]pdb.ModuleInfo, 0)
+			for _, mod := range modules {
+				if filterRe.MatchString(mod.Name) {
+					matches = append(matches, mod)
+				}
+			}
+			modules = matches
+		}
+		result["modules"] = modules
 	}
 
 	if *showFunctions || *showAll {
-		result["functions"] = p.Functions()
+		if filterRe != nil {
+			result["functions"], _ = p.FindFunctions(*filter)
+		} else {
+			result["functions"] = p.Functions()
+		}
 	}
 
 	if *showVariables || *showAll {
-		result["variables"] = p.Variables()
+		if filterRe != nil {
+			result["variables"], _ = p.FindVariables(*filter)
+		} else {
+			result["variables"] = p.Variables()
+		}
 	}
 
 	if *showTypes || *showAll {
-		result["types"] = p.Types()
+		types := p.Types()
+		if filterRe != nil {
+			matches := make([]pdb.TypeInfo, 0)
+			for _, ti := range types {
+				if filterRe.MatchString(ti.Name) {
+					matches = append(matches, ti)
+				}
+			}
+			types = matches
+		}
+		result["types"] = types
 	}
 
 	if *showPublics || *showAll {
-		result["public_symbols"] = p.PublicSymbols()
+		if filterRe != nil {
+			result["public_symbols"], _ = p.FindPublicSymbols(*filter)
+		} else {
+			result["public_symbols"] = p.PublicSymbols()
+		}
+	}
+
+	if *showLines || *showAll {
+		lines := p.Lines()
+		if filterRe != nil {
+			matches := make([]pdb.LineInfo, 0)
+			for _, line := range lines {
+				if filterRe.MatchString(line.FileName) {
+					matches = append(matches, line)
+				}
+			}
+			lines = matches
+		}
+		result["lines"] = lines
+	}
+
+	if *showStreams || *showAll {
+		result["streams"] = p.Streams()
 	}
 
+	if *csvOutput {
+		outputCSV(result)
+		return
+	}
 	outputJSON(result)
 }
+
+// addrLocation is the result of an address lookup.
+type addrLocation struct {
+	RVA      uint32 `json:"rva"`
+	Function string `json:"function,omitempty"`
+	Offset   uint32 `json:"offset"` // Offset from the function start (or its separated code range)
+	FileName string `json:"file_name,omitempty"`
+	Line     uint32 `json:"line_number,omitempty"`
+}
+
+// lookupAddr resolves an RVA to its enclosing function and source line.
+func lookupAddr(p *pdb.PDB, rva uint32) addrLocation {
+	loc := addrLocation{RVA: rva}
+	if fn := p.SymbolAtRVA(rva); fn != nil {
+		loc.Function = fn.Name
+		if fn.DemangledName != "" {
+			loc.Function = fn.DemangledName
+		}
+		loc.Offset = rva - fn.RVA
+		if sep := p.SeparatedCodeAtRVA(rva); sep != nil && sep.ParentRVA == fn.RVA {
+			loc.Offset = rva - sep.RVA
+		}
+	}
+	if line := p.LineAtRVA(rva); line != nil {
+		loc.FileName = line.FileName
+		loc.Line = line.LineNumber
+	}
+	return loc
+}
+
+// String formats the location like addr2line: "func at file:line (+offset)".
+// Unknown parts are left empty.
+func (l addrLocation) String() string {
+	line := ""
+	if l.Line > 0 {
+		line = strconv.FormatUint(uint64(l.Line), 10)
+	}
+	return fmt.Sprintf("%s at %s:%s (+0x%x)", l.Function, l.FileName, line, l.Offset)
+}
+
+// extractSources writes the embedded source files of p below dir and
+// returns how many were written. Names are made relative to dir, so a
+// path such as "c:\src\a.cpp" is written to dir/src/a.cpp.
+func extractSources(p *pdb.PDB, dir string) (int, error) {
+	count := 0
+	for _, src := range p.EmbeddedSources() {
+		name := strings.ReplaceAll(src.Name, "\\", "/")
+		if len(name) >= 2 && name[1] == ':' {
+			name = name[2:]
+		}
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if name == "" {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return count, err
+		}
+		if err := os.WriteFile(target, src.Content, 0o644); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// dumpRawStream writes the contents of the stream named by spec, either a
+// stream index or a named stream such as "/names", to the file out, or to
+// stdout if out is empty or "-". It returns the number of bytes written.
+func dumpRawStream(p *pdb.PDB, spec, out string) (int, error) {
+	var stream *msf.Stream
+	if index, err := strconv.ParseInt(spec, 0, 32); err == nil {
+		stream, err = p.Stream(int(index))
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		var ok bool
+		if stream, ok = p.NamedStream(spec); !ok {
+			names := make([]string, 0)
+			for name := range p.Info().NamedStreams {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return 0, fmt.Errorf("no stream named %q (named streams: %s)", spec, strings.Join(names, ", "))
+		}
+	}
+
+	data, err := stream.ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stream %s: %w", spec, err)
+	}
+	if out == "" || out == "-" {
+		return os.Stdout.Write(data)
+	}
+	return len(data), os.WriteFile(out, data, 0o644)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jtang613/gopdb/pkg/pdb"
+)
+
+// schemaVersion is written to the JSON output as "schema_version". It is
+// bumped whenever a field is removed, renamed or changes type; adding a
+// field keeps the version.
+const schemaVersion = 1
+
+// outputSections maps the keys of the JSON output to the values stored
+// under them.
+var outputSections = map[string]reflect.Type{
+	"info":           reflect.TypeOf(&pdb.PDBInfo{}),
+	"modules":        reflect.TypeOf([]pdb.ModuleInfo{}),
+	"functions":      reflect.TypeOf([]pdb.Function{}),
+	"variables":      reflect.TypeOf([]pdb.Variable{}),
+	"types":          reflect.TypeOf([]pdb.TypeInfo{}),
+	"public_symbols": reflect.TypeOf([]pdb.PublicSymbol{}),
+	"lines":          reflect.TypeOf([]pdb.LineInfo{}),
+	"streams":        reflect.TypeOf([]pdb.StreamInfo{}),
+}
+
+// outputSchema returns a JSON Schema for the JSON output, generated from
+// the struct tags of the pdb types. Structs are described once under
+// "$defs", which also covers the single TypeInfo printed by -type.
+func outputSchema() map[string]interface{} {
+	g := &schemaGen{defs: make(map[string]interface{})}
+
+	props := map[string]interface{}{
+		"schema_version": map[string]interface{}{"type": "integer", "const": schemaVersion},
+	}
+	for key, t := range outputSections {
+		props[key] = g.schema(t)
+	}
+	g.schema(reflect.TypeOf(pdb.TypeInfo{}))
+
+	return map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      "pdbdump output",
+		"type":       "object",
+		"properties": props,
+		"required":   []string{"schema_version"},
+		"$defs":      g.defs,
+	}
+}
+
+// schemaGen builds JSON Schemas for Go types, collecting struct
+// definitions by type name.
+type schemaGen struct {
+	defs map[string]interface{}
+}
+
+// schema returns the schema of values of type t as encoding/json writes
+// them.
+func (g *schemaGen) schema(t reflect.Type) interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{
+			"anyOf": []interface{}{g.schema(t.Elem()), map[string]interface{}{"type": "null"}},
+		}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // Reserve the name for recursive types
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": g.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type": "array", "items": g.schema(t.Elem()),
+			"minItems": t.Len(), "maxItems": t.Len(),
+		}
+	case reflect.Map:
+		return map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct as an object whose properties are its
+// exported fields. Fields without omitempty are always present; other
+// properties are allowed, since fields are added without a version bump.
+func (g *schemaGen) structSchema(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+
+	"github.com/jtang613/gopdb/pkg/pdb"
+)
+
+// streamWriter writes the output object of -stream. Listings are written
+// as JSON arrays one element at a time, so output starts at once and memory
+// does not grow with the size of the listing. Write errors are sticky: once
+// one occurs, later writes are dropped and close reports it.
+type streamWriter struct {
+	w     *bufio.Writer
+	buf   bytes.Buffer
+	enc   *json.Encoder
+	keys  int   // Keys written so far
+	elems int   // Elements written to the open array
+	err   error // First encoding or write error
+}
+
+func newStreamWriter(w io.Writer) *streamWriter {
+	s := &streamWriter{w: bufio.NewWriter(w)}
+	s.enc = json.NewEncoder(&s.buf)
+	s.enc.SetEscapeHTML(false) // Match the non-streaming output
+	return s
+}
+
+func (s *streamWriter) write(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+	}
+}
+
+// encode writes v as compact JSON, without the newline json.Encoder
+// appends to each value.
+func (s *streamWriter) encode(v interface{}) {
+	if s.err != nil {
+		return
+	}
+	s.buf.Reset()
+	if s.err = s.enc.Encode(v); s.err == nil {
+		s.write(bytes.TrimSuffix(s.buf.Bytes(), []byte("\n")))
+	}
+}
+
+// key starts the member name of the output object.
+func (s *streamWriter) key(name string) {
+	if s.keys == 0 {
+		s.write([]byte("{"))
+	} else {
+		s.write([]byte(","))
+	}
+	s.keys++
+	s.encode(name)
+	s.write([]byte(":"))
+}
+
+// value writes a whole member of the output object.
+func (s *streamWriter) value(name string, v interface{}) {
+	s.key(name)
+	s.encode(v)
+}
+
+// beginArray opens an array member; elements follow with element.
+func (s *streamWriter) beginArray(name string) {
+	s.key(name)
+	s.write([]byte("["))
+	s.elems = 0
+}
+
+// element writes one element of the open array. It returns the sticky
+// error so that walks can stop once output fails.
+func (s *streamWriter) element(v interface{}) error {
+	if s.elems > 0 {
+		s.write([]byte(","))
+	}
+	s.elems++
+	s.encode(v)
+	return s.err
+}
+
+func (s *streamWriter) endArray() {
+	s.write([]byte("]"))
+}
+
+// close ends the output object and flushes it.
+func (s *streamWriter) close() error {
+	if s.keys == 0 {
+		s.write([]byte("{"))
+	}
+	s.write([]byte("}\n"))
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	return s.err
+}
+
+// writeStream writes the sections of p selected by key to w as one JSON
+// object, like the non-streaming output. Functions and variables are
+// walked from the symbol streams instead of being loaded first. Keys are
+// written in the order encoding/json sorts map keys, so for non-empty
+// listings the output is the same byte for byte.
+func writeStream(w io.Writer, p *pdb.PDB, sections map[string]bool, filterRe *regexp.Regexp) error {
+	s := newStreamWriter(w)
+
+	if sections["functions"] {
+		s.beginArray("functions")
+		p.WalkFunctions(func(fn *pdb.Function) error {
+			if filterRe != nil && !matchName(filterRe, fn.Name, fn.DemangledName) {
+				return nil
+			}
+			return s.element(fn)
+		})
+		s.endArray()
+	}
+
+	if sections["info"] {
+		s.value("info", p.Info())
+	}
+
+	if sections["lines"] {
+		s.beginArray("lines")
+		for i := range p.Modules() {
+			for _, line := range p.LinesForModule(i) {
+				if filterRe != nil && !filterRe.MatchString(line.FileName) {
+					continue
+				}
+				s.element(line)
+			}
+		}
+		s.endArray()
+	}
+
+	if sections["modules"] {
+		s.beginArray("modules")
+		for _, mod := range p.Modules() {
+			if filterRe != nil && !filterRe.MatchString(mod.Name) {
+				continue
+			}
+			s.element(mod)
+		}
+		s.endArray()
+	}
+
+	if sections["public_symbols"] {
+		s.beginArray("public_symbols")
+		for _, pub := range p.PublicSymbols() {
+			if filterRe != nil && !matchName(filterRe, pub.Name, pub.DemangledName) {
+				continue
+			}
+			s.element(pub)
+		}
+		s.endArray()
+	}
+
+	s.value("schema_version", schemaVersion)
+
+	if sections["streams"] {
+		s.beginArray("streams")
+		for _, st := range p.Streams() {
+			s.element(st)
+		}
+		s.endArray()
+	}
+
+	if sections["types"] {
+		s.beginArray("types")
+		for _, ti := range p.Types() {
+			if filterRe != nil && !filterRe.MatchString(ti.Name) {
+				continue
+			}
+			s.element(ti)
+		}
+		s.endArray()
+	}
+
+	if sections["variables"] {
+		s.beginArray("variables")
+		p.WalkVariables(func(v *pdb.Variable) error {
+			if filterRe != nil && !matchName(filterRe, v.Name, v.DemangledName) {
+				return nil
+			}
+			return s.element(v)
+		})
+		s.endArray()
+	}
+
+	return s.close()
+}
+
+// matchName reports whether re matches a symbol's name or its demangled
+// name, as the pdb package's Find functions do.
+func matchName(re *regexp.Regexp, name, demangled string) bool {
+	return re.MatchString(name) || (demangled != "" && re.MatchString(demangled))
+}
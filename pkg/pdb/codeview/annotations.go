@@ -0,0 +1,166 @@
+package codeview
+
+import (
+	"fmt"
+)
+
+// Binary annotation opcodes (BinaryAnnotationOpcode) used by S_INLINESITE
+const (
+	BA_OP_Invalid                       = 0
+	BA_OP_CodeOffset                    = 1
+	BA_OP_ChangeCodeOffsetBase          = 2
+	BA_OP_ChangeCodeOffset              = 3
+	BA_OP_ChangeCodeLength              = 4
+	BA_OP_ChangeFile                    = 5
+	BA_OP_ChangeLineOffset              = 6
+	BA_OP_ChangeLineEndDelta            = 7
+	BA_OP_ChangeRangeKind               = 8
+	BA_OP_ChangeColumnStart             = 9
+	BA_OP_ChangeColumnEndDelta          = 10
+	BA_OP_ChangeCodeOffsetAndLineOffset = 11
+	BA_OP_ChangeCodeLengthAndCodeOffset = 12
+	BA_OP_ChangeColumnEnd               = 13
+)
+
+// BinaryAnnotation is a single decoded binary annotation.
+type BinaryAnnotation struct {
+	Opcode   uint32
+	Operands []uint32
+}
+
+// InlineLineEntry maps a range of inlined code to a source line.
+type InlineLineEntry struct {
+	CodeOffset uint32 // Offset from the start of the enclosing procedure
+	Length     uint32 // Code length (0 if unknown)
+	FileID     uint32 // Offset into the DEBUG_S_FILECHECKSUMS subsection
+	LineNumber uint32 // Source line number
+}
+
+// DecodeCompressedUint decodes a CodeView compressed unsigned integer
+// (CVUncompressData) and returns the value and the number of bytes used.
+// Values take 1, 2, or 4 bytes depending on the high bits of the first.
+func DecodeCompressedUint(data []byte) (uint32, int, error) {
+	if len(data) == 0 {
+		return 0, 0, fmt.Errorf("compressed integer truncated")
+	}
+
+	b := data[0]
+	switch {
+	case b&0x80 == 0x00:
+		return uint32(b), 1, nil
+	case b&0xC0 == 0x80:
+		if len(data) < 2 {
+			return 0, 0, fmt.Errorf("compressed integer truncated")
+		}
+		return uint32(b&0x3F)<<8 | uint32(data[1]), 2, nil
+	case b&0xE0 == 0xC0:
+		if len(data) < 4 {
+			return 0, 0, fmt.Errorf("compressed integer truncated")
+		}
+		return uint32(b&0x1F)<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3]), 4, nil
+	}
+
+	return 0, 0, fmt.Errorf("invalid compressed integer prefix: 0x%02x", b)
+}
+
+// DecodeSignedOperand decodes a signed annotation operand, which stores
+// the sign in the low bit and the magnitude in the remaining bits.
+func DecodeSignedOperand(v uint32) int32 {
+	if v&1 != 0 {
+		return -int32(v >> 1)
+	}
+	return int32(v >> 1)
+}
+
+// annotationOperands returns the number of operands an opcode takes.
+func annotationOperands(op uint32) int {
+	if op == BA_OP_ChangeCodeLengthAndCodeOffset {
+		return 2
+	}
+	return 1
+}
+
+// ParseBinaryAnnotations decodes the compressed binary annotation stream
+// of an inline site. Decoding stops at the first BA_OP_Invalid, which
+// pads the stream to a 4-byte boundary.
+func ParseBinaryAnnotations(data []byte) ([]BinaryAnnotation, error) {
+	var annotations []BinaryAnnotation
+	offset := 0
+
+	for offset < len(data) {
+		op, n, err := DecodeCompressedUint(data[offset:])
+		if err != nil {
+			return annotations, err
+		}
+		offset += n
+		if op == BA_OP_Invalid {
+			break
+		}
+		if op > BA_OP_ChangeColumnEnd {
+			return annotations, fmt.Errorf("unknown binary annotation opcode: %d", op)
+		}
+
+		ann := BinaryAnnotation{Opcode: op}
+		for i := 0; i < annotationOperands(op); i++ {
+			v, n, err := DecodeCompressedUint(data[offset:])
+			if err != nil {
+				return annotations, fmt.Errorf("opcode %d: %w", op, err)
+			}
+			offset += n
+			ann.Operands = append(ann.Operands, v)
+		}
+		annotations = append(annotations, ann)
+	}
+
+	return annotations, nil
+}
+
+// InlineLines replays binary annotations into line entries. fileID and
+// line give the inlinee's starting file and line, as recorded in the
+// module's DEBUG_S_INLINEELINES subsection. An entry without an explicit
+// length extends to the start of the next entry.
+func InlineLines(annotations []BinaryAnnotation, fileID, line uint32) []InlineLineEntry {
+	var entries []InlineLineEntry
+	var codeOffset uint32
+
+	emit := func() {
+		if n := len(entries); n > 0 && entries[n-1].Length == 0 && codeOffset > entries[n-1].CodeOffset {
+			entries[n-1].Length = codeOffset - entries[n-1].CodeOffset
+		}
+		entries = append(entries, InlineLineEntry{
+			CodeOffset: codeOffset,
+			FileID:     fileID,
+			LineNumber: line,
+		})
+	}
+
+	for _, ann := range annotations {
+		op := ann.Operands[0]
+		switch ann.Opcode {
+		case BA_OP_CodeOffset:
+			codeOffset = op
+		case BA_OP_ChangeCodeOffset:
+			codeOffset += op
+			emit()
+		case BA_OP_ChangeCodeLength:
+			if n := len(entries); n > 0 {
+				entries[n-1].Length = op
+				codeOffset += op
+			}
+		case BA_OP_ChangeFile:
+			fileID = op
+		case BA_OP_ChangeLineOffset:
+			line = uint32(int32(line) + DecodeSignedOperand(op))
+		case BA_OP_ChangeCodeOffsetAndLineOffset:
+			codeOffset += op & 0xF
+			line = uint32(int32(line) + DecodeSignedOperand(op>>4))
+			emit()
+		case BA_OP_ChangeCodeLengthAndCodeOffset:
+			codeOffset += ann.Operands[1]
+			emit()
+			entries[len(entries)-1].Length = op
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,90 @@
+package codeview
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCompressedUint(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		value uint32
+		n     int
+		err   bool
+	}{
+		{[]byte{0x00}, 0, 1, false},
+		{[]byte{0x7F}, 0x7F, 1, false},
+		{[]byte{0x80, 0x80}, 0x80, 2, false},
+		{[]byte{0xBF, 0xFF}, 0x3FFF, 2, false},
+		{[]byte{0xC0, 0x00, 0x40, 0x00}, 0x4000, 4, false},
+		{[]byte{0xDF, 0xFF, 0xFF, 0xFF}, 0x1FFFFFFF, 4, false},
+		{[]byte{0xE0, 0, 0, 0}, 0, 0, true},
+		{[]byte{0x80}, 0, 0, true},
+		{[]byte{0xC0, 0x00, 0x40}, 0, 0, true},
+		{nil, 0, 0, true},
+	}
+	for _, tc := range tests {
+		value, n, err := DecodeCompressedUint(tc.data)
+		if value != tc.value || n != tc.n || (err != nil) != tc.err {
+			t.Errorf("DecodeCompressedUint(% x) = %#x, %d, %v; want %#x, %d, error %v", tc.data, value, n, err, tc.value, tc.n, tc.err)
+		}
+	}
+}
+
+func TestDecodeSignedOperand(t *testing.T) {
+	for v, want := range map[uint32]int32{0: 0, 1: 0, 2: 1, 3: -1, 8: 4, 9: -4, 0x3FFE: 0x1FFF} {
+		if got := DecodeSignedOperand(v); got != want {
+			t.Errorf("DecodeSignedOperand(%d) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestParseBinaryAnnotations(t *testing.T) {
+	data := []byte{
+		BA_OP_ChangeLineOffset, 0x02, // line +1
+		BA_OP_ChangeCodeOffset, 0x05, // code +5
+		BA_OP_ChangeCodeOffsetAndLineOffset, 0x23, // code +3, line +1
+		BA_OP_ChangeCodeLengthAndCodeOffset, 0x04, 0x02, // code +2, length 4
+		BA_OP_ChangeFile, 0x80, 0x18, // file 0x18, two-byte operand
+		BA_OP_ChangeLineOffset, 0x05, // line -2
+		BA_OP_ChangeCodeOffset, 0x01, // code +1
+		BA_OP_ChangeCodeLength, 0x03,
+		BA_OP_Invalid, 0x00, // padding
+	}
+
+	annotations, err := ParseBinaryAnnotations(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAnnotations := []BinaryAnnotation{
+		{BA_OP_ChangeLineOffset, []uint32{2}},
+		{BA_OP_ChangeCodeOffset, []uint32{5}},
+		{BA_OP_ChangeCodeOffsetAndLineOffset, []uint32{0x23}},
+		{BA_OP_ChangeCodeLengthAndCodeOffset, []uint32{4, 2}},
+		{BA_OP_ChangeFile, []uint32{0x18}},
+		{BA_OP_ChangeLineOffset, []uint32{5}},
+		{BA_OP_ChangeCodeOffset, []uint32{1}},
+		{BA_OP_ChangeCodeLength, []uint32{3}},
+	}
+	if !reflect.DeepEqual(annotations, wantAnnotations) {
+		t.Fatalf("ParseBinaryAnnotations = %v, want %v", annotations, wantAnnotations)
+	}
+
+	got := InlineLines(annotations, 0, 10)
+	want := []InlineLineEntry{
+		{CodeOffset: 5, Length: 3, FileID: 0, LineNumber: 11},
+		{CodeOffset: 8, Length: 2, FileID: 0, LineNumber: 12},
+		{CodeOffset: 10, Length: 4, FileID: 0, LineNumber: 12},
+		{CodeOffset: 11, Length: 3, FileID: 0x18, LineNumber: 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InlineLines = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseBinaryAnnotations([]byte{BA_OP_ChangeCodeLengthAndCodeOffset, 0x04}); err == nil {
+		t.Error("missing second operand not reported")
+	}
+	if _, err := ParseBinaryAnnotations([]byte{0x0E, 0x00}); err == nil {
+		t.Error("unknown opcode not reported")
+	}
+}
@@ -0,0 +1,114 @@
+package codeview
+
+import (
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// symbol encodes a symbol record: its length, kind and data.
+func symbol(kind uint16, data bb) bb {
+	return bb(nil).u16(uint16(len(data) + 2)).u16(kind).bytes(data)
+}
+
+func (b bb) bytes(data []byte) bb { return append(b, data...) }
+
+// symbolParsers are run on every record by FuzzParseSymbols, whatever its
+// kind, so each parser sees arbitrary data.
+var symbolParsers = []func(kind uint16, data []byte){
+	func(k uint16, d []byte) { ParseProcSym(d) },
+	func(k uint16, d []byte) { ParseProcSym16t(d) },
+	func(k uint16, d []byte) { ParseProcSymKind(k, d) },
+	func(k uint16, d []byte) { ParseDataSym(d) },
+	func(k uint16, d []byte) { ParseDataSym16t(d) },
+	func(k uint16, d []byte) { ParseDataSymKind(k, d) },
+	func(k uint16, d []byte) { ParsePubSym(d) },
+	func(k uint16, d []byte) { ParsePubSymKind(k, d) },
+	func(k uint16, d []byte) { ParseUDTSym(d) },
+	func(k uint16, d []byte) { ParseConstantSym(d) },
+	func(k uint16, d []byte) { ParseCompile2Sym(d) },
+	func(k uint16, d []byte) { ParseCompile3Sym(d) },
+	func(k uint16, d []byte) { ParseObjNameSym(d) },
+	func(k uint16, d []byte) { ParseEnvBlockSym(d) },
+	func(k uint16, d []byte) { ParseBuildInfoSym(d) },
+	func(k uint16, d []byte) { ParseBlockSym(d) },
+	func(k uint16, d []byte) { ParseLabelSym(d) },
+	func(k uint16, d []byte) { ParseLabelSymKind(k, d) },
+	func(k uint16, d []byte) { ParseThunkSym(d) },
+	func(k uint16, d []byte) { ParseSepCodeSym(d) },
+	func(k uint16, d []byte) { ParseFrameProcSym(d) },
+	func(k uint16, d []byte) { ParseExportSym(d) },
+	func(k uint16, d []byte) { ParseAnnotationSym(d) },
+	func(k uint16, d []byte) { ParseSectionSym(d) },
+	func(k uint16, d []byte) { ParseCoffGroupSym(d) },
+	func(k uint16, d []byte) { ParseRefSym(d) },
+	func(k uint16, d []byte) { ParseHeapAllocSiteSym(d) },
+	func(k uint16, d []byte) { ParseFunctionListSym(d) },
+	func(k uint16, d []byte) { ParseInlineSiteSym(d) },
+	func(k uint16, d []byte) { ParseInlineSite2Sym(d) },
+	func(k uint16, d []byte) { ParseLocalSym(d) },
+	func(k uint16, d []byte) { ParseRegRel32Sym(d) },
+	func(k uint16, d []byte) { ParseRegisterSym(d) },
+	func(k uint16, d []byte) { ParseDefRangeSym(k, d) },
+	func(k uint16, d []byte) { ParseBinaryAnnotations(d) },
+}
+
+func FuzzParseSymbols(f *testing.F) {
+	proc := bb(nil).u32(0).u32(0).u32(0).u32(0x20).u32(0).u32(0x20).u32(0x1000).u32(0x10).u16(1).u8(0)
+	f.Add([]byte(bb(nil).u32(4).bytes(symbol(S_GPROC32, proc.str("main"))).bytes(symbol(S_END, nil))))
+	// Name region of exactly 35 bytes: the fixed part with no name
+	f.Add([]byte(symbol(S_GPROC32, proc)))
+	f.Add([]byte(symbol(S_LPROC32, proc[:34])))
+	// Truncated records and record lengths that underflow
+	f.Add([]byte(symbol(S_GPROC32, proc.str("main"))[:20]))
+	f.Add([]byte(bb(nil).u16(0).u16(S_END)))
+	f.Add([]byte(bb(nil).u16(1).u16(S_END)))
+	f.Add([]byte(bb(nil).u16(0xFFFF).u16(S_GDATA32).u32(0x74)))
+	f.Add([]byte(symbol(S_PUB32, bb(nil).u32(0).u32(0x10))))
+	f.Add([]byte(symbol(S_CONSTANT, bb(nil).u32(0x74).u16(streams.LF_QUADWORD).u32(1))))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		syms, _ := ParseSymbols(data)
+		for _, sym := range syms {
+			for _, parse := range symbolParsers {
+				parse(sym.Kind, sym.Data)
+			}
+		}
+	})
+}
+
+func FuzzParseFieldList(f *testing.F) {
+	entries := fieldList(
+		member("x", tInt4, 0),
+		leaf(streams.LF_ONEMETHOD_newformat).u16(0x10).u32(0x1001).u32(0).str("f").pad(),
+		leaf(streams.LF_VBCLASS).u16(0).u32(0x1001).u32(0x1001).u16(0).u16(1).pad(),
+		leaf(streams.LF_ENUMERATE).u16(3).u16(streams.LF_REAL64).u32(0).u32(0x40000000).str("E").pad(),
+		leaf(streams.LF_INDEX).u16(0).u32(0x1000),
+	)[2:]
+	f.Add([]byte(entries))
+	// Truncated entries
+	f.Add([]byte(entries[:5]))
+	f.Add([]byte(leaf(streams.LF_MEMBER_newformat).u16(3).u32(tInt4).u16(streams.LF_ULONG)))
+	f.Add([]byte(leaf(streams.LF_ONEMETHOD_newformat).u16(0x10).u32(0x1001)))
+	f.Add([]byte{0xF1, 0xF2, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// The field list is also the stream's first record, so LF_INDEX
+		// continuations can refer back to it
+		var tpi *streams.TPIStream
+		if len(data) < 0xFFF0 {
+			tpi, _ = streams.ReadTPIStream(tpiBytes(streams.TypeIndexBegin,
+				leaf(streams.LF_FIELDLIST).bytes(data),
+				structure("S", 0x1000, 8),
+			))
+		}
+		r := NewTypeResolver(tpi)
+		r.parseFieldList(data, make(map[uint32]bool))
+		r.parseEnumFieldList(data, enumValues{}, make(map[uint32]bool))
+		fieldListReferences(data, streams.TypeIndexBegin)
+		if tpi != nil {
+			r.ParseStructureType(tpi.GetType(0x1001))
+			r.ResolveType(0x1001)
+		}
+	})
+}
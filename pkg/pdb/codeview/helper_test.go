@@ -0,0 +1,66 @@
+package codeview
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// bb builds little-endian record data.
+type bb []byte
+
+// leaf starts a record or field list entry of the given kind.
+func leaf(kind uint16) bb { return bb(nil).u16(kind) }
+
+func (b bb) u8(v uint8) bb   { return append(b, v) }
+func (b bb) u16(v uint16) bb { return binary.LittleEndian.AppendUint16(b, v) }
+func (b bb) u32(v uint32) bb { return binary.LittleEndian.AppendUint32(b, v) }
+func (b bb) str(s string) bb { return append(append(b, s...), 0) }
+
+// pad pads a field list entry to a multiple of four bytes with LF_PAD
+// bytes, as entries follow the four-byte record length and kind.
+func (b bb) pad() bb {
+	for len(b)%4 != 0 {
+		b = append(b, byte(0xF0+4-len(b)%4))
+	}
+	return b
+}
+
+// buildTPI returns a TPI stream holding recs, each a leaf kind followed by
+// its data, numbered from TypeIndexBegin.
+func buildTPI(tb testing.TB, recs ...bb) *streams.TPIStream {
+	tb.Helper()
+	return buildTPIAt(tb, streams.TypeIndexBegin, recs...)
+}
+
+// buildTPIAt is like buildTPI but numbers the records from begin.
+func buildTPIAt(tb testing.TB, begin uint32, recs ...bb) *streams.TPIStream {
+	tb.Helper()
+	tpi, err := streams.ReadTPIStream(tpiBytes(begin, recs...))
+	if err != nil {
+		tb.Fatalf("ReadTPIStream: %v", err)
+	}
+	return tpi
+}
+
+// tpiBytes encodes a V80 TPI stream holding recs, numbered from begin.
+func tpiBytes(begin uint32, recs ...bb) []byte {
+	var body []byte
+	for _, r := range recs {
+		for (len(r)+2)%4 != 0 {
+			r = append(r, 0)
+		}
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(r)))
+		body = append(body, r...)
+	}
+	h := make([]byte, 56)
+	binary.LittleEndian.PutUint32(h[0:], streams.TPIStreamVersionV80)
+	binary.LittleEndian.PutUint32(h[4:], 56)
+	binary.LittleEndian.PutUint32(h[8:], begin)
+	binary.LittleEndian.PutUint32(h[12:], begin+uint32(len(recs)))
+	binary.LittleEndian.PutUint32(h[16:], uint32(len(body)))
+	binary.LittleEndian.PutUint16(h[20:], 0xFFFF)
+	binary.LittleEndian.PutUint16(h[22:], 0xFFFF)
+	return append(h, body...)
+}
@@ -0,0 +1,296 @@
+package codeview
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// FuncID represents an LF_FUNC_ID record from the IPI stream.
+type FuncID struct {
+	ParentScope  uint32 // ID index of the enclosing scope (0 if none)
+	FunctionType uint32 // TPI index of the LF_PROCEDURE
+	Name         string // Function name
+}
+
+// MFuncID represents an LF_MFUNC_ID record from the IPI stream.
+type MFuncID struct {
+	ParentType   uint32 // TPI index of the owning class
+	FunctionType uint32 // TPI index of the LF_MFUNCTION
+	Name         string // Method name
+}
+
+// StringID represents an LF_STRING_ID record from the IPI stream.
+type StringID struct {
+	SubstringList uint32 // ID index of an LF_SUBSTR_LIST (0 if none)
+	String        string // String value
+}
+
+// BuildInfo represents an LF_BUILDINFO record from the IPI stream.
+type BuildInfo struct {
+	Args []uint32 // ID indices of LF_STRING_ID arguments
+}
+
+// Positions of the arguments of an LF_BUILDINFO record.
+const (
+	BuildInfoCurrentDirectory = 0 // Working directory of the compiler
+	BuildInfoBuildTool        = 1 // Path of the compiler executable
+	BuildInfoSourceFile       = 2 // Source file of the translation unit
+	BuildInfoTypeServerPDB    = 3 // PDB the compiler wrote types to
+	BuildInfoCommandLine      = 4 // Compiler arguments
+)
+
+// UDTSrcLine represents an LF_UDT_SRC_LINE or LF_UDT_MOD_SRC_LINE record.
+type UDTSrcLine struct {
+	UDT        uint32 // TPI index of the user-defined type
+	SourceFile uint32 // ID index of LF_STRING_ID, or /names offset for LF_UDT_MOD_SRC_LINE
+	LineNumber uint32 // Line number of the definition
+	Module     uint16 // Module index (LF_UDT_MOD_SRC_LINE only)
+}
+
+// ParseFuncID parses an LF_FUNC_ID record.
+func ParseFuncID(data []byte) (*FuncID, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("func id data too small: %d bytes", len(data))
+	}
+
+	id := &FuncID{
+		ParentScope:  binary.LittleEndian.Uint32(data[0:]),
+		FunctionType: binary.LittleEndian.Uint32(data[4:]),
+	}
+	id.Name, _ = streams.ParseString(data[8:])
+
+	return id, nil
+}
+
+// ParseMFuncID parses an LF_MFUNC_ID record.
+func ParseMFuncID(data []byte) (*MFuncID, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("mfunc id data too small: %d bytes", len(data))
+	}
+
+	id := &MFuncID{
+		ParentType:   binary.LittleEndian.Uint32(data[0:]),
+		FunctionType: binary.LittleEndian.Uint32(data[4:]),
+	}
+	id.Name, _ = streams.ParseString(data[8:])
+
+	return id, nil
+}
+
+// ParseStringID parses an LF_STRING_ID record.
+func ParseStringID(data []byte) (*StringID, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("string id data too small: %d bytes", len(data))
+	}
+
+	id := &StringID{
+		SubstringList: binary.LittleEndian.Uint32(data[0:]),
+	}
+	id.String, _ = streams.ParseString(data[4:])
+
+	return id, nil
+}
+
+// ParseBuildInfo parses an LF_BUILDINFO record.
+func ParseBuildInfo(data []byte) (*BuildInfo, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("build info data too small: %d bytes", len(data))
+	}
+
+	count := binary.LittleEndian.Uint16(data[0:])
+	info := &BuildInfo{}
+	offset := 2
+	for i := uint16(0); i < count && offset+4 <= len(data); i++ {
+		info.Args = append(info.Args, binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+	}
+
+	return info, nil
+}
+
+// ParseUDTSrcLine parses an LF_UDT_SRC_LINE or LF_UDT_MOD_SRC_LINE record.
+func ParseUDTSrcLine(data []byte) (*UDTSrcLine, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("udt src line data too small: %d bytes", len(data))
+	}
+
+	line := &UDTSrcLine{
+		UDT:        binary.LittleEndian.Uint32(data[0:]),
+		SourceFile: binary.LittleEndian.Uint32(data[4:]),
+		LineNumber: binary.LittleEndian.Uint32(data[8:]),
+	}
+	if len(data) >= 14 {
+		line.Module = binary.LittleEndian.Uint16(data[12:])
+	}
+
+	return line, nil
+}
+
+// IDResolver provides resolution of ID indices from the IPI stream.
+// Function and method IDs are dereferenced to their TPI signatures.
+type IDResolver struct {
+	ipi   *streams.TPIStream
+	types *TypeResolver
+}
+
+// NewIDResolver creates a new ID resolver. The type resolver is used to
+// render the TPI types referenced by ID records and may be nil.
+func NewIDResolver(ipi *streams.TPIStream, types *TypeResolver) *IDResolver {
+	return &IDResolver{ipi: ipi, types: types}
+}
+
+// GetID returns the IPI record for the given ID index.
+func (r *IDResolver) GetID(idIdx uint32) *streams.TypeRecord {
+	if r.ipi == nil {
+		return nil
+	}
+	return r.ipi.GetType(idIdx)
+}
+
+// FunctionType returns the TPI type index referenced by an LF_FUNC_ID or
+// LF_MFUNC_ID record. The second return is false for any other record.
+func (r *IDResolver) FunctionType(idIdx uint32) (uint32, bool) {
+	rec := r.GetID(idIdx)
+	if rec == nil {
+		return 0, false
+	}
+
+	switch rec.Kind {
+	case streams.LF_FUNC_ID:
+		id, err := ParseFuncID(rec.Data)
+		if err != nil {
+			return 0, false
+		}
+		return id.FunctionType, true
+	case streams.LF_MFUNC_ID:
+		id, err := ParseMFuncID(rec.Data)
+		if err != nil {
+			return 0, false
+		}
+		return id.FunctionType, true
+	}
+
+	return 0, false
+}
+
+// ResolveSignature resolves an LF_FUNC_ID or LF_MFUNC_ID to the signature of
+// the underlying LF_PROCEDURE or LF_MFUNCTION type.
+func (r *IDResolver) ResolveSignature(idIdx uint32) string {
+	typeIdx, ok := r.FunctionType(idIdx)
+	if !ok {
+		return fmt.Sprintf("id_0x%x", idIdx)
+	}
+	if r.types == nil {
+		return fmt.Sprintf("type_0x%x", typeIdx)
+	}
+	return r.types.ResolveType(typeIdx)
+}
+
+// ResolveID resolves an ID index to a human-readable string.
+func (r *IDResolver) ResolveID(idIdx uint32) string {
+	rec := r.GetID(idIdx)
+	if rec == nil {
+		return fmt.Sprintf("id_0x%x", idIdx)
+	}
+
+	switch rec.Kind {
+	case streams.LF_FUNC_ID:
+		id, err := ParseFuncID(rec.Data)
+		if err != nil {
+			break
+		}
+		// Records only reference earlier indices; anything else is malformed
+		if id.ParentScope != 0 && id.ParentScope < idIdx {
+			return r.ResolveID(id.ParentScope) + "::" + id.Name
+		}
+		return id.Name
+
+	case streams.LF_MFUNC_ID:
+		id, err := ParseMFuncID(rec.Data)
+		if err != nil {
+			break
+		}
+		if r.types != nil {
+			return r.types.ResolveType(id.ParentType) + "::" + id.Name
+		}
+		return id.Name
+
+	case streams.LF_STRING_ID:
+		id, err := ParseStringID(rec.Data)
+		if err != nil {
+			break
+		}
+		if id.SubstringList != 0 && id.SubstringList < idIdx {
+			return r.resolveSubstrList(id.SubstringList) + id.String
+		}
+		return id.String
+
+	case streams.LF_SUBSTR_LIST:
+		return r.resolveSubstrList(idIdx)
+
+	case streams.LF_BUILDINFO:
+		args, ok := r.ResolveBuildInfo(idIdx)
+		if !ok {
+			break
+		}
+		return strings.Join(args, " ")
+
+	case streams.LF_UDT_SRC_LINE:
+		line, err := ParseUDTSrcLine(rec.Data)
+		if err != nil {
+			break
+		}
+		file := ""
+		if line.SourceFile < idIdx {
+			file = r.ResolveID(line.SourceFile)
+		}
+		return fmt.Sprintf("%s:%d", file, line.LineNumber)
+	}
+
+	return fmt.Sprintf("id_0x%x", idIdx)
+}
+
+// ResolveBuildInfo resolves the arguments of an LF_BUILDINFO record to
+// their strings, indexed by the BuildInfo* constants. Missing arguments are
+// empty. The second return is false if idIdx is not an LF_BUILDINFO record.
+func (r *IDResolver) ResolveBuildInfo(idIdx uint32) ([]string, bool) {
+	rec := r.GetID(idIdx)
+	if rec == nil || rec.Kind != streams.LF_BUILDINFO {
+		return nil, false
+	}
+	info, err := ParseBuildInfo(rec.Data)
+	if err != nil {
+		return nil, false
+	}
+
+	args := make([]string, len(info.Args))
+	for i, arg := range info.Args {
+		if arg != 0 && arg < idIdx {
+			args[i] = r.ResolveID(arg)
+		}
+	}
+	return args, true
+}
+
+// resolveSubstrList concatenates the strings of an LF_SUBSTR_LIST record.
+func (r *IDResolver) resolveSubstrList(idIdx uint32) string {
+	rec := r.GetID(idIdx)
+	if rec == nil || rec.Kind != streams.LF_SUBSTR_LIST || len(rec.Data) < 4 {
+		return ""
+	}
+
+	count := binary.LittleEndian.Uint32(rec.Data[0:])
+	var sb strings.Builder
+	offset := 4
+	for i := uint32(0); i < count && offset+4 <= len(rec.Data); i++ {
+		sub := binary.LittleEndian.Uint32(rec.Data[offset:])
+		if sub < idIdx {
+			sb.WriteString(r.ResolveID(sub))
+		}
+		offset += 4
+	}
+	return sb.String()
+}
@@ -0,0 +1,242 @@
+package codeview
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// C13 debug subsection types (DEBUG_S_* values)
+const (
+	DEBUG_S_IGNORE               = 0x80000000
+	DEBUG_S_SYMBOLS              = 0xf1
+	DEBUG_S_LINES                = 0xf2
+	DEBUG_S_STRINGTABLE          = 0xf3
+	DEBUG_S_FILECHECKSUMS        = 0xf4
+	DEBUG_S_FRAMEDATA            = 0xf5
+	DEBUG_S_INLINEELINES         = 0xf6
+	DEBUG_S_CROSSSCOPEIMPORTS    = 0xf7
+	DEBUG_S_CROSSSCOPEEXPORTS    = 0xf8
+	DEBUG_S_IL_LINES             = 0xf9
+	DEBUG_S_FUNC_MDTOKEN_MAP     = 0xfa
+	DEBUG_S_TYPE_MDTOKEN_MAP     = 0xfb
+	DEBUG_S_MERGED_ASSEMBLYINPUT = 0xfc
+	DEBUG_S_COFF_SYMBOL_RVA      = 0xfd
+)
+
+// CV_LINES_HAVE_COLUMNS is set in a DEBUG_S_LINES header when column
+// records follow each block's line records.
+const CV_LINES_HAVE_COLUMNS = 0x0001
+
+// DEBUG_S_INLINEELINES signatures
+const (
+	CV_INLINEE_SOURCE_LINE_SIGNATURE    = 0x0
+	CV_INLINEE_SOURCE_LINE_SIGNATURE_EX = 0x1
+)
+
+// Line record bit fields
+const (
+	lineNumberMask  = 0x00FFFFFF
+	lineIsStatement = 0x80000000
+)
+
+// DebugSubsection is a single C13 debug subsection.
+type DebugSubsection struct {
+	Kind uint32
+	Data []byte
+}
+
+// LineEntry is a single line record within a line block.
+type LineEntry struct {
+	Offset      uint32 // Code offset relative to the subsection's Offset
+	LineNumber  uint32 // Starting line number
+	IsStatement bool   // True if this is a statement (vs. expression)
+	ColumnStart uint16 // Starting column (0 if not present)
+	ColumnEnd   uint16 // Ending column (0 if not present)
+}
+
+// LineBlock groups line records that belong to a single source file.
+type LineBlock struct {
+	FileID uint32 // Offset into the DEBUG_S_FILECHECKSUMS subsection
+	Lines  []LineEntry
+}
+
+// LinesSubsection represents a parsed DEBUG_S_LINES subsection.
+type LinesSubsection struct {
+	Offset  uint32 // Code offset of the contribution
+	Segment uint16 // Code segment of the contribution
+	Flags   uint16 // CV_LINES_* flags
+	Length  uint32 // Code length of the contribution
+	Blocks  []LineBlock
+}
+
+// FileChecksum represents an entry of the DEBUG_S_FILECHECKSUMS subsection.
+type FileChecksum struct {
+	FileNameOffset uint32 // Offset into the /names string table
+	ChecksumKind   uint8  // Checksum algorithm (0=none, 1=MD5, 2=SHA1, 3=SHA256)
+	Checksum       []byte // Raw checksum bytes
+}
+
+// InlineeSourceLine records where an inlined function is defined.
+type InlineeSourceLine struct {
+	Inlinee    uint32   // ID index of the inlined function
+	FileID     uint32   // Offset into the DEBUG_S_FILECHECKSUMS subsection
+	SourceLine uint32   // Line number of the function's definition
+	ExtraFiles []uint32 // Additional file IDs (extended format only)
+}
+
+// ParseDebugSubsections splits a module's C13 line info into subsections.
+// Subsections flagged with DEBUG_S_IGNORE are skipped.
+func ParseDebugSubsections(data []byte) []DebugSubsection {
+	var subsections []DebugSubsection
+	offset := 0
+
+	for offset+8 <= len(data) {
+		kind := binary.LittleEndian.Uint32(data[offset:])
+		length := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			break
+		}
+
+		if kind&DEBUG_S_IGNORE == 0 {
+			subsections = append(subsections, DebugSubsection{
+				Kind: kind,
+				Data: data[offset : offset+int(length)],
+			})
+		}
+
+		offset = alignTo(offset+int(length), 4)
+	}
+
+	return subsections
+}
+
+// ParseLinesSubsection parses a DEBUG_S_LINES subsection.
+func ParseLinesSubsection(data []byte) (*LinesSubsection, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("lines subsection too small: %d bytes", len(data))
+	}
+
+	lines := &LinesSubsection{
+		Offset:  binary.LittleEndian.Uint32(data[0:]),
+		Segment: binary.LittleEndian.Uint16(data[4:]),
+		Flags:   binary.LittleEndian.Uint16(data[6:]),
+		Length:  binary.LittleEndian.Uint32(data[8:]),
+	}
+	hasColumns := lines.Flags&CV_LINES_HAVE_COLUMNS != 0
+
+	offset := 12
+	for offset+12 <= len(data) {
+		fileID := binary.LittleEndian.Uint32(data[offset:])
+		numLines := binary.LittleEndian.Uint32(data[offset+4:])
+		blockSize := binary.LittleEndian.Uint32(data[offset+8:])
+
+		blockEnd := offset + int(blockSize)
+		if blockSize < 12 || blockEnd > len(data) || blockEnd < offset {
+			break
+		}
+
+		block := LineBlock{FileID: fileID}
+		linesOffset := offset + 12
+		columnsOffset := linesOffset + int(numLines)*8
+		for i := 0; i < int(numLines); i++ {
+			pos := linesOffset + i*8
+			if pos+8 > blockEnd {
+				break
+			}
+			flags := binary.LittleEndian.Uint32(data[pos+4:])
+			entry := LineEntry{
+				Offset:      binary.LittleEndian.Uint32(data[pos:]),
+				LineNumber:  flags & lineNumberMask,
+				IsStatement: flags&lineIsStatement != 0,
+			}
+			if hasColumns {
+				colPos := columnsOffset + i*4
+				if colPos+4 <= blockEnd {
+					entry.ColumnStart = binary.LittleEndian.Uint16(data[colPos:])
+					entry.ColumnEnd = binary.LittleEndian.Uint16(data[colPos+2:])
+				}
+			}
+			block.Lines = append(block.Lines, entry)
+		}
+
+		lines.Blocks = append(lines.Blocks, block)
+		offset = blockEnd
+	}
+
+	return lines, nil
+}
+
+// ParseFileChecksums parses a DEBUG_S_FILECHECKSUMS subsection.
+// The result is keyed by the entry's byte offset within the subsection,
+// which is the FileID used by line blocks.
+func ParseFileChecksums(data []byte) map[uint32]FileChecksum {
+	checksums := make(map[uint32]FileChecksum)
+	offset := 0
+
+	for offset+6 <= len(data) {
+		entryOffset := uint32(offset)
+		nameOffset := binary.LittleEndian.Uint32(data[offset:])
+		size := int(data[offset+4])
+		kind := data[offset+5]
+		offset += 6
+
+		if offset+size > len(data) {
+			break
+		}
+
+		checksums[entryOffset] = FileChecksum{
+			FileNameOffset: nameOffset,
+			ChecksumKind:   kind,
+			Checksum:       data[offset : offset+size],
+		}
+
+		offset = alignTo(offset+size, 4)
+	}
+
+	return checksums
+}
+
+// ParseInlineeLines parses a DEBUG_S_INLINEELINES subsection.
+// The result is keyed by the inlinee's ID index.
+func ParseInlineeLines(data []byte) (map[uint32]InlineeSourceLine, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("inlinee lines subsection too small: %d bytes", len(data))
+	}
+
+	signature := binary.LittleEndian.Uint32(data[0:])
+	if signature != CV_INLINEE_SOURCE_LINE_SIGNATURE && signature != CV_INLINEE_SOURCE_LINE_SIGNATURE_EX {
+		return nil, fmt.Errorf("unknown inlinee lines signature: 0x%x", signature)
+	}
+
+	inlinees := make(map[uint32]InlineeSourceLine)
+	offset := 4
+	for offset+12 <= len(data) {
+		line := InlineeSourceLine{
+			Inlinee:    binary.LittleEndian.Uint32(data[offset:]),
+			FileID:     binary.LittleEndian.Uint32(data[offset+4:]),
+			SourceLine: binary.LittleEndian.Uint32(data[offset+8:]),
+		}
+		offset += 12
+
+		if signature == CV_INLINEE_SOURCE_LINE_SIGNATURE_EX {
+			if offset+4 > len(data) {
+				break
+			}
+			count := int(binary.LittleEndian.Uint32(data[offset:]))
+			offset += 4
+			if count < 0 || count > (len(data)-offset)/4 {
+				break
+			}
+			for i := 0; i < count; i++ {
+				line.ExtraFiles = append(line.ExtraFiles, binary.LittleEndian.Uint32(data[offset:]))
+				offset += 4
+			}
+		}
+
+		inlinees[line.Inlinee] = line
+	}
+
+	return inlinees, nil
+}
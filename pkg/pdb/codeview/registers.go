@@ -0,0 +1,142 @@
+package codeview
+
+import (
+	"fmt"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// RegVFrame is CV_ALLREG_VFRAME, the x86 virtual frame pointer used by FPO
+// code.
+const RegVFrame = 30006
+
+// x86 registers (CV_REG_*) shared by the I386 and AMD64 numbering
+var x86Registers = map[uint16]string{
+	1: "AL", 2: "CL", 3: "DL", 4: "BL", 5: "AH", 6: "CH", 7: "DH", 8: "BH",
+	9: "AX", 10: "CX", 11: "DX", 12: "BX", 13: "SP", 14: "BP", 15: "SI", 16: "DI",
+	17: "EAX", 18: "ECX", 19: "EDX", 20: "EBX", 21: "ESP", 22: "EBP", 23: "ESI", 24: "EDI",
+	25: "ES", 26: "CS", 27: "SS", 28: "DS", 29: "FS", 30: "GS",
+}
+
+// I386 registers (CV_REG_*) beyond x86Registers
+var i386Registers = map[uint16]string{
+	31: "IP", 32: "FLAGS", 33: "EIP", 34: "EFLAGS",
+	RegVFrame: "VFRAME",
+}
+
+// AMD64 registers (CV_AMD64_*) beyond x86Registers
+var amd64Registers = map[uint16]string{
+	31: "FLAGS", 32: "RIP", 33: "EFLAGS",
+	324: "SIL", 325: "DIL", 326: "BPL", 327: "SPL",
+	328: "RAX", 329: "RBX", 330: "RCX", 331: "RDX",
+	332: "RSI", 333: "RDI", 334: "RBP", 335: "RSP",
+}
+
+// ARM registers (CV_ARM_*) with single numbers; the numbered register
+// files are computed in RegisterName
+var armRegisters = map[uint16]string{
+	23: "SP", 24: "LR", 25: "PC", 26: "CPSR", 27: "ACC0",
+	40: "FPSCR", 41: "FPEXC",
+}
+
+// RegisterName returns the name of a CodeView register number, as used by
+// S_REGISTER, S_REGREL32 and the def-range records, for the given DBI
+// machine type. Numbers it does not know yield "unknown(n)".
+func RegisterName(reg uint16, machine uint16) string {
+	switch machine {
+	case streams.MachineI386:
+		if name, ok := x86Registers[reg]; ok {
+			return name
+		}
+		if name, ok := i386Registers[reg]; ok {
+			return name
+		}
+		switch {
+		case reg >= 128 && reg <= 135:
+			return fmt.Sprintf("ST%d", reg-128)
+		case reg >= 146 && reg <= 153:
+			return fmt.Sprintf("MM%d", reg-146)
+		case reg >= 154 && reg <= 161:
+			return fmt.Sprintf("XMM%d", reg-154)
+		}
+
+	case streams.MachineAMD64:
+		if name, ok := x86Registers[reg]; ok {
+			return name
+		}
+		if name, ok := amd64Registers[reg]; ok {
+			return name
+		}
+		switch {
+		case reg >= 128 && reg <= 135:
+			return fmt.Sprintf("ST%d", reg-128)
+		case reg >= 146 && reg <= 153:
+			return fmt.Sprintf("MM%d", reg-146)
+		case reg >= 154 && reg <= 161:
+			return fmt.Sprintf("XMM%d", reg-154)
+		case reg >= 252 && reg <= 259:
+			return fmt.Sprintf("XMM%d", reg-252+8)
+		case reg >= 336 && reg <= 343:
+			return fmt.Sprintf("R%d", reg-336+8)
+		case reg >= 344 && reg <= 351:
+			return fmt.Sprintf("R%dB", reg-344+8)
+		case reg >= 352 && reg <= 359:
+			return fmt.Sprintf("R%dW", reg-352+8)
+		case reg >= 360 && reg <= 367:
+			return fmt.Sprintf("R%dD", reg-360+8)
+		case reg >= 368 && reg <= 383:
+			return fmt.Sprintf("YMM%d", reg-368)
+		}
+
+	case streams.MachineARM, streams.MachineARMNT:
+		if name, ok := armRegisters[reg]; ok {
+			return name
+		}
+		switch {
+		case reg >= 10 && reg <= 22:
+			return fmt.Sprintf("R%d", reg-10)
+		case reg >= 50 && reg <= 81:
+			return fmt.Sprintf("S%d", reg-50)
+		case reg >= 90 && reg <= 97:
+			return fmt.Sprintf("FPEXTRA%d", reg-90)
+		case reg >= 300 && reg <= 331:
+			return fmt.Sprintf("D%d", reg-300)
+		case reg >= 400 && reg <= 415:
+			return fmt.Sprintf("Q%d", reg-400)
+		}
+
+	case streams.MachineARM64:
+		switch {
+		case reg >= 10 && reg <= 40:
+			return fmt.Sprintf("W%d", reg-10)
+		case reg == 41:
+			return "WZR"
+		case reg >= 50 && reg <= 78:
+			return fmt.Sprintf("X%d", reg-50)
+		case reg == 79:
+			return "FP"
+		case reg == 80:
+			return "LR"
+		case reg == 81:
+			return "SP"
+		case reg == 82:
+			return "ZR"
+		case reg == 83:
+			return "PC"
+		case reg == 90:
+			return "NZCV"
+		case reg >= 100 && reg <= 131:
+			return fmt.Sprintf("S%d", reg-100)
+		case reg >= 140 && reg <= 171:
+			return fmt.Sprintf("D%d", reg-140)
+		case reg >= 180 && reg <= 211:
+			return fmt.Sprintf("Q%d", reg-180)
+		case reg == 220:
+			return "FPSR"
+		case reg == 221:
+			return "FPCR"
+		}
+	}
+
+	return fmt.Sprintf("unknown(%d)", reg)
+}
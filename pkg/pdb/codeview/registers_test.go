@@ -0,0 +1,45 @@
+package codeview
+
+import (
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+func TestRegisterName(t *testing.T) {
+	tests := []struct {
+		reg     uint16
+		machine uint16
+		want    string
+	}{
+		// The same number names a different register on each machine
+		{17, streams.MachineI386, "EAX"},
+		{17, streams.MachineAMD64, "EAX"},
+		{17, streams.MachineARM, "R7"},
+		{17, streams.MachineARM64, "W7"},
+
+		{22, streams.MachineI386, "EBP"},
+		{33, streams.MachineI386, "EIP"},
+		{RegVFrame, streams.MachineI386, "VFRAME"},
+		{335, streams.MachineAMD64, "RSP"},
+		{334, streams.MachineAMD64, "RBP"},
+		{343, streams.MachineAMD64, "R15"},
+		{252, streams.MachineAMD64, "XMM8"},
+		{23, streams.MachineARMNT, "SP"},
+		{24, streams.MachineARMNT, "LR"},
+		{50, streams.MachineARM64, "X0"},
+		{78, streams.MachineARM64, "X28"},
+		{79, streams.MachineARM64, "FP"},
+		{80, streams.MachineARM64, "LR"},
+		{81, streams.MachineARM64, "SP"},
+		{140, streams.MachineARM64, "D0"},
+
+		{9999, streams.MachineAMD64, "unknown(9999)"},
+		{17, streams.MachineIA64, "unknown(17)"},
+	}
+	for _, tc := range tests {
+		if got := RegisterName(tc.reg, tc.machine); got != tc.want {
+			t.Errorf("RegisterName(%d, %#x) = %q, want %q", tc.reg, tc.machine, got, tc.want)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 // Package codeview provides parsing for CodeView debug symbol records.
+//
+// The parsers accept arbitrary bytes: malformed or truncated records yield
+// an error or a partial result, never a panic, and reference cycles between
+// type or ID records are cut rather than followed.
 package codeview
 
 import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
 )
 
 // Symbol type constants (S_* values)
@@ -17,7 +23,7 @@ const (
 	S_END           = 0x0006
 	S_SKIP          = 0x0007
 	S_CVRESERVE     = 0x0008
-	S_OBJNAME       = 0x0009
+	S_OBJNAME_ST    = 0x0009
 	S_ENDARG        = 0x000a
 	S_COBOLUDT      = 0x000b
 	S_MANYREG       = 0x000c
@@ -44,10 +50,10 @@ const (
 	S_PUB32_16t     = 0x0203
 	S_LPROC32_16t   = 0x0204
 	S_GPROC32_16t   = 0x0205
-	S_THUNK32       = 0x0206
-	S_BLOCK32       = 0x0207
-	S_WITH32        = 0x0208
-	S_LABEL32       = 0x0209
+	S_THUNK32_ST    = 0x0206
+	S_BLOCK32_ST    = 0x0207
+	S_WITH32_ST     = 0x0208
+	S_LABEL32_ST    = 0x0209
 	S_CEXMODEL32    = 0x020a
 	S_VFTABLE32_16t = 0x020b
 	S_REGREL32_16t  = 0x020c
@@ -121,11 +127,11 @@ const (
 	// Symbols without the _ST suffix (new format with null-terminated strings)
 	S_ST_MAX        = 0x1100
 
-	S_OBJNAME_ST    = 0x1101
-	S_THUNK32_ST    = 0x1102
-	S_BLOCK32_ST    = 0x1103
-	S_WITH32_ST     = 0x1104
-	S_LABEL32_ST    = 0x1105
+	S_OBJNAME       = 0x1101
+	S_THUNK32       = 0x1102
+	S_BLOCK32       = 0x1103
+	S_WITH32        = 0x1104
+	S_LABEL32       = 0x1105
 
 	S_REGISTER_NEW  = 0x1106
 	S_CONSTANT_NEW  = 0x1107
@@ -285,24 +291,347 @@ type UDTSym struct {
 	Name      string // UDT name
 }
 
+// S_PUB32 flags (CV_PUBSYMFLAGS)
+const (
+	PubCode     = 0x00000001 // Symbol is a code address
+	PubFunction = 0x00000002 // Symbol is a function
+	PubManaged  = 0x00000004 // Symbol is managed code (native or IL)
+	PubMSIL     = 0x00000008 // Symbol is managed IL code
+)
+
 // PubSym represents a public symbol (S_PUB32).
 type PubSym struct {
-	Flags   uint32 // Public symbol flags
+	Flags   uint32 // Pub* flags
 	Offset  uint32 // Offset
 	Segment uint16 // Segment
 	Name    string // Symbol name
 }
 
+// BlockSym represents a lexical block symbol (S_BLOCK32).
+type BlockSym struct {
+	Parent  uint32 // Pointer to parent
+	End     uint32 // Pointer to end
+	Length  uint32 // Block length
+	Offset  uint32 // Code offset
+	Segment uint16 // Code segment
+	Name    string // Block name (usually empty)
+}
+
+// Thunk ordinals (THUNK_ORDINAL)
+const (
+	ThunkNoType            = 0 // Plain jump, such as an incremental link stub
+	ThunkAdjustor          = 1 // Adjusts this, then jumps to the target
+	ThunkVCall             = 2 // Calls through a vtable slot
+	ThunkPCode             = 3 // P-code entry
+	ThunkLoad              = 4 // Delay-load thunk
+	ThunkTrampIncremental  = 5 // Incremental link trampoline
+	ThunkTrampBranchIsland = 6 // Branch island trampoline
+)
+
+// ThunkSym represents a thunk symbol (S_THUNK32).
+type ThunkSym struct {
+	Parent    uint32 // Pointer to parent
+	End       uint32 // Pointer to end
+	Next      uint32 // Pointer to next symbol
+	Offset    uint32 // Code offset
+	Segment   uint16 // Code segment
+	Length    uint16 // Thunk length
+	Ordinal   uint8  // Thunk* constant
+	Name      string // Thunk name
+	ThunkData []byte // Ordinal-specific data following the name
+
+	// Decoded from ThunkData
+	AdjustorDelta  int16  // ThunkAdjustor: adjustment applied to this
+	AdjustorTarget string // ThunkAdjustor: name of the target function
+	VCallOffset    uint16 // ThunkVCall: offset of the vtable slot
+}
+
+// LabelSym represents a code label (S_LABEL32), such as a jump target or
+// a label in an __asm block.
+type LabelSym struct {
+	Offset  uint32 // Code offset
+	Segment uint16 // Code segment
+	Flags   uint8  // Proc* flags
+	Name    string // Label name
+}
+
+// Procedure flags (CV_PROCFLAGS) of procedure and label symbols
+const (
+	ProcNoFPO        = 0x01 // Frame pointer present
+	ProcInterrupt    = 0x02 // Interrupt return
+	ProcFarReturn    = 0x04 // Far return
+	ProcNeverReturn  = 0x08 // Does not return
+	ProcNotReached   = 0x10 // Not fallen into
+	ProcCustomCall   = 0x20 // Custom calling convention
+	ProcNoInline     = 0x40 // Marked noinline
+	ProcOptDebugInfo = 0x80 // Has debug information for optimized code
+)
+
+// SepCodeSym represents a separated code range (S_SEPCODE): a piece of a
+// function moved away from it by hot/cold splitting or code folding.
+type SepCodeSym struct {
+	Parent        uint32 // Pointer to parent
+	End           uint32 // Pointer to end
+	Length        uint32 // Length of the separated code
+	Flags         uint32 // SepCode* flags
+	Offset        uint32 // Code offset
+	Segment       uint16 // Code segment
+	ParentOffset  uint32 // Offset of the parent procedure
+	ParentSegment uint16 // Segment of the parent procedure
+}
+
+// S_SEPCODE flags (CV_SEPCODEFLAGS)
+const (
+	SepCodeIsLexicalScope  = 0x0001 // S_SEPCODE doubles as a lexical scope
+	SepCodeReturnsToParent = 0x0002 // Code returns to the parent
+)
+
+// S_LOCAL flags (CV_LVARFLAGS)
+const (
+	LocalIsParam        = 0x0001 // Variable is a parameter
+	LocalAddrTaken      = 0x0002 // Address is taken
+	LocalCompGenerated  = 0x0004 // Variable is compiler generated
+	LocalIsAggregate    = 0x0008 // Symbol is splitted in temporaries
+	LocalIsAggregated   = 0x0010 // Symbol is part of an aggregate
+	LocalIsAliased      = 0x0020 // Variable has multiple simultaneous lifetimes
+	LocalIsAlias        = 0x0040 // Represents one of the lifetimes
+	LocalIsRetValue     = 0x0080 // Represents a function return value
+	LocalIsOptimizedOut = 0x0100 // Variable has no lifetimes
+	LocalIsEnregGlobal  = 0x0200 // Variable is an enregistered global
+	LocalIsEnregStatic  = 0x0400 // Variable is an enregistered static
+)
+
+// LocalSym represents a local variable symbol (S_LOCAL). Its storage is
+// described by the S_DEFRANGE_* records that follow it.
+type LocalSym struct {
+	TypeIndex uint32 // Type index
+	Flags     uint16 // Local* flags
+	Name      string // Variable name
+}
+
+// RegRelSym represents a register-relative variable (S_REGREL32).
+type RegRelSym struct {
+	Offset    int32  // Offset from the register, signed
+	TypeIndex uint32 // Type index
+	Register  uint16 // CodeView register number
+	Name      string // Variable name
+}
+
+// RegisterSym represents an enregistered variable (S_REGISTER).
+type RegisterSym struct {
+	TypeIndex uint32 // Type index
+	Register  uint16 // CodeView register number
+	Name      string // Variable name
+}
+
+// AddressRange is the code range over which a def-range is valid
+// (CV_LVAR_ADDR_RANGE).
+type AddressRange struct {
+	Offset  uint32 // Start offset
+	Segment uint16 // Start segment
+	Length  uint16 // Range length in bytes
+}
+
+// AddressGap is a sub-range of an AddressRange where a def-range is not
+// valid (CV_LVAR_ADDR_GAP).
+type AddressGap struct {
+	Offset uint16 // Start offset relative to the range start
+	Length uint16 // Gap length in bytes
+}
+
+// DefRangeSym represents any of the S_DEFRANGE_* records. Which fields are
+// meaningful depends on Kind.
+type DefRangeSym struct {
+	Kind          uint16       // S_DEFRANGE_* kind
+	Program       uint32       // DIA program (S_DEFRANGE, S_DEFRANGE_SUBFIELD)
+	Register      uint16       // Register (or base register for S_DEFRANGE_REGISTER_REL)
+	Offset        int32        // Frame pointer or base register offset
+	OffsetParent  uint16       // Offset within the parent variable (subfield records)
+	MayHaveNoName bool         // Register attribute (S_DEFRANGE_REGISTER)
+	FullScope     bool         // Valid for the whole function (no range)
+	Range         AddressRange // Code range
+	Gaps          []AddressGap // Gaps within the range
+}
+
+// S_EXPORT flags (EXPORTSYM)
+const (
+	ExportConstant  = 0x01 // Exported constant
+	ExportData      = 0x02 // Exported data
+	ExportPrivate   = 0x04 // Not in the import library
+	ExportNoName    = 0x08 // Exported by ordinal only
+	ExportOrdinal   = 0x10 // Ordinal was explicitly assigned
+	ExportForwarder = 0x20 // Forwarded to another DLL
+)
+
+// ExportSym represents a DLL export (S_EXPORT).
+type ExportSym struct {
+	Ordinal uint16 // Export ordinal
+	Flags   uint16 // Export* flags
+	Name    string // Exported name
+}
+
+// AnnotationSym represents the strings of an __annotation() call
+// (S_ANNOTATION).
+type AnnotationSym struct {
+	Offset  uint32   // Code offset of the annotation
+	Segment uint16   // Code segment
+	Strings []string // Annotation strings, in order
+}
+
+// RefSym represents a reference from the global symbol stream to a record
+// in a module symbol stream (S_ANNOTATIONREF and the other REFSYM2 kinds).
+type RefSym struct {
+	SumName   uint32 // SUC of the name
+	SymOffset uint32 // Offset of the record in the module symbol stream
+	Module    uint16 // 1-based index of the module
+	Name      string // Name of the referenced symbol, if any
+}
+
+// SectionSym describes a section of the linked image (S_SECTION). The
+// linker writes one per section to the "* Linker *" module.
+type SectionSym struct {
+	Section         uint16 // 1-based section number
+	Alignment       uint8  // Log2 of the section alignment
+	RVA             uint32 // Start of the section
+	Length          uint32 // Size of the section
+	Characteristics uint32 // IMAGE_SCN_* flags
+	Name            string
+}
+
+// CoffGroupSym describes a COFF group (S_COFFGROUP): the contributions of
+// like-named input sections, such as ".text$mn", within an image section.
+type CoffGroupSym struct {
+	Length          uint32 // Size of the group
+	Characteristics uint32 // IMAGE_SCN_* flags
+	Offset          uint32 // Offset of the group within its section
+	Segment         uint16 // 1-based section number
+	Name            string
+}
+
+// HeapAllocSiteSym represents a heap allocation call site
+// (S_HEAPALLOCSITE).
+type HeapAllocSiteSym struct {
+	Offset            uint32 // Offset of the call instruction
+	Segment           uint16 // Segment of the call instruction
+	InstructionLength uint16 // Length of the call instruction
+	TypeIndex         uint32 // Type of the allocated object
+}
+
+// FunctionListSym represents the callees or callers of the enclosing
+// procedure (S_CALLEES, S_CALLERS).
+type FunctionListSym struct {
+	Functions   []uint32 // ID indices (LF_FUNC_ID/LF_MFUNC_ID)
+	Invocations []uint32 // Invocation count of each function; zero if not recorded
+}
+
+// InlineSiteSym represents an inlined call site (S_INLINESITE,
+// S_INLINESITE2).
+type InlineSiteSym struct {
+	Parent            uint32 // Offset of the enclosing scope symbol
+	End               uint32 // Offset of the matching S_INLINESITE_END
+	Inlinee           uint32 // ID index of the inlined function (LF_FUNC_ID/LF_MFUNC_ID)
+	Invocations       uint32 // Dynamic invocation count (S_INLINESITE2 only)
+	BinaryAnnotations []byte // Compressed binary annotations
+}
+
+// S_FRAMEPROC flags (FRAMEPROCSYM.flags)
+const (
+	FrameHasAlloca          = 0x00000001 // Function uses _alloca()
+	FrameHasSetJmp          = 0x00000002 // Function uses setjmp()
+	FrameHasLongJmp         = 0x00000004 // Function uses longjmp()
+	FrameHasInlineAsm       = 0x00000008 // Function uses inline asm
+	FrameHasEH              = 0x00000010 // Function has EH states
+	FrameInlineSpec         = 0x00000020 // Function was specified as inline
+	FrameHasSEH             = 0x00000040 // Function has SEH
+	FrameNaked              = 0x00000080 // Function is __declspec(naked)
+	FrameSecurityChecks     = 0x00000100 // Function has buffer security check (/GS)
+	FrameAsyncEH            = 0x00000200 // Function compiled with /EHa
+	FrameGSNoStackOrdering  = 0x00000400 // /GS stack ordering was not done
+	FrameWasInlined         = 0x00000800 // Function was inlined within another
+	FrameGSCheck            = 0x00001000 // Function is __declspec(strict_gs_check)
+	FrameSafeBuffers        = 0x00002000 // Function is __declspec(safebuffers)
+	FramePogoOn             = 0x00040000 // Compiled with PGO/PGU
+	FrameValidCounts        = 0x00080000 // PGO counts are valid
+	FrameOptSpeed           = 0x00100000 // Optimized for speed
+	FrameGuardCF            = 0x00200000 // Contains CFG checks
+	FrameGuardCFW           = 0x00400000 // Contains CFW checks
+	frameLocalBasePtrShift  = 14
+	frameParamBasePtrShift  = 16
+	frameEncodedBasePtrMask = 0x3
+)
+
+// FrameProcSym represents extra frame information of a procedure
+// (S_FRAMEPROC).
+type FrameProcSym struct {
+	TotalFrameBytes         uint32 // Size of the stack frame
+	PaddingBytes            uint32 // Size of the padding in the frame
+	PaddingOffset           uint32 // Frame offset of the padding
+	CalleeSavesBytes        uint32 // Size of the callee-saved registers area
+	ExceptionHandlerOffset  uint32 // Offset of the exception handler
+	ExceptionHandlerSection uint16 // Section of the exception handler
+	Flags                   uint32 // Frame* flags
+}
+
+// LocalBasePointer returns the encoded register used to address locals.
+func (f *FrameProcSym) LocalBasePointer() uint8 {
+	return uint8(f.Flags >> frameLocalBasePtrShift & frameEncodedBasePtrMask)
+}
+
+// ParamBasePointer returns the encoded register used to address parameters.
+func (f *FrameProcSym) ParamBasePointer() uint8 {
+	return uint8(f.Flags >> frameParamBasePtrShift & frameEncodedBasePtrMask)
+}
+
 // ConstantSym represents a constant symbol (S_CONSTANT).
 type ConstantSym struct {
-	TypeIndex uint32 // Type index
-	Value     uint64 // Constant value
-	Name      string // Constant name
+	TypeIndex uint32          // Type index
+	Value     uint64          // Constant value (integer leaves)
+	Numeric   streams.Numeric // Decoded value, including floating-point and wide leaves
+	Name      string          // Constant name
+}
+
+// ObjNameSym represents the object file name of a module (S_OBJNAME).
+type ObjNameSym struct {
+	Signature uint32 // Object file signature
+	Name      string // Object file path
+}
+
+// EnvBlockSym represents the build environment of a module (S_ENVBLOCK).
+type EnvBlockSym struct {
+	Flags uint8             // Reserved flags
+	Env   map[string]string // Key/value pairs such as "cwd" and "cmd"
+}
+
+// BuildInfoSym references the build information of a module (S_BUILDINFO).
+type BuildInfoSym struct {
+	BuildID uint32 // ID index of the LF_BUILDINFO record in the IPI stream
+}
+
+// CompileSym represents compiler information (S_COMPILE2, S_COMPILE3).
+type CompileSym struct {
+	Language        uint8     // CV_CFL_* source language
+	Flags           uint32    // Compile flags (bits above the language byte)
+	Machine         uint16    // CV_CFL_* target processor
+	FrontendVersion [4]uint16 // Major, minor, build, QFE
+	BackendVersion  [4]uint16 // Major, minor, build, QFE
+	CompilerName    string    // Compiler version string
 }
 
 // ParseSymbols parses all symbol records from raw symbol data.
 func ParseSymbols(data []byte) ([]SymbolRecord, error) {
 	var symbols []SymbolRecord
+	err := WalkSymbols(data, func(sym SymbolRecord) error {
+		sym.Data = append([]byte(nil), sym.Data...)
+		symbols = append(symbols, sym)
+		return nil
+	})
+	return symbols, err
+}
+
+// WalkSymbols calls fn for each symbol record in raw symbol data, without
+// collecting them. The record's Data aliases data. Walking stops at the
+// first malformed record or when fn returns an error, which is returned.
+func WalkSymbols(data []byte, fn func(sym SymbolRecord) error) error {
 	offset := 0
 
 	// Skip the signature at the start (4 bytes)
@@ -318,29 +647,30 @@ func ParseSymbols(data []byte) ([]SymbolRecord, error) {
 		recLen := binary.LittleEndian.Uint16(data[offset:])
 		offset += 2
 
-		if recLen < 2 || offset+int(recLen) > len(data) {
-			break
+		if recLen < 2 {
+			return fmt.Errorf("invalid symbol record length %d at offset %d", recLen, offset-2)
+		}
+		if offset+int(recLen) > len(data) {
+			return fmt.Errorf("symbol record at offset %d overruns data (%d > %d bytes)", offset-2, recLen, len(data)-offset)
 		}
 
 		// Read record kind (2 bytes)
-		recKind := binary.LittleEndian.Uint16(data[offset:])
-
 		sym := SymbolRecord{
-			Kind: recKind,
-			Data: make([]byte, recLen-2),
+			Kind: binary.LittleEndian.Uint16(data[offset:]),
+			Data: data[offset+2 : offset+int(recLen)],
+		}
+		if err := fn(sym); err != nil {
+			return err
 		}
-		copy(sym.Data, data[offset+2:offset+int(recLen)])
-
-		symbols = append(symbols, sym)
 		offset += int(recLen)
 	}
 
-	return symbols, nil
+	return nil
 }
 
 // ParseProcSym parses a procedure symbol record.
 func ParseProcSym(data []byte) (*ProcSym, error) {
-	if len(data) < 32 {
+	if len(data) < 35 {
 		return nil, fmt.Errorf("proc symbol data too small: %d bytes", len(data))
 	}
 
@@ -395,6 +725,83 @@ func ParseDataSym(data []byte) (*DataSym, error) {
 	return dataSym, nil
 }
 
+// ParseProcSym16t parses a 16-bit type index procedure symbol record
+// (S_GPROC32_16t, S_LPROC32_16t), whose name is length-prefixed.
+func ParseProcSym16t(data []byte) (*ProcSym, error) {
+	if len(data) < 32 {
+		return nil, fmt.Errorf("proc symbol data too small: %d bytes", len(data))
+	}
+
+	proc := &ProcSym{
+		Parent:    binary.LittleEndian.Uint32(data[0:]),
+		End:       binary.LittleEndian.Uint32(data[4:]),
+		Next:      binary.LittleEndian.Uint32(data[8:]),
+		Length:    binary.LittleEndian.Uint32(data[12:]),
+		DbgStart:  binary.LittleEndian.Uint32(data[16:]),
+		DbgEnd:    binary.LittleEndian.Uint32(data[20:]),
+		Offset:    binary.LittleEndian.Uint32(data[24:]),
+		Segment:   binary.LittleEndian.Uint16(data[28:]),
+		TypeIndex: uint32(binary.LittleEndian.Uint16(data[30:])),
+	}
+	if len(data) > 32 {
+		proc.Flags = data[32]
+		proc.Name, _ = streams.ParseLengthPrefixedString(data[33:])
+	}
+
+	return proc, nil
+}
+
+// ParseDataSym16t parses a 16-bit type index data symbol record
+// (S_GDATA32_16t, S_LDATA32_16t, S_GTHREAD32_16t, S_LTHREAD32_16t,
+// S_PUB32_16t), whose name is length-prefixed.
+func ParseDataSym16t(data []byte) (*DataSym, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("data symbol data too small: %d bytes", len(data))
+	}
+
+	dataSym := &DataSym{
+		Offset:    binary.LittleEndian.Uint32(data[0:]),
+		Segment:   binary.LittleEndian.Uint16(data[4:]),
+		TypeIndex: uint32(binary.LittleEndian.Uint16(data[6:])),
+	}
+	dataSym.Name, _ = streams.ParseLengthPrefixedString(data[8:])
+
+	return dataSym, nil
+}
+
+// ParseProcSymKind parses a procedure symbol record of the given kind,
+// choosing the 16-bit layout for the _16t kinds.
+func ParseProcSymKind(kind uint16, data []byte) (*ProcSym, error) {
+	switch kind {
+	case S_GPROC32_16t, S_LPROC32_16t:
+		return ParseProcSym16t(data)
+	}
+	return ParseProcSym(data)
+}
+
+// ParseDataSymKind parses a data symbol record of the given kind,
+// choosing the 16-bit layout for the _16t kinds.
+func ParseDataSymKind(kind uint16, data []byte) (*DataSym, error) {
+	switch kind {
+	case S_GDATA32_16t, S_LDATA32_16t, S_GTHREAD32_16t, S_LTHREAD32_16t:
+		return ParseDataSym16t(data)
+	}
+	return ParseDataSym(data)
+}
+
+// ParsePubSymKind parses a public symbol record of the given kind. The
+// S_PUB32_16t layout matches the 16-bit data symbol and carries no flags.
+func ParsePubSymKind(kind uint16, data []byte) (*PubSym, error) {
+	if kind != S_PUB32_16t {
+		return ParsePubSym(data)
+	}
+	d, err := ParseDataSym16t(data)
+	if err != nil {
+		return nil, err
+	}
+	return &PubSym{Offset: d.Offset, Segment: d.Segment, Name: d.Name}, nil
+}
+
 // ParseUDTSym parses a UDT symbol record.
 func ParseUDTSym(data []byte) (*UDTSym, error) {
 	if len(data) < 4 {
@@ -454,8 +861,9 @@ func ParseConstantSym(data []byte) (*ConstantSym, error) {
 	}
 
 	// Parse numeric value
-	val, consumed := parseNumeric(data[4:])
-	constant.Value = val
+	numeric, consumed := streams.ParseNumericLeaf(data[4:])
+	constant.Value = numeric.Value
+	constant.Numeric = numeric
 
 	// Parse null-terminated name
 	nameOffset := 4 + consumed
@@ -471,56 +879,806 @@ func ParseConstantSym(data []byte) (*ConstantSym, error) {
 	return constant, nil
 }
 
-// parseNumeric parses a numeric leaf value.
-func parseNumeric(data []byte) (uint64, int) {
-	if len(data) < 2 {
-		return 0, 0
+// ParseCompile3Sym parses a compiler information record (S_COMPILE3).
+func ParseCompile3Sym(data []byte) (*CompileSym, error) {
+	if len(data) < 22 {
+		return nil, fmt.Errorf("compile3 symbol data too small: %d bytes", len(data))
+	}
+
+	flags := binary.LittleEndian.Uint32(data[0:])
+	compile := &CompileSym{
+		Language: uint8(flags),
+		Flags:    flags >> 8,
+		Machine:  binary.LittleEndian.Uint16(data[4:]),
+	}
+	for i := 0; i < 4; i++ {
+		compile.FrontendVersion[i] = binary.LittleEndian.Uint16(data[6+i*2:])
+		compile.BackendVersion[i] = binary.LittleEndian.Uint16(data[14+i*2:])
+	}
+
+	// Parse null-terminated compiler name
+	if len(data) > 22 {
+		nameEnd := bytes.IndexByte(data[22:], 0)
+		if nameEnd == -1 {
+			compile.CompilerName = string(data[22:])
+		} else {
+			compile.CompilerName = string(data[22 : 22+nameEnd])
+		}
+	}
+
+	return compile, nil
+}
+
+// ParseCompile2Sym parses a compiler information record (S_COMPILE2).
+// S_COMPILE2 has no QFE version numbers; they are left as zero.
+func ParseCompile2Sym(data []byte) (*CompileSym, error) {
+	if len(data) < 18 {
+		return nil, fmt.Errorf("compile2 symbol data too small: %d bytes", len(data))
+	}
+
+	flags := binary.LittleEndian.Uint32(data[0:])
+	compile := &CompileSym{
+		Language: uint8(flags),
+		Flags:    flags >> 8,
+		Machine:  binary.LittleEndian.Uint16(data[4:]),
+	}
+	for i := 0; i < 3; i++ {
+		compile.FrontendVersion[i] = binary.LittleEndian.Uint16(data[6+i*2:])
+		compile.BackendVersion[i] = binary.LittleEndian.Uint16(data[12+i*2:])
+	}
+
+	// Parse null-terminated compiler name
+	if len(data) > 18 {
+		nameEnd := bytes.IndexByte(data[18:], 0)
+		if nameEnd == -1 {
+			compile.CompilerName = string(data[18:])
+		} else {
+			compile.CompilerName = string(data[18 : 18+nameEnd])
+		}
+	}
+
+	return compile, nil
+}
+
+// ParseObjNameSym parses an object file name record (S_OBJNAME).
+func ParseObjNameSym(data []byte) (*ObjNameSym, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("objname symbol data too small: %d bytes", len(data))
 	}
 
-	val := binary.LittleEndian.Uint16(data)
-	if val < 0x8000 {
-		return uint64(val), 2
+	obj := &ObjNameSym{
+		Signature: binary.LittleEndian.Uint32(data[0:]),
 	}
+	obj.Name, _ = streams.ParseString(data[4:])
+
+	return obj, nil
+}
 
-	switch val {
-	case 0x8000: // LF_CHAR
-		if len(data) < 3 {
-			return 0, 0
+// ParseEnvBlockSym parses a build environment record (S_ENVBLOCK). The
+// flags byte is followed by null-terminated key and value strings, ending
+// with an empty key.
+func ParseEnvBlockSym(data []byte) (*EnvBlockSym, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("envblock symbol data too small: %d bytes", len(data))
+	}
+
+	env := &EnvBlockSym{
+		Flags: data[0],
+		Env:   make(map[string]string),
+	}
+
+	offset := 1
+	for offset < len(data) {
+		key, n := streams.ParseString(data[offset:])
+		offset += n
+		if key == "" {
+			break
 		}
-		return uint64(int8(data[2])), 3
-	case 0x8001: // LF_SHORT
-		if len(data) < 4 {
-			return 0, 0
+		value, n := streams.ParseString(data[offset:])
+		offset += n
+		env.Env[key] = value
+	}
+
+	return env, nil
+}
+
+// ParseBuildInfoSym parses a build information record (S_BUILDINFO).
+func ParseBuildInfoSym(data []byte) (*BuildInfoSym, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("buildinfo symbol data too small: %d bytes", len(data))
+	}
+
+	return &BuildInfoSym{BuildID: binary.LittleEndian.Uint32(data[0:])}, nil
+}
+
+// ParseBlockSym parses a block symbol record (S_BLOCK32).
+func ParseBlockSym(data []byte) (*BlockSym, error) {
+	if len(data) < 18 {
+		return nil, fmt.Errorf("block symbol data too small: %d bytes", len(data))
+	}
+
+	block := &BlockSym{
+		Parent:  binary.LittleEndian.Uint32(data[0:]),
+		End:     binary.LittleEndian.Uint32(data[4:]),
+		Length:  binary.LittleEndian.Uint32(data[8:]),
+		Offset:  binary.LittleEndian.Uint32(data[12:]),
+		Segment: binary.LittleEndian.Uint16(data[16:]),
+	}
+
+	// Parse null-terminated name
+	if len(data) > 18 {
+		nameEnd := bytes.IndexByte(data[18:], 0)
+		if nameEnd == -1 {
+			block.Name = string(data[18:])
+		} else {
+			block.Name = string(data[18 : 18+nameEnd])
 		}
-		return uint64(int16(binary.LittleEndian.Uint16(data[2:]))), 4
-	case 0x8002: // LF_USHORT
-		if len(data) < 4 {
-			return 0, 0
+	}
+
+	return block, nil
+}
+
+// ParseLabelSym parses a code label record (S_LABEL32).
+func ParseLabelSym(data []byte) (*LabelSym, error) {
+	if len(data) < 7 {
+		return nil, fmt.Errorf("label symbol data too small: %d bytes", len(data))
+	}
+
+	label := &LabelSym{
+		Offset:  binary.LittleEndian.Uint32(data[0:]),
+		Segment: binary.LittleEndian.Uint16(data[4:]),
+		Flags:   data[6],
+	}
+	label.Name, _ = streams.ParseString(data[7:])
+
+	return label, nil
+}
+
+// ParseLabelSymKind parses a code label record of the given kind. The
+// S_LABEL32_ST layout is the same but for its length-prefixed name.
+func ParseLabelSymKind(kind uint16, data []byte) (*LabelSym, error) {
+	label, err := ParseLabelSym(data)
+	if err != nil || kind != S_LABEL32_ST {
+		return label, err
+	}
+	label.Name, _ = streams.ParseLengthPrefixedString(data[7:])
+	return label, nil
+}
+
+// ParseThunkSym parses a thunk symbol record (S_THUNK32).
+func ParseThunkSym(data []byte) (*ThunkSym, error) {
+	if len(data) < 21 {
+		return nil, fmt.Errorf("thunk symbol data too small: %d bytes", len(data))
+	}
+
+	thunk := &ThunkSym{
+		Parent:  binary.LittleEndian.Uint32(data[0:]),
+		End:     binary.LittleEndian.Uint32(data[4:]),
+		Next:    binary.LittleEndian.Uint32(data[8:]),
+		Offset:  binary.LittleEndian.Uint32(data[12:]),
+		Segment: binary.LittleEndian.Uint16(data[16:]),
+		Length:  binary.LittleEndian.Uint16(data[18:]),
+		Ordinal: data[20],
+	}
+
+	name, n := streams.ParseString(data[21:])
+	thunk.Name = name
+	thunk.ThunkData = data[21+n:]
+
+	switch thunk.Ordinal {
+	case ThunkAdjustor:
+		if len(thunk.ThunkData) >= 2 {
+			thunk.AdjustorDelta = int16(binary.LittleEndian.Uint16(thunk.ThunkData))
+			thunk.AdjustorTarget, _ = streams.ParseString(thunk.ThunkData[2:])
 		}
-		return uint64(binary.LittleEndian.Uint16(data[2:])), 4
-	case 0x8003: // LF_LONG
-		if len(data) < 6 {
-			return 0, 0
+	case ThunkVCall:
+		if len(thunk.ThunkData) >= 2 {
+			thunk.VCallOffset = binary.LittleEndian.Uint16(thunk.ThunkData)
 		}
-		return uint64(int32(binary.LittleEndian.Uint32(data[2:]))), 6
-	case 0x8004: // LF_ULONG
-		if len(data) < 6 {
-			return 0, 0
+	}
+
+	return thunk, nil
+}
+
+// ParseSepCodeSym parses a separated code symbol record (S_SEPCODE).
+func ParseSepCodeSym(data []byte) (*SepCodeSym, error) {
+	if len(data) < 28 {
+		return nil, fmt.Errorf("sepcode symbol data too small: %d bytes", len(data))
+	}
+
+	return &SepCodeSym{
+		Parent:        binary.LittleEndian.Uint32(data[0:]),
+		End:           binary.LittleEndian.Uint32(data[4:]),
+		Length:        binary.LittleEndian.Uint32(data[8:]),
+		Flags:         binary.LittleEndian.Uint32(data[12:]),
+		Offset:        binary.LittleEndian.Uint32(data[16:]),
+		ParentOffset:  binary.LittleEndian.Uint32(data[20:]),
+		Segment:       binary.LittleEndian.Uint16(data[24:]),
+		ParentSegment: binary.LittleEndian.Uint16(data[26:]),
+	}, nil
+}
+
+// ThunkOrdinalName returns a short name for a thunk ordinal.
+func ThunkOrdinalName(ordinal uint8) string {
+	switch ordinal {
+	case ThunkNoType:
+		return "notype"
+	case ThunkAdjustor:
+		return "adjustor"
+	case ThunkVCall:
+		return "vcall"
+	case ThunkPCode:
+		return "pcode"
+	case ThunkLoad:
+		return "load"
+	case ThunkTrampIncremental:
+		return "trampoline_incremental"
+	case ThunkTrampBranchIsland:
+		return "trampoline_branch_island"
+	default:
+		return fmt.Sprintf("thunk_%d", ordinal)
+	}
+}
+
+// ParseFrameProcSym parses a frame information record (S_FRAMEPROC).
+func ParseFrameProcSym(data []byte) (*FrameProcSym, error) {
+	if len(data) < 26 {
+		return nil, fmt.Errorf("frame proc symbol data too small: %d bytes", len(data))
+	}
+
+	return &FrameProcSym{
+		TotalFrameBytes:         binary.LittleEndian.Uint32(data[0:]),
+		PaddingBytes:            binary.LittleEndian.Uint32(data[4:]),
+		PaddingOffset:           binary.LittleEndian.Uint32(data[8:]),
+		CalleeSavesBytes:        binary.LittleEndian.Uint32(data[12:]),
+		ExceptionHandlerOffset:  binary.LittleEndian.Uint32(data[16:]),
+		ExceptionHandlerSection: binary.LittleEndian.Uint16(data[20:]),
+		Flags:                   binary.LittleEndian.Uint32(data[22:]),
+	}, nil
+}
+
+// ParseExportSym parses an export record (S_EXPORT).
+func ParseExportSym(data []byte) (*ExportSym, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("export symbol data too small: %d bytes", len(data))
+	}
+
+	export := &ExportSym{
+		Ordinal: binary.LittleEndian.Uint16(data[0:]),
+		Flags:   binary.LittleEndian.Uint16(data[2:]),
+	}
+	export.Name, _ = streams.ParseString(data[4:])
+
+	return export, nil
+}
+
+// ParseAnnotationSym parses an annotation record (S_ANNOTATION): an
+// address followed by a count of null-terminated strings.
+func ParseAnnotationSym(data []byte) (*AnnotationSym, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("annotation symbol data too small: %d bytes", len(data))
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[6:]))
+	annotation := &AnnotationSym{
+		Offset:  binary.LittleEndian.Uint32(data[0:]),
+		Segment: binary.LittleEndian.Uint16(data[4:]),
+		Strings: make([]string, 0, count),
+	}
+
+	pos := 8
+	for i := 0; i < count; i++ {
+		if pos >= len(data) {
+			return nil, fmt.Errorf("annotation has %d of %d strings", i, count)
 		}
-		return uint64(binary.LittleEndian.Uint32(data[2:])), 6
-	case 0x8009: // LF_QUADWORD
-		if len(data) < 10 {
-			return 0, 0
+		str, n := streams.ParseString(data[pos:])
+		annotation.Strings = append(annotation.Strings, str)
+		pos += n
+	}
+
+	return annotation, nil
+}
+
+// ParseSectionSym parses an image section record (S_SECTION).
+func ParseSectionSym(data []byte) (*SectionSym, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("section symbol data too small: %d bytes", len(data))
+	}
+
+	section := &SectionSym{
+		Section:         binary.LittleEndian.Uint16(data[0:]),
+		Alignment:       data[2],
+		RVA:             binary.LittleEndian.Uint32(data[4:]),
+		Length:          binary.LittleEndian.Uint32(data[8:]),
+		Characteristics: binary.LittleEndian.Uint32(data[12:]),
+	}
+	section.Name, _ = streams.ParseString(data[16:])
+
+	return section, nil
+}
+
+// ParseCoffGroupSym parses a COFF group record (S_COFFGROUP).
+func ParseCoffGroupSym(data []byte) (*CoffGroupSym, error) {
+	if len(data) < 14 {
+		return nil, fmt.Errorf("coff group symbol data too small: %d bytes", len(data))
+	}
+
+	group := &CoffGroupSym{
+		Length:          binary.LittleEndian.Uint32(data[0:]),
+		Characteristics: binary.LittleEndian.Uint32(data[4:]),
+		Offset:          binary.LittleEndian.Uint32(data[8:]),
+		Segment:         binary.LittleEndian.Uint16(data[12:]),
+	}
+	group.Name, _ = streams.ParseString(data[14:])
+
+	return group, nil
+}
+
+// ParseRefSym parses a symbol reference record (S_ANNOTATIONREF and the
+// other REFSYM2 kinds).
+func ParseRefSym(data []byte) (*RefSym, error) {
+	if len(data) < 10 {
+		return nil, fmt.Errorf("reference symbol data too small: %d bytes", len(data))
+	}
+
+	ref := &RefSym{
+		SumName:   binary.LittleEndian.Uint32(data[0:]),
+		SymOffset: binary.LittleEndian.Uint32(data[4:]),
+		Module:    binary.LittleEndian.Uint16(data[8:]),
+	}
+	ref.Name, _ = streams.ParseString(data[10:])
+
+	return ref, nil
+}
+
+// SymbolAt returns the symbol record starting at offset in raw symbol
+// data, as referenced by RefSym.SymOffset. The offset counts from the start
+// of the stream, including its signature. The record's Data aliases data.
+func SymbolAt(data []byte, offset uint32) (SymbolRecord, error) {
+	if uint64(offset)+4 > uint64(len(data)) {
+		return SymbolRecord{}, fmt.Errorf("symbol offset %d out of range (%d bytes)", offset, len(data))
+	}
+
+	recLen := binary.LittleEndian.Uint16(data[offset:])
+	end := uint64(offset) + 2 + uint64(recLen)
+	if recLen < 2 || end > uint64(len(data)) {
+		return SymbolRecord{}, fmt.Errorf("invalid symbol record length %d at offset %d", recLen, offset)
+	}
+	return SymbolRecord{
+		Kind: binary.LittleEndian.Uint16(data[offset+2:]),
+		Data: data[offset+4 : end],
+	}, nil
+}
+
+// ParseHeapAllocSiteSym parses a heap allocation site record
+// (S_HEAPALLOCSITE).
+func ParseHeapAllocSiteSym(data []byte) (*HeapAllocSiteSym, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("heap alloc site symbol data too small: %d bytes", len(data))
+	}
+
+	return &HeapAllocSiteSym{
+		Offset:            binary.LittleEndian.Uint32(data[0:]),
+		Segment:           binary.LittleEndian.Uint16(data[4:]),
+		InstructionLength: binary.LittleEndian.Uint16(data[6:]),
+		TypeIndex:         binary.LittleEndian.Uint32(data[8:]),
+	}, nil
+}
+
+// ParseFunctionListSym parses a function list record (S_CALLEES,
+// S_CALLERS). The function IDs are followed by an optional array of
+// invocation counts, which may be shorter than the list.
+func ParseFunctionListSym(data []byte) (*FunctionListSym, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("function list symbol data too small: %d bytes", len(data))
+	}
+
+	count := binary.LittleEndian.Uint32(data[0:])
+	if uint64(count)*4 > uint64(len(data)-4) {
+		return nil, fmt.Errorf("function list count %d exceeds record size %d", count, len(data))
+	}
+
+	list := &FunctionListSym{
+		Functions:   make([]uint32, count),
+		Invocations: make([]uint32, count),
+	}
+	offset := 4
+	for i := range list.Functions {
+		list.Functions[i] = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+	}
+	for i := range list.Invocations {
+		if offset+4 > len(data) {
+			break
 		}
-		return binary.LittleEndian.Uint64(data[2:]), 10
-	case 0x800a: // LF_UQUADWORD
-		if len(data) < 10 {
-			return 0, 0
+		list.Invocations[i] = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+	}
+
+	return list, nil
+}
+
+// ParseInlineSiteSym parses an inlined call site record (S_INLINESITE).
+func ParseInlineSiteSym(data []byte) (*InlineSiteSym, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("inline site symbol data too small: %d bytes", len(data))
+	}
+
+	return &InlineSiteSym{
+		Parent:            binary.LittleEndian.Uint32(data[0:]),
+		End:               binary.LittleEndian.Uint32(data[4:]),
+		Inlinee:           binary.LittleEndian.Uint32(data[8:]),
+		BinaryAnnotations: data[12:],
+	}, nil
+}
+
+// ParseInlineSite2Sym parses an inlined call site record with an
+// invocation count (S_INLINESITE2).
+func ParseInlineSite2Sym(data []byte) (*InlineSiteSym, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("inline site2 symbol data too small: %d bytes", len(data))
+	}
+
+	return &InlineSiteSym{
+		Parent:            binary.LittleEndian.Uint32(data[0:]),
+		End:               binary.LittleEndian.Uint32(data[4:]),
+		Inlinee:           binary.LittleEndian.Uint32(data[8:]),
+		Invocations:       binary.LittleEndian.Uint32(data[12:]),
+		BinaryAnnotations: data[16:],
+	}, nil
+}
+
+// ParseLocalSym parses a local variable symbol record (S_LOCAL).
+func ParseLocalSym(data []byte) (*LocalSym, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("local symbol data too small: %d bytes", len(data))
+	}
+
+	local := &LocalSym{
+		TypeIndex: binary.LittleEndian.Uint32(data[0:]),
+		Flags:     binary.LittleEndian.Uint16(data[4:]),
+	}
+
+	// Parse null-terminated name
+	if len(data) > 6 {
+		nameEnd := bytes.IndexByte(data[6:], 0)
+		if nameEnd == -1 {
+			local.Name = string(data[6:])
+		} else {
+			local.Name = string(data[6 : 6+nameEnd])
 		}
-		return binary.LittleEndian.Uint64(data[2:]), 10
+	}
+
+	return local, nil
+}
+
+// ParseRegRel32Sym parses a register-relative symbol record (S_REGREL32).
+func ParseRegRel32Sym(data []byte) (*RegRelSym, error) {
+	if len(data) < 10 {
+		return nil, fmt.Errorf("register-relative symbol data too small: %d bytes", len(data))
+	}
+
+	sym := &RegRelSym{
+		Offset:    int32(binary.LittleEndian.Uint32(data[0:])),
+		TypeIndex: binary.LittleEndian.Uint32(data[4:]),
+		Register:  binary.LittleEndian.Uint16(data[8:]),
+	}
+	sym.Name, _ = streams.ParseString(data[10:])
+
+	return sym, nil
+}
+
+// ParseRegisterSym parses a register symbol record (S_REGISTER).
+func ParseRegisterSym(data []byte) (*RegisterSym, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("register symbol data too small: %d bytes", len(data))
+	}
+
+	sym := &RegisterSym{
+		TypeIndex: binary.LittleEndian.Uint32(data[0:]),
+		Register:  binary.LittleEndian.Uint16(data[4:]),
+	}
+	sym.Name, _ = streams.ParseString(data[6:])
+
+	return sym, nil
+}
+
+// parseAddressRange parses a CV_LVAR_ADDR_RANGE followed by the gap list
+// that occupies the rest of the record.
+func parseAddressRange(data []byte) (AddressRange, []AddressGap) {
+	var rng AddressRange
+	if len(data) < 8 {
+		return rng, nil
+	}
+
+	rng.Offset = binary.LittleEndian.Uint32(data[0:])
+	rng.Segment = binary.LittleEndian.Uint16(data[4:])
+	rng.Length = binary.LittleEndian.Uint16(data[6:])
+
+	var gaps []AddressGap
+	for offset := 8; offset+4 <= len(data); offset += 4 {
+		gaps = append(gaps, AddressGap{
+			Offset: binary.LittleEndian.Uint16(data[offset:]),
+			Length: binary.LittleEndian.Uint16(data[offset+2:]),
+		})
+	}
+
+	return rng, gaps
+}
+
+// ParseDefRange parses a DIA program def-range record (S_DEFRANGE).
+func ParseDefRange(data []byte) (*DefRangeSym, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("defrange data too small: %d bytes", len(data))
+	}
+
+	def := &DefRangeSym{
+		Kind:    S_DEFRANGE,
+		Program: binary.LittleEndian.Uint32(data[0:]),
+	}
+	def.Range, def.Gaps = parseAddressRange(data[4:])
+
+	return def, nil
+}
+
+// ParseDefRangeSubfield parses a DIA program subfield def-range record
+// (S_DEFRANGE_SUBFIELD).
+func ParseDefRangeSubfield(data []byte) (*DefRangeSym, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("defrange subfield data too small: %d bytes", len(data))
+	}
+
+	def := &DefRangeSym{
+		Kind:         S_DEFRANGE_SUBFIELD,
+		Program:      binary.LittleEndian.Uint32(data[0:]),
+		OffsetParent: uint16(binary.LittleEndian.Uint32(data[4:])),
+	}
+	def.Range, def.Gaps = parseAddressRange(data[8:])
+
+	return def, nil
+}
+
+// ParseDefRangeRegister parses a register def-range record
+// (S_DEFRANGE_REGISTER).
+func ParseDefRangeRegister(data []byte) (*DefRangeSym, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("defrange register data too small: %d bytes", len(data))
+	}
+
+	def := &DefRangeSym{
+		Kind:          S_DEFRANGE_REGISTER,
+		Register:      binary.LittleEndian.Uint16(data[0:]),
+		MayHaveNoName: binary.LittleEndian.Uint16(data[2:])&0x1 != 0,
+	}
+	def.Range, def.Gaps = parseAddressRange(data[4:])
+
+	return def, nil
+}
+
+// ParseDefRangeFramePointerRel parses a frame-pointer-relative def-range
+// record (S_DEFRANGE_FRAMEPOINTER_REL).
+func ParseDefRangeFramePointerRel(data []byte) (*DefRangeSym, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("defrange frame pointer rel data too small: %d bytes", len(data))
+	}
+
+	def := &DefRangeSym{
+		Kind:   S_DEFRANGE_FRAMEPOINTER_REL,
+		Offset: int32(binary.LittleEndian.Uint32(data[0:])),
+	}
+	def.Range, def.Gaps = parseAddressRange(data[4:])
+
+	return def, nil
+}
+
+// ParseDefRangeSubfieldRegister parses a register subfield def-range record
+// (S_DEFRANGE_SUBFIELD_REGISTER).
+func ParseDefRangeSubfieldRegister(data []byte) (*DefRangeSym, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("defrange subfield register data too small: %d bytes", len(data))
+	}
+
+	def := &DefRangeSym{
+		Kind:          S_DEFRANGE_SUBFIELD_REGISTER,
+		Register:      binary.LittleEndian.Uint16(data[0:]),
+		MayHaveNoName: binary.LittleEndian.Uint16(data[2:])&0x1 != 0,
+		OffsetParent:  uint16(binary.LittleEndian.Uint32(data[4:]) & 0xFFF),
+	}
+	def.Range, def.Gaps = parseAddressRange(data[8:])
+
+	return def, nil
+}
+
+// ParseDefRangeFramePointerRelFullScope parses a frame-pointer-relative
+// def-range valid for the whole function
+// (S_DEFRANGE_FRAMEPOINTER_REL_FULL_SCOPE).
+func ParseDefRangeFramePointerRelFullScope(data []byte) (*DefRangeSym, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("defrange full scope data too small: %d bytes", len(data))
+	}
+
+	return &DefRangeSym{
+		Kind:      S_DEFRANGE_FRAMEPOINTER_REL_FULL_SCOPE,
+		Offset:    int32(binary.LittleEndian.Uint32(data[0:])),
+		FullScope: true,
+	}, nil
+}
+
+// ParseDefRangeRegisterRel parses a register-relative def-range record
+// (S_DEFRANGE_REGISTER_REL).
+func ParseDefRangeRegisterRel(data []byte) (*DefRangeSym, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("defrange register rel data too small: %d bytes", len(data))
+	}
+
+	flags := binary.LittleEndian.Uint16(data[2:])
+	def := &DefRangeSym{
+		Kind:         S_DEFRANGE_REGISTER_REL,
+		Register:     binary.LittleEndian.Uint16(data[0:]),
+		OffsetParent: flags >> 4,
+		Offset:       int32(binary.LittleEndian.Uint32(data[4:])),
+	}
+	def.Range, def.Gaps = parseAddressRange(data[8:])
+
+	return def, nil
+}
+
+// ParseDefRangeSym parses any S_DEFRANGE_* record by dispatching on kind.
+func ParseDefRangeSym(kind uint16, data []byte) (*DefRangeSym, error) {
+	switch kind {
+	case S_DEFRANGE:
+		return ParseDefRange(data)
+	case S_DEFRANGE_SUBFIELD:
+		return ParseDefRangeSubfield(data)
+	case S_DEFRANGE_REGISTER:
+		return ParseDefRangeRegister(data)
+	case S_DEFRANGE_FRAMEPOINTER_REL:
+		return ParseDefRangeFramePointerRel(data)
+	case S_DEFRANGE_SUBFIELD_REGISTER:
+		return ParseDefRangeSubfieldRegister(data)
+	case S_DEFRANGE_FRAMEPOINTER_REL_FULL_SCOPE:
+		return ParseDefRangeFramePointerRelFullScope(data)
+	case S_DEFRANGE_REGISTER_REL:
+		return ParseDefRangeRegisterRel(data)
+	}
+	return nil, fmt.Errorf("not a defrange symbol: %s", SymbolKindName(kind))
+}
+
+// IsDefRangeSymbol returns true if the kind is an S_DEFRANGE_* record.
+func IsDefRangeSymbol(kind uint16) bool {
+	switch kind {
+	case S_DEFRANGE, S_DEFRANGE_SUBFIELD, S_DEFRANGE_REGISTER,
+		S_DEFRANGE_FRAMEPOINTER_REL, S_DEFRANGE_SUBFIELD_REGISTER,
+		S_DEFRANGE_FRAMEPOINTER_REL_FULL_SCOPE, S_DEFRANGE_REGISTER_REL:
+		return true
+	}
+	return false
+}
+
+// IsScopeStart returns true if the kind opens a scope that is closed by a
+// matching S_END, S_PROC_ID_END or S_INLINESITE_END.
+func IsScopeStart(kind uint16) bool {
+	if IsProcSymbol(kind) {
+		return true
+	}
+	switch kind {
+	case S_BLOCK32, S_THUNK32, S_WITH32, S_SEPCODE,
+		S_INLINESITE, S_INLINESITE2:
+		return true
+	}
+	return false
+}
+
+// IsScopeEnd returns true if the kind closes a scope.
+func IsScopeEnd(kind uint16) bool {
+	switch kind {
+	case S_END, S_PROC_ID_END, S_INLINESITE_END:
+		return true
+	}
+	return false
+}
+
+// LanguageName returns the name of a CV_CFL_* source language.
+func LanguageName(lang uint8) string {
+	switch lang {
+	case 0x00:
+		return "C"
+	case 0x01:
+		return "C++"
+	case 0x02:
+		return "Fortran"
+	case 0x03:
+		return "MASM"
+	case 0x04:
+		return "Pascal"
+	case 0x05:
+		return "Basic"
+	case 0x06:
+		return "Cobol"
+	case 0x07:
+		return "Link"
+	case 0x08:
+		return "CVTRES"
+	case 0x09:
+		return "CVTPGD"
+	case 0x0a:
+		return "C#"
+	case 0x0b:
+		return "Visual Basic"
+	case 0x0c:
+		return "ILASM"
+	case 0x0d:
+		return "Java"
+	case 0x0e:
+		return "JScript"
+	case 0x0f:
+		return "MSIL"
+	case 0x10:
+		return "HLSL"
+	case 0x11:
+		return "Objective-C"
+	case 0x12:
+		return "Objective-C++"
+	case 0x13:
+		return "Swift"
+	case 0x1a:
+		return "AliasObj"
+	case 0x1b:
+		return "Rust"
+	case 0x1c:
+		return "Go"
+	}
+	return fmt.Sprintf("unknown(0x%x)", lang)
+}
+
+// MachineName returns the name of a CV_CFL_* target processor.
+func MachineName(machine uint16) string {
+	switch machine {
+	case 0x03:
+		return "80386"
+	case 0x04:
+		return "80486"
+	case 0x05:
+		return "Pentium"
+	case 0x06:
+		return "Pentium Pro"
+	case 0x07:
+		return "Pentium III"
+	case 0x80:
+		return "IA64"
+	case 0xd0:
+		return "x64"
+	case 0xf4:
+		return "ARMNT"
+	case 0xf6:
+		return "ARM64"
+	}
+	return fmt.Sprintf("unknown(0x%x)", machine)
+}
+
+// BasePointerName returns the register named by an encoded S_FRAMEPROC
+// base pointer for the given DBI machine type. An encoding of 0 means no
+// base pointer and yields "".
+func BasePointerName(encoded uint8, machine uint16) string {
+	if encoded == 0 {
+		return ""
+	}
+
+	var regs [4]string
+	switch machine {
+	case streams.MachineI386:
+		regs = [4]string{"", "VFRAME", "EBP", "EBX"}
+	case streams.MachineAMD64:
+		regs = [4]string{"", "RSP", "RBP", "R13"}
+	case streams.MachineARM64:
+		regs = [4]string{"", "SP", "FP", "X19"}
 	default:
-		return 0, 0
+		return fmt.Sprintf("unknown(%d)", encoded)
 	}
+	if int(encoded) >= len(regs) {
+		return fmt.Sprintf("unknown(%d)", encoded)
+	}
+	return regs[encoded]
 }
 
 // SymbolKindName returns the name for a symbol kind constant.
@@ -564,14 +1722,24 @@ func SymbolKindName(kind uint16) string {
 		return "S_LABEL32"
 	case S_THUNK32:
 		return "S_THUNK32"
+	case S_ANNOTATION:
+		return "S_ANNOTATION"
+	case S_ANNOTATIONREF:
+		return "S_ANNOTATIONREF"
+	case S_SEPCODE:
+		return "S_SEPCODE"
 	case S_REGREL32:
 		return "S_REGREL32"
+	case S_REGISTER_NEW:
+		return "S_REGISTER"
 	case S_LTHREAD32:
 		return "S_LTHREAD32"
 	case S_GTHREAD32:
 		return "S_GTHREAD32"
 	case S_LOCAL:
 		return "S_LOCAL"
+	case S_PROC_ID_END:
+		return "S_PROC_ID_END"
 	case S_BUILDINFO:
 		return "S_BUILDINFO"
 	case S_INLINESITE:
@@ -590,6 +1758,10 @@ func SymbolKindName(kind uint16) string {
 		return "S_CALLSITEINFO"
 	case S_FRAMECOOKIE:
 		return "S_FRAMECOOKIE"
+	case S_DEFRANGE:
+		return "S_DEFRANGE"
+	case S_DEFRANGE_SUBFIELD:
+		return "S_DEFRANGE_SUBFIELD"
 	case S_DEFRANGE_REGISTER:
 		return "S_DEFRANGE_REGISTER"
 	case S_DEFRANGE_FRAMEPOINTER_REL:
@@ -600,10 +1772,22 @@ func SymbolKindName(kind uint16) string {
 		return "S_DEFRANGE_FRAMEPOINTER_REL_FULL_SCOPE"
 	case S_DEFRANGE_REGISTER_REL:
 		return "S_DEFRANGE_REGISTER_REL"
-	case S_OBJNAME_ST:
+	case S_OBJNAME:
 		return "S_OBJNAME"
+	case S_OBJNAME_ST:
+		return "S_OBJNAME_ST"
 	case S_HEAPALLOCSITE:
 		return "S_HEAPALLOCSITE"
+	case S_GPROC32_16t:
+		return "S_GPROC32_16t"
+	case S_LPROC32_16t:
+		return "S_LPROC32_16t"
+	case S_GDATA32_16t:
+		return "S_GDATA32_16t"
+	case S_LDATA32_16t:
+		return "S_LDATA32_16t"
+	case S_PUB32_16t:
+		return "S_PUB32_16t"
 	default:
 		return fmt.Sprintf("S_0x%04x", kind)
 	}
@@ -615,7 +1799,18 @@ func IsProcSymbol(kind uint16) bool {
 	case S_GPROC32, S_LPROC32, S_GPROC32_ID, S_LPROC32_ID,
 		S_GPROC32_ST, S_LPROC32_ST, S_GPROCIA64, S_LPROCIA64,
 		S_GPROCMIPS, S_LPROCMIPS, S_GMANPROC, S_LMANPROC,
-		S_LPROC32_DPC, S_LPROC32_DPC_ID:
+		S_LPROC32_DPC, S_LPROC32_DPC_ID, S_GPROC32_16t, S_LPROC32_16t:
+		return true
+	}
+	return false
+}
+
+// IsIDProcSymbol returns true if the procedure symbol's type index refers
+// to the IPI stream (LF_FUNC_ID / LF_MFUNC_ID) rather than the TPI stream.
+func IsIDProcSymbol(kind uint16) bool {
+	switch kind {
+	case S_GPROC32_ID, S_LPROC32_ID, S_GPROCMIPS_ID, S_LPROCMIPS_ID,
+		S_GPROCIA64_ID, S_LPROCIA64_ID, S_LPROC32_DPC_ID:
 		return true
 	}
 	return false
@@ -625,7 +1820,19 @@ func IsProcSymbol(kind uint16) bool {
 func IsDataSymbol(kind uint16) bool {
 	switch kind {
 	case S_GDATA32, S_LDATA32, S_GDATA32_ST, S_LDATA32_ST,
-		S_GMANDATA, S_LMANDATA, S_GTHREAD32, S_LTHREAD32:
+		S_GMANDATA, S_LMANDATA, S_GTHREAD32, S_LTHREAD32,
+		S_GDATA32_16t, S_LDATA32_16t, S_GTHREAD32_16t, S_LTHREAD32_16t:
+		return true
+	}
+	return false
+}
+
+// IsThreadLocalSymbol returns true if the kind is a thread-local data
+// symbol, whose offset is relative to the thread's TLS block.
+func IsThreadLocalSymbol(kind uint16) bool {
+	switch kind {
+	case S_GTHREAD32, S_LTHREAD32, S_GTHREAD32_ST, S_LTHREAD32_ST,
+		S_GTHREAD32_16t, S_LTHREAD32_16t:
 		return true
 	}
 	return false
@@ -636,7 +1843,8 @@ func IsGlobalSymbol(kind uint16) bool {
 	switch kind {
 	case S_GPROC32, S_GPROC32_ID, S_GPROC32_ST, S_GPROCIA64,
 		S_GPROCMIPS, S_GMANPROC, S_GDATA32, S_GDATA32_ST,
-		S_GMANDATA, S_GTHREAD32, S_PUB32:
+		S_GMANDATA, S_GTHREAD32, S_PUB32, S_GPROC32_16t,
+		S_GDATA32_16t, S_GTHREAD32_16t, S_PUB32_16t:
 		return true
 	}
 	return false
@@ -0,0 +1,53 @@
+package codeview
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvBlockSym(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  bb
+		flags uint8
+		env   map[string]string
+		err   bool
+	}{
+		{"empty", nil, 0, nil, true},
+		{"no pairs", bb(nil).u8(0).u8(0), 0, map[string]string{}, false},
+		{
+			"pairs",
+			bb(nil).u8(1).str("cwd").str(`C:\src`).str("exe").str("cl.exe").str("cmd").str("-c -Zi").u8(0),
+			1, map[string]string{"cwd": `C:\src`, "exe": "cl.exe", "cmd": "-c -Zi"}, false,
+		},
+		{
+			"empty value",
+			bb(nil).u8(0).str("src").str("").str("pdb").str("a.pdb").u8(0),
+			0, map[string]string{"src": "", "pdb": "a.pdb"}, false,
+		},
+		// Records without the closing empty key end at the record end
+		{"unterminated", bb(nil).u8(0).str("cwd").str("/src"), 0, map[string]string{"cwd": "/src"}, false},
+		{
+			"after the closing key",
+			bb(nil).u8(0).str("cwd").str("/src").u8(0).str("pad").str("x"),
+			0, map[string]string{"cwd": "/src"}, false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env, err := ParseEnvBlockSym(tc.data)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("ParseEnvBlockSym = %+v, want an error", env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEnvBlockSym: %v", err)
+			}
+			if env.Flags != tc.flags || !reflect.DeepEqual(env.Env, tc.env) {
+				t.Errorf("ParseEnvBlockSym = %d, %v; want %d, %v", env.Flags, env.Env, tc.flags, tc.env)
+			}
+		})
+	}
+}
@@ -4,24 +4,79 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/jtang613/gopdb/pkg/pdb/streams"
 )
 
 // TypeResolver provides type resolution from TPI stream.
 type TypeResolver struct {
-	tpi *streams.TPIStream
+	tpi   *streams.TPIStream
+	begin uint32 // Lowest index of a record; indices below it are builtin
+
+	completeOnce  sync.Once
+	completeTypes map[string]uint32 // UDT name to complete definition index
+
+	// Strings already produced by ResolveType, by type index, and the
+	// resolvers consulted for indices missing from tpi
+	resolvedMu sync.RWMutex
+	resolved   map[uint32]string
+	fallbacks  []*TypeResolver
 }
 
-// NewTypeResolver creates a new type resolver.
+// cycleCut is set in the visited set of resolveType once a reference cycle
+// has been cut short. Type indices in the set are all at least the
+// resolver's begin, which is never below 1, so it cannot collide with one.
+const cycleCut = 0
+
+// NewTypeResolver creates a new type resolver. Records are numbered from
+// the begin index in the stream's header. Indices below TypeIndexBegin are
+// builtin even if the header claims a higher begin, and index 0 is always
+// builtin.
 func NewTypeResolver(tpi *streams.TPIStream) *TypeResolver {
-	return &TypeResolver{tpi: tpi}
+	r := &TypeResolver{tpi: tpi, begin: streams.TypeIndexBegin}
+	if tpi != nil {
+		if begin, _ := tpi.IndexRange(); begin > cycleCut && begin < r.begin {
+			r.begin = begin
+		}
+	}
+	return r
+}
+
+// IsBuiltin reports whether a type index names a builtin type rather than
+// a record of the stream.
+func (r *TypeResolver) IsBuiltin(typeIdx uint32) bool {
+	return typeIdx < r.begin
+}
+
+// SetFallbacks sets resolvers that ResolveType consults, in order, for
+// type indices with no record in this resolver's stream, such as the types
+// of modules compiled against a type server. It is safe to call while
+// other goroutines resolve types.
+func (r *TypeResolver) SetFallbacks(fallbacks ...*TypeResolver) {
+	r.resolvedMu.Lock()
+	r.fallbacks = fallbacks
+	// Indices they supply may have been cached as unresolved
+	r.resolved = nil
+	r.resolvedMu.Unlock()
 }
 
-// ResolveType resolves a type index to a human-readable string.
+// ResolveType resolves a type index to a human-readable string. Results
+// are cached, so each index is rendered once however many types share it.
 func (r *TypeResolver) ResolveType(typeIdx uint32) string {
+	return r.resolveType(typeIdx, make(map[uint32]bool))
+}
+
+// resolveType implements ResolveType. visited holds the types being
+// resolved on the current path; a type that refers back to one of them,
+// as only a malformed stream can, renders as its index instead of
+// recursing forever. Such a string depends on the path that led to it,
+// so it is not cached.
+func (r *TypeResolver) resolveType(typeIdx uint32, visited map[uint32]bool) string {
 	// Handle built-in types
-	if typeIdx < streams.TypeIndexBegin {
+	if r.IsBuiltin(typeIdx) {
 		return streams.GetBuiltinTypeName(typeIdx)
 	}
 
@@ -29,26 +84,63 @@ func (r *TypeResolver) ResolveType(typeIdx uint32) string {
 	if r.tpi == nil {
 		return fmt.Sprintf("type_0x%x", typeIdx)
 	}
+	if visited[typeIdx] {
+		visited[cycleCut] = true
+		return fmt.Sprintf("type_0x%x", typeIdx)
+	}
+
+	r.resolvedMu.RLock()
+	str, ok := r.resolved[typeIdx]
+	fallbacks := r.fallbacks
+	r.resolvedMu.RUnlock()
+	if ok {
+		return str
+	}
 
 	rec := r.tpi.GetType(typeIdx)
 	if rec == nil {
+		for _, fb := range fallbacks {
+			if fb.tpi != nil && fb.tpi.GetType(typeIdx) != nil {
+				return fb.ResolveType(typeIdx)
+			}
+		}
 		return fmt.Sprintf("type_0x%x", typeIdx)
 	}
 
-	return r.resolveTypeRecord(rec)
+	// Track cuts within this type alone, then pass them on to the caller
+	cutBefore := visited[cycleCut]
+	delete(visited, cycleCut)
+	visited[typeIdx] = true
+	str = r.resolveTypeRecord(rec, visited)
+	delete(visited, typeIdx)
+
+	if visited[cycleCut] {
+		return str
+	}
+	if cutBefore {
+		visited[cycleCut] = true
+	}
+
+	r.resolvedMu.Lock()
+	if r.resolved == nil {
+		r.resolved = make(map[uint32]string)
+	}
+	r.resolved[typeIdx] = str
+	r.resolvedMu.Unlock()
+	return str
 }
 
 // resolveTypeRecord converts a type record to a string.
-func (r *TypeResolver) resolveTypeRecord(rec *streams.TypeRecord) string {
+func (r *TypeResolver) resolveTypeRecord(rec *streams.TypeRecord, visited map[uint32]bool) string {
 	switch rec.Kind {
 	case streams.LF_POINTER:
-		return r.resolvePointer(rec.Data)
+		return r.resolvePointer(rec.Data, visited)
 	case streams.LF_ARRAY, streams.LF_ARRAY_newformat:
-		return r.resolveArray(rec.Data)
+		return r.resolveArray(rec.Data, visited)
 	case streams.LF_PROCEDURE:
-		return r.resolveProcedure(rec.Data)
+		return r.resolveProcedure(rec.Data, visited)
 	case streams.LF_MFUNCTION:
-		return r.resolveMemberFunction(rec.Data)
+		return r.resolveMemberFunction(rec.Data, visited)
 	case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat:
 		return r.resolveStructure(rec.Data, "struct")
 	case streams.LF_CLASS, streams.LF_CLASS_newformat:
@@ -58,18 +150,18 @@ func (r *TypeResolver) resolveTypeRecord(rec *streams.TypeRecord) string {
 	case streams.LF_ENUM, streams.LF_ENUM_newformat:
 		return r.resolveEnum(rec.Data)
 	case streams.LF_MODIFIER:
-		return r.resolveModifier(rec.Data)
+		return r.resolveModifier(rec.Data, visited)
 	case streams.LF_ARGLIST:
-		return r.resolveArgList(rec.Data)
+		return r.resolveArgList(rec.Data, visited)
 	case streams.LF_BITFIELD:
-		return r.resolveBitfield(rec.Data)
+		return r.resolveBitfield(rec.Data, visited)
 	default:
 		return fmt.Sprintf("type_0x%x", rec.Index)
 	}
 }
 
 // resolvePointer resolves LF_POINTER type.
-func (r *TypeResolver) resolvePointer(data []byte) string {
+func (r *TypeResolver) resolvePointer(data []byte, visited map[uint32]bool) string {
 	if len(data) < 8 {
 		return "ptr<?>"
 	}
@@ -79,10 +171,59 @@ func (r *TypeResolver) resolvePointer(data []byte) string {
 
 	ptrKind := (attrs >> 0) & 0x1F
 	ptrMode := (attrs >> 5) & 0x07
+	isVolatile := (attrs >> 9) & 0x01
 	isConst := (attrs >> 10) & 0x01
-	isVolatile := (attrs >> 11) & 0x01
+	isUnaligned := (attrs >> 11) & 0x01
+	isRestrict := (attrs >> 12) & 0x01
+
+	var result string
+	switch ptrMode {
+	case 2, 3: // Pointer to data member, pointer to member function
+		result = r.resolveMemberPointer(underlyingType, data[8:], ptrMode == 3, visited)
+	default:
+		result = r.resolvePlainPointer(underlyingType, ptrKind, ptrMode, visited)
+	}
 
-	underlyingStr := r.ResolveType(underlyingType)
+	if isConst != 0 {
+		result = "const " + result
+	}
+	if isVolatile != 0 {
+		result = "volatile " + result
+	}
+	if isUnaligned != 0 {
+		result += " __unaligned"
+	}
+	if isRestrict != 0 {
+		result += " __restrict"
+	}
+
+	return result
+}
+
+// resolveMemberPointer renders a pointer to member. The class type follows
+// the attributes word, then the member pointer representation (pmtype),
+// which does not affect the rendered type.
+func (r *TypeResolver) resolveMemberPointer(underlyingType uint32, extra []byte, isFunc bool, visited map[uint32]bool) string {
+	className := "?"
+	if len(extra) >= 4 {
+		className = r.resolveType(binary.LittleEndian.Uint32(extra), visited)
+	}
+
+	if isFunc && r.tpi != nil {
+		rec := r.tpi.GetType(underlyingType)
+		if rec != nil && rec.Kind == streams.LF_MFUNCTION && len(rec.Data) >= 24 {
+			retStr := r.resolveType(binary.LittleEndian.Uint32(rec.Data[0:]), visited)
+			argStr := r.resolveType(binary.LittleEndian.Uint32(rec.Data[16:]), visited)
+			quals := r.thisQualifiers(binary.LittleEndian.Uint32(rec.Data[8:]))
+			return fmt.Sprintf("%s (%s::*)(%s)%s", retStr, className, argStr, quals)
+		}
+	}
+	return fmt.Sprintf("%s %s::*", r.resolveType(underlyingType, visited), className)
+}
+
+// resolvePlainPointer renders a pointer or reference to underlyingType.
+func (r *TypeResolver) resolvePlainPointer(underlyingType, ptrKind, ptrMode uint32, visited map[uint32]bool) string {
+	underlyingStr := r.resolveType(underlyingType, visited)
 
 	var suffix string
 	switch ptrKind {
@@ -106,44 +247,115 @@ func (r *TypeResolver) resolvePointer(data []byte) string {
 	switch ptrMode {
 	case 1: // L-value reference
 		suffix = "&"
-	case 2: // R-value reference
+	case 4: // R-value reference
 		suffix = "&&"
 	}
 
-	result := underlyingStr + suffix
-	if isConst != 0 {
-		result = "const " + result
-	}
-	if isVolatile != 0 {
-		result = "volatile " + result
-	}
-
-	return result
+	return underlyingStr + suffix
 }
 
 // resolveArray resolves LF_ARRAY type.
-func (r *TypeResolver) resolveArray(data []byte) string {
+func (r *TypeResolver) resolveArray(data []byte, visited map[uint32]bool) string {
 	if len(data) < 8 {
 		return "array<?>"
 	}
 
+	elem, dims := r.arrayParts(data, visited)
+	return elem + dims
+}
+
+// arrayParts splits an LF_ARRAY into its innermost element type and its
+// dimensions, so that nested arrays render as elem[outer][inner].
+func (r *TypeResolver) arrayParts(data []byte, visited map[uint32]bool) (string, string) {
 	elemType := binary.LittleEndian.Uint32(data[0:])
 	// idxType := binary.LittleEndian.Uint32(data[4:])
 
-	elemStr := r.ResolveType(elemType)
-
-	// Parse size (numeric leaf)
-	size, consumed := streams.ParseNumeric(data[8:])
-	_ = consumed
+	// Parse size in bytes (numeric leaf) and convert it to an element count
+	size, _ := streams.ParseNumeric(data[8:])
+	if elemSize, ok := r.SizeOf(elemType); ok && elemSize > 0 {
+		size /= elemSize
+	}
 
+	dim := "[]"
 	if size > 0 {
-		return fmt.Sprintf("%s[%d]", elemStr, size)
+		dim = fmt.Sprintf("[%d]", size)
+	}
+
+	if r.tpi != nil && !r.IsBuiltin(elemType) && !visited[elemType] {
+		rec := r.tpi.GetType(elemType)
+		if rec != nil && (rec.Kind == streams.LF_ARRAY || rec.Kind == streams.LF_ARRAY_newformat) && len(rec.Data) >= 8 {
+			visited[elemType] = true
+			defer delete(visited, elemType)
+			elem, inner := r.arrayParts(rec.Data, visited)
+			return elem, dim + inner
+		}
+	}
+
+	return r.resolveType(elemType, visited), dim
+}
+
+// SizeOf returns the size in bytes of a type and whether it is known.
+// Forward-declared UDTs are measured by their definition.
+func (r *TypeResolver) SizeOf(typeIdx uint32) (uint64, bool) {
+	return r.sizeOf(typeIdx, make(map[uint32]bool))
+}
+
+// sizeOf implements SizeOf. visited guards against reference cycles in
+// malformed type streams.
+func (r *TypeResolver) sizeOf(typeIdx uint32, visited map[uint32]bool) (uint64, bool) {
+	if r.IsBuiltin(typeIdx) {
+		size := streams.GetBuiltinTypeSize(typeIdx)
+		return size, size > 0
+	}
+	if r.tpi == nil || visited[typeIdx] {
+		return 0, false
+	}
+	visited[typeIdx] = true
+
+	rec := r.tpi.GetType(r.CompleteType(typeIdx))
+	if rec == nil {
+		return 0, false
 	}
-	return fmt.Sprintf("%s[]", elemStr)
+	data := rec.Data
+
+	switch rec.Kind {
+	case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
+		streams.LF_CLASS, streams.LF_CLASS_newformat:
+		if len(data) >= 18 && binary.LittleEndian.Uint16(data[2:])&streams.PropForwardRef == 0 {
+			size, _ := streams.ParseNumeric(data[16:])
+			return size, true
+		}
+	case streams.LF_UNION, streams.LF_UNION_newformat:
+		if len(data) >= 10 && binary.LittleEndian.Uint16(data[2:])&streams.PropForwardRef == 0 {
+			size, _ := streams.ParseNumeric(data[8:])
+			return size, true
+		}
+	case streams.LF_ARRAY, streams.LF_ARRAY_newformat:
+		if len(data) >= 10 {
+			size, _ := streams.ParseNumeric(data[8:])
+			return size, true
+		}
+	case streams.LF_ENUM, streams.LF_ENUM_newformat:
+		if len(data) >= 8 {
+			return r.sizeOf(binary.LittleEndian.Uint32(data[4:]), visited)
+		}
+	case streams.LF_POINTER:
+		if len(data) >= 8 {
+			attrs := binary.LittleEndian.Uint32(data[4:])
+			size := uint64((attrs >> 13) & 0x3F)
+			return size, size > 0
+		}
+	case streams.LF_MODIFIER, streams.LF_BITFIELD:
+		if len(data) >= 4 {
+			return r.sizeOf(binary.LittleEndian.Uint32(data[0:]), visited)
+		}
+	}
+
+	return 0, false
 }
 
 // resolveProcedure resolves LF_PROCEDURE type.
-func (r *TypeResolver) resolveProcedure(data []byte) string {
+func (r *TypeResolver) resolveProcedure(data []byte, visited map[uint32]bool) string {
 	if len(data) < 12 {
 		return "func<?>"
 	}
@@ -154,17 +366,36 @@ func (r *TypeResolver) resolveProcedure(data []byte) string {
 	numParams := binary.LittleEndian.Uint16(data[6:])
 	argListIdx := binary.LittleEndian.Uint32(data[8:])
 
-	retStr := r.ResolveType(retType)
-	argStr := r.ResolveType(argListIdx)
+	retStr := r.resolveType(retType, visited)
+	argStr := r.resolveParams(argListIdx, numParams, visited)
 
-	_ = numParams
-	_ = callConv
+	return fmt.Sprintf("%s %s(%s)", retStr, CallingConventionName(callConv), argStr)
+}
 
-	return fmt.Sprintf("%s (%s)", retStr, argStr)
+// resolveMemberFunction resolves LF_MFUNCTION type. A type record has no
+// method name, so the class stands alone in the declarator, as in
+// "int32 (__thiscall Foo::)(char) const"; MethodSignature renders a named
+// method.
+func (r *TypeResolver) resolveMemberFunction(data []byte, visited map[uint32]bool) string {
+	return r.memberFunction(data, "", visited)
 }
 
-// resolveMemberFunction resolves LF_MFUNCTION type.
-func (r *TypeResolver) resolveMemberFunction(data []byte) string {
+// MethodSignature renders the LF_MFUNCTION type of a method together with
+// its name, as in "int32 __thiscall Foo::bar(char) const". Any other type
+// renders as by ResolveType.
+func (r *TypeResolver) MethodSignature(typeIdx uint32, name string) string {
+	if !r.IsBuiltin(typeIdx) && r.tpi != nil {
+		if rec := r.tpi.GetType(typeIdx); rec != nil && rec.Kind == streams.LF_MFUNCTION {
+			return r.memberFunction(rec.Data, name, map[uint32]bool{typeIdx: true})
+		}
+	}
+	return r.ResolveType(typeIdx)
+}
+
+// memberFunction renders an LF_MFUNCTION record for a method named name,
+// or for no method when name is empty. Static member functions, which
+// have no this pointer, are prefixed with "static".
+func (r *TypeResolver) memberFunction(data []byte, name string, visited map[uint32]bool) string {
 	if len(data) < 24 {
 		return "mfunc<?>"
 	}
@@ -178,20 +409,159 @@ func (r *TypeResolver) resolveMemberFunction(data []byte) string {
 	argListIdx := binary.LittleEndian.Uint32(data[16:])
 	// thisAdjust := binary.LittleEndian.Uint32(data[20:])
 
-	retStr := r.ResolveType(retType)
-	classStr := r.ResolveType(classType)
-	argStr := r.ResolveType(argListIdx)
+	retStr := r.resolveType(retType, visited)
+	classStr := r.resolveType(classType, visited)
+	argStr := r.resolveParams(argListIdx, numParams, visited)
 
-	_ = numParams
-	_ = callConv
-	_ = thisType
+	static := ""
+	if thisType == streams.T_NOTYPE {
+		static = "static "
+	}
 
-	return fmt.Sprintf("%s::%s (%s)", classStr, retStr, argStr)
+	quals := r.thisQualifiers(thisType)
+	if name == "" {
+		return fmt.Sprintf("%s%s (%s %s::)(%s)%s", static, retStr, CallingConventionName(callConv), classStr, argStr, quals)
+	}
+	return fmt.Sprintf("%s%s %s %s::%s(%s)%s", static, retStr, CallingConventionName(callConv), classStr, name, argStr, quals)
+}
+
+// Pointer attribute bits (CV_ptrattr) marking the this pointer of a
+// ref-qualified member function
+const (
+	ptrLValueRefThis = 0x00100000
+	ptrRValueRefThis = 0x00200000
+)
+
+// thisQualifiers returns the qualifiers of a member function implied by
+// the type of its this pointer, such as " const" or " &&": the modifiers
+// of the class it points to and the pointer's ref-qualifier.
+func (r *TypeResolver) thisQualifiers(thisType uint32) string {
+	if r.IsBuiltin(thisType) || r.tpi == nil {
+		return ""
+	}
+	ptr := r.tpi.GetType(thisType)
+	if ptr == nil || ptr.Kind != streams.LF_POINTER || len(ptr.Data) < 8 {
+		return ""
+	}
+
+	var quals string
+	if mod := r.tpi.GetType(binary.LittleEndian.Uint32(ptr.Data[0:])); mod != nil && mod.Kind == streams.LF_MODIFIER && len(mod.Data) >= 6 {
+		modifiers := binary.LittleEndian.Uint16(mod.Data[4:])
+		if modifiers&0x01 != 0 {
+			quals += " const"
+		}
+		if modifiers&0x02 != 0 {
+			quals += " volatile"
+		}
+	}
+
+	attrs := binary.LittleEndian.Uint32(ptr.Data[4:])
+	switch {
+	case attrs&ptrLValueRefThis != 0:
+		quals += " &"
+	case attrs&ptrRValueRefThis != 0:
+		quals += " &&"
+	}
+	return quals
+}
+
+// resolveParams renders the LF_ARGLIST of a procedure, checked against the
+// parameter count of the procedure record: arguments missing from the list
+// render as "?" and surplus ones are dropped. Anything other than an
+// argument list resolves as a plain type.
+func (r *TypeResolver) resolveParams(argListIdx uint32, numParams uint16, visited map[uint32]bool) string {
+	var rec *streams.TypeRecord
+	if r.tpi != nil && !visited[argListIdx] {
+		rec = r.tpi.GetType(argListIdx)
+	}
+	if rec == nil || rec.Kind != streams.LF_ARGLIST || len(rec.Data) < 4 {
+		return r.resolveType(argListIdx, visited)
+	}
+	if numParams == 0 {
+		return "void"
+	}
+
+	count := binary.LittleEndian.Uint32(rec.Data[0:])
+	args := make([]string, numParams)
+	for i := range args {
+		offset := 4 + 4*i
+		if uint32(i) < count && offset+4 <= len(rec.Data) {
+			args[i] = r.resolveType(binary.LittleEndian.Uint32(rec.Data[offset:]), visited)
+		} else {
+			args[i] = "?"
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+// CallingConventionName returns the name of a CV_call_e calling
+// convention, using the MSVC keyword where there is one.
+func CallingConventionName(cc uint8) string {
+	switch cc {
+	case 0x00:
+		return "__cdecl"
+	case 0x01:
+		return "__far __cdecl"
+	case 0x02:
+		return "__pascal"
+	case 0x03:
+		return "__far __pascal"
+	case 0x04:
+		return "__fastcall"
+	case 0x05:
+		return "__far __fastcall"
+	case 0x06:
+		return "skipped"
+	case 0x07:
+		return "__stdcall"
+	case 0x08:
+		return "__far __stdcall"
+	case 0x09:
+		return "__syscall"
+	case 0x0a:
+		return "__far __syscall"
+	case 0x0b:
+		return "__thiscall"
+	case 0x0c:
+		return "mipscall"
+	case 0x0d:
+		return "generic"
+	case 0x0e:
+		return "alphacall"
+	case 0x0f:
+		return "ppccall"
+	case 0x10:
+		return "shcall"
+	case 0x11:
+		return "armcall"
+	case 0x12:
+		return "am33call"
+	case 0x13:
+		return "tricall"
+	case 0x14:
+		return "sh5call"
+	case 0x15:
+		return "m32rcall"
+	case 0x16:
+		return "__clrcall"
+	case 0x17:
+		return "inline"
+	case 0x18:
+		return "__vectorcall"
+	case 0x19:
+		return "swift"
+	}
+	return fmt.Sprintf("call_0x%x", cc)
 }
 
 // resolveStructure resolves LF_STRUCTURE, LF_CLASS, LF_UNION types.
 func (r *TypeResolver) resolveStructure(data []byte, kind string) string {
-	if len(data) < 18 {
+	// Unions have no derivation list or vtable shape before the size
+	sizeOffset := 16
+	if kind == "union" {
+		sizeOffset = 8
+	}
+	if len(data) < sizeOffset+2 {
 		return fmt.Sprintf("%s<?>", kind)
 	}
 
@@ -202,10 +572,10 @@ func (r *TypeResolver) resolveStructure(data []byte, kind string) string {
 	// vshape := binary.LittleEndian.Uint32(data[12:])
 
 	// Parse size (numeric leaf)
-	_, consumed := streams.ParseNumeric(data[16:])
+	_, consumed := streams.ParseNumeric(data[sizeOffset:])
 
 	// Parse name
-	nameOffset := 16 + consumed
+	nameOffset := sizeOffset + consumed
 	if nameOffset < len(data) {
 		name, _ := streams.ParseString(data[nameOffset:])
 		if name != "" {
@@ -239,7 +609,7 @@ func (r *TypeResolver) resolveEnum(data []byte) string {
 }
 
 // resolveModifier resolves LF_MODIFIER type.
-func (r *TypeResolver) resolveModifier(data []byte) string {
+func (r *TypeResolver) resolveModifier(data []byte, visited map[uint32]bool) string {
 	if len(data) < 6 {
 		return "mod<?>"
 	}
@@ -247,7 +617,7 @@ func (r *TypeResolver) resolveModifier(data []byte) string {
 	modifiedType := binary.LittleEndian.Uint32(data[0:])
 	modifiers := binary.LittleEndian.Uint16(data[4:])
 
-	modStr := r.ResolveType(modifiedType)
+	modStr := r.resolveType(modifiedType, visited)
 
 	if modifiers&0x01 != 0 {
 		modStr = "const " + modStr
@@ -263,7 +633,7 @@ func (r *TypeResolver) resolveModifier(data []byte) string {
 }
 
 // resolveArgList resolves LF_ARGLIST type.
-func (r *TypeResolver) resolveArgList(data []byte) string {
+func (r *TypeResolver) resolveArgList(data []byte, visited map[uint32]bool) string {
 	if len(data) < 4 {
 		return ""
 	}
@@ -277,7 +647,7 @@ func (r *TypeResolver) resolveArgList(data []byte) string {
 	offset := 4
 	for i := uint32(0); i < count && offset+4 <= len(data); i++ {
 		argType := binary.LittleEndian.Uint32(data[offset:])
-		args = append(args, r.ResolveType(argType))
+		args = append(args, r.resolveType(argType, visited))
 		offset += 4
 	}
 
@@ -292,7 +662,7 @@ func (r *TypeResolver) resolveArgList(data []byte) string {
 }
 
 // resolveBitfield resolves LF_BITFIELD type.
-func (r *TypeResolver) resolveBitfield(data []byte) string {
+func (r *TypeResolver) resolveBitfield(data []byte, visited map[uint32]bool) string {
 	if len(data) < 6 {
 		return "bitfield<?>"
 	}
@@ -301,10 +671,23 @@ func (r *TypeResolver) resolveBitfield(data []byte) string {
 	length := data[4]
 	position := data[5]
 
-	baseStr := r.ResolveType(baseType)
+	baseStr := r.resolveType(baseType, visited)
 	return fmt.Sprintf("%s : %d (pos %d)", baseStr, length, position)
 }
 
+// bitfieldLayout returns the width and bit position of an LF_BITFIELD
+// type, or zeros for any other type.
+func (r *TypeResolver) bitfieldLayout(typeIdx uint32) (width, position uint8) {
+	if r.IsBuiltin(typeIdx) || r.tpi == nil {
+		return 0, 0
+	}
+	rec := r.tpi.GetType(typeIdx)
+	if rec == nil || rec.Kind != streams.LF_BITFIELD || len(rec.Data) < 6 {
+		return 0, 0
+	}
+	return rec.Data[4], rec.Data[5]
+}
+
 // ParsedType represents a fully parsed type.
 type ParsedType struct {
 	Index     uint32
@@ -314,6 +697,26 @@ type ParsedType struct {
 	Size      uint64
 	Signature string
 	Members   []ParsedMember
+	Methods   []Method
+
+	// NestedTypes lists the types declared inside the type, from its
+	// LF_NESTTYPE entries.
+	NestedTypes []NestedType
+
+	// UnderlyingType is the resolved integer type of an enum.
+	UnderlyingType string
+
+	// TrailingPadding is the number of bytes between the end of the last
+	// member and the end of the type.
+	TrailingPadding uint64
+
+	// IsForwardRef is set when the parsed record was a forward declaration.
+	// If a complete definition exists, the other fields describe it.
+	IsForwardRef bool
+
+	// VTShape describes the slots of the vtable of a class or structure
+	// with a vfuncptr, from its LF_VTSHAPE record; nil otherwise.
+	VTShape []VTShapeEntry
 }
 
 // ParsedMember represents a member of a struct/class/union.
@@ -322,11 +725,445 @@ type ParsedMember struct {
 	TypeIdx  uint32
 	TypeName string
 	Offset   uint64
+	IsStatic bool
+
+	// Bitfield members only; the storage unit is at Offset
+	BitWidth    uint8
+	BitPosition uint8
+
+	// PaddingBefore is the number of unused bytes between the end of the
+	// previous member and this one.
+	PaddingBefore uint64
+
+	// Virtual base classes only; Offset holds the vbptr offset
+	IsVirtualBase bool
+	VBPtrTypeIdx  uint32 // Type of the virtual base pointer
+	VBTableIndex  uint64 // Index of the base in the virtual base table
+}
+
+// Method represents a member function of a class, taken from an
+// LF_ONEMETHOD record or an entry of an LF_METHODLIST record.
+type Method struct {
+	Attributes   uint16 // CV_fldattr_t (access, method property, ...)
+	TypeIndex    uint32 // LF_MFUNCTION type of the method
+	VtableOffset uint32 // Vtable offset (introducing virtual methods only)
+	Name         string
+	Signature    string // Set by ParseStructureType; see MethodSignature
+}
+
+// NestedType is a type declared inside a class, structure or union, such
+// as a nested enum or a member typedef, from an LF_NESTTYPE entry.
+type NestedType struct {
+	Name      string // Unqualified name within the enclosing type
+	TypeIndex uint32 // Index of the nested type
+	TypeName  string // Resolved nested type, e.g. "A::E"
+}
+
+// Method property values (bits 2-4 of the field attributes)
+const (
+	methodPropIntroVirtual     = 4
+	methodPropPureIntroVirtual = 6
+)
+
+// isIntroVirtual reports whether field attributes describe an introducing
+// virtual method, which carries a vtable offset.
+func isIntroVirtual(attrs uint16) bool {
+	mprop := (attrs >> 2) & 0x7
+	return mprop == methodPropIntroVirtual || mprop == methodPropPureIntroVirtual
+}
+
+// VTShapeEntry is the kind of a vtable slot, a CV_VTS_desc_e descriptor
+// from an LF_VTSHAPE record.
+type VTShapeEntry uint8
+
+// Vtable slot kinds
+const (
+	VTSNear   VTShapeEntry = 0 // 16-bit near pointer
+	VTSFar    VTShapeEntry = 1 // 16:16 far pointer
+	VTSThin   VTShapeEntry = 2 // Thin pointer
+	VTSOuter  VTShapeEntry = 3 // Address point displacement to outermost class
+	VTSMeta   VTShapeEntry = 4 // Far pointer to metaclass descriptor
+	VTSNear32 VTShapeEntry = 5 // 32- or 64-bit near pointer
+	VTSFar32  VTShapeEntry = 6 // 16:32 far pointer
+	VTSUnused VTShapeEntry = 7 // Unused slot
+)
+
+// String returns a short name for the slot kind.
+func (e VTShapeEntry) String() string {
+	switch e {
+	case VTSNear:
+		return "near"
+	case VTSFar:
+		return "far"
+	case VTSThin:
+		return "thin"
+	case VTSOuter:
+		return "outer"
+	case VTSMeta:
+		return "meta"
+	case VTSNear32:
+		return "near32"
+	case VTSFar32:
+		return "far32"
+	case VTSUnused:
+		return "unused"
+	default:
+		return fmt.Sprintf("vts_%d", uint8(e))
+	}
+}
+
+// ParseVTShape parses the data of an LF_VTSHAPE record into one entry per
+// vtable slot. The slot count is followed by 4-bit descriptors packed two
+// per byte, low nibble first.
+func ParseVTShape(data []byte) ([]VTShapeEntry, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("vtable shape too small: %d bytes", len(data))
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[0:]))
+	if 2+(count+1)/2 > len(data) {
+		return nil, fmt.Errorf("vtable shape truncated: %d slots in %d bytes", count, len(data))
+	}
+
+	entries := make([]VTShapeEntry, count)
+	for i := range entries {
+		desc := data[2+i/2]
+		if i%2 == 1 {
+			desc >>= 4
+		}
+		entries[i] = VTShapeEntry(desc & 0x0F)
+	}
+	return entries, nil
+}
+
+// ParseMethodList parses an LF_METHODLIST record. The entries carry no
+// names; the referencing LF_METHOD record supplies the shared name.
+func ParseMethodList(rec *streams.TypeRecord) []Method {
+	if rec == nil || rec.Kind != streams.LF_METHODLIST {
+		return nil
+	}
+
+	var methods []Method
+	data := rec.Data
+	offset := 0
+
+	for offset+8 <= len(data) {
+		m := Method{
+			Attributes: binary.LittleEndian.Uint16(data[offset:]),
+			TypeIndex:  binary.LittleEndian.Uint32(data[offset+4:]),
+		}
+		offset += 8
+
+		if isIntroVirtual(m.Attributes) {
+			if offset+4 > len(data) {
+				break
+			}
+			m.VtableOffset = binary.LittleEndian.Uint32(data[offset:])
+			offset += 4
+		}
+
+		methods = append(methods, m)
+	}
+
+	return methods
+}
+
+// TypeReferences returns the type indices a type record refers to, in
+// record order. Builtin types are left out, so the result lists the edges
+// to other TPI records. Field lists contribute the types of their members,
+// bases, methods and nested types, and their continuation, which is not
+// followed. Records are assumed to be numbered from TypeIndexBegin; the
+// TypeReferences method uses the begin index of the resolver's stream.
+func TypeReferences(rec *streams.TypeRecord) []uint32 {
+	return typeReferences(rec, streams.TypeIndexBegin)
+}
+
+// TypeReferences is like the TypeReferences function, but leaves out only
+// the indices that are builtin in the resolver's stream.
+func (r *TypeResolver) TypeReferences(rec *streams.TypeRecord) []uint32 {
+	return typeReferences(rec, r.begin)
+}
+
+// typeReferences implements TypeReferences for records numbered from
+// begin.
+func typeReferences(rec *streams.TypeRecord, begin uint32) []uint32 {
+	if rec == nil {
+		return nil
+	}
+
+	var refs []uint32
+	add := func(data []byte, offsets ...int) {
+		for _, off := range offsets {
+			if off+4 > len(data) {
+				return
+			}
+			if idx := binary.LittleEndian.Uint32(data[off:]); idx >= begin {
+				refs = append(refs, idx)
+			}
+		}
+	}
+
+	data := rec.Data
+	switch rec.Kind {
+	case streams.LF_MODIFIER, streams.LF_POINTER, streams.LF_BITFIELD:
+		add(data, 0)
+	case streams.LF_ARRAY, streams.LF_ARRAY_newformat:
+		add(data, 0, 4)
+	case streams.LF_PROCEDURE:
+		add(data, 0, 8)
+	case streams.LF_MFUNCTION:
+		add(data, 0, 4, 8, 16)
+	case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
+		streams.LF_CLASS, streams.LF_CLASS_newformat:
+		add(data, 4, 8, 12)
+	case streams.LF_UNION, streams.LF_UNION_newformat:
+		add(data, 4)
+	case streams.LF_ENUM, streams.LF_ENUM_newformat:
+		add(data, 4, 8)
+	case streams.LF_ARGLIST:
+		if len(data) >= 4 {
+			count := int(binary.LittleEndian.Uint32(data))
+			for i := 0; i < count; i++ {
+				add(data, 4+i*4)
+			}
+		}
+	case streams.LF_METHODLIST:
+		for _, m := range ParseMethodList(rec) {
+			if m.TypeIndex >= begin {
+				refs = append(refs, m.TypeIndex)
+			}
+		}
+	case streams.LF_FIELDLIST:
+		refs = fieldListReferences(data, begin)
+	}
+
+	return refs
+}
+
+// Dependencies returns the set of non-builtin type indices a type record
+// depends on, in first-seen order. Unlike TypeReferences, it looks through
+// the field lists and argument lists the record refers to, so a structure
+// depends on the types of its members and bases rather than on its field
+// list, and a procedure on its argument types.
+func (r *TypeResolver) Dependencies(rec *streams.TypeRecord) []uint32 {
+	var deps []uint32
+	seen := make(map[uint32]bool)
+	expanded := make(map[uint32]bool) // Field and argument lists already followed
+
+	var walk func(refs []uint32)
+	walk = func(refs []uint32) {
+		for _, idx := range refs {
+			if r.tpi != nil {
+				if ref := r.tpi.GetType(idx); ref != nil && (ref.Kind == streams.LF_FIELDLIST || ref.Kind == streams.LF_ARGLIST) {
+					if !expanded[idx] {
+						expanded[idx] = true
+						walk(r.TypeReferences(ref))
+					}
+					continue
+				}
+			}
+			if !seen[idx] {
+				seen[idx] = true
+				deps = append(deps, idx)
+			}
+		}
+	}
+
+	if rec != nil {
+		expanded[rec.Index] = true
+	}
+	walk(r.TypeReferences(rec))
+	return deps
+}
+
+// fieldListReferences returns the non-builtin type indices referred to by
+// the entries of a field list.
+func fieldListReferences(data []byte, begin uint32) []uint32 {
+	var refs []uint32
+	offset := 0
+
+	for offset+2 <= len(data) {
+		leafKind := binary.LittleEndian.Uint16(data[offset:])
+		offset += 2
+
+		// Every entry but LF_ENUMERATE has a type index after two bytes
+		// of attributes, count or padding
+		if leafKind != streams.LF_ENUMERATE {
+			if offset+6 > len(data) {
+				break
+			}
+			if idx := binary.LittleEndian.Uint32(data[offset+2:]); idx >= begin {
+				refs = append(refs, idx)
+			}
+		}
+
+		switch leafKind {
+		case streams.LF_MEMBER, streams.LF_MEMBER_newformat, streams.LF_BCLASS:
+			offset += 6
+			_, consumed := streams.ParseNumeric(data[offset:])
+			offset += consumed
+			if leafKind != streams.LF_BCLASS {
+				_, nameLen := streams.ParseString(data[offset:])
+				offset += nameLen
+			}
+
+		case streams.LF_STMEMBER, streams.LF_STMEMBER_newformat,
+			streams.LF_METHOD, streams.LF_METHOD_newformat,
+			streams.LF_NESTTYPE, streams.LF_NESTTYPE_newformat:
+			offset += 6
+			_, nameLen := streams.ParseString(data[offset:])
+			offset += nameLen
+
+		case streams.LF_ONEMETHOD, streams.LF_ONEMETHOD_newformat:
+			attrs := binary.LittleEndian.Uint16(data[offset:])
+			offset += 6
+			if isIntroVirtual(attrs) {
+				offset += 4
+			}
+			if offset > len(data) {
+				return refs
+			}
+			_, nameLen := streams.ParseString(data[offset:])
+			offset += nameLen
+
+		case streams.LF_VBCLASS, streams.LF_IVBCLASS:
+			offset += 6
+			if offset+4 > len(data) {
+				return refs
+			}
+			if idx := binary.LittleEndian.Uint32(data[offset:]); idx >= begin {
+				refs = append(refs, idx)
+			}
+			offset += 4
+			_, consumed := streams.ParseNumeric(data[offset:])
+			offset += consumed
+			_, consumed = streams.ParseNumeric(data[offset:])
+			offset += consumed
+
+		case streams.LF_VFUNCTAB, streams.LF_INDEX:
+			offset += 6
+
+		case streams.LF_ENUMERATE:
+			offset += 2
+			if offset > len(data) {
+				return refs
+			}
+			_, consumed := streams.ParseNumeric(data[offset:])
+			offset += consumed
+			_, nameLen := streams.ParseString(data[offset:])
+			offset += nameLen
+
+		default:
+			if leafKind < 0xF0 || leafKind > 0xFF {
+				return refs // Unknown entry
+			}
+			offset += max(int(leafKind)&0x0F, 1) - 2 // Padding
+		}
+
+		offset = alignTo(offset, 4)
+	}
+
+	return refs
+}
+
+// CompleteType returns the index of the complete definition of a forward
+// declared struct/class/union/enum, or typeIdx itself if it is not a forward
+// declaration or no definition exists. Records are matched by unique name
+// when present, otherwise by name.
+func (r *TypeResolver) CompleteType(typeIdx uint32) uint32 {
+	if r.tpi == nil {
+		return typeIdx
+	}
+	rec := r.tpi.GetType(typeIdx)
+	if rec == nil {
+		return typeIdx
+	}
+	name, unique, fwd := streams.UDTNames(rec)
+	if !fwd {
+		return typeIdx
+	}
+
+	// The hash finds candidates without indexing every record
+	if r.tpi.HasHash() {
+		for _, key := range []string{unique, name} {
+			for _, def := range r.tpi.LookupByName(key) {
+				if _, u, _ := streams.UDTNames(r.tpi.GetType(def)); unique == "" || u == unique {
+					return def
+				}
+			}
+		}
+	}
+
+	r.completeOnce.Do(r.buildCompleteTypes)
+	for _, key := range []string{unique, name} {
+		if key == "" {
+			continue
+		}
+		if def, ok := r.completeTypes[key]; ok {
+			return def
+		}
+	}
+	return typeIdx
+}
+
+// IsForwardRef reports whether typeIdx names a forward declared
+// struct/class/union/enum.
+func (r *TypeResolver) IsForwardRef(typeIdx uint32) bool {
+	if r.tpi == nil {
+		return false
+	}
+	rec := r.tpi.GetType(typeIdx)
+	if rec == nil {
+		return false
+	}
+	_, _, fwd := streams.UDTNames(rec)
+	return fwd
+}
+
+// FindType returns the index of the complete struct/class/union/enum
+// definition with the given name or unique name.
+func (r *TypeResolver) FindType(name string) (uint32, bool) {
+	if r.tpi == nil || name == "" {
+		return 0, false
+	}
+	if matches := r.tpi.LookupByName(name); len(matches) > 0 {
+		return matches[0], true
+	}
+
+	// Names the TPI hash does not key on, such as unique names
+	r.completeOnce.Do(r.buildCompleteTypes)
+	typeIdx, ok := r.completeTypes[name]
+	return typeIdx, ok
+}
+
+// buildCompleteTypes maps the name and unique name of every complete
+// struct/class/union/enum definition to its type index. The first definition
+// of a name wins.
+func (r *TypeResolver) buildCompleteTypes() {
+	r.completeTypes = make(map[string]uint32)
+	records, _ := r.tpi.Records()
+	for i := range records {
+		rec := &records[i]
+		name, unique, fwd := streams.UDTNames(rec)
+		if fwd {
+			continue
+		}
+		for _, key := range []string{unique, name} {
+			if _, ok := r.completeTypes[key]; key != "" && !ok {
+				r.completeTypes[key] = rec.Index
+			}
+		}
+	}
 }
 
 // ParseStructureType parses a structure/class/union type fully.
 func (r *TypeResolver) ParseStructureType(rec *streams.TypeRecord) *ParsedType {
-	if rec == nil || len(rec.Data) < 18 {
+	// Unions have no derivation list or vtable shape before the size
+	sizeOffset := 16
+	if rec != nil && (rec.Kind == streams.LF_UNION || rec.Kind == streams.LF_UNION_newformat) {
+		sizeOffset = 8
+	}
+	if rec == nil || len(rec.Data) < sizeOffset+2 {
 		return nil
 	}
 
@@ -335,13 +1172,12 @@ func (r *TypeResolver) ParseStructureType(rec *streams.TypeRecord) *ParsedType {
 	property := binary.LittleEndian.Uint16(data[2:])
 	fieldListIdx := binary.LittleEndian.Uint32(data[4:])
 	// derived := binary.LittleEndian.Uint32(data[8:])
-	// vshape := binary.LittleEndian.Uint32(data[12:])
 
 	// Parse size
-	size, consumed := streams.ParseNumeric(data[16:])
+	size, consumed := streams.ParseNumeric(data[sizeOffset:])
 
 	// Parse name
-	nameOffset := 16 + consumed
+	nameOffset := sizeOffset + consumed
 	name := ""
 	if nameOffset < len(data) {
 		name, _ = streams.ParseString(data[nameOffset:])
@@ -366,16 +1202,37 @@ func (r *TypeResolver) ParseStructureType(rec *streams.TypeRecord) *ParsedType {
 		Signature: fmt.Sprintf("%s %s", kindName, name),
 	}
 
-	// Skip forward declaration
-	if property&0x80 != 0 {
+	// Follow a forward declaration to the complete definition
+	if property&streams.PropForwardRef != 0 {
+		if def := r.CompleteType(rec.Index); def != rec.Index {
+			if full := r.ParseStructureType(r.tpi.GetType(def)); full != nil {
+				full.Index = rec.Index
+				full.IsForwardRef = true
+				return full
+			}
+		}
+		parsed.IsForwardRef = true
 		return parsed
 	}
 
 	// Parse field list if present
-	if fieldListIdx != 0 && fieldListIdx >= streams.TypeIndexBegin && r.tpi != nil {
+	if !r.IsBuiltin(fieldListIdx) && r.tpi != nil {
 		fieldRec := r.tpi.GetType(fieldListIdx)
 		if fieldRec != nil && fieldRec.Kind == streams.LF_FIELDLIST {
-			parsed.Members = r.parseFieldList(fieldRec.Data)
+			parsed.Members, parsed.Methods, parsed.NestedTypes = r.parseFieldList(fieldRec.Data, map[uint32]bool{fieldListIdx: true})
+		}
+	}
+	for i := range parsed.Methods {
+		m := &parsed.Methods[i]
+		m.Signature = r.MethodSignature(m.TypeIndex, m.Name)
+	}
+	r.computePadding(parsed)
+
+	// Parse the vtable shape of classes with a vfuncptr
+	if sizeOffset == 16 && r.tpi != nil {
+		vshape := binary.LittleEndian.Uint32(data[12:])
+		if shapeRec := r.tpi.GetType(vshape); shapeRec != nil && shapeRec.Kind == streams.LF_VTSHAPE {
+			parsed.VTShape, _ = ParseVTShape(shapeRec.Data)
 		}
 	}
 
@@ -383,9 +1240,37 @@ func (r *TypeResolver) ParseStructureType(rec *streams.TypeRecord) *ParsedType {
 	return parsed
 }
 
-// parseFieldList parses an LF_FIELDLIST record.
-func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
+// computePadding fills in the padding before each member and after the
+// last one. Static members and virtual bases take no space at their
+// recorded offset and are skipped. Bitfields sharing a storage unit have
+// the same offset, so a run of them counts the base type's size once;
+// union members overlap in the same way.
+func (r *TypeResolver) computePadding(parsed *ParsedType) {
+	var end uint64
+	for i := range parsed.Members {
+		m := &parsed.Members[i]
+		if m.IsStatic || m.IsVirtualBase {
+			continue
+		}
+		if m.Offset > end {
+			m.PaddingBefore = m.Offset - end
+		}
+		size, _ := r.SizeOf(m.TypeIdx)
+		if m.Offset+size > end {
+			end = m.Offset + size
+		}
+	}
+	if parsed.Size > end && len(parsed.Members) > 0 {
+		parsed.TrailingPadding = parsed.Size - end
+	}
+}
+
+// parseFieldList parses an LF_FIELDLIST record. visited holds the field
+// lists already parsed, so that a continuation cycle ends the list.
+func (r *TypeResolver) parseFieldList(data []byte, visited map[uint32]bool) ([]ParsedMember, []Method, []NestedType) {
 	var members []ParsedMember
+	var methods []Method
+	var nested []NestedType
 	offset := 0
 
 	for offset < len(data) {
@@ -400,7 +1285,7 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 		switch leafKind {
 		case streams.LF_MEMBER, streams.LF_MEMBER_newformat:
 			if offset+8 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			// attrs := binary.LittleEndian.Uint16(data[offset:])
 			offset += 2
@@ -418,17 +1303,19 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 			name, nameLen := streams.ParseString(data[offset:])
 			offset += nameLen
 
-			members = append(members, ParsedMember{
+			member := ParsedMember{
 				Name:     name,
 				TypeIdx:  typeIdx,
 				TypeName: r.ResolveType(typeIdx),
 				Offset:   memberOffset,
-			})
+			}
+			member.BitWidth, member.BitPosition = r.bitfieldLayout(typeIdx)
+			members = append(members, member)
 
 		case streams.LF_STMEMBER, streams.LF_STMEMBER_newformat:
 			// Static member
 			if offset+6 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			offset += 2 // attrs
 			typeIdx := binary.LittleEndian.Uint32(data[offset:])
@@ -445,59 +1332,86 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 				TypeIdx:  typeIdx,
 				TypeName: r.ResolveType(typeIdx) + " (static)",
 				Offset:   0,
+				IsStatic: true,
 			})
 
 		case streams.LF_METHOD, streams.LF_METHOD_newformat:
 			// Method list
 			if offset+6 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			// count := binary.LittleEndian.Uint16(data[offset:])
 			offset += 2
-			// mlist := binary.LittleEndian.Uint32(data[offset:])
+			mlist := binary.LittleEndian.Uint32(data[offset:])
 			offset += 4
 
 			if offset >= len(data) {
 				break
 			}
-			_, nameLen := streams.ParseString(data[offset:])
+			name, nameLen := streams.ParseString(data[offset:])
 			offset += nameLen
 
+			// Resolve the overloads from the method list
+			if !r.IsBuiltin(mlist) && r.tpi != nil {
+				for _, m := range ParseMethodList(r.tpi.GetType(mlist)) {
+					m.Name = name
+					methods = append(methods, m)
+				}
+			}
+
 		case streams.LF_ONEMETHOD, streams.LF_ONEMETHOD_newformat:
 			// Single method
 			if offset+6 > len(data) {
-				return members
+				return members, methods, nested
 			}
-			// attrs := binary.LittleEndian.Uint16(data[offset:])
+			attrs := binary.LittleEndian.Uint16(data[offset:])
 			offset += 2
-			// typeIdx := binary.LittleEndian.Uint32(data[offset:])
+			typeIdx := binary.LittleEndian.Uint32(data[offset:])
 			offset += 4
 
+			m := Method{Attributes: attrs, TypeIndex: typeIdx}
+			if isIntroVirtual(attrs) {
+				if offset+4 > len(data) {
+					return members, methods, nested
+				}
+				m.VtableOffset = binary.LittleEndian.Uint32(data[offset:])
+				offset += 4
+			}
+
 			if offset >= len(data) {
 				break
 			}
-			_, nameLen := streams.ParseString(data[offset:])
+			name, nameLen := streams.ParseString(data[offset:])
 			offset += nameLen
 
+			m.Name = name
+			methods = append(methods, m)
+
 		case streams.LF_NESTTYPE, streams.LF_NESTTYPE_newformat:
 			// Nested type
 			if offset+6 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			offset += 2 // padding
-			// typeIdx := binary.LittleEndian.Uint32(data[offset:])
+			typeIdx := binary.LittleEndian.Uint32(data[offset:])
 			offset += 4
 
 			if offset >= len(data) {
 				break
 			}
-			_, nameLen := streams.ParseString(data[offset:])
+			name, nameLen := streams.ParseString(data[offset:])
 			offset += nameLen
 
+			nested = append(nested, NestedType{
+				Name:      name,
+				TypeIndex: typeIdx,
+				TypeName:  r.ResolveType(typeIdx),
+			})
+
 		case streams.LF_BCLASS:
 			// Base class
 			if offset+8 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			offset += 2 // attrs
 			typeIdx := binary.LittleEndian.Uint32(data[offset:])
@@ -513,10 +1427,36 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 				Offset:   baseOffset,
 			})
 
+		case streams.LF_VBCLASS, streams.LF_IVBCLASS:
+			// Direct or indirect virtual base class
+			if offset+12 > len(data) {
+				return members, methods, nested
+			}
+			offset += 2 // attrs
+			typeIdx := binary.LittleEndian.Uint32(data[offset:])
+			offset += 4
+			vbptrIdx := binary.LittleEndian.Uint32(data[offset:])
+			offset += 4
+
+			vbptrOffset, consumed := streams.ParseNumeric(data[offset:])
+			offset += consumed
+			vbIndex, consumed := streams.ParseNumeric(data[offset:])
+			offset += consumed
+
+			members = append(members, ParsedMember{
+				Name:          "(virtual base)",
+				TypeIdx:       typeIdx,
+				TypeName:      r.ResolveType(typeIdx),
+				Offset:        vbptrOffset,
+				IsVirtualBase: true,
+				VBPtrTypeIdx:  vbptrIdx,
+				VBTableIndex:  vbIndex,
+			})
+
 		case streams.LF_VFUNCTAB:
 			// Virtual function table pointer
 			if offset+6 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			offset += 2 // padding
 			// typeIdx := binary.LittleEndian.Uint32(data[offset:])
@@ -525,7 +1465,7 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 		case streams.LF_ENUMERATE:
 			// Enum value
 			if offset+2 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			// attrs := binary.LittleEndian.Uint16(data[offset:])
 			offset += 2
@@ -542,33 +1482,33 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 		case streams.LF_INDEX:
 			// Continuation
 			if offset+6 > len(data) {
-				return members
+				return members, methods, nested
 			}
 			offset += 2 // padding
 			contIdx := binary.LittleEndian.Uint32(data[offset:])
 			offset += 4
 
 			// Follow the continuation
-			if contIdx >= streams.TypeIndexBegin && r.tpi != nil {
+			if !r.IsBuiltin(contIdx) && r.tpi != nil && !visited[contIdx] {
+				visited[contIdx] = true
 				contRec := r.tpi.GetType(contIdx)
 				if contRec != nil && contRec.Kind == streams.LF_FIELDLIST {
-					contMembers := r.parseFieldList(contRec.Data)
+					contMembers, contMethods, contNested := r.parseFieldList(contRec.Data, visited)
 					members = append(members, contMembers...)
+					methods = append(methods, contMethods...)
+					nested = append(nested, contNested...)
 				}
 			}
 
 		default:
 			// Unknown leaf type - try to skip padding
 			if leafKind >= 0xF0 && leafKind <= 0xFF {
-				// Padding byte
-				offset += int(leafKind) & 0x0F
+				// Padding byte; an LF_PAD0 skips one byte so parsing advances
+				offset += max(int(leafKind)&0x0F, 1)
 				offset -= 2 // We already consumed 2 bytes for the "kind"
-				if offset < 0 {
-					offset = 0
-				}
 			} else {
 				// Unknown, stop parsing
-				return members
+				return members, methods, nested
 			}
 		}
 
@@ -576,7 +1516,7 @@ func (r *TypeResolver) parseFieldList(data []byte) []ParsedMember {
 		offset = alignTo(offset, 4)
 	}
 
-	return members
+	return members, methods, nested
 }
 
 // alignTo aligns offset to the given alignment.
@@ -605,19 +1545,23 @@ func (r *TypeResolver) ParseEnumType(rec *streams.TypeRecord) *ParsedType {
 		name, _ = streams.ParseString(data[12:])
 	}
 
+	underlyingName := r.ResolveType(underlyingType)
 	parsed := &ParsedType{
-		Index:     rec.Index,
-		Kind:      rec.Kind,
-		KindName:  "enum",
-		Name:      name,
-		Signature: fmt.Sprintf("enum %s : %s", name, r.ResolveType(underlyingType)),
+		Index:          rec.Index,
+		Kind:           rec.Kind,
+		KindName:       "enum",
+		Name:           name,
+		Signature:      fmt.Sprintf("enum %s : %s", name, underlyingName),
+		UnderlyingType: underlyingName,
 	}
 
 	// Parse enum values from field list
-	if fieldListIdx != 0 && fieldListIdx >= streams.TypeIndexBegin && r.tpi != nil {
+	if !r.IsBuiltin(fieldListIdx) && r.tpi != nil {
 		fieldRec := r.tpi.GetType(fieldListIdx)
 		if fieldRec != nil && fieldRec.Kind == streams.LF_FIELDLIST {
-			parsed.Members = r.parseEnumFieldList(fieldRec.Data)
+			ev := enumValues{unsigned: IsUnsignedTypeName(underlyingName)}
+			ev.size, _ = r.SizeOf(underlyingType)
+			parsed.Members = r.parseEnumFieldList(fieldRec.Data, ev, map[uint32]bool{fieldListIdx: true})
 		}
 	}
 
@@ -625,8 +1569,9 @@ func (r *TypeResolver) ParseEnumType(rec *streams.TypeRecord) *ParsedType {
 	return parsed
 }
 
-// parseEnumFieldList parses enum values from a field list.
-func (r *TypeResolver) parseEnumFieldList(data []byte) []ParsedMember {
+// parseEnumFieldList parses enum values from a field list. visited holds
+// the field lists already parsed, as for parseFieldList.
+func (r *TypeResolver) parseEnumFieldList(data []byte, ev enumValues, visited map[uint32]bool) []ParsedMember {
 	var members []ParsedMember
 	offset := 0
 
@@ -645,7 +1590,7 @@ func (r *TypeResolver) parseEnumFieldList(data []byte) []ParsedMember {
 			// attrs := binary.LittleEndian.Uint16(data[offset:])
 			offset += 2
 
-			value, consumed := streams.ParseNumeric(data[offset:])
+			value, consumed := streams.ParseNumericLeaf(data[offset:])
 			offset += consumed
 
 			if offset >= len(data) {
@@ -661,10 +1606,11 @@ func (r *TypeResolver) parseEnumFieldList(data []byte) []ParsedMember {
 				offset += idx + 1
 			}
 
+			v, text := ev.format(value)
 			members = append(members, ParsedMember{
 				Name:     name,
-				TypeName: fmt.Sprintf("%d", value),
-				Offset:   value,
+				TypeName: text,
+				Offset:   v,
 			})
 		} else if leafKind == streams.LF_INDEX {
 			// Continuation
@@ -675,20 +1621,18 @@ func (r *TypeResolver) parseEnumFieldList(data []byte) []ParsedMember {
 			contIdx := binary.LittleEndian.Uint32(data[offset:])
 			offset += 4
 
-			if contIdx >= streams.TypeIndexBegin && r.tpi != nil {
+			if !r.IsBuiltin(contIdx) && r.tpi != nil && !visited[contIdx] {
+				visited[contIdx] = true
 				contRec := r.tpi.GetType(contIdx)
 				if contRec != nil && contRec.Kind == streams.LF_FIELDLIST {
-					contMembers := r.parseEnumFieldList(contRec.Data)
+					contMembers := r.parseEnumFieldList(contRec.Data, ev, visited)
 					members = append(members, contMembers...)
 				}
 			}
 		} else if leafKind >= 0xF0 && leafKind <= 0xFF {
 			// Padding
-			offset += int(leafKind) & 0x0F
+			offset += max(int(leafKind)&0x0F, 1)
 			offset -= 2
-			if offset < 0 {
-				offset = 0
-			}
 		} else {
 			break
 		}
@@ -698,3 +1642,48 @@ func (r *TypeResolver) parseEnumFieldList(data []byte) []ParsedMember {
 
 	return members
 }
+
+// enumValues describes the underlying type of an enum, which decides how
+// its enumerate values are read.
+type enumValues struct {
+	size     uint64 // Width in bytes, 0 if unknown
+	unsigned bool
+}
+
+// format returns an enumerate's value as the underlying type holds it,
+// truncated to the type's width and sign-extended unless the type is
+// unsigned, along with its decimal text. Numeric leaves are sign-extended
+// only for signed leaf kinds, so -1 stored as LF_ULONG would otherwise
+// read as 4294967295.
+func (ev enumValues) format(value streams.Numeric) (uint64, string) {
+	if value.IsFloat || value.Raw != nil {
+		return value.Value, value.String()
+	}
+
+	v := value.Value
+	if bits := ev.size * 8; bits > 0 && bits < 64 {
+		v &= 1<<bits - 1
+		if !ev.unsigned && v&(1<<(bits-1)) != 0 {
+			v |= ^uint64(0) << bits
+		}
+	}
+	if ev.unsigned {
+		return v, strconv.FormatUint(v, 10)
+	}
+	return v, strconv.FormatInt(int64(v), 10)
+}
+
+// IsUnsignedTypeName reports whether a resolved type name denotes an
+// unsigned integer type. Numeric leaves are sign-extended when parsed, so
+// values of these types must be displayed unsigned.
+func IsUnsignedTypeName(name string) bool {
+	for _, qual := range []string{"const ", "volatile "} {
+		name = strings.TrimPrefix(name, qual)
+	}
+
+	switch name {
+	case "bool", "wchar_t", "char16_t", "char32_t":
+		return true
+	}
+	return strings.HasPrefix(name, "unsigned ") || strings.HasPrefix(name, "uint")
+}
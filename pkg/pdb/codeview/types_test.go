@@ -0,0 +1,477 @@
+package codeview
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// Builtin type indices used by the tests
+const (
+	tChar  = 0x0010 // T_CHAR
+	tInt4  = 0x0074 // T_INT4
+	tUint4 = 0x0075 // T_UINT4
+	tVoid  = 0x0003 // T_VOID
+)
+
+// ptr64 is the attributes word of a plain 64-bit pointer of size 8.
+const ptr64 = 0x0C | 8<<13
+
+// structure encodes an LF_STRUCTURE record with a small size.
+func structure(name string, fieldList uint32, size uint16) bb {
+	return udt(streams.LF_STRUCTURE_newformat, name, fieldList, size)
+}
+
+// udt encodes a class, structure or union record with a small size.
+func udt(kind uint16, name string, fieldList uint32, size uint16) bb {
+	if kind == streams.LF_UNION_newformat {
+		return leaf(kind).u16(2).u16(0).u32(fieldList).u16(size).str(name)
+	}
+	return leaf(kind).u16(2).u16(0).u32(fieldList).u32(0).u32(0).u16(size).str(name)
+}
+
+// member encodes an LF_MEMBER field list entry with a small offset.
+func member(name string, typeIdx uint32, offset uint16) bb {
+	return leaf(streams.LF_MEMBER_newformat).u16(3).u32(typeIdx).u16(offset).str(name).pad()
+}
+
+// memberNames lists the members of a parsed type as name:type.
+func memberNames(parsed *ParsedType) []string {
+	var names []string
+	for _, m := range parsed.Members {
+		names = append(names, m.Name+":"+m.TypeName)
+	}
+	return names
+}
+
+// fieldList concatenates field list entries into an LF_FIELDLIST record.
+func fieldList(entries ...bb) bb {
+	b := leaf(streams.LF_FIELDLIST)
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	return b
+}
+
+func TestTypeResolverIndexBegin(t *testing.T) {
+	// A stream numbered from 0x800: every reference between its own
+	// records lies below TypeIndexBegin.
+	tpi := buildTPIAt(t, 0x800,
+		fieldList(member("x", tInt4, 0), member("next", 0x802, 8)),              // 0x800
+		structure("Node", 0x800, 16),                                            // 0x801
+		leaf(streams.LF_POINTER).u32(0x801).u32(ptr64),                          // 0x802
+		leaf(streams.LF_ARRAY_newformat).u32(0x801).u32(tUint4).u16(64).str(""), // 0x803
+	)
+	r := NewTypeResolver(tpi)
+
+	for _, tc := range []struct {
+		index uint32
+		want  bool
+	}{
+		{0, true},
+		{tInt4, true},
+		{0x7FF, true},
+		{0x800, false},
+		{0x803, false},
+		{streams.TypeIndexBegin, false},
+	} {
+		if got := r.IsBuiltin(tc.index); got != tc.want {
+			t.Errorf("IsBuiltin(0x%x) = %v, want %v", tc.index, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		index uint32
+		want  string
+	}{
+		{0x801, "Node"},
+		{0x802, "Node*"},
+		{0x803, "Node[4]"},
+	} {
+		if got := r.ResolveType(tc.index); got != tc.want {
+			t.Errorf("ResolveType(0x%x) = %q, want %q", tc.index, got, tc.want)
+		}
+	}
+
+	if size, ok := r.SizeOf(0x803); !ok || size != 64 {
+		t.Errorf("SizeOf(0x803) = %d, %v; want 64, true", size, ok)
+	}
+
+	parsed := r.ParseStructureType(tpi.GetType(0x801))
+	if parsed == nil {
+		t.Fatal("ParseStructureType(0x801) = nil")
+	}
+	if names, want := memberNames(parsed), []string{"x:int32", "next:Node*"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("members = %q, want %q", names, want)
+	}
+
+	if got, want := r.TypeReferences(tpi.GetType(0x800)), []uint32{0x802}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TypeReferences(fieldlist) = %#x, want %#x", got, want)
+	}
+	if got, want := r.Dependencies(tpi.GetType(0x801)), []uint32{0x802}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies(Node) = %#x, want %#x", got, want)
+	}
+}
+
+func TestTypeResolverZeroBegin(t *testing.T) {
+	// A header claiming begin 0 must not make T_NOTYPE a record
+	tpi := buildTPIAt(t, 0, structure("A", 0, 0))
+	r := NewTypeResolver(tpi)
+	if !r.IsBuiltin(0) {
+		t.Error("IsBuiltin(0) = false for a stream starting at 0")
+	}
+	if got := r.ResolveType(0); got != streams.GetBuiltinTypeName(0) {
+		t.Errorf("ResolveType(0) = %q", got)
+	}
+}
+
+func TestParseFieldListVirtualBases(t *testing.T) {
+	// class D : A, virtual B { int x; }, where B reaches D indirectly
+	// through a virtual base of its own too
+	tpi := buildTPI(t,
+		structure("A", 0, 4), // 0x1000
+		structure("B", 0, 4), // 0x1001
+		structure("C", 0, 4), // 0x1002
+		fieldList( // 0x1003
+			leaf(streams.LF_BCLASS).u16(3).u32(0x1000).u16(0).pad(),
+			leaf(streams.LF_VBCLASS).u16(3).u32(0x1001).u32(0x0674).u16(8).u16(1).pad(),
+			leaf(streams.LF_IVBCLASS).u16(3).u32(0x1002).u32(0x0674).u16(streams.LF_USHORT).u16(8).u16(2).pad(),
+			member("x", tInt4, 16),
+		),
+		udt(streams.LF_CLASS_newformat, "D", 0x1003, 24), // 0x1004
+	)
+	r := NewTypeResolver(tpi)
+
+	parsed := r.ParseStructureType(tpi.GetType(0x1004))
+	if parsed == nil {
+		t.Fatal("ParseStructureType = nil")
+	}
+	want := []ParsedMember{
+		{Name: "(base)", TypeIdx: 0x1000, TypeName: "A"},
+		{Name: "(virtual base)", TypeIdx: 0x1001, TypeName: "B", Offset: 8, IsVirtualBase: true, VBPtrTypeIdx: 0x0674, VBTableIndex: 1},
+		{Name: "(virtual base)", TypeIdx: 0x1002, TypeName: "C", Offset: 8, IsVirtualBase: true, VBPtrTypeIdx: 0x0674, VBTableIndex: 2},
+		{Name: "x", TypeIdx: tInt4, TypeName: "int32", Offset: 16},
+	}
+	if len(parsed.Members) != len(want) {
+		t.Fatalf("members = %q, want %d members", memberNames(parsed), len(want))
+	}
+	for i, m := range parsed.Members {
+		m.PaddingBefore = 0
+		if m != want[i] {
+			t.Errorf("member %d = %+v, want %+v", i, m, want[i])
+		}
+	}
+}
+
+// array encodes an LF_ARRAY record with a small size in bytes.
+func array(elem uint32, size uint16) bb {
+	return leaf(streams.LF_ARRAY_newformat).u32(elem).u32(tUint4).u16(size).str("")
+}
+
+func TestResolveArray(t *testing.T) {
+	tpi := buildTPI(t,
+		array(tChar, 16),      // 0x1000 char[16]
+		array(tInt4, 16),      // 0x1001 int[4]
+		array(tInt4, 32),      // 0x1002 int[8]
+		array(0x1002, 128),    // 0x1003 int[4][8]
+		structure("P", 0, 12), // 0x1004
+		array(0x1004, 36),     // 0x1005 P[3]
+		array(tInt4, 0),       // 0x1006 int[]
+	)
+	r := NewTypeResolver(tpi)
+
+	tests := []struct {
+		index uint32
+		want  string
+		size  uint64
+	}{
+		{0x1000, "char[16]", 16},
+		{0x1001, "int32[4]", 16},
+		{0x1003, "int32[4][8]", 128},
+		{0x1005, "P[3]", 36},
+		{0x1006, "int32[]", 0},
+	}
+	for _, tc := range tests {
+		if got := r.ResolveType(tc.index); got != tc.want {
+			t.Errorf("ResolveType(0x%x) = %q, want %q", tc.index, got, tc.want)
+		}
+		if size, _ := r.SizeOf(tc.index); size != tc.size {
+			t.Errorf("SizeOf(0x%x) = %d, want %d", tc.index, size, tc.size)
+		}
+	}
+}
+
+// Pointer attribute words used by the tests: a 64-bit pointer of size 8
+// with the given mode and flag bits.
+func ptrAttrs(mode, flags uint32) uint32 {
+	return ptr64 | mode<<5 | flags
+}
+
+// pointer encodes an LF_POINTER record, followed by the containing class
+// and representation of a pointer to member.
+func pointer(to, attrs uint32, class ...uint32) bb {
+	b := leaf(streams.LF_POINTER).u32(to).u32(attrs)
+	for _, c := range class {
+		b = b.u32(c).u16(0)
+	}
+	return b
+}
+
+// mfunction encodes an LF_MFUNCTION record with a __thiscall convention.
+func mfunction(ret, class, this uint32, params uint16, argList uint32) bb {
+	return leaf(streams.LF_MFUNCTION).u32(ret).u32(class).u32(this).u8(0x0B).u8(0).u16(params).u32(argList).u32(0)
+}
+
+func TestResolvePointer(t *testing.T) {
+	tpi := buildTPI(t,
+		structure("Foo", 0, 8),                       // 0x1000
+		leaf(streams.LF_ARGLIST).u32(1).u32(tChar),   // 0x1001 (char)
+		pointer(0x1000, ptrAttrs(0, 0)),              // 0x1002 Foo* this
+		mfunction(tInt4, 0x1000, 0x1002, 1, 0x1001),  // 0x1003
+		pointer(0x1003, ptrAttrs(3, 0), 0x1000),      // 0x1004 member function pointer
+		pointer(tInt4, ptrAttrs(2, 0), 0x1000),       // 0x1005 data member pointer
+		pointer(tInt4, ptrAttrs(0, 1<<12)),           // 0x1006 __restrict
+		pointer(0x1000, ptrAttrs(0, 1<<10|1<<11)),    // 0x1007 const __unaligned
+		pointer(tInt4, ptrAttrs(1, 0)),               // 0x1008 reference
+		pointer(tInt4, ptrAttrs(4, 0)),               // 0x1009 rvalue reference
+		pointer(0x1003, ptrAttrs(3, 1<<12), 0x1000),  // 0x100a __restrict member function pointer
+		leaf(streams.LF_MODIFIER).u32(0x1000).u16(1), // 0x100b const Foo
+		pointer(0x100b, ptrAttrs(0, 0)),              // 0x100c const Foo* this
+		mfunction(tVoid, 0x1000, 0x100c, 0, 0x1001),  // 0x100d
+		pointer(0x100d, ptrAttrs(3, 0), 0x1000),      // 0x100e const member function pointer
+	)
+	r := NewTypeResolver(tpi)
+
+	tests := []struct {
+		index uint32
+		want  string
+	}{
+		{0x1004, "int32 (Foo::*)(char)"},
+		{0x1005, "int32 Foo::*"},
+		{0x1006, "int32* __restrict"},
+		{0x1007, "const Foo* __unaligned"},
+		{0x1008, "int32&"},
+		{0x1009, "int32&&"},
+		{0x100a, "int32 (Foo::*)(char) __restrict"},
+		{0x100e, "void (Foo::*)(char) const"},
+	}
+	for _, tc := range tests {
+		if got := r.ResolveType(tc.index); got != tc.want {
+			t.Errorf("ResolveType(0x%x) = %q, want %q", tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestStructurePadding(t *testing.T) {
+	// struct S { char c; int i; char d; double x; unsigned a : 3, b : 5; char e; };
+	const tReal64 = 0x0041
+	tpi := buildTPI(t,
+		leaf(streams.LF_BITFIELD).u32(tUint4).u8(3).u8(0), // 0x1000
+		leaf(streams.LF_BITFIELD).u32(tUint4).u8(5).u8(3), // 0x1001
+		fieldList( // 0x1002
+			member("c", tChar, 0),
+			member("i", tInt4, 4),
+			member("d", tChar, 8),
+			member("x", tReal64, 16),
+			member("a", 0x1000, 24),
+			member("b", 0x1001, 24),
+			member("e", tChar, 28),
+		),
+		structure("S", 0x1002, 32), // 0x1003
+	)
+	r := NewTypeResolver(tpi)
+
+	parsed := r.ParseStructureType(tpi.GetType(0x1003))
+	if parsed == nil {
+		t.Fatal("ParseStructureType = nil")
+	}
+	want := map[string]uint64{"c": 0, "i": 3, "d": 0, "x": 7, "a": 0, "b": 0, "e": 0}
+	if len(parsed.Members) != len(want) {
+		t.Fatalf("members = %q", memberNames(parsed))
+	}
+	for _, m := range parsed.Members {
+		if m.PaddingBefore != want[m.Name] {
+			t.Errorf("%s.PaddingBefore = %d, want %d", m.Name, m.PaddingBefore, want[m.Name])
+		}
+	}
+	if parsed.TrailingPadding != 3 {
+		t.Errorf("TrailingPadding = %d, want 3", parsed.TrailingPadding)
+	}
+	if b := parsed.Members[5]; b.BitWidth != 5 || b.BitPosition != 3 {
+		t.Errorf("b is %d bits at %d, want 5 at 3", b.BitWidth, b.BitPosition)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	tpi := buildTPI(t,
+		structure("D", 0, 4),            // 0x1000
+		structure("B", 0, 4),            // 0x1001
+		pointer(0x1001, ptrAttrs(0, 0)), // 0x1002 B*
+		array(0x1001, 8),                // 0x1003 B[2]
+		structure("E", 0, 1),            // 0x1004
+		fieldList( // 0x1005
+			leaf(streams.LF_BCLASS).u16(3).u32(0x1000).u16(0).pad(),
+			member("b", 0x1001, 4),
+			member("p", 0x1002, 8),
+			member("arr", 0x1003, 16),
+			leaf(streams.LF_NESTTYPE_newformat).u16(0).u32(0x1004).str("E").pad(),
+			member("x", tInt4, 24),
+			member("again", 0x1001, 28),
+		),
+		structure("S", 0x1005, 32),                                            // 0x1006
+		leaf(streams.LF_ARGLIST).u32(2).u32(0x1002).u32(tInt4),                // 0x1007
+		leaf(streams.LF_PROCEDURE).u32(0x1006).u8(0).u8(0).u16(2).u32(0x1007), // 0x1008
+	)
+	r := NewTypeResolver(tpi)
+
+	tests := []struct {
+		index uint32
+		want  []uint32
+	}{
+		{0x1006, []uint32{0x1000, 0x1001, 0x1002, 0x1003, 0x1004}},
+		{0x1008, []uint32{0x1006, 0x1002}},
+		{0x1003, []uint32{0x1001}},
+		{0x1001, nil},
+	}
+	for _, tc := range tests {
+		if got := r.Dependencies(tpi.GetType(tc.index)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Dependencies(0x%x) = %#x, want %#x", tc.index, got, tc.want)
+		}
+	}
+}
+
+// enumerate encodes an LF_ENUMERATE field list entry with a numeric leaf.
+func enumerate(name string, value bb) bb {
+	return leaf(streams.LF_ENUMERATE).u16(3).bytes(value).str(name).pad()
+}
+
+func TestParseEnumNumericLeaves(t *testing.T) {
+	real64 := bb(nil).u16(streams.LF_REAL64).u32(0).u32(0x40040000) // 2.5
+	tpi := buildTPI(t,
+		fieldList( // 0x1000
+			enumerate("A", real64),
+			enumerate("B", bb(nil).u16(streams.LF_CHAR).u8(0xFF)),
+			enumerate("C", bb(nil).u16(7)),
+			enumerate("D", bb(nil).u16(streams.LF_QUADWORD).u32(0x100).u32(0)),
+		),
+		leaf(streams.LF_ENUM_newformat).u16(4).u16(0).u32(tInt4).u32(0x1000).str("E"), // 0x1001
+	)
+	r := NewTypeResolver(tpi)
+
+	parsed := r.ParseEnumType(tpi.GetType(0x1001))
+	if parsed == nil {
+		t.Fatal("ParseEnumType = nil")
+	}
+	want := []string{"A:2.5", "B:-1", "C:7", "D:256"}
+	if got := memberNames(parsed); !reflect.DeepEqual(got, want) {
+		t.Errorf("enumerates = %q, want %q", got, want)
+	}
+
+	constant, err := ParseConstantSym(bb(nil).u32(0x0041).bytes(real64).str("PI_ISH"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !constant.Numeric.IsFloat || constant.Numeric.Float != 2.5 || constant.Name != "PI_ISH" {
+		t.Errorf("ParseConstantSym = %+v, want 2.5 named PI_ISH", constant)
+	}
+}
+
+func TestResolveMemberFunction(t *testing.T) {
+	recs := []bb{
+		structure("Foo", 0, 8),                         // 0x1000
+		leaf(streams.LF_ARGLIST).u32(1).u32(tChar),     // 0x1001
+		pointer(0x1000, ptrAttrs(0, 0)),                // 0x1002 Foo*
+		leaf(streams.LF_MODIFIER).u32(0x1000).u16(1),   // 0x1003 const Foo
+		pointer(0x1003, ptrAttrs(0, 0)),                // 0x1004
+		leaf(streams.LF_MODIFIER).u32(0x1000).u16(2),   // 0x1005 volatile Foo
+		pointer(0x1005, ptrAttrs(0, 0)),                // 0x1006
+		pointer(0x1000, ptrAttrs(0, ptrLValueRefThis)), // 0x1007
+		pointer(0x1000, ptrAttrs(0, ptrRValueRefThis)), // 0x1008
+		leaf(streams.LF_MODIFIER).u32(0x1000).u16(3),   // 0x1009 const volatile Foo
+		pointer(0x1009, ptrAttrs(0, ptrRValueRefThis)), // 0x100a
+	}
+	tests := []struct {
+		name   string
+		this   uint32
+		want   string
+		method string // MethodSignature for a method named bar
+	}{
+		{"plain", 0x1002, "int32 (__thiscall Foo::)(char)", "int32 __thiscall Foo::bar(char)"},
+		{"const", 0x1004, "int32 (__thiscall Foo::)(char) const", "int32 __thiscall Foo::bar(char) const"},
+		{"volatile", 0x1006, "int32 (__thiscall Foo::)(char) volatile", "int32 __thiscall Foo::bar(char) volatile"},
+		{"lvalue ref", 0x1007, "int32 (__thiscall Foo::)(char) &", "int32 __thiscall Foo::bar(char) &"},
+		{"rvalue ref", 0x1008, "int32 (__thiscall Foo::)(char) &&", "int32 __thiscall Foo::bar(char) &&"},
+		{"const volatile rvalue ref", 0x100a, "int32 (__thiscall Foo::)(char) const volatile &&", "int32 __thiscall Foo::bar(char) const volatile &&"},
+		{"static", streams.T_NOTYPE, "static int32 (__cdecl Foo::)(char)", "static int32 __cdecl Foo::bar(char)"},
+	}
+	first := streams.TypeIndexBegin + uint32(len(recs))
+	for _, tc := range tests {
+		if tc.this == streams.T_NOTYPE {
+			recs = append(recs, leaf(streams.LF_MFUNCTION).u32(tInt4).u32(0x1000).u32(tc.this).u8(0).u8(0).u16(1).u32(0x1001).u32(0))
+			continue
+		}
+		recs = append(recs, mfunction(tInt4, 0x1000, tc.this, 1, 0x1001))
+	}
+	// Pointers to the const and the rvalue-ref member functions
+	recs = append(recs,
+		pointer(first+1, ptrAttrs(3, 0), 0x1000),
+		pointer(first+4, ptrAttrs(3, 0), 0x1000),
+	)
+	r := NewTypeResolver(buildTPI(t, recs...))
+
+	for i, tc := range tests {
+		if got := r.ResolveType(first + uint32(i)); got != tc.want {
+			t.Errorf("%s: ResolveType = %q, want %q", tc.name, got, tc.want)
+		}
+		if got := r.MethodSignature(first+uint32(i), "bar"); got != tc.method {
+			t.Errorf("%s: MethodSignature = %q, want %q", tc.name, got, tc.method)
+		}
+	}
+	// Other types render as by ResolveType
+	if got := r.MethodSignature(0x1002, "bar"); got != "Foo*" {
+		t.Errorf("MethodSignature(0x1002) = %q, want %q", got, "Foo*")
+	}
+	ptrs := first + uint32(len(tests))
+	for index, want := range map[uint32]string{
+		ptrs:     "int32 (Foo::*)(char) const",
+		ptrs + 1: "int32 (Foo::*)(char) &&",
+	} {
+		if got := r.ResolveType(index); got != want {
+			t.Errorf("ResolveType(0x%x) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestResolveCycleNotCached(t *testing.T) {
+	recs := []bb{
+		pointer(0x1001, ptr64), // 0x1000
+		pointer(0x1000, ptr64), // 0x1001
+		pointer(0x1000, ptr64), // 0x1002 refers into the cycle
+		pointer(tInt4, ptr64),  // 0x1003
+	}
+	want := map[uint32]string{
+		0x1000: "type_0x1000**",
+		0x1001: "type_0x1001**",
+		0x1002: "type_0x1000***",
+		0x1003: "int32*",
+	}
+
+	// Whichever index is resolved first, the others must not get a string
+	// cut short on its path
+	for _, first := range []uint32{0x1000, 0x1001, 0x1002} {
+		r := NewTypeResolver(buildTPI(t, recs...))
+		r.ResolveType(first)
+		r.ResolveType(0x1003)
+		for index, s := range want {
+			if got := r.ResolveType(index); got != s {
+				t.Errorf("after resolving 0x%x: ResolveType(0x%x) = %q, want %q", first, index, got, s)
+			}
+		}
+		for index := range r.resolved {
+			if index != 0x1003 {
+				t.Errorf("after resolving 0x%x: 0x%x is cached", first, index)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package pdb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/codeview"
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// TestConcurrentAccess fires the lazily cached accessors from many
+// goroutines at once. Run it with -race.
+func TestConcurrentAccess(t *testing.T) {
+	const (
+		numFunctions = 200
+		goroutines   = 50
+	)
+	var syms, globals bb
+	for i := 0; i < numFunctions; i++ {
+		name := fmt.Sprintf("?f%d@@YAHH@Z", i)
+		syms = syms.bytes(symbol(codeview.S_GPROC32, procSym(0x1001, uint32(i)*0x10, 1, 0x10, name))).
+			bytes(symbol(codeview.S_END, nil))
+		globals = globals.bytes(symbol(codeview.S_PUB32, pubSym(codeview.PubFunction, uint32(i)*0x10, 1, name))).
+			bytes(symbol(codeview.S_GDATA32, dataSym(0x1002, uint32(i)*8, 2, fmt.Sprintf("g_%d", i))))
+	}
+	p := openPDB(t, &testPDB{
+		types: []bb{
+			leaf(streams.LF_ARGLIST).u32(1).u32(streams.T_INT4), // 0x1000
+			leaf(streams.LF_PROCEDURE).u32(streams.T_INT4).u8(0).u8(0).u16(1).u32(0x1000),
+			leaf(streams.LF_POINTER).u32(streams.T_CHAR).u32(0x0C | 8<<13),
+		},
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000), section(".data", 0x3000, 0x1000)},
+		globals:  globals,
+		modules:  []testModule{{name: "a.obj", syms: syms}},
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			switch g % 10 {
+			case 0:
+				p.SetDemangle(true)
+			case 1:
+				p.SetTypeServerResolver(func(TypeServerRef) *streams.TPIStream { return nil })
+			}
+			if n := len(p.Functions()); n != numFunctions {
+				errs <- fmt.Errorf("goroutine %d: %d functions", g, n)
+				return
+			}
+			if n := len(p.Variables()); n != numFunctions {
+				errs <- fmt.Errorf("goroutine %d: %d variables", g, n)
+				return
+			}
+			p.PublicSymbols()
+			p.Sections()
+			p.ResolveType(0x1002)
+			for i := 0; i < numFunctions; i++ {
+				rva := 0x1000 + uint32((i*7+g)%numFunctions)*0x10 + 4
+				fn := p.SymbolAtRVA(rva)
+				if fn == nil || rva-fn.RVA >= fn.Length {
+					errs <- fmt.Errorf("goroutine %d: SymbolAtRVA(0x%x) = %+v", g, rva, fn)
+					return
+				}
+				if fn.Signature != "int32 __cdecl(int32)" || fn.DemangledName == "" {
+					errs <- fmt.Errorf("goroutine %d: %s has signature %q, demangled %q", g, fn.Name, fn.Signature, fn.DemangledName)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
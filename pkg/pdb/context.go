@@ -0,0 +1,90 @@
+package pdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ctxCheckInterval is the number of records the context-aware loaders
+// process between checks for cancellation.
+const ctxCheckInterval = 256
+
+// cancelCheck returns a function to call once per record. Every
+// ctxCheckInterval calls it returns ctx's error, if any.
+func cancelCheck(ctx context.Context) func() error {
+	n := 0
+	return func() error {
+		n++
+		if n%ctxCheckInterval != 0 {
+			return nil
+		}
+		return ctx.Err()
+	}
+}
+
+// cacheOnce guards a lazily built cache. Unlike sync.Once, a load that
+// fails because its context was cancelled leaves the cache unbuilt, so
+// the next caller builds it again.
+type cacheOnce struct {
+	done atomic.Bool
+	mu   sync.Mutex
+}
+
+// do runs load unless an earlier call to do completed it, and returns
+// load's error. Concurrent callers wait for the running load.
+func (o *cacheOnce) do(ctx context.Context, load func(context.Context) error) error {
+	if o.done.Load() {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done.Load() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := load(ctx); err != nil {
+		return err
+	}
+	o.done.Store(true)
+	return nil
+}
+
+// FunctionsContext is like FunctionsE but returns ctx's error and no
+// functions if ctx is done before the symbol streams are walked. A
+// cancelled call caches nothing.
+func (p *PDB) FunctionsContext(ctx context.Context) ([]Function, error) {
+	if err := p.functionsOnce.do(ctx, p.loadFunctions); err != nil {
+		return nil, err
+	}
+	return p.functions, p.functionsErr
+}
+
+// VariablesContext is like VariablesE but returns ctx's error and no
+// variables if ctx is done before the symbol streams are walked.
+func (p *PDB) VariablesContext(ctx context.Context) ([]Variable, error) {
+	if err := p.variablesOnce.do(ctx, p.loadVariables); err != nil {
+		return nil, err
+	}
+	return p.variables, p.variablesErr
+}
+
+// PublicSymbolsContext is like PublicSymbols but returns ctx's error if
+// ctx is done before the public symbols are parsed.
+func (p *PDB) PublicSymbolsContext(ctx context.Context) ([]PublicSymbol, error) {
+	if err := p.publicsOnce.do(ctx, p.loadPublics); err != nil {
+		return nil, err
+	}
+	return p.publics, nil
+}
+
+// LinesContext is like Lines but returns ctx's error if ctx is done before
+// every module's line information is collected.
+func (p *PDB) LinesContext(ctx context.Context) ([]LineInfo, error) {
+	if err := p.linesOnce.do(ctx, p.loadLines); err != nil {
+		return nil, err
+	}
+	return p.lines, nil
+}
@@ -1,6 +1,7 @@
 package pdb
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -10,16 +11,46 @@ type DemangleResult struct {
 	Prototype string // The function prototype (e.g., "void __cdecl(int, char*)")
 }
 
-// DemangleFull attempts to demangle an MSVC decorated name and returns
-// the name and prototype separately.
-func DemangleFull(name string) DemangleResult {
+// Demangler demangles MSVC decorated names, and Itanium mangled names
+// as produced by clang and GCC. Its options select the
+// optional parts of the prototype; the zero value leaves them all out.
+type Demangler struct {
+	IncludeAccess      bool // Access and storage class (e.g., "public: virtual")
+	IncludeCallingConv bool // Calling convention (e.g., "__cdecl")
+	IncludeReturnType  bool // Return type of functions
+}
+
+// defaultDemangler is the configuration used by Demangle and DemangleFull.
+var defaultDemangler = Demangler{
+	IncludeAccess:      true,
+	IncludeCallingConv: true,
+	IncludeReturnType:  true,
+}
+
+// Demangle attempts to demangle an MSVC decorated name and returns the
+// name and prototype separately.
+func (dm Demangler) Demangle(name string) DemangleResult {
 	if name == "" {
 		return DemangleResult{}
 	}
 
+	// Check for __imp_ prefix (import thunk)
+	if strings.HasPrefix(name, "__imp_") {
+		inner := dm.Demangle(name[6:])
+		if inner.Name != "" {
+			inner.Name = inner.Name + " [import]"
+			return inner
+		}
+	}
+
 	// Check for MSVC C++ mangled name (starts with ?)
 	if strings.HasPrefix(name, "?") {
-		return demangleMSVCFull(name)
+		return demangleMSVCFull(name, dm)
+	}
+
+	// Check for Itanium C++ mangled name (clang/GCC, starts with _Z)
+	if strings.HasPrefix(name, "_Z") {
+		return demangleItanium(name, dm)
 	}
 
 	// Check for MSVC C decorated name (starts with _ and may end with @nn)
@@ -27,19 +58,39 @@ func DemangleFull(name string) DemangleResult {
 		return DemangleResult{Name: demangleCDecl(name)}
 	}
 
-	// Check for __imp_ prefix (import thunk)
-	if strings.HasPrefix(name, "__imp_") {
-		inner := DemangleFull(name[6:])
-		if inner.Name != "" {
-			inner.Name = inner.Name + " [import]"
-			return inner
-		}
-	}
-
 	return DemangleResult{Name: name}
 }
 
-// Demangle attempts to demangle an MSVC decorated name.
+// DemangleFull attempts to demangle an MSVC decorated name and returns
+// the name and prototype separately, including every optional part of the
+// prototype.
+func DemangleFull(name string) DemangleResult {
+	return defaultDemangler.Demangle(name)
+}
+
+// SetDemangle controls whether Functions, Variables, PublicSymbols and
+// Constants demangle symbol names. Demangling is on by default; turning it
+// off leaves DemangledName and Prototype empty, which speeds up listing
+// PDBs with many symbols when only raw names are needed.
+//
+// It is safe to call concurrently with the accessors, but results they
+// have already cached are not recomputed, so call it right after opening
+// the PDB for the setting to apply to every result.
+func (p *PDB) SetDemangle(enabled bool) {
+	p.noDemangle.Store(!enabled)
+}
+
+// demangle demangles a symbol name unless demangling is turned off, in
+// which case the name is returned unchanged.
+func (p *PDB) demangle(name string) DemangleResult {
+	if p.noDemangle.Load() {
+		return DemangleResult{Name: name}
+	}
+	return DemangleFull(name)
+}
+
+// Demangle attempts to demangle an MSVC decorated name, including every
+// optional part of the prototype.
 // Returns the demangled name, or the original if demangling fails.
 // For separate name and prototype, use DemangleFull instead.
 func Demangle(name string) string {
@@ -75,7 +126,7 @@ func demangleCDecl(name string) string {
 }
 
 // demangleMSVCFull handles MSVC C++ mangled names and returns name and prototype separately
-func demangleMSVCFull(name string) DemangleResult {
+func demangleMSVCFull(name string, opts Demangler) DemangleResult {
 	if len(name) < 2 || name[0] != '?' {
 		return DemangleResult{Name: name}
 	}
@@ -83,6 +134,7 @@ func demangleMSVCFull(name string) DemangleResult {
 	d := &msvcDemangler{
 		input: name,
 		pos:   1, // Skip initial '?'
+		opts:  opts,
 		names: make([]string, 0),
 	}
 
@@ -92,12 +144,14 @@ func demangleMSVCFull(name string) DemangleResult {
 type msvcDemangler struct {
 	input string
 	pos   int
-	names []string // Back-reference table
+	opts  Demangler
+	names    []string // Back-reference table
+	typeRefs []string // Argument type back-reference table
 }
 
 func (d *msvcDemangler) demangleFull() DemangleResult {
 	// Parse the qualified name
-	qualName := d.parseQualifiedName()
+	qualName := d.parseQualifiedName(true)
 	if qualName == "" {
 		return DemangleResult{}
 	}
@@ -107,6 +161,12 @@ func (d *msvcDemangler) demangleFull() DemangleResult {
 		return DemangleResult{Name: qualName}
 	}
 
+	// Virtual tables and complete object locators name the base class
+	// whose part of the object they describe
+	if c := d.input[d.pos]; c == '6' || c == '7' {
+		return DemangleResult{Name: qualName + d.parseVTableScope()}
+	}
+
 	// Parse the type/encoding info (prototype)
 	prototype := d.parseTypeEncoding()
 
@@ -116,20 +176,48 @@ func (d *msvcDemangler) demangleFull() DemangleResult {
 	}
 }
 
-func (d *msvcDemangler) parseQualifiedName() string {
+// parseVTableScope parses the storage class, cv-qualifier and optional
+// base class list of a virtual table symbol, returning the list in the
+// form "{for `A's `B'}".
+func (d *msvcDemangler) parseVTableScope() string {
+	d.pos = min(d.pos+2, len(d.input)) // Storage class and cv-qualifier
+
+	var bases []string
+	for d.pos < len(d.input) && d.input[d.pos] != '@' {
+		start := d.pos
+		base := d.parseQualifiedName(false)
+		if d.pos == start {
+			break
+		}
+		bases = append(bases, "`"+base+"'")
+	}
+	if len(bases) == 0 {
+		return ""
+	}
+	return "{for " + strings.Join(bases, "s ") + "}"
+}
+
+// Placeholders for constructor and destructor names, which take the name
+// of the enclosing class once the full qualified name is known.
+const (
+	ctorPlaceholder = "\x00ctor"
+	dtorPlaceholder = "\x00dtor"
+)
+
+// parseQualifiedName parses a fully qualified name up to its terminating
+// '@'. When symbol is true the name is that of a function or data symbol,
+// whose own template instantiation is not entered in the back-reference
+// table (type and namespace names are).
+func (d *msvcDemangler) parseQualifiedName(symbol bool) string {
 	var parts []string
 
 	for d.pos < len(d.input) {
 		c := d.input[d.pos]
 
-		// '@' terminates a name segment, '@@' terminates the qualified name
+		// A lone '@' terminates the qualified name
 		if c == '@' {
 			d.pos++
-			if d.pos < len(d.input) && d.input[d.pos] == '@' {
-				d.pos++
-				break
-			}
-			continue
+			break
 		}
 
 		// Back-reference (0-9)
@@ -142,6 +230,37 @@ func (d *msvcDemangler) parseQualifiedName() string {
 			continue
 		}
 
+		// Template instantiation name
+		if c == '?' && d.pos+1 < len(d.input) && d.input[d.pos+1] == '$' {
+			d.pos += 2
+			name := d.parseTemplateName()
+			if !symbol || len(parts) > 0 {
+				d.memorizeName(name)
+			}
+			parts = append(parts, name)
+			continue
+		}
+
+		// Scopes after the first part: anonymous namespaces and the
+		// numbered scopes of function-local names
+		if c == '?' && len(parts) > 0 {
+			if strings.HasPrefix(d.input[d.pos:], "?A") {
+				d.pos += 2
+				d.parseName() // Unique suffix, e.g. "0x1234abcd"
+				if d.pos < len(d.input) && d.input[d.pos] == '@' {
+					d.pos++
+				}
+				name := "`anonymous namespace'"
+				d.memorizeName(name)
+				parts = append(parts, name)
+				continue
+			}
+			if d.isLocalScope() {
+				parts = append(parts, d.parseLocalScope())
+				continue
+			}
+		}
+
 		// Special names
 		if c == '?' {
 			d.pos++
@@ -152,10 +271,13 @@ func (d *msvcDemangler) parseQualifiedName() string {
 			continue
 		}
 
-		// Regular name segment
+		// Regular name segment, terminated by '@'
 		name := d.parseName()
+		if d.pos < len(d.input) && d.input[d.pos] == '@' {
+			d.pos++
+		}
 		if name != "" {
-			d.names = append(d.names, name)
+			d.memorizeName(name)
 			parts = append(parts, name)
 		}
 	}
@@ -165,9 +287,184 @@ func (d *msvcDemangler) parseQualifiedName() string {
 		parts[i], parts[j] = parts[j], parts[i]
 	}
 
+	// Constructors and destructors are named after their class
+	if n := len(parts); n > 0 && (parts[n-1] == ctorPlaceholder || parts[n-1] == dtorPlaceholder) {
+		class := ""
+		if n > 1 {
+			class = parts[n-2]
+			if idx := strings.IndexByte(class, '<'); idx > 0 {
+				class = class[:idx]
+			}
+		}
+		if parts[n-1] == dtorPlaceholder {
+			class = "~" + class
+		}
+		parts[n-1] = class
+	}
+
 	return strings.Join(parts, "::")
 }
 
+// isLocalScope reports whether the input continues with a function-local
+// scope: '?', an encoded number, then '?' and the enclosing function's
+// mangled name.
+func (d *msvcDemangler) isLocalScope() bool {
+	rest := d.input[d.pos:]
+	if len(rest) < 3 || rest[0] != '?' {
+		return false
+	}
+	rest = strings.TrimPrefix(rest[1:], "?") // Negative number
+	if rest[0] >= '0' && rest[0] <= '9' {
+		return len(rest) > 1 && rest[1] == '?'
+	}
+	end := strings.IndexByte(rest, '@')
+	if end <= 0 || end+1 >= len(rest) || rest[end+1] != '?' {
+		return false
+	}
+	for i := 0; i < end; i++ {
+		if rest[i] < 'A' || rest[i] > 'P' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLocalScope parses a function-local scope, rendering it as the
+// enclosing function followed by the scope number, e.g. "`foo'::`2'".
+func (d *msvcDemangler) parseLocalScope() string {
+	d.pos++ // '?'
+	num := d.parseEncodedNumber()
+	if d.pos < len(d.input) && d.input[d.pos] == '?' {
+		d.pos++ // Terminates the number
+	}
+	function := d.parseSymbolReference()
+	return "`" + function + "'::`" + num + "'"
+}
+
+// memorizeName records a name in the back-reference table, which holds at
+// most ten entries.
+func (d *msvcDemangler) memorizeName(name string) {
+	if len(d.names) < 10 {
+		d.names = append(d.names, name)
+	}
+}
+
+// parseTemplateName parses a template instantiation name following the
+// "?$" introducer: the template name, then its argument list up to '@'.
+// Template arguments use their own back-reference tables.
+func (d *msvcDemangler) parseTemplateName() string {
+	outerNames, outerTypes := d.names, d.typeRefs
+	d.names, d.typeRefs = make([]string, 0), nil
+	defer func() { d.names, d.typeRefs = outerNames, outerTypes }()
+
+	name := d.parseName()
+	if d.pos < len(d.input) && d.input[d.pos] == '@' {
+		d.pos++
+	}
+	d.memorizeName(name)
+
+	args := d.parseTemplateArgs()
+	return name + "<" + args + ">"
+}
+
+// parseTemplateArgs parses a template argument list terminated by '@'.
+func (d *msvcDemangler) parseTemplateArgs() string {
+	var args []string
+	for d.pos < len(d.input) {
+		if d.input[d.pos] == '@' {
+			d.pos++
+			break
+		}
+
+		var arg string
+		switch {
+		case strings.HasPrefix(d.input[d.pos:], "$$V"), strings.HasPrefix(d.input[d.pos:], "$$Z"):
+			// Empty parameter pack
+			d.pos += 3
+			continue
+		case strings.HasPrefix(d.input[d.pos:], "$$T"):
+			d.pos += 3
+			arg = "nullptr"
+		case strings.HasPrefix(d.input[d.pos:], "$0"):
+			// Integral non-type parameter
+			d.pos += 2
+			arg = d.parseEncodedNumber()
+		case strings.HasPrefix(d.input[d.pos:], "$1"):
+			// Pointer to a symbol
+			d.pos += 2
+			arg = "&" + d.parseSymbolReference()
+		default:
+			start := d.pos
+			arg = d.parseType()
+			if d.pos == start {
+				// Unknown encoding; give up on the rest of the list
+				return strings.Join(args, ", ")
+			}
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, ", ")
+}
+
+// parseEncodedNumber parses an MSVC encoded integer: an optional '?' for
+// negative values, then either a single digit (value+1) or hex digits
+// 'A'-'P' terminated by '@'.
+func (d *msvcDemangler) parseEncodedNumber() string {
+	negative := false
+	if d.pos < len(d.input) && d.input[d.pos] == '?' {
+		negative = true
+		d.pos++
+	}
+	if d.pos >= len(d.input) {
+		return ""
+	}
+
+	var value uint64
+	c := d.input[d.pos]
+	if c >= '0' && c <= '9' {
+		d.pos++
+		value = uint64(c-'0') + 1
+	} else {
+		for d.pos < len(d.input) && d.input[d.pos] != '@' {
+			c := d.input[d.pos]
+			if c < 'A' || c > 'P' {
+				break
+			}
+			value = value<<4 | uint64(c-'A')
+			d.pos++
+		}
+		if d.pos < len(d.input) && d.input[d.pos] == '@' {
+			d.pos++
+		}
+	}
+
+	if negative {
+		return "-" + strconv.FormatUint(value, 10)
+	}
+	return strconv.FormatUint(value, 10)
+}
+
+// parseSymbolReference parses a nested mangled symbol (as used by pointer
+// template arguments) and returns its qualified name.
+func (d *msvcDemangler) parseSymbolReference() string {
+	if d.pos >= len(d.input) || d.input[d.pos] != '?' {
+		return ""
+	}
+	d.pos++
+	name := d.parseQualifiedName(true)
+	if d.pos < len(d.input) && d.input[d.pos] >= '0' && d.input[d.pos] <= '4' {
+		// Data symbol: skip the storage class and type
+		d.pos++
+		d.parseType()
+		if d.pos < len(d.input) {
+			d.pos++ // cv-qualifier
+		}
+	} else {
+		d.parseTypeEncoding()
+	}
+	return name
+}
+
 func (d *msvcDemangler) parseName() string {
 	start := d.pos
 	for d.pos < len(d.input) {
@@ -190,9 +487,9 @@ func (d *msvcDemangler) parseSpecialName() string {
 
 	switch c {
 	case '0':
-		return d.parseName() // Constructor
+		return ctorPlaceholder
 	case '1':
-		return "~" + d.parseName() // Destructor
+		return dtorPlaceholder
 	case '2':
 		return "operator new"
 	case '3':
@@ -262,34 +559,142 @@ func (d *msvcDemangler) parseSpecialName() string {
 	case 'Z':
 		return "operator-="
 	case '_':
-		if d.pos < len(d.input) {
-			c2 := d.input[d.pos]
+		return d.parseExtendedSpecialName()
+	}
+
+	return ""
+}
+
+// Special names introduced by "?_", other than those needing extra parsing
+var extendedSpecialNames = map[byte]string{
+	'0': "operator/=",
+	'1': "operator%=",
+	'2': "operator>>=",
+	'3': "operator<<=",
+	'4': "operator&=",
+	'5': "operator|=",
+	'6': "operator^=",
+	'7': "`vftable'",
+	'8': "`vbtable'",
+	'9': "`vcall'",
+	'A': "`typeof'",
+	'B': "`local static guard'",
+	'D': "`vbase destructor'",
+	'E': "`vector deleting destructor'",
+	'F': "`default constructor closure'",
+	'G': "`scalar deleting destructor'",
+	'H': "`vector constructor iterator'",
+	'I': "`vector destructor iterator'",
+	'J': "`vector vbase constructor iterator'",
+	'K': "`virtual displacement map'",
+	'L': "`eh vector constructor iterator'",
+	'M': "`eh vector destructor iterator'",
+	'N': "`eh vector vbase constructor iterator'",
+	'O': "`copy constructor closure'",
+	'S': "`local vftable'",
+	'T': "`local vftable constructor closure'",
+	'U': "operator new[]",
+	'V': "operator delete[]",
+	'X': "`placement delete closure'",
+	'Y': "`placement delete[] closure'",
+}
+
+// Special names introduced by "?__"
+var doubleSpecialNames = map[byte]string{
+	'A': "`managed vector constructor iterator'",
+	'B': "`managed vector destructor iterator'",
+	'C': "`eh vector copy constructor iterator'",
+	'D': "`eh vector vbase copy constructor iterator'",
+	'G': "`vector copy constructor iterator'",
+	'H': "`vector vbase copy constructor iterator'",
+	'I': "`managed vector copy constructor iterator'",
+	'J': "`local static thread guard'",
+	'L': "operator co_await",
+	'M': "operator<=>",
+}
+
+// parseExtendedSpecialName parses a special name following "?_": compound
+// assignment operators, compiler-generated functions and tables, RTTI
+// descriptors, string literals and, after "?__", dynamic initializers.
+func (d *msvcDemangler) parseExtendedSpecialName() string {
+	if d.pos >= len(d.input) {
+		return ""
+	}
+	c := d.input[d.pos]
+	d.pos++
+
+	switch c {
+	case 'C':
+		// String literal: the encoded contents are not rendered
+		d.pos = len(d.input)
+		return "`string'"
+	case 'R':
+		return d.parseRTTIName()
+	case '_':
+		return d.parseDoubleSpecialName()
+	}
+	return extendedSpecialNames[c]
+}
+
+// parseDoubleSpecialName parses a special name following "?__".
+func (d *msvcDemangler) parseDoubleSpecialName() string {
+	if d.pos >= len(d.input) {
+		return ""
+	}
+	c := d.input[d.pos]
+	d.pos++
+
+	switch c {
+	case 'E', 'F':
+		// The variable is a plain name or a nested mangled symbol,
+		// terminated by '@'
+		var target string
+		if d.pos < len(d.input) && d.input[d.pos] == '?' {
+			target = d.parseSymbolReference()
+		} else {
+			target = d.parseName()
+		}
+		if d.pos < len(d.input) && d.input[d.pos] == '@' {
 			d.pos++
-			switch c2 {
-			case '0':
-				return "operator/="
-			case '1':
-				return "operator%="
-			case '2':
-				return "operator>>="
-			case '3':
-				return "operator<<="
-			case '4':
-				return "operator&="
-			case '5':
-				return "operator|="
-			case '6':
-				return "operator^="
-			case 'E':
-				return "dynamic initializer"
-			case 'F':
-				return "dynamic atexit destructor"
-			case 'K':
-				return "operator \"\" " + d.parseName()
-			}
 		}
+		if c == 'E' {
+			return "`dynamic initializer for '" + target + "''"
+		}
+		return "`dynamic atexit destructor for '" + target + "''"
+	case 'K':
+		return "operator \"\" " + d.parseName()
 	}
+	return doubleSpecialNames[c]
+}
 
+// parseRTTIName parses an RTTI descriptor name following "?_R".
+func (d *msvcDemangler) parseRTTIName() string {
+	if d.pos >= len(d.input) {
+		return ""
+	}
+	c := d.input[d.pos]
+	d.pos++
+
+	switch c {
+	case '0':
+		// Followed by the described type, with a "?A" cv prefix
+		if strings.HasPrefix(d.input[d.pos:], "?A") {
+			d.pos += 2
+		}
+		return d.parseType() + " `RTTI Type Descriptor'"
+	case '1':
+		var nums [4]string
+		for i := range nums {
+			nums[i] = d.parseEncodedNumber()
+		}
+		return "`RTTI Base Class Descriptor at (" + strings.Join(nums[:], ",") + ")'"
+	case '2':
+		return "`RTTI Base Class Array'"
+	case '3':
+		return "`RTTI Class Hierarchy Descriptor'"
+	case '4':
+		return "`RTTI Complete Object Locator'"
+	}
 	return ""
 }
 
@@ -300,23 +705,18 @@ func (d *msvcDemangler) parseTypeEncoding() string {
 
 	c := d.input[d.pos]
 
-	// Function encoding
-	switch c {
-	case 'Y': // C function (extern "C")
-		d.pos++
-		return d.parseFunctionType("")
-	case 'Q', 'R', 'S', 'T': // Member functions
+	switch {
+	case c == 'Y' || c == 'Z': // Global function
 		d.pos++
-		access := d.parseAccessModifier(c)
-		return d.parseFunctionType(access)
-	case 'A', 'B', 'C', 'D': // Member functions with different CV
+		return d.parseFunctionType("", false)
+	case c >= 'A' && c <= 'X': // Member function
 		d.pos++
-		access := d.parseAccessModifier(c)
-		return d.parseFunctionType(access)
-	case '3': // Static member data
-		d.pos++
-		return ""
-	case '0', '1', '2': // Member data
+		access, member, thunk := d.parseAccessModifier(c)
+		if thunk {
+			d.parseEncodedNumber() // this adjustment
+		}
+		return d.parseFunctionType(access, member)
+	case c >= '0' && c <= '4': // Static member, global or local static data
 		d.pos++
 		return ""
 	}
@@ -324,56 +724,103 @@ func (d *msvcDemangler) parseTypeEncoding() string {
 	return ""
 }
 
-func (d *msvcDemangler) parseAccessModifier(c byte) string {
+// parseAccessModifier decodes the function class of a member function:
+// letters A-H are private, I-P protected and Q-X public, and within each
+// group pairs select a plain, static, virtual or adjustor thunk function.
+// member reports whether the function takes a this pointer, whose
+// qualifiers follow; thunk whether an adjustment precedes them.
+func (d *msvcDemangler) parseAccessModifier(c byte) (access string, member, thunk bool) {
+	idx := c - 'A'
+	switch idx / 8 {
+	case 0:
+		access = "private:"
+	case 1:
+		access = "protected:"
+	default:
+		access = "public:"
+	}
+
+	switch idx % 8 / 2 {
+	case 0:
+		member = true
+	case 1:
+		access += " static"
+	case 2:
+		access += " virtual"
+		member = true
+	case 3:
+		access += " virtual"
+		member, thunk = true, true
+	}
+
+	if !d.opts.IncludeAccess {
+		access = ""
+	}
+	return access, member, thunk
+}
+
+// parseThisQualifiers parses the qualifiers of a member function's this
+// pointer, returning its cv-qualifier as a suffix for the prototype.
+func (d *msvcDemangler) parseThisQualifiers() string {
+	for d.pos < len(d.input) && strings.IndexByte("EFI", d.input[d.pos]) >= 0 {
+		d.pos++ // __ptr64, __unaligned, __restrict
+	}
+	if d.pos >= len(d.input) {
+		return ""
+	}
+
+	c := d.input[d.pos]
+	d.pos++
 	switch c {
-	case 'A', 'Q':
-		return "private:"
-	case 'B', 'R':
-		return "private: static"
-	case 'C', 'I', 'S':
-		return "protected:"
-	case 'D', 'J', 'T':
-		return "protected: static"
-	case 'E', 'K':
-		return "public:"
-	case 'F', 'L':
-		return "public: static"
-	case 'M':
-		return "private: virtual"
-	case 'N':
-		return "protected: virtual"
-	case 'O':
-		return "public: virtual"
+	case 'A':
+		return ""
+	case 'B':
+		return " const"
+	case 'C':
+		return " volatile"
+	case 'D':
+		return " const volatile"
 	}
+	d.pos--
 	return ""
 }
 
-func (d *msvcDemangler) parseFunctionType(access string) string {
+func (d *msvcDemangler) parseFunctionType(access string, member bool) string {
 	if d.pos >= len(d.input) {
 		return access
 	}
 
+	var thisCV string
+	if member {
+		thisCV = d.parseThisQualifiers()
+	}
+
 	// Parse calling convention
 	callingConv := d.parseCallingConvention()
 
-	// Parse return type
+	// Parse return type; constructors and destructors have none ('@')
 	returnType := d.parseType()
 
-	// Parse arguments
+	// Parse arguments, then the throw specification ('Z' when absent)
 	args := d.parseArguments()
+	if d.pos < len(d.input) && d.input[d.pos] == 'Z' {
+		d.pos++
+	}
 
-	result := ""
-	if returnType != "" {
-		result = returnType
+	var parts []string
+	if access != "" {
+		parts = append(parts, access)
 	}
-	if callingConv != "" {
-		if result != "" {
-			result += " "
-		}
-		result += callingConv
+	if returnType != "" && d.opts.IncludeReturnType {
+		parts = append(parts, returnType)
+	}
+	if callingConv != "" && d.opts.IncludeCallingConv {
+		parts = append(parts, callingConv)
 	}
+
+	result := strings.Join(parts, " ")
 	if args != "" {
-		result += "(" + args + ")"
+		result += "(" + args + ")" + thisCV
 	}
 
 	return result
@@ -479,21 +926,26 @@ func (d *msvcDemangler) parseType() string {
 		}
 	// Pointer types
 	case 'P':
-		inner := d.parseType()
-		return inner + "*"
+		return d.parsePointer("*", "")
 	case 'Q':
-		inner := d.parseType()
-		return inner + "* const"
+		return d.parsePointer("*", " const")
+	case 'R':
+		return d.parsePointer("*", " volatile")
+	case 'S':
+		return d.parsePointer("*", " const volatile")
 	case 'A':
-		inner := d.parseType()
-		return inner + "&"
+		return d.parsePointer("&", "")
 	case 'B':
-		inner := d.parseType()
-		return "volatile " + inner
+		return d.parsePointer("&", " volatile")
 	// User-defined types
 	case 'U', 'V', 'T':
-		// Skip until @@ for class/struct/union name
-		return d.parseClassName()
+		return d.parseQualifiedName(false)
+	case 'W':
+		// Enum; the next character gives the underlying type
+		if d.pos < len(d.input) {
+			d.pos++
+		}
+		return d.parseQualifiedName(false)
 	case '@':
 		return "" // End of type
 	case 'Z':
@@ -504,41 +956,89 @@ func (d *msvcDemangler) parseType() string {
 	return ""
 }
 
-func (d *msvcDemangler) parseClassName() string {
-	start := d.pos
-	depth := 0
-	for d.pos < len(d.input) {
-		c := d.input[d.pos]
-		if c == '@' {
-			if d.pos+1 < len(d.input) && d.input[d.pos+1] == '@' {
-				d.pos += 2
-				break
-			}
-			depth++
-		}
+// parsePointer parses the pointee of a pointer or reference type whose
+// kind character has been consumed: optional __ptr64/__unaligned/__restrict
+// modifiers, the pointee's cv-qualifier, then the pointee type.
+func (d *msvcDemangler) parsePointer(op, cv string) string {
+	for d.pos < len(d.input) && strings.IndexByte("EFI", d.input[d.pos]) >= 0 {
 		d.pos++
 	}
-	name := d.input[start : d.pos-2]
-	// Reverse namespace order
-	parts := strings.Split(name, "@")
-	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
-		parts[i], parts[j] = parts[j], parts[i]
+	if d.pos >= len(d.input) {
+		return ""
 	}
-	return strings.Join(parts, "::")
+
+	if d.input[d.pos] == '6' {
+		// Pointer to function
+		d.pos++
+		callingConv := d.parseCallingConvention()
+		returnType := d.parseType()
+		args := d.parseArguments()
+		if d.pos < len(d.input) && d.input[d.pos] == 'Z' {
+			d.pos++ // Throw specification
+		}
+		return returnType + " (" + callingConv + " " + op + ")(" + args + ")"
+	}
+
+	var pointeeCV string
+	switch d.input[d.pos] {
+	case 'A':
+	case 'B':
+		pointeeCV = "const "
+	case 'C':
+		pointeeCV = "volatile "
+	case 'D':
+		pointeeCV = "const volatile "
+	default:
+		return ""
+	}
+	d.pos++
+
+	pointee := d.parseType()
+	if pointee == "" {
+		return ""
+	}
+	return pointeeCV + pointee + op + cv
 }
 
+// parseArguments parses a function's argument list: 'X' for void, or
+// argument types terminated by '@', or by 'Z' for a variadic function.
 func (d *msvcDemangler) parseArguments() string {
+	if d.pos < len(d.input) && d.input[d.pos] == 'X' {
+		d.pos++
+		return "void"
+	}
+
 	var args []string
 	for d.pos < len(d.input) {
 		c := d.input[d.pos]
-		if c == '@' || c == 'Z' {
+		if c == '@' {
+			d.pos++
+			break
+		}
+		if c == 'Z' {
 			d.pos++
+			args = append(args, "...")
 			break
 		}
+		if c >= '0' && c <= '9' {
+			// Back-reference to an earlier argument type
+			d.pos++
+			idx := int(c - '0')
+			if idx >= len(d.typeRefs) {
+				break
+			}
+			args = append(args, d.typeRefs[idx])
+			continue
+		}
+		start := d.pos
 		arg := d.parseType()
 		if arg == "" {
 			break
 		}
+		// Only types with multi-character encodings are memorized
+		if d.pos-start > 1 && len(d.typeRefs) < 10 {
+			d.typeRefs = append(d.typeRefs, arg)
+		}
 		args = append(args, arg)
 		if len(args) > 20 { // Safety limit
 			break
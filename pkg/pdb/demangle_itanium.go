@@ -0,0 +1,759 @@
+package pdb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// itaniumDemangler parses Itanium C++ ABI mangled names (_Z...), as
+// emitted by clang and GCC. It covers nested and template names,
+// substitutions, constructors, destructors, operators, builtin and
+// compound types, and the vtable/typeinfo special names. Anything else
+// marks the parse as failed and the caller keeps the mangled name.
+type itaniumDemangler struct {
+	input  string
+	pos    int
+	opts   Demangler
+	subs   []itaniumType // Substitution candidates
+	tmpl   []string      // Arguments of the most recent template argument list
+	failed bool
+}
+
+// itaniumType is a demangled type. Function types are kept in parts so
+// that pointer and reference declarators can be placed inside them.
+type itaniumType struct {
+	name   string // Rendered type, or the return type of a function type
+	params string // Function types only: parameter list
+	decl   string // Function types only: declarators, e.g. "*" or "C::*"
+	fn     bool
+
+	qualifiers string // Function types only: cv-qualifiers of the function
+}
+
+// String renders the type.
+func (t itaniumType) String() string {
+	if !t.fn {
+		return t.name
+	}
+	if t.decl == "" {
+		return t.name + " (" + t.params + ")" + t.qualifiers
+	}
+	return t.name + " (" + t.decl + ")(" + t.params + ")" + t.qualifiers
+}
+
+// itaniumName is a demangled name together with what the signature that
+// follows it depends on.
+type itaniumName struct {
+	name       string
+	isTemplate bool   // Ends in template arguments; a return type follows
+	noReturn   bool   // Constructor, destructor or conversion operator
+	qualifiers string // Member function cv- and ref-qualifiers
+}
+
+// demangleItanium demangles an Itanium mangled name, or returns it
+// unchanged if it is not fully understood.
+func demangleItanium(name string, opts Demangler) DemangleResult {
+	d := &itaniumDemangler{input: name, pos: 2, opts: opts}
+
+	result := d.parseEncoding()
+
+	// Clone suffixes such as ".cold" or ".constprop.0" are not rendered
+	if d.pos < len(d.input) && d.input[d.pos] == '.' {
+		d.pos = len(d.input)
+	}
+	if d.failed || d.pos != len(d.input) || result.Name == "" {
+		return DemangleResult{Name: name}
+	}
+	return result
+}
+
+func (d *itaniumDemangler) peek() byte {
+	if d.pos >= len(d.input) {
+		return 0
+	}
+	return d.input[d.pos]
+}
+
+func (d *itaniumDemangler) consume(prefix string) bool {
+	if strings.HasPrefix(d.input[d.pos:], prefix) {
+		d.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (d *itaniumDemangler) fail() {
+	d.failed = true
+	d.pos = len(d.input)
+}
+
+// parseEncoding parses a function or data name and, for functions, the
+// parameter types that follow it.
+func (d *itaniumDemangler) parseEncoding() DemangleResult {
+	if special, ok := d.parseSpecialName(); ok {
+		return DemangleResult{Name: special}
+	}
+
+	name := d.parseName()
+	if d.failed {
+		return DemangleResult{}
+	}
+	if c := d.peek(); c == 0 || c == 'E' || c == '.' {
+		return DemangleResult{Name: name.name} // Data
+	}
+
+	var returnType string
+	if name.isTemplate && !name.noReturn {
+		returnType = d.parseType().String()
+	}
+	params := d.parseParams()
+
+	var parts []string
+	if returnType != "" && d.opts.IncludeReturnType {
+		parts = append(parts, returnType)
+	}
+	prototype := strings.Join(parts, " ") + "(" + params + ")" + name.qualifiers
+
+	return DemangleResult{Name: name.name, Prototype: prototype}
+}
+
+// parseParams parses a list of parameter types up to the end of the
+// enclosing encoding. A lone "v" is an empty list.
+func (d *itaniumDemangler) parseParams() string {
+	if d.consume("v") {
+		if c := d.peek(); c == 0 || c == 'E' || c == '.' {
+			return "void"
+		}
+		d.fail()
+		return ""
+	}
+
+	var params []string
+	for !d.failed {
+		if c := d.peek(); c == 0 || c == 'E' || c == '.' {
+			break
+		}
+		params = append(params, d.parseType().String())
+	}
+	return strings.Join(params, ", ")
+}
+
+// parseSpecialName parses the virtual table, typeinfo, thunk and guard
+// variable names.
+func (d *itaniumDemangler) parseSpecialName() (string, bool) {
+	switch {
+	case d.consume("TV"):
+		return "vtable for " + d.parseType().String(), true
+	case d.consume("TT"):
+		return "VTT for " + d.parseType().String(), true
+	case d.consume("TI"):
+		return "typeinfo for " + d.parseType().String(), true
+	case d.consume("TS"):
+		return "typeinfo name for " + d.parseType().String(), true
+	case d.consume("Th"):
+		d.parseNumber()
+		if !d.consume("_") {
+			d.fail()
+		}
+		return "non-virtual thunk to " + d.parseEncoding().Name, true
+	case d.consume("Tv"):
+		d.parseNumber()
+		if !d.consume("_") {
+			d.fail()
+		}
+		d.parseNumber()
+		if !d.consume("_") {
+			d.fail()
+		}
+		return "virtual thunk to " + d.parseEncoding().Name, true
+	case d.consume("GV"):
+		return "guard variable for " + d.parseName().name, true
+	}
+	return "", false
+}
+
+// parseName parses a nested, local, unscoped or substituted name.
+func (d *itaniumDemangler) parseName() itaniumName {
+	switch c := d.peek(); {
+	case c == 'N':
+		d.pos++
+		return d.parseNestedName()
+
+	case c == 'Z':
+		// Local name: the enclosing function, then the entity
+		d.pos++
+		function := d.parseEncoding()
+		if !d.consume("E") {
+			d.fail()
+			return itaniumName{}
+		}
+		if d.consume("s") {
+			d.parseDiscriminator()
+			return itaniumName{name: function.Name + "::string literal"}
+		}
+		entity := d.parseName()
+		d.parseDiscriminator()
+		entity.name = function.Name + "::" + entity.name
+		return entity
+
+	case c == 'S' && !strings.HasPrefix(d.input[d.pos:], "St"):
+		// Substituted template name
+		sub := d.parseSubstitution()
+		if d.peek() != 'I' {
+			d.fail()
+			return itaniumName{}
+		}
+		args := d.parseTemplateArgs()
+		d.subs = append(d.subs, itaniumType{name: sub.name + args})
+		return itaniumName{name: sub.name + args, isTemplate: true}
+	}
+
+	name := ""
+	if d.consume("St") {
+		name = "std::"
+	}
+	unqualified, _ := d.parseUnqualifiedName("")
+	name += unqualified
+	if d.peek() == 'I' {
+		d.subs = append(d.subs, itaniumType{name: name})
+		name += d.parseTemplateArgs()
+		return itaniumName{name: name, isTemplate: true}
+	}
+	return itaniumName{name: name}
+}
+
+// parseDiscriminator skips the discriminator of a local name.
+func (d *itaniumDemangler) parseDiscriminator() {
+	if d.consume("__") {
+		d.parseNumber()
+		d.consume("_")
+	} else if d.consume("_") {
+		d.parseNumber()
+	}
+}
+
+// parseNestedName parses a nested name after its 'N', up to and including
+// the closing 'E'. Each prefix becomes a substitution candidate, except
+// the complete name.
+func (d *itaniumDemangler) parseNestedName() itaniumName {
+	var result itaniumName
+	for {
+		switch {
+		case d.consume("r"):
+			result.qualifiers += " restrict"
+			continue
+		case d.consume("V"):
+			result.qualifiers += " volatile"
+			continue
+		case d.consume("K"):
+			result.qualifiers = " const" + result.qualifiers
+			continue
+		}
+		break
+	}
+	if d.consume("R") {
+		result.qualifiers += " &"
+	} else if d.consume("O") {
+		result.qualifiers += " &&"
+	}
+
+	prefix := "" // Name so far
+	last := ""   // Last unqualified component, naming constructors
+	pushed := 0  // Candidates added by this name
+	for !d.failed {
+		if d.consume("E") {
+			break
+		}
+		if d.pos >= len(d.input) {
+			d.fail()
+			break
+		}
+
+		result.isTemplate = false
+		result.noReturn = false
+		switch c := d.peek(); {
+		case c == 'S' && strings.HasPrefix(d.input[d.pos:], "St"):
+			d.pos += 2
+			prefix = "std"
+			continue
+		case c == 'S':
+			prefix = d.parseSubstitution().name
+			last = prefix
+			continue
+		case c == 'T':
+			prefix = d.parseTemplateParam()
+			last = prefix
+		case c == 'I':
+			if prefix == "" {
+				d.fail()
+				break
+			}
+			prefix += d.parseTemplateArgs()
+			result.isTemplate = true
+		default:
+			component, special := d.parseUnqualifiedName(last)
+			if component == "" {
+				d.fail()
+				break
+			}
+			result.noReturn = special
+			if !special {
+				last = component
+			}
+			if prefix != "" {
+				prefix += "::"
+			}
+			prefix += component
+		}
+		d.subs = append(d.subs, itaniumType{name: prefix})
+		pushed++
+	}
+
+	if pushed > 0 {
+		d.subs = d.subs[:len(d.subs)-1]
+	}
+	result.name = prefix
+	return result
+}
+
+// parseUnqualifiedName parses a source name, operator name, or (given the
+// enclosing class name) constructor or destructor name. special reports a
+// name without a return type: constructor, destructor or conversion.
+func (d *itaniumDemangler) parseUnqualifiedName(class string) (name string, special bool) {
+	c := d.peek()
+	switch {
+	case c >= '0' && c <= '9':
+		name = d.parseSourceName()
+	case c == 'C' && d.pos+1 < len(d.input) && d.input[d.pos+1] >= '1' && d.input[d.pos+1] <= '5':
+		d.pos += 2
+		name, special = baseName(class), true
+	case c == 'D' && d.pos+1 < len(d.input) && d.input[d.pos+1] >= '0' && d.input[d.pos+1] <= '5':
+		d.pos += 2
+		name, special = "~"+baseName(class), true
+	case c == 'U' && strings.HasPrefix(d.input[d.pos:], "Ut"):
+		d.pos += 2
+		n := d.parseNumber()
+		d.consume("_")
+		name = "{unnamed type#" + strconv.Itoa(n+2) + "}"
+	case c >= 'a' && c <= 'z':
+		name, special = d.parseOperatorName()
+	default:
+		d.fail()
+		return "", false
+	}
+
+	// ABI tags, e.g. B5cxx11
+	for d.peek() == 'B' {
+		d.pos++
+		name += "[abi:" + d.parseSourceName() + "]"
+	}
+	return name, special
+}
+
+// baseName strips the template arguments and scope from a class name.
+func baseName(class string) string {
+	if idx := strings.IndexByte(class, '<'); idx > 0 {
+		class = class[:idx]
+	}
+	if idx := strings.LastIndex(class, "::"); idx >= 0 {
+		class = class[idx+2:]
+	}
+	return class
+}
+
+// parseSourceName parses a length-prefixed identifier.
+func (d *itaniumDemangler) parseSourceName() string {
+	n := d.parseNumber()
+	if n <= 0 || d.pos+n > len(d.input) {
+		d.fail()
+		return ""
+	}
+	name := d.input[d.pos : d.pos+n]
+	d.pos += n
+
+	if strings.HasPrefix(name, "_GLOBAL__N") {
+		return "(anonymous namespace)"
+	}
+	return name
+}
+
+// parseNumber parses a non-negative decimal number.
+func (d *itaniumDemangler) parseNumber() int {
+	start := d.pos
+	for d.pos < len(d.input) && d.input[d.pos] >= '0' && d.input[d.pos] <= '9' {
+		d.pos++
+	}
+	n, err := strconv.Atoi(d.input[start:d.pos])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Operator names by their two-letter encodings
+var itaniumOperators = map[string]string{
+	"nw": "operator new", "na": "operator new[]", "dl": "operator delete",
+	"da": "operator delete[]", "ps": "operator+", "ng": "operator-",
+	"ad": "operator&", "de": "operator*", "co": "operator~", "pl": "operator+",
+	"mi": "operator-", "ml": "operator*", "dv": "operator/", "rm": "operator%",
+	"an": "operator&", "or": "operator|", "eo": "operator^", "aS": "operator=",
+	"pL": "operator+=", "mI": "operator-=", "mL": "operator*=", "dV": "operator/=",
+	"rM": "operator%=", "aN": "operator&=", "oR": "operator|=", "eO": "operator^=",
+	"ls": "operator<<", "rs": "operator>>", "lS": "operator<<=", "rS": "operator>>=",
+	"eq": "operator==", "ne": "operator!=", "lt": "operator<", "gt": "operator>",
+	"le": "operator<=", "ge": "operator>=", "ss": "operator<=>", "nt": "operator!",
+	"aa": "operator&&", "oo": "operator||", "pp": "operator++", "mm": "operator--",
+	"cm": "operator,", "pm": "operator->*", "pt": "operator->", "cl": "operator()",
+	"ix": "operator[]", "aw": "operator co_await",
+}
+
+// parseOperatorName parses an operator name. Conversion operators report
+// special, as they have no encoded return type.
+func (d *itaniumDemangler) parseOperatorName() (string, bool) {
+	if d.pos+2 > len(d.input) {
+		d.fail()
+		return "", false
+	}
+	code := d.input[d.pos : d.pos+2]
+	d.pos += 2
+
+	switch code {
+	case "cv":
+		return "operator " + d.parseType().String(), true
+	case "li":
+		return "operator\"\" " + d.parseSourceName(), false
+	}
+	if name, ok := itaniumOperators[code]; ok {
+		return name, false
+	}
+	d.fail()
+	return "", false
+}
+
+// parseSubstitution parses a back-reference to an earlier component or
+// type, or one of the abbreviations for std names.
+func (d *itaniumDemangler) parseSubstitution() itaniumType {
+	if !d.consume("S") {
+		d.fail()
+		return itaniumType{}
+	}
+
+	switch d.peek() {
+	case 'a':
+		d.pos++
+		return itaniumType{name: "std::allocator"}
+	case 'b':
+		d.pos++
+		return itaniumType{name: "std::basic_string"}
+	case 's':
+		d.pos++
+		return itaniumType{name: "std::string"}
+	case 'i':
+		d.pos++
+		return itaniumType{name: "std::istream"}
+	case 'o':
+		d.pos++
+		return itaniumType{name: "std::ostream"}
+	case 'd':
+		d.pos++
+		return itaniumType{name: "std::iostream"}
+	}
+
+	// S_ is the first candidate, S<base-36 n>_ the (n+2)th
+	idx := 0
+	if !d.consume("_") {
+		n := 0
+		for {
+			c := d.peek()
+			switch {
+			case c >= '0' && c <= '9':
+				n = n*36 + int(c-'0')
+			case c >= 'A' && c <= 'Z':
+				n = n*36 + int(c-'A') + 10
+			case c == '_':
+				idx = n + 1
+			default:
+				d.fail()
+				return itaniumType{}
+			}
+			d.pos++
+			if c == '_' {
+				break
+			}
+		}
+	}
+
+	if idx >= len(d.subs) {
+		d.fail()
+		return itaniumType{}
+	}
+	return d.subs[idx]
+}
+
+// parseTemplateParam parses a reference to a template argument (T_, T<n>_).
+func (d *itaniumDemangler) parseTemplateParam() string {
+	if !d.consume("T") {
+		d.fail()
+		return ""
+	}
+	idx := 0
+	if !d.consume("_") {
+		idx = d.parseNumber() + 1
+		if !d.consume("_") {
+			d.fail()
+			return ""
+		}
+	}
+	if idx >= len(d.tmpl) {
+		d.fail()
+		return ""
+	}
+	return d.tmpl[idx]
+}
+
+// parseTemplateArgs parses a template argument list (I...E) and returns it
+// rendered as "<...>".
+func (d *itaniumDemangler) parseTemplateArgs() string {
+	if !d.consume("I") {
+		d.fail()
+		return ""
+	}
+
+	var args []string
+	for !d.failed && !d.consume("E") {
+		if d.pos >= len(d.input) {
+			d.fail()
+			break
+		}
+		args = append(args, d.parseTemplateArg()...)
+	}
+	d.tmpl = args
+
+	rendered := strings.Join(args, ", ")
+	if strings.HasSuffix(rendered, ">") {
+		rendered += " "
+	}
+	return "<" + rendered + ">"
+}
+
+// parseTemplateArg parses a template argument: a type, a literal or an
+// argument pack.
+func (d *itaniumDemangler) parseTemplateArg() []string {
+	switch d.peek() {
+	case 'L':
+		return []string{d.parseLiteral()}
+	case 'J':
+		d.pos++
+		var pack []string
+		for !d.failed && !d.consume("E") {
+			if d.pos >= len(d.input) {
+				d.fail()
+				break
+			}
+			pack = append(pack, d.parseTemplateArg()...)
+		}
+		// Kept as one argument so that T_ refers to the whole pack
+		return []string{strings.Join(pack, ", ")}
+	case 'X':
+		d.fail() // Expressions are not supported
+		return nil
+	}
+
+	outer := d.tmpl
+	arg := d.parseType().String()
+	d.tmpl = outer
+	return []string{arg}
+}
+
+// parseLiteral parses an expression literal (L...E): an integer of a
+// builtin type, or the address of an external name.
+func (d *itaniumDemangler) parseLiteral() string {
+	d.consume("L")
+	if d.consume("_Z") {
+		enc := d.parseEncoding()
+		if !d.consume("E") {
+			d.fail()
+		}
+		return "&" + enc.Name
+	}
+
+	typ := d.parseType().String()
+	negative := d.consume("n")
+	value := strconv.Itoa(d.parseNumber())
+	if !d.consume("E") {
+		d.fail()
+		return ""
+	}
+
+	switch {
+	case typ == "bool" && value == "0":
+		return "false"
+	case typ == "bool" && value == "1":
+		return "true"
+	case negative:
+		value = "-" + value
+	}
+	if typ == "int" {
+		return value
+	}
+	return "(" + typ + ")" + value
+}
+
+// Builtin types by their one-letter encodings
+var itaniumBuiltins = map[byte]string{
+	'v': "void", 'w': "wchar_t", 'b': "bool", 'c': "char", 'a': "signed char",
+	'h': "unsigned char", 's': "short", 't': "unsigned short", 'i': "int",
+	'j': "unsigned int", 'l': "long", 'm': "unsigned long", 'x': "long long",
+	'y': "unsigned long long", 'n': "__int128", 'o': "unsigned __int128",
+	'f': "float", 'd': "double", 'e': "long double", 'g': "__float128",
+	'z': "...",
+}
+
+// Builtin types by the letter following 'D'
+var itaniumDBuiltins = map[byte]string{
+	'n': "std::nullptr_t", 's': "char16_t", 'i': "char32_t", 'u': "char8_t",
+	'a': "auto", 'c': "decltype(auto)", 'h': "half", 'f': "decimal32",
+	'd': "decimal64", 'e': "decimal128",
+}
+
+// parseType parses a type. Every type except builtins and plain
+// substitutions becomes a substitution candidate.
+func (d *itaniumDemangler) parseType() itaniumType {
+	c := d.peek()
+	if name, ok := itaniumBuiltins[c]; ok {
+		d.pos++
+		return itaniumType{name: name}
+	}
+
+	var t itaniumType
+	switch c {
+	case 'D':
+		if d.pos+1 < len(d.input) {
+			if name, ok := itaniumDBuiltins[d.input[d.pos+1]]; ok {
+				d.pos += 2
+				return itaniumType{name: name}
+			}
+		}
+		if !d.consume("Dp") {
+			d.fail()
+			return itaniumType{}
+		}
+		t = d.parseType() // Pack expansion, rendered as its elements
+
+	case 'u':
+		d.pos++
+		return itaniumType{name: d.parseSourceName()}
+
+	case 'P', 'R', 'O':
+		d.pos++
+		op := map[byte]string{'P': "*", 'R': "&", 'O': "&&"}[c]
+		t = d.parseType()
+		if t.fn {
+			t.decl = op + t.decl
+		} else {
+			t.name += op
+		}
+
+	case 'K', 'V', 'r':
+		qualifiers := ""
+		for {
+			switch {
+			case d.consume("r"):
+				qualifiers += " restrict"
+				continue
+			case d.consume("V"):
+				qualifiers += " volatile"
+				continue
+			case d.consume("K"):
+				qualifiers = " const" + qualifiers
+				continue
+			}
+			break
+		}
+		t = d.parseType()
+		switch {
+		case t.fn && t.decl != "":
+			t.decl += qualifiers
+		case t.fn:
+			t.qualifiers += qualifiers
+		case strings.HasSuffix(t.name, "*") || strings.HasSuffix(t.name, "&"):
+			t.name += qualifiers
+		default:
+			t.name = strings.TrimPrefix(qualifiers, " ") + " " + t.name
+		}
+
+	case 'F':
+		d.pos++
+		d.consume("Y")
+		ret := d.parseType().String()
+		var params []string
+		for !d.failed && !d.consume("E") {
+			if d.consume("v") && d.peek() == 'E' {
+				continue
+			}
+			if d.consume("RE") || d.consume("OE") {
+				break
+			}
+			if d.pos >= len(d.input) {
+				d.fail()
+				break
+			}
+			params = append(params, d.parseType().String())
+		}
+		t = itaniumType{name: ret, params: strings.Join(params, ", "), fn: true}
+
+	case 'A':
+		d.pos++
+		size := d.parseNumber()
+		if !d.consume("_") {
+			d.fail()
+			return itaniumType{}
+		}
+		t = d.parseType()
+		t.name += "[" + strconv.Itoa(size) + "]"
+
+	case 'M':
+		d.pos++
+		class := d.parseType().String()
+		t = d.parseType()
+		if t.fn {
+			t.decl = class + "::*" + t.decl
+		} else {
+			t.name += " " + class + "::*"
+		}
+
+	case 'T':
+		t = itaniumType{name: d.parseTemplateParam()}
+		if d.peek() == 'I' {
+			d.subs = append(d.subs, t)
+			t.name += d.parseTemplateArgs()
+		}
+
+	case 'S':
+		if !strings.HasPrefix(d.input[d.pos:], "St") {
+			t = d.parseSubstitution()
+			if d.peek() != 'I' {
+				return t
+			}
+			t.name += d.parseTemplateArgs()
+			break
+		}
+		t = itaniumType{name: d.parseName().name}
+
+	case 'N', 'Z':
+		t = itaniumType{name: d.parseName().name}
+
+	default:
+		if c >= '0' && c <= '9' {
+			t = itaniumType{name: d.parseName().name}
+			break
+		}
+		d.fail()
+		return itaniumType{}
+	}
+
+	if !d.failed {
+		d.subs = append(d.subs, t)
+	}
+	return t
+}
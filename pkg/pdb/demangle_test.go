@@ -0,0 +1,87 @@
+package pdb
+
+import "testing"
+
+func TestDemangleMSVC(t *testing.T) {
+	tests := []struct {
+		name      string
+		mangled   string
+		want      string
+		prototype string
+	}{
+		{"method", "?Init@MyClass@@QEAAXXZ", "MyClass::Init", "public: void __cdecl(void)"},
+		{"constructor", "??0MyClass@@QEAA@XZ", "MyClass::MyClass", "public: __cdecl(void)"},
+		{"virtual destructor", "??1MyClass@@UEAA@XZ", "MyClass::~MyClass", "public: virtual __cdecl(void)"},
+		{"vftable", "??_7MyClass@@6B@", "MyClass::`vftable'", ""},
+		{"import thunk", "__imp_?Init@MyClass@@QEAAXXZ", "MyClass::Init [import]", "public: void __cdecl(void)"},
+		{"C decorated", "_func@8", "func", ""},
+
+		// Anonymous namespaces, whatever their hash
+		{"anonymous namespace", "?g@?A0x1234abcd@@3HA", "`anonymous namespace'::g", ""},
+		{"anonymous namespace without hash", "?g@?A@@3HA", "`anonymous namespace'::g", ""},
+		{"nested anonymous namespace", "?f@?A0x1234abcd@ns@@YAXXZ", "ns::`anonymous namespace'::f", "void __cdecl(void)"},
+
+		// Function-local statics name their enclosing function and scope
+		{"local static", "?x@?1??foo@@YAXXZ@4HA", "`foo'::`2'::x", ""},
+		{"local static in nested scope", "?x@?2??foo@@YAXXZ@4HA", "`foo'::`3'::x", ""},
+		{"local static in method", "?counter@?1??next@Gen@@QEAAHXZ@4HA", "`Gen::next'::`2'::counter", ""},
+		{"local static of class type", "?s@?1??get@@YAAEAUS@@XZ@4U2@A", "`get'::`2'::s", ""},
+		{"local static guard", "?$TSS0@?1??get@@YAAEAUS@@XZ@4HA", "`get'::`2'::$TSS0", ""},
+		{"local static in anonymous namespace", "?x@?1??foo@?A0x1@@YAXXZ@4HA", "``anonymous namespace'::foo'::`2'::x", ""},
+
+		// Lambdas are classes local to the function that defines them
+		{"lambda", "??R<lambda_1>@?0??main@@YAHXZ@QEBA@XZ", "`main'::`1'::<lambda_1>::operator()", "public: __cdecl(void) const"},
+		{"lambda in method", "??R<lambda_2b3c>@?0??run@Worker@@QEAAXH@Z@QEBAXH@Z", "`Worker::run'::`1'::<lambda_2b3c>::operator()", "public: void __cdecl(int) const"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DemangleFull(tc.mangled)
+			if got.Name != tc.want || got.Prototype != tc.prototype {
+				t.Errorf("DemangleFull(%q) = %q, %q; want %q, %q", tc.mangled, got.Name, got.Prototype, tc.want, tc.prototype)
+			}
+			if got := Demangle(tc.mangled); got != tc.want {
+				t.Errorf("Demangle(%q) = %q, want %q", tc.mangled, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDemangleItanium(t *testing.T) {
+	tests := []struct {
+		mangled   string
+		want      string
+		prototype string
+	}{
+		{"_Z3fooi", "foo", "(int)"},
+		{"_Z3foov", "foo", "(void)"},
+		{"_ZN3foo3barEv", "foo::bar", "(void)"},
+		{"_ZNK3Foo3getEv", "Foo::get", "(void) const"},
+		{"_ZN3FooC1Ev", "Foo::Foo", "(void)"},
+		{"_ZN3FooC2ERKS_", "Foo::Foo", "(const Foo&)"},
+		{"_ZN3FooD0Ev", "Foo::~Foo", "(void)"},
+		{"_ZN3FooplERKS_", "Foo::operator+", "(const Foo&)"},
+		{"_Z1fPKc", "f", "(const char*)"},
+		{"_Z1fRKSs", "f", "(const std::string&)"},
+		{"_Z1fPFviE", "f", "(void (*)(int))"},
+		{"_Z1fM1AKFvvE", "f", "(void (A::*)() const)"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back", "(const int&)"},
+		{"_ZN9__gnu_cxx13new_allocatorIcE8allocateEmPKv", "__gnu_cxx::new_allocator<char>::allocate", "(unsigned long, const void*)"},
+		{"_Z3maxIiET_S0_S0_", "max<int>", "int(int, int)"},
+		{"_ZZ4mainE1x", "main::x", ""},
+		{"_ZTV3Foo", "vtable for Foo", ""},
+		{"_ZTI3Foo", "typeinfo for Foo", ""},
+		{"_ZTS3Foo", "typeinfo name for Foo", ""},
+		{"_Z3foov.cold", "foo", "(void)"},
+
+		// Names that do not parse are kept as they are
+		{"_Zgarbage", "_Zgarbage", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.mangled, func(t *testing.T) {
+			got := DemangleFull(tc.mangled)
+			if got.Name != tc.want || got.Prototype != tc.prototype {
+				t.Errorf("DemangleFull(%q) = %q, %q; want %q, %q", tc.mangled, got.Name, got.Prototype, tc.want, tc.prototype)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package pdb
+
+// DiffResult lists the functions and types that differ between two PDBs.
+// "Added" entries are only in the second PDB, "removed" ones only in the
+// first.
+type DiffResult struct {
+	AddedFunctions   []Function       `json:"added_functions,omitempty"`
+	RemovedFunctions []Function       `json:"removed_functions,omitempty"`
+	ChangedFunctions []FunctionChange `json:"changed_functions,omitempty"`
+
+	AddedTypes   []TypeInfo   `json:"added_types,omitempty"`
+	RemovedTypes []TypeInfo   `json:"removed_types,omitempty"`
+	ChangedTypes []TypeChange `json:"changed_types,omitempty"`
+}
+
+// FunctionChange is a function present in both PDBs whose signature or
+// length differs.
+type FunctionChange struct {
+	Name         string `json:"name"` // Demangled name, if any, else the raw name
+	OldRVA       uint32 `json:"old_rva"`
+	NewRVA       uint32 `json:"new_rva"`
+	OldSignature string `json:"old_signature"`
+	NewSignature string `json:"new_signature"`
+	OldLength    uint32 `json:"old_length"`
+	NewLength    uint32 `json:"new_length"`
+}
+
+// TypeChange is a named type present in both PDBs whose layout differs.
+type TypeChange struct {
+	Name string   `json:"name"`
+	Old  TypeInfo `json:"old"`
+	New  TypeInfo `json:"new"`
+}
+
+// Empty reports whether the diff found no differences.
+func (d *DiffResult) Empty() bool {
+	return len(d.AddedFunctions) == 0 && len(d.RemovedFunctions) == 0 && len(d.ChangedFunctions) == 0 &&
+		len(d.AddedTypes) == 0 && len(d.RemovedTypes) == 0 && len(d.ChangedTypes) == 0
+}
+
+// Diff compares the functions and named types of a and b. Functions are
+// matched by demangled name (or raw name) and reported as changed when
+// their signature or length differs; a moved RVA alone is not a change, as
+// relinking shifts most code. The RVA only decides which of several
+// same-named functions pair up. Types are matched by name and reported as
+// changed when their kind, size or members differ.
+func Diff(a, b *PDB) *DiffResult {
+	d := &DiffResult{}
+
+	fa, fb := a.Functions(), b.Functions()
+	pairs, removed, added := pairByKey(functionKeys(fa), functionKeys(fb), func(i, j int) bool {
+		return sameFunction(&fa[i], &fb[j]) && fa[i].RVA == fb[j].RVA
+	})
+	for _, i := range removed {
+		d.RemovedFunctions = append(d.RemovedFunctions, fa[i])
+	}
+	for _, j := range added {
+		d.AddedFunctions = append(d.AddedFunctions, fb[j])
+	}
+	for _, pair := range pairs {
+		before, after := &fa[pair[0]], &fb[pair[1]]
+		if sameFunction(before, after) {
+			continue
+		}
+		d.ChangedFunctions = append(d.ChangedFunctions, FunctionChange{
+			Name:         functionKey(before),
+			OldRVA:       before.RVA,
+			NewRVA:       after.RVA,
+			OldSignature: before.Signature,
+			NewSignature: after.Signature,
+			OldLength:    before.Length,
+			NewLength:    after.Length,
+		})
+	}
+
+	ta, tb := diffTypes(a), diffTypes(b)
+	pairs, removed, added = pairByKey(typeKeys(ta), typeKeys(tb), func(i, j int) bool {
+		return sameLayout(&ta[i], &tb[j])
+	})
+	for _, i := range removed {
+		d.RemovedTypes = append(d.RemovedTypes, ta[i])
+	}
+	for _, j := range added {
+		d.AddedTypes = append(d.AddedTypes, tb[j])
+	}
+	for _, pair := range pairs {
+		before, after := ta[pair[0]], tb[pair[1]]
+		if !sameLayout(&before, &after) {
+			d.ChangedTypes = append(d.ChangedTypes, TypeChange{Name: before.Name, Old: before, New: after})
+		}
+	}
+
+	return d
+}
+
+// functionKey is the name functions are matched by.
+func functionKey(fn *Function) string {
+	if fn.DemangledName != "" {
+		return fn.DemangledName
+	}
+	return fn.Name
+}
+
+func functionKeys(functions []Function) []string {
+	keys := make([]string, len(functions))
+	for i := range functions {
+		keys[i] = functionKey(&functions[i])
+	}
+	return keys
+}
+
+// sameFunction reports whether two matched functions are unchanged.
+func sameFunction(a, b *Function) bool {
+	return a.Signature == b.Signature && a.Length == b.Length
+}
+
+// diffTypes returns the named types of p to compare, leaving out forward
+// references to types that are also defined.
+func diffTypes(p *PDB) []TypeInfo {
+	types := p.Types()
+	defined := make(map[string]bool)
+	for _, ti := range types {
+		if !ti.IsForwardRef {
+			defined[ti.Name] = true
+		}
+	}
+
+	result := make([]TypeInfo, 0, len(types))
+	for _, ti := range types {
+		if ti.IsForwardRef && defined[ti.Name] {
+			continue
+		}
+		result = append(result, ti)
+	}
+	return result
+}
+
+func typeKeys(types []TypeInfo) []string {
+	keys := make([]string, len(types))
+	for i := range types {
+		keys[i] = types[i].Name
+	}
+	return keys
+}
+
+// sameLayout reports whether two types have the same kind, size and
+// members. Type indices are ignored, as they differ between builds.
+func sameLayout(a, b *TypeInfo) bool {
+	if a.Kind != b.Kind || a.Size != b.Size || a.UnderlyingType != b.UnderlyingType ||
+		len(a.Members) != len(b.Members) || len(a.Methods) != len(b.Methods) {
+		return false
+	}
+	for i := range a.Members {
+		ma, mb := a.Members[i], b.Members[i]
+		ma.TypeIndex, mb.TypeIndex = 0, 0
+		if ma != mb {
+			return false
+		}
+	}
+	for i := range a.Methods {
+		ma, mb := a.Methods[i], b.Methods[i]
+		if ma.Name != mb.Name || ma.TypeName != mb.TypeName || ma.Attributes != mb.Attributes || ma.VtableOffset != mb.VtableOffset {
+			return false
+		}
+	}
+	return true
+}
+
+// pairByKey pairs the entries of two lists, given as their keys, whose keys
+// are equal. Within a key, entries for which same reports true are paired
+// first, then the rest in order. It returns the pairs as indices into a and
+// b, in the order of a, and the indices left unpaired in each list.
+func pairByKey(aKeys, bKeys []string, same func(i, j int) bool) (pairs [][2]int, removed, added []int) {
+	candidates := make(map[string][]int)
+	for j, key := range bKeys {
+		candidates[key] = append(candidates[key], j)
+	}
+
+	match := make([]int, len(aKeys))
+	used := make([]bool, len(bKeys))
+	for i := range match {
+		match[i] = -1
+	}
+	pass := func(accept func(i, j int) bool) {
+		for i, key := range aKeys {
+			if match[i] >= 0 {
+				continue
+			}
+			for _, j := range candidates[key] {
+				if !used[j] && accept(i, j) {
+					match[i] = j
+					used[j] = true
+					break
+				}
+			}
+		}
+	}
+	pass(same)
+	pass(func(i, j int) bool { return true })
+
+	for i, j := range match {
+		if j < 0 {
+			removed = append(removed, i)
+		} else {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	for j := range bKeys {
+		if !used[j] {
+			added = append(added, j)
+		}
+	}
+	return pairs, removed, added
+}
@@ -0,0 +1,88 @@
+package pdb
+
+import (
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/codeview"
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// diffFixture returns a PDB with a struct, its forward declaration and
+// three functions, two of them overloads sharing a name.
+func diffFixture() *testPDB {
+	fields := leaf(streams.LF_FIELDLIST).
+		bytes(leaf(streams.LF_MEMBER_newformat).u16(3).u32(streams.T_INT4).u16(0).str("x").pad()).
+		bytes(leaf(streams.LF_MEMBER_newformat).u16(3).u32(streams.T_INT4).u16(4).str("y").pad())
+	return &testPDB{
+		types: []bb{
+			leaf(streams.LF_STRUCTURE_newformat).u16(0).u16(streams.PropForwardRef).u32(0).u32(0).u32(0).u16(0).str("Point"),
+			fields, // 0x1001
+			leaf(streams.LF_STRUCTURE_newformat).u16(2).u16(0).u32(0x1001).u32(0).u32(0).u16(8).str("Point"),
+			leaf(streams.LF_ARGLIST).u32(1).u32(streams.T_INT4), // 0x1003
+			leaf(streams.LF_PROCEDURE).u32(streams.T_VOID).u8(0).u8(0).u16(1).u32(0x1003),
+		},
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000)},
+		globals:  diffGlobals(0x30),
+	}
+}
+
+// diffGlobals returns the functions of diffFixture, with main mainLength
+// bytes long.
+func diffGlobals(mainLength uint32) bb {
+	return bb(nil).
+		bytes(symbol(codeview.S_GPROC32, procSym(0x1004, 0x10, 1, 0x10, "draw"))).
+		bytes(symbol(codeview.S_GPROC32, procSym(0x1004, 0x20, 1, 0x18, "draw"))).
+		bytes(symbol(codeview.S_GPROC32, procSym(0, 0x40, 1, mainLength, "main")))
+}
+
+func TestDiff(t *testing.T) {
+	base := openPDB(t, diffFixture())
+	if n, types := len(base.Functions()), len(diffTypes(base)); n != 3 || types != 1 {
+		t.Fatalf("fixture has %d functions and %d types, want 3 and 1", n, types)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*testPDB)
+		// Added, removed and changed functions, then types
+		counts [6]int
+	}{
+		{"identical", func(*testPDB) {}, [6]int{}},
+		{"moved", func(f *testPDB) {
+			f.sections[0].VirtualAddress += 0x2000
+		}, [6]int{}},
+		{"changed length", func(f *testPDB) {
+			f.globals = diffGlobals(0x34)
+		}, [6]int{0, 0, 1, 0, 0, 0}},
+		{"added function", func(f *testPDB) {
+			f.globals = f.globals.bytes(symbol(codeview.S_GPROC32, procSym(0, 0x80, 1, 0x10, "exit")))
+		}, [6]int{1, 0, 0, 0, 0, 0}},
+		{"added type", func(f *testPDB) {
+			f.types = append(f.types, leaf(streams.LF_STRUCTURE_newformat).u16(2).u16(0).u32(0x1001).u32(0).u32(0).u16(8).str("Size"))
+		}, [6]int{0, 0, 0, 1, 0, 0}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixture := diffFixture()
+			tc.modify(fixture)
+			other := openPDB(t, fixture)
+
+			d := Diff(base, other)
+			got := [6]int{
+				len(d.AddedFunctions), len(d.RemovedFunctions), len(d.ChangedFunctions),
+				len(d.AddedTypes), len(d.RemovedTypes), len(d.ChangedTypes),
+			}
+			if got != tc.counts {
+				t.Errorf("Diff counts = %v, want %v: %+v", got, tc.counts, d)
+			}
+			if d.Empty() != (tc.counts == [6]int{}) {
+				t.Errorf("Empty = %v", d.Empty())
+			}
+		})
+	}
+
+	// A PDB against itself
+	if d := Diff(base, base); !d.Empty() {
+		t.Errorf("Diff(p, p) = %+v, want an empty diff", d)
+	}
+}
@@ -0,0 +1,753 @@
+package pdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jtang613/gopdb/pkg/pdb/codeview"
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// headerIndent is one level of indentation in generated headers.
+const headerIndent = "    "
+
+// headerMaxAlign is the default structure packing of MSVC (/Zp8).
+const headerMaxAlign = 8
+
+// GenerateHeader writes C declarations for the named types and everything
+// they depend on to w. Names are looked up as by FindType; a name given by
+// an S_UDT record for a different type is emitted as a typedef.
+//
+// Definitions are ordered so that every type is complete before it is
+// embedded by value, and types referred to by pointer ahead of their
+// definition are forward-declared. Structures whose recorded layout does
+// not match the natural one are emitted under #pragma pack(1) with their
+// padding spelled out. Classes are emitted as structs, base classes as
+// leading members, and nested types at file scope with "::" in their
+// names replaced by "__". Unnamed member types are defined inline, and
+// member functions are listed in comments.
+func (p *PDB) GenerateHeader(w io.Writer, typeNames []string) error {
+	if p.resolver == nil {
+		return fmt.Errorf("no type information")
+	}
+
+	g := &headerGen{
+		p:       p,
+		r:       p.resolver,
+		state:   make(map[uint32]int),
+		pos:     make(map[uint32]int),
+		layouts: make(map[uint32]headerLayout),
+	}
+
+	p.udtsOnce.Do(p.loadUDTs)
+	for _, name := range typeNames {
+		if i, ok := p.udtIndex[name]; ok {
+			typeIdx := p.resolver.CompleteType(p.udts[i].TypeIndex)
+			if tag, ok := g.tag(typeIdx); !ok || tag != name {
+				g.visitType(typeIdx, true, 0)
+				g.typedefs = append(g.typedefs, headerTypedef{name: name, typeIdx: typeIdx})
+				continue
+			}
+			g.require(typeIdx)
+			continue
+		}
+		typeIdx, ok := p.resolver.FindType(name)
+		if !ok {
+			return fmt.Errorf("type %q not found", name)
+		}
+		g.require(p.resolver.CompleteType(typeIdx))
+	}
+	for len(g.pending) > 0 {
+		typeIdx := g.pending[0]
+		g.pending = g.pending[1:]
+		g.require(typeIdx)
+	}
+
+	var b strings.Builder
+	b.WriteString("#pragma once\n")
+
+	// Forward declarations for types used by pointer before their definition
+	declared := make(map[uint32]bool)
+	var forward []string
+	for _, e := range g.edges {
+		if declared[e.to] || e.from == 0 || g.pos[e.to] <= g.pos[e.from] {
+			continue
+		}
+		declared[e.to] = true
+		forward = append(forward, g.keyword(e.to)+" "+g.tagName(e.to)+";")
+	}
+	if len(forward) > 0 {
+		b.WriteString("\n" + strings.Join(forward, "\n") + "\n")
+	}
+
+	for _, typeIdx := range g.order {
+		b.WriteString("\n")
+		g.writeDefinition(&b, typeIdx)
+	}
+
+	if len(g.typedefs) > 0 {
+		b.WriteString("\n")
+		for _, td := range g.typedefs {
+			// Unnamed structures are defined in the typedef itself
+			packed := g.isUnnamed(td.typeIdx) && g.keyword(td.typeIdx) != "enum" && g.layout(td.typeIdx).packed
+			if packed {
+				b.WriteString("#pragma pack(push, 1)\n")
+			}
+			b.WriteString("typedef " + g.declare(td.typeIdx, td.name, "") + ";\n")
+			if packed {
+				b.WriteString("#pragma pack(pop)\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// headerGen holds the state of a GenerateHeader call.
+type headerGen struct {
+	p *PDB
+	r *codeview.TypeResolver
+
+	order    []uint32       // Types to define, in definition order
+	state    map[uint32]int // 1 while a type's dependencies are visited, 2 once ordered
+	pos      map[uint32]int // Position of each type in order
+	pending  []uint32       // Types referred to by pointer, to define later
+	edges    []headerEdge   // Pointer references, for forward declarations
+	typedefs []headerTypedef
+	layouts  map[uint32]headerLayout
+}
+
+// headerEdge records that a type refers to another by pointer. A zero from
+// is a typedef, which is emitted after every definition.
+type headerEdge struct {
+	from, to uint32
+}
+
+// headerTypedef is a typedef requested by name.
+type headerTypedef struct {
+	name    string
+	typeIdx uint32
+}
+
+// headerLayout describes how a structure is emitted.
+type headerLayout struct {
+	packed bool   // Emitted under #pragma pack(1) with explicit padding
+	align  uint64 // Alignment of the emitted structure
+}
+
+// headerGroup is a run of members emitted together: a single member, a
+// set of bitfields sharing a storage unit, or an anonymous union of
+// members at the same offset.
+type headerGroup struct {
+	members  []codeview.ParsedMember
+	offset   uint64
+	bitfield bool
+}
+
+// record returns the type record for an index, following forward
+// declarations to their definition.
+func (g *headerGen) record(typeIdx uint32) *streams.TypeRecord {
+	if g.r.IsBuiltin(typeIdx) {
+		return nil
+	}
+	return g.p.tpi.GetType(g.r.CompleteType(typeIdx))
+}
+
+// isUDT reports whether a record is a structure, class, union or enum.
+func isUDT(rec *streams.TypeRecord) bool {
+	switch rec.Kind {
+	case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
+		streams.LF_CLASS, streams.LF_CLASS_newformat,
+		streams.LF_UNION, streams.LF_UNION_newformat,
+		streams.LF_ENUM, streams.LF_ENUM_newformat:
+		return true
+	}
+	return false
+}
+
+// isUnion reports whether a record is a union.
+func isUnion(rec *streams.TypeRecord) bool {
+	return rec.Kind == streams.LF_UNION || rec.Kind == streams.LF_UNION_newformat
+}
+
+// isEnum reports whether a record is an enum.
+func isEnum(rec *streams.TypeRecord) bool {
+	return rec.Kind == streams.LF_ENUM || rec.Kind == streams.LF_ENUM_newformat
+}
+
+// tag returns the name of a structure, class, union or enum.
+func (g *headerGen) tag(typeIdx uint32) (string, bool) {
+	rec := g.record(typeIdx)
+	if rec == nil || !isUDT(rec) {
+		return "", false
+	}
+	name, _, _ := streams.UDTNames(rec)
+	return name, true
+}
+
+// isUnnamed reports whether a UDT has a compiler-generated name.
+func (g *headerGen) isUnnamed(typeIdx uint32) bool {
+	name, ok := g.tag(typeIdx)
+	if !ok {
+		return false
+	}
+	if idx := strings.LastIndex(name, "::"); idx >= 0 {
+		name = name[idx+2:]
+	}
+	return name == "" || name == "__unnamed" ||
+		strings.HasPrefix(name, "<unnamed-") || strings.HasPrefix(name, "<anonymous-")
+}
+
+// tagName returns a UDT's name as a C identifier.
+func (g *headerGen) tagName(typeIdx uint32) string {
+	typeIdx = g.r.CompleteType(typeIdx)
+	if g.isUnnamed(typeIdx) {
+		return fmt.Sprintf("unnamed_0x%x", typeIdx)
+	}
+	name, _ := g.tag(typeIdx)
+	name = strings.ReplaceAll(name, "::", "__")
+	return strings.Map(func(c rune) rune {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			return c
+		}
+		return '_'
+	}, name)
+}
+
+// keyword returns the C keyword introducing a UDT.
+func (g *headerGen) keyword(typeIdx uint32) string {
+	rec := g.record(typeIdx)
+	switch {
+	case rec == nil:
+		return "struct"
+	case isUnion(rec):
+		return "union"
+	case isEnum(rec):
+		return "enum"
+	}
+	return "struct"
+}
+
+// require orders a UDT after the types it embeds by value.
+func (g *headerGen) require(typeIdx uint32) {
+	typeIdx = g.r.CompleteType(typeIdx)
+	if g.state[typeIdx] != 0 {
+		return
+	}
+	rec := g.record(typeIdx)
+	if rec == nil || !isUDT(rec) {
+		return
+	}
+
+	g.state[typeIdx] = 1
+	if !isEnum(rec) {
+		g.visitMembers(typeIdx, typeIdx)
+	}
+	g.state[typeIdx] = 2
+	g.pos[typeIdx] = len(g.order)
+	g.order = append(g.order, typeIdx)
+}
+
+// visitMembers visits the member types of a structure or union on behalf
+// of the type being defined, from.
+func (g *headerGen) visitMembers(typeIdx, from uint32) {
+	parsed := g.r.ParseStructureType(g.record(typeIdx))
+	if parsed == nil {
+		return
+	}
+	for _, m := range parsed.Members {
+		if m.IsStatic || m.IsVirtualBase {
+			continue
+		}
+		g.visitType(m.TypeIdx, true, from)
+	}
+}
+
+// visitType collects the UDTs a type needs: complete definitions for those
+// used by value, and at least a declaration for those used by pointer.
+func (g *headerGen) visitType(typeIdx uint32, byValue bool, from uint32) {
+	rec := g.record(typeIdx)
+	if rec == nil {
+		return
+	}
+	data := rec.Data
+
+	switch rec.Kind {
+	case streams.LF_POINTER:
+		if len(data) >= 4 {
+			g.visitType(binary.LittleEndian.Uint32(data), false, from)
+		}
+
+	case streams.LF_MODIFIER, streams.LF_BITFIELD, streams.LF_ARRAY, streams.LF_ARRAY_newformat:
+		if len(data) >= 4 {
+			g.visitType(binary.LittleEndian.Uint32(data), byValue, from)
+		}
+
+	case streams.LF_PROCEDURE, streams.LF_MFUNCTION:
+		for _, dep := range g.r.Dependencies(rec) {
+			g.visitType(dep, false, from)
+		}
+
+	case streams.LF_ENUM, streams.LF_ENUM_newformat:
+		g.require(rec.Index)
+
+	default:
+		if !isUDT(rec) {
+			return
+		}
+		switch {
+		case byValue && g.isUnnamed(rec.Index):
+			g.visitMembers(rec.Index, from) // Defined inline
+		case byValue:
+			g.require(rec.Index)
+		default:
+			g.edges = append(g.edges, headerEdge{from: from, to: rec.Index})
+			g.pending = append(g.pending, rec.Index)
+		}
+	}
+}
+
+// writeDefinition writes the definition of a UDT at file scope.
+func (g *headerGen) writeDefinition(b *strings.Builder, typeIdx uint32) {
+	rec := g.record(typeIdx)
+	if isEnum(rec) {
+		b.WriteString(g.enumBody(rec, "") + ";\n")
+		return
+	}
+
+	layout := g.layout(typeIdx)
+	if layout.packed {
+		b.WriteString("#pragma pack(push, 1)\n")
+	}
+	b.WriteString(g.structBody(typeIdx, g.tagName(typeIdx), "") + ";\n")
+	if layout.packed {
+		b.WriteString("#pragma pack(pop)\n")
+	}
+}
+
+// enumBody renders an enum definition, without the closing semicolon.
+func (g *headerGen) enumBody(rec *streams.TypeRecord, indent string) string {
+	parsed := g.r.ParseEnumType(rec)
+	if parsed == nil {
+		return "enum " + g.tagName(rec.Index) + " {}"
+	}
+
+	var b strings.Builder
+	b.WriteString("enum " + g.tagName(rec.Index) + " {\n")
+
+	// Enumerate values are already formatted for the underlying type
+	for _, m := range parsed.Members {
+		b.WriteString(indent + headerIndent + m.Name + " = " + m.TypeName + ",\n")
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// enumUnderlying returns the underlying type of an LF_ENUM record.
+func enumUnderlying(rec *streams.TypeRecord) uint32 {
+	if len(rec.Data) < 8 {
+		return streams.T_INT4
+	}
+	return binary.LittleEndian.Uint32(rec.Data[4:])
+}
+
+// structBody renders a structure or union definition, without the closing
+// semicolon. An empty name renders an unnamed type for inline use.
+func (g *headerGen) structBody(typeIdx uint32, name, indent string) string {
+	parsed := g.r.ParseStructureType(g.record(typeIdx))
+	if parsed == nil {
+		return g.keyword(typeIdx) + " " + name + " {}"
+	}
+	layout := g.layout(typeIdx)
+	inner := indent + headerIndent
+
+	var b strings.Builder
+	b.WriteString(g.keyword(typeIdx))
+	if name != "" {
+		b.WriteString(" " + name)
+	}
+	b.WriteString(" {\n")
+
+	pads := 0
+	writePadding := func(n uint64) {
+		if n > 0 {
+			b.WriteString(fmt.Sprintf("%sunsigned char _padding%d[%d];\n", inner, pads, n))
+			pads++
+		}
+	}
+
+	bases := 0
+	member := func(m codeview.ParsedMember, indent string) string {
+		memberName := m.Name
+		if memberName == "(base)" {
+			memberName = fmt.Sprintf("_base%d", bases)
+			bases++
+		}
+		if m.BitWidth > 0 {
+			return g.declare(g.bitfieldBase(m.TypeIdx), memberName, indent) + fmt.Sprintf(" : %d", m.BitWidth)
+		}
+		return g.declare(m.TypeIdx, memberName, indent)
+	}
+
+	groups := g.groupMembers(parsed)
+	for _, group := range groups {
+		if layout.packed {
+			writePadding(group.members[0].PaddingBefore)
+		}
+		if len(group.members) > 1 && !group.bitfield {
+			b.WriteString(inner + "union {\n")
+			for _, m := range group.members {
+				b.WriteString(inner + headerIndent + member(m, inner+headerIndent) + ";\n")
+			}
+			b.WriteString(inner + "};\n")
+			continue
+		}
+		for _, m := range group.members {
+			b.WriteString(inner + member(m, inner) + ";\n")
+		}
+	}
+	if layout.packed {
+		if len(groups) == 0 {
+			writePadding(parsed.Size)
+		} else if isUnion(g.record(typeIdx)) {
+			if size := g.membersEnd(groups); parsed.Size > size {
+				b.WriteString(fmt.Sprintf("%sunsigned char _padding%d[%d];\n", inner, pads, parsed.Size))
+			}
+		} else {
+			writePadding(parsed.TrailingPadding)
+		}
+	}
+
+	// C has no member functions; they are listed for reference
+	for _, m := range parsed.Methods {
+		b.WriteString(inner + "/* " + m.Signature + "; */\n")
+	}
+
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// bitfieldBase returns the storage type of an LF_BITFIELD type.
+func (g *headerGen) bitfieldBase(typeIdx uint32) uint32 {
+	if rec := g.record(typeIdx); rec != nil && rec.Kind == streams.LF_BITFIELD && len(rec.Data) >= 4 {
+		return binary.LittleEndian.Uint32(rec.Data)
+	}
+	return typeIdx
+}
+
+// groupMembers splits the members of a structure into emitted groups.
+// Static members and virtual bases have no storage in the structure and
+// are left out. Members of a union each form their own group.
+func (g *headerGen) groupMembers(parsed *codeview.ParsedType) []headerGroup {
+	union := parsed.KindName == "union"
+
+	var groups []headerGroup
+	for _, m := range parsed.Members {
+		if m.IsStatic || m.IsVirtualBase {
+			continue
+		}
+		if n := len(groups); n > 0 && !union && groups[n-1].offset == m.Offset {
+			last := &groups[n-1]
+			switch {
+			case m.BitWidth > 0 && last.bitfield && m.BitPosition > 0:
+				last.members = append(last.members, m)
+				continue
+			case m.BitWidth == 0 && !last.bitfield:
+				last.members = append(last.members, m)
+				continue
+			}
+		}
+		groups = append(groups, headerGroup{
+			members:  []codeview.ParsedMember{m},
+			offset:   m.Offset,
+			bitfield: m.BitWidth > 0,
+		})
+	}
+	return groups
+}
+
+// groupExtent returns the size and alignment of a member group.
+func (g *headerGen) groupExtent(group headerGroup) (size, align uint64) {
+	align = 1
+	for _, m := range group.members {
+		typeIdx := m.TypeIdx
+		if group.bitfield {
+			typeIdx = g.bitfieldBase(typeIdx)
+		}
+		s, _ := g.r.SizeOf(typeIdx)
+		size = max(size, s)
+		align = max(align, g.alignOf(typeIdx))
+	}
+	return size, align
+}
+
+// membersEnd returns the end offset of the last byte used by any group.
+func (g *headerGen) membersEnd(groups []headerGroup) uint64 {
+	var end uint64
+	for _, group := range groups {
+		size, _ := g.groupExtent(group)
+		end = max(end, group.offset+size)
+	}
+	return end
+}
+
+// layout decides whether a structure needs packing: it does when laying
+// out its members with their natural alignment would not reproduce the
+// recorded offsets and size.
+func (g *headerGen) layout(typeIdx uint32) headerLayout {
+	typeIdx = g.r.CompleteType(typeIdx)
+	if l, ok := g.layouts[typeIdx]; ok {
+		return l
+	}
+	g.layouts[typeIdx] = headerLayout{align: 1} // Guards against cycles
+
+	parsed := g.r.ParseStructureType(g.record(typeIdx))
+	if parsed == nil {
+		return g.layouts[typeIdx]
+	}
+
+	groups := g.groupMembers(parsed)
+	natural := len(groups) > 0
+	var offset, align uint64 = 0, 1
+	for _, group := range groups {
+		size, a := g.groupExtent(group)
+		for _, m := range group.members {
+			if rec := g.record(m.TypeIdx); rec != nil && isUDT(rec) && !isEnum(rec) && g.isUnnamed(rec.Index) && g.layout(rec.Index).packed {
+				natural = false // Inline types cannot be packed on their own
+			}
+		}
+		a = min(a, headerMaxAlign)
+		align = max(align, a)
+
+		if parsed.KindName == "union" {
+			offset = max(offset, size)
+			continue
+		}
+		if alignUp(offset, a) != group.offset {
+			natural = false
+		}
+		offset = group.offset + size
+	}
+	if alignUp(offset, align) != parsed.Size {
+		natural = false
+	}
+
+	l := headerLayout{align: align}
+	if !natural {
+		l = headerLayout{packed: true, align: 1}
+	}
+	g.layouts[typeIdx] = l
+	return l
+}
+
+// alignUp rounds offset up to a multiple of align.
+func alignUp(offset, align uint64) uint64 {
+	if align <= 1 {
+		return offset
+	}
+	return (offset + align - 1) / align * align
+}
+
+// alignOf returns the alignment of a type as emitted.
+func (g *headerGen) alignOf(typeIdx uint32) uint64 {
+	if g.r.IsBuiltin(typeIdx) {
+		return min(max(streams.GetBuiltinTypeSize(typeIdx), 1), headerMaxAlign)
+	}
+	rec := g.record(typeIdx)
+	if rec == nil {
+		return 1
+	}
+
+	switch rec.Kind {
+	case streams.LF_MODIFIER, streams.LF_BITFIELD, streams.LF_ARRAY, streams.LF_ARRAY_newformat:
+		if len(rec.Data) >= 4 {
+			return g.alignOf(binary.LittleEndian.Uint32(rec.Data))
+		}
+	case streams.LF_POINTER, streams.LF_ENUM, streams.LF_ENUM_newformat:
+		size, _ := g.r.SizeOf(rec.Index)
+		return min(max(size, 1), headerMaxAlign)
+	default:
+		if isUDT(rec) {
+			return g.layout(rec.Index).align
+		}
+	}
+	return 1
+}
+
+// declare renders a declaration of name with the given type. An empty name
+// renders an abstract declarator, as used for parameters. Unnamed
+// structures and unions are defined inline at the given indentation.
+func (g *headerGen) declare(typeIdx uint32, name, indent string) string {
+	return g.declareCV(typeIdx, name, "", indent)
+}
+
+// declareCV is declare with cv-qualifiers ("const ", "volatile ") that
+// apply to the declared type itself.
+func (g *headerGen) declareCV(typeIdx uint32, name, cv, indent string) string {
+	join := func(base, decl string) string {
+		if decl == "" {
+			return base
+		}
+		return base + " " + decl
+	}
+	// Declarators of pointers bind looser than arrays and calls
+	wrap := func(decl string) string {
+		if strings.HasPrefix(decl, "*") || strings.HasPrefix(decl, "&") {
+			return "(" + decl + ")"
+		}
+		return decl
+	}
+
+	if g.r.IsBuiltin(typeIdx) {
+		if (typeIdx>>8)&0xF != streams.TM_DIRECT {
+			return join(headerBuiltinName(typeIdx&0xFF), "*"+name) // Builtin pointer
+		}
+		return join(cv+headerBuiltinName(typeIdx), name)
+	}
+	rec := g.record(typeIdx)
+	if rec == nil {
+		return join(cv+fmt.Sprintf("void /* type 0x%x */", typeIdx), name)
+	}
+	data := rec.Data
+
+	switch rec.Kind {
+	case streams.LF_POINTER:
+		if len(data) < 8 {
+			break
+		}
+		attrs := binary.LittleEndian.Uint32(data[4:])
+		decl := "*"
+		switch (attrs >> 5) & 0x07 {
+		case 1:
+			decl = "&"
+		case 4:
+			decl = "&&"
+		}
+		if attrs&(1<<10) != 0 {
+			cv = "const " + cv
+		}
+		if attrs&(1<<9) != 0 {
+			cv = "volatile " + cv
+		}
+		if cv != "" {
+			decl += " " + strings.TrimSpace(cv)
+			if name != "" {
+				decl += " "
+			}
+		}
+		return g.declare(binary.LittleEndian.Uint32(data), decl+name, indent)
+
+	case streams.LF_MODIFIER:
+		if len(data) < 6 {
+			break
+		}
+		modifiers := binary.LittleEndian.Uint16(data[4:])
+		if modifiers&0x02 != 0 {
+			cv = "volatile " + cv
+		}
+		if modifiers&0x01 != 0 {
+			cv = "const " + cv
+		}
+		return g.declareCV(binary.LittleEndian.Uint32(data), name, cv, indent)
+
+	case streams.LF_ARRAY, streams.LF_ARRAY_newformat:
+		if len(data) < 10 {
+			break
+		}
+		elem := binary.LittleEndian.Uint32(data)
+		size, _ := streams.ParseNumeric(data[8:])
+		if elemSize, ok := g.r.SizeOf(elem); ok && elemSize > 0 {
+			size /= elemSize
+		}
+		return g.declareCV(elem, wrap(name)+fmt.Sprintf("[%d]", size), cv, indent)
+
+	case streams.LF_PROCEDURE, streams.LF_MFUNCTION:
+		retType, argList := uint32(0), uint32(0)
+		switch {
+		case rec.Kind == streams.LF_PROCEDURE && len(data) >= 12:
+			retType, argList = binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[8:])
+		case rec.Kind == streams.LF_MFUNCTION && len(data) >= 20:
+			retType, argList = binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[16:])
+		}
+		return g.declare(retType, wrap(name)+"("+g.params(argList, indent)+")", indent)
+
+	case streams.LF_BITFIELD:
+		return g.declareCV(g.bitfieldBase(rec.Index), name, cv, indent)
+
+	default:
+		if !isUDT(rec) {
+			break
+		}
+		switch {
+		case isEnum(rec):
+			// C cannot give an enum another underlying type, so such
+			// enums are declared by their underlying type
+			if underlying := enumUnderlying(rec); underlying != streams.T_INT4 && underlying != streams.T_LONG {
+				return join(cv+headerBuiltinName(underlying)+" /* enum "+g.tagName(rec.Index)+" */", name)
+			}
+			return join(cv+"enum "+g.tagName(rec.Index), name)
+		case g.isUnnamed(rec.Index):
+			return join(cv+g.structBody(rec.Index, "", indent), name)
+		}
+		return join(cv+g.keyword(rec.Index)+" "+g.tagName(rec.Index), name)
+	}
+
+	return join(cv+"void /* "+g.r.ResolveType(typeIdx)+" */", name)
+}
+
+// headerBuiltins are the C spellings of builtin types whose CodeView
+// names are not valid C.
+var headerBuiltins = map[uint32]string{
+	streams.T_NOTYPE:  "void",
+	streams.T_QUAD:    "long long",
+	streams.T_UQUAD:   "unsigned long long",
+	streams.T_OCT:     "__int128",
+	streams.T_UOCT:    "unsigned __int128",
+	streams.T_BOOL32:  "int",
+	streams.T_HRESULT: "long",
+	streams.T_INT1:    "signed char",
+	streams.T_UINT1:   "unsigned char",
+	streams.T_RCHAR:   "char",
+	streams.T_INT2:    "short",
+	streams.T_UINT2:   "unsigned short",
+	streams.T_INT4:    "int",
+	streams.T_UINT4:   "unsigned int",
+	streams.T_INT8:    "long long",
+	streams.T_UINT8:   "unsigned long long",
+	streams.T_INT16:   "__int128",
+	streams.T_UINT16:  "unsigned __int128",
+}
+
+// headerBuiltinName returns the C name of a non-pointer builtin type.
+func headerBuiltinName(typeIdx uint32) string {
+	if name, ok := headerBuiltins[typeIdx]; ok {
+		return name
+	}
+	return streams.GetBuiltinTypeName(typeIdx)
+}
+
+// params renders the parameter list of a function type.
+func (g *headerGen) params(argList uint32, indent string) string {
+	rec := g.record(argList)
+	if rec == nil || rec.Kind != streams.LF_ARGLIST || len(rec.Data) < 4 {
+		return "void"
+	}
+
+	count := int(binary.LittleEndian.Uint32(rec.Data))
+	if count == 0 {
+		return "void"
+	}
+	var params []string
+	for i := 0; i < count && 8+i*4 <= len(rec.Data); i++ {
+		arg := binary.LittleEndian.Uint32(rec.Data[4+i*4:])
+		if arg == streams.T_NOTYPE {
+			params = append(params, "...") // Variadic
+			continue
+		}
+		params = append(params, g.declare(arg, "", indent))
+	}
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,90 @@
+package pdb
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+var update = flag.Bool("update", false, "rewrite the testdata fixtures and golden files")
+
+// headerFixture describes testdata/header.pdb. Node embeds Packet, which
+// refers back to Node by pointer; Header holds bitfields, Packet an
+// unnamed union and members sharing an offset, Node a method, and Packed
+// a member at an unnatural offset.
+var headerFixture = testPDB{
+	types: []bb{
+		leaf(streams.LF_BITFIELD).u32(streams.T_UINT4).u8(3).u8(0), // 0x1000
+		leaf(streams.LF_BITFIELD).u32(streams.T_UINT4).u8(5).u8(3),
+		leaf(streams.LF_FIELDLIST).
+			bytes(member("kind", 0x1000, 0)).
+			bytes(member("prio", 0x1001, 0)).
+			bytes(member("len", streams.T_USHORT, 4)), // 0x1002
+		structure("Header", 3, 0x1002, 8),
+		leaf(streams.LF_FIELDLIST).
+			bytes(member("value", streams.T_UINT4, 0)).
+			bytes(member("ratio", streams.T_REAL32, 0)), // 0x1004
+		leaf(streams.LF_UNION_newformat).u16(2).u16(0).u32(0x1004).u16(4).str("<unnamed-tag>"),
+		leaf(streams.LF_POINTER).u32(0x100A).u32(0x0C | 8<<13), // 0x1006
+		leaf(streams.LF_FIELDLIST).
+			bytes(member("hdr", 0x1003, 0)).
+			bytes(member("owner", 0x1006, 8)).
+			bytes(member("payload", 0x1005, 16)).
+			bytes(member("seq", streams.T_UINT4, 20)).
+			bytes(member("ack", streams.T_UINT4, 20)), // 0x1007
+		structure("Packet", 5, 0x1007, 24),
+		leaf(streams.LF_FIELDLIST).
+			bytes(member("pkt", 0x1008, 0)).
+			bytes(member("next", 0x1006, 24)).
+			bytes(leaf(streams.LF_ONEMETHOD_newformat).u16(3).u32(0x100E).str("depth").pad()), // 0x1009
+		structure("Node", 3, 0x1009, 32),
+		leaf(streams.LF_FIELDLIST).
+			bytes(member("c", streams.T_CHAR, 0)).
+			bytes(member("i", streams.T_INT4, 1)), // 0x100B
+		structure("Packed", 2, 0x100B, 5),
+		leaf(streams.LF_ARGLIST).u32(0), // 0x100D
+		leaf(streams.LF_MFUNCTION).u32(streams.T_INT4).u32(0x100A).u32(0x1006).u8(0x0B).u8(0).u16(0).u32(0x100D).u32(0),
+	},
+}
+
+func TestGenerateHeaderGolden(t *testing.T) {
+	fixture := filepath.Join("testdata", "header.pdb")
+	golden := filepath.Join("testdata", "header.h")
+	if *update {
+		data, err := os.ReadFile(writePDB(t, &headerFixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fixture, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := Open(fixture)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer p.Close()
+
+	var buf bytes.Buffer
+	if err := p.GenerateHeader(&buf, []string{"Node", "Packed"}); err != nil {
+		t.Fatalf("GenerateHeader: %v", err)
+	}
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("GenerateHeader output differs from %s:\n%s", golden, buf.Bytes())
+	}
+}
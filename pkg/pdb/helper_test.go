@@ -0,0 +1,290 @@
+package pdb
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/msf"
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// testGUID is the GUID of the PDBs written by writePDB.
+var testGUID = [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// bb builds little-endian record data.
+type bb []byte
+
+// leaf starts a type record or field list entry of the given kind.
+func leaf(kind uint16) bb { return bb(nil).u16(kind) }
+
+func (b bb) u8(v uint8) bb        { return append(b, v) }
+func (b bb) u16(v uint16) bb      { return binary.LittleEndian.AppendUint16(b, v) }
+func (b bb) u32(v uint32) bb      { return binary.LittleEndian.AppendUint32(b, v) }
+func (b bb) str(s string) bb      { return append(append(b, s...), 0) }
+func (b bb) bytes(data []byte) bb { return append(b, data...) }
+
+// pad pads a field list entry to a multiple of four bytes with LF_PAD
+// bytes.
+func (b bb) pad() bb {
+	for len(b)%4 != 0 {
+		b = append(b, byte(0xF0+4-len(b)%4))
+	}
+	return b
+}
+
+// member encodes an LF_MEMBER field list entry.
+func member(name string, typeIndex uint32, offset uint16) bb {
+	return leaf(streams.LF_MEMBER_newformat).u16(3).u32(typeIndex).u16(offset).str(name).pad()
+}
+
+// structure encodes a struct record named name.
+func structure(name string, count uint16, fields uint32, size uint16) bb {
+	return leaf(streams.LF_STRUCTURE_newformat).u16(count).u16(0).u32(fields).u32(0).u32(0).u16(size).str(name)
+}
+
+// symbol encodes a symbol record: its length, kind and data.
+func symbol(kind uint16, data bb) bb {
+	for (len(data)+2)%4 != 0 {
+		data = append(data, 0)
+	}
+	return bb(nil).u16(uint16(len(data) + 2)).u16(kind).bytes(data)
+}
+
+// procSym encodes the data of an S_GPROC32-style record.
+func procSym(typeIndex, offset uint32, segment uint16, length uint32, name string) bb {
+	return bb(nil).u32(0).u32(0).u32(0).u32(length).u32(0).u32(length).
+		u32(typeIndex).u32(offset).u16(segment).u8(0).str(name)
+}
+
+// dataSym encodes the data of an S_GDATA32-style record.
+func dataSym(typeIndex, offset uint32, segment uint16, name string) bb {
+	return bb(nil).u32(typeIndex).u32(offset).u16(segment).str(name)
+}
+
+// pubSym encodes the data of an S_PUB32 record.
+func pubSym(flags, offset uint32, segment uint16, name string) bb {
+	return bb(nil).u32(flags).u32(offset).u16(segment).str(name)
+}
+
+// section returns a PE section header.
+func section(name string, rva, size uint32) streams.PESectionHeader {
+	hdr := streams.PESectionHeader{VirtualAddress: rva, VirtualSize: size, SizeOfRawData: size}
+	copy(hdr.Name[:], name)
+	return hdr
+}
+
+// testModule is a module of a PDB written by writePDB.
+type testModule struct {
+	name  string
+	syms  bb       // Symbol records, after the C13 signature
+	files []string // Source files, as listed in the DBI source info
+}
+
+// testPDB describes the streams of a PDB written by writePDB. Sections,
+// original sections, OMAP tables and frame data are only written when set.
+type testPDB struct {
+	guid         [16]byte // testGUID if zero
+	age          uint32   // PDB info stream age
+	dbiAge       uint32   // DBI header age
+	types        []bb     // TPI records, numbered from TypeIndexBegin
+	ids          []bb     // IPI records, numbered from TypeIndexBegin
+	names        []string
+	globals      bb // Global symbol record stream
+	modules      []testModule
+	sections     []streams.PESectionHeader
+	origSections []streams.PESectionHeader
+	omapFromSrc  []streams.OMAPEntry
+	omapToSrc    []streams.OMAPEntry
+	fpo          bb  // FPO stream
+	frameData    bb  // NewFPO stream
+	dbiSize      int // Truncates the DBI stream when > 0
+}
+
+// writePDB writes the PDB described by t to a temporary file and returns
+// its path. Streams are laid out as link.exe does for the fixed ones: the
+// PDB info, TPI, DBI and IPI streams, then /names, the symbol records, the
+// debug streams and the module streams.
+func writePDB(tb testing.TB, t *testPDB) string {
+	tb.Helper()
+
+	const (
+		namesStream = 5 + iota
+		symRecordStream
+		sectionStream
+		origSectionStream
+		omapFromSrcStream
+		omapToSrcStream
+		fpoStream
+		frameDataStream
+		firstModuleStream
+	)
+	streamData := make([][]byte, firstModuleStream)
+	guid := t.guid
+	if guid == ([16]byte{}) {
+		guid = testGUID
+	}
+	streamData[1] = pdbInfoBytes(guid, t.age, namesStream)
+	streamData[2] = typeStreamBytes(t.types)
+	streamData[4] = typeStreamBytes(t.ids)
+	streamData[namesStream] = namesBytes(t.names)
+	streamData[symRecordStream] = t.globals
+	streamData[sectionStream] = sectionHeaderBytes(t.sections)
+	streamData[origSectionStream] = sectionHeaderBytes(t.origSections)
+	streamData[omapFromSrcStream] = omapBytes(t.omapFromSrc)
+	streamData[omapToSrcStream] = omapBytes(t.omapToSrc)
+	streamData[fpoStream] = t.fpo
+	streamData[frameDataStream] = t.frameData
+
+	debugHeader := make([]uint16, 11)
+	for i := range debugHeader {
+		debugHeader[i] = 0xFFFF
+	}
+	if t.sections != nil {
+		debugHeader[5] = sectionStream
+	}
+	if t.omapFromSrc != nil {
+		debugHeader[4] = omapFromSrcStream
+		debugHeader[10] = origSectionStream
+	}
+	if t.omapToSrc != nil {
+		debugHeader[3] = omapToSrcStream
+	}
+	if t.fpo != nil {
+		debugHeader[0] = fpoStream
+	}
+	if t.frameData != nil {
+		debugHeader[9] = frameDataStream
+	}
+
+	var modInfo, sourceInfo, fileNames bb
+	var fileOffsets []uint32
+	sourceInfo = sourceInfo.u16(uint16(len(t.modules))).u16(0)
+	for i := range t.modules {
+		sourceInfo = sourceInfo.u16(uint16(i))
+	}
+	for i, mod := range t.modules {
+		syms := bb(nil).u32(4).bytes(mod.syms)
+		streamData = append(streamData, syms)
+
+		modInfo = modInfo.u32(0).bytes(make([]byte, 28)).u16(0).
+			u16(uint16(firstModuleStream + i)).u32(uint32(len(syms))).u32(0).u32(0).
+			u16(uint16(len(mod.files))).u16(0).u32(0).u32(0).u32(0).
+			str(mod.name).str(mod.name)
+		for len(modInfo)%4 != 0 {
+			modInfo = append(modInfo, 0)
+		}
+
+		sourceInfo = sourceInfo.u16(uint16(len(mod.files)))
+		for _, f := range mod.files {
+			fileOffsets = append(fileOffsets, uint32(len(fileNames)))
+			fileNames = fileNames.str(f)
+		}
+	}
+	for _, off := range fileOffsets {
+		sourceInfo = sourceInfo.u32(off)
+	}
+	sourceInfo = sourceInfo.bytes(fileNames)
+	for len(sourceInfo)%4 != 0 {
+		sourceInfo = append(sourceInfo, 0)
+	}
+
+	dbi := bb(nil).u32(0xFFFFFFFF).u32(streams.DBIStreamVersionV70).u32(t.dbiAge).
+		u16(0xFFFF).u16(0).u16(0xFFFF).u16(0).u16(symRecordStream).u16(0).
+		u32(uint32(len(modInfo))).u32(0).u32(0).u32(uint32(len(sourceInfo))).u32(0).u32(0).
+		u32(uint32(2 * len(debugHeader))).u32(0).u16(0).u16(streams.MachineAMD64).u32(0).
+		bytes(modInfo).bytes(sourceInfo)
+	for _, index := range debugHeader {
+		dbi = dbi.u16(index)
+	}
+	if t.dbiSize > 0 {
+		dbi = dbi[:t.dbiSize]
+	}
+	streamData[3] = dbi
+
+	path := filepath.Join(tb.TempDir(), "test.pdb")
+	w, err := msf.NewWriter(path, 512)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, data := range streamData {
+		if _, err := w.AddStream(data); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		tb.Fatalf("writing PDB: %v", err)
+	}
+	return path
+}
+
+// openPDB writes the PDB described by t and opens it with opts. The PDB
+// is closed when the test ends.
+func openPDB(tb testing.TB, t *testPDB, opts ...Option) *PDB {
+	tb.Helper()
+	p, err := Open(writePDB(tb, t), opts...)
+	if err != nil {
+		tb.Fatalf("Open: %v", err)
+	}
+	tb.Cleanup(func() { p.Close() })
+	return p
+}
+
+// pdbInfoBytes encodes a VC70 PDB info stream with a named stream map
+// holding /names.
+func pdbInfoBytes(guid [16]byte, age uint32, namesStream uint32) []byte {
+	return bb(nil).u32(streams.PDBStreamVersionVC70).u32(0x5F3759DF).u32(age).bytes(guid[:]).
+		u32(7).str("/names").
+		u32(1).u32(1). // Size and capacity
+		u32(1).u32(1). // Present bits
+		u32(0).        // Deleted bits
+		u32(0).u32(namesStream)
+}
+
+// typeStreamBytes encodes a V80 TPI or IPI stream holding recs, each a
+// leaf kind followed by its data, without hash streams.
+func typeStreamBytes(recs []bb) []byte {
+	var body bb
+	for _, r := range recs {
+		for (len(r)+2)%4 != 0 {
+			r = append(r, 0)
+		}
+		body = body.u16(uint16(len(r))).bytes(r)
+	}
+	return bb(nil).u32(streams.TPIStreamVersionV80).u32(56).
+		u32(streams.TypeIndexBegin).u32(streams.TypeIndexBegin + uint32(len(recs))).
+		u32(uint32(len(body))).u16(0xFFFF).u16(0xFFFF).bytes(make([]byte, 32)).
+		bytes(body)
+}
+
+// namesBytes encodes a /names stream holding strs after the empty string
+// at offset 0.
+func namesBytes(strs []string) []byte {
+	buf := bb{0}
+	for _, s := range strs {
+		buf = buf.str(s)
+	}
+	return bb(nil).u32(streams.NamesSignature).u32(streams.NamesHashVersionV1).
+		u32(uint32(len(buf))).bytes(buf).u32(0).u32(uint32(len(strs)))
+}
+
+// sectionHeaderBytes encodes PE section headers.
+func sectionHeaderBytes(headers []streams.PESectionHeader) []byte {
+	var data bb
+	for _, h := range headers {
+		data = data.bytes(h.Name[:]).u32(h.VirtualSize).u32(h.VirtualAddress).
+			u32(h.SizeOfRawData).u32(h.PointerToRawData).u32(h.PointerToRelocations).
+			u32(h.PointerToLinenumbers).u16(h.NumberOfRelocations).u16(h.NumberOfLinenumbers).
+			u32(h.Characteristics)
+	}
+	return data
+}
+
+// omapBytes encodes an OMAP table.
+func omapBytes(entries []streams.OMAPEntry) []byte {
+	var data bb
+	for _, e := range entries {
+		data = data.u32(e.From).u32(e.To)
+	}
+	return data
+}
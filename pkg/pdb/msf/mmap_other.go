@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package msf
+
+// OpenMmap opens an MSF file. Memory mapping is not supported on this
+// platform, so it falls back to Open.
+func OpenMmap(path string) (*MSF, error) {
+	return Open(path)
+}
@@ -0,0 +1,75 @@
+package msf
+
+import (
+	"bytes"
+	"testing"
+)
+
+// largeTPISize is the size of the TPI stream of the benchmark fixture,
+// large enough that per-block reads dominate.
+const largeTPISize = 64 << 20
+
+// writeLargePDB writes an MSF file whose stream 2, the TPI stream of a
+// PDB, holds largeTPISize bytes in 4096-byte blocks.
+func writeLargePDB(tb testing.TB) string {
+	tb.Helper()
+	return writeMSF(tb, 4096, nil, pattern(64, 1), pattern(largeTPISize, 2))
+}
+
+func TestOpenMmap(t *testing.T) {
+	want := [][]byte{nil, pattern(100, 1), pattern(3*4096+5, 2)}
+	path := writeMSF(t, 4096, want...)
+
+	m, err := OpenMmap(path)
+	if err != nil {
+		t.Fatalf("OpenMmap: %v", err)
+	}
+	defer m.Close()
+	for i, data := range want {
+		s, err := m.Stream(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.ReadAll()
+		if err != nil {
+			t.Fatalf("stream %d: %v", i, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("stream %d: content mismatch", i)
+		}
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+// benchmarkReadAll measures ReadAll of the TPI stream of a large file
+// opened by open.
+func benchmarkReadAll(b *testing.B, open func(path string) (*MSF, error)) {
+	path := writeLargePDB(b)
+	m, err := open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+	s, err := m.Stream(2)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(s.Size()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ReadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadAllFile(b *testing.B) {
+	benchmarkReadAll(b, Open)
+}
+
+func BenchmarkReadAllMmap(b *testing.B) {
+	benchmarkReadAll(b, OpenMmap)
+}
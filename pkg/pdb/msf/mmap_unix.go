@@ -0,0 +1,70 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package msf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmapReader serves reads from a read-only memory mapping of a file.
+type mmapReader struct {
+	data []byte
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *mmapReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close unmaps the file.
+func (r *mmapReader) Close() error {
+	if r.data == nil {
+		return nil
+	}
+	data := r.data
+	r.data = nil
+	return syscall.Munmap(data)
+}
+
+// OpenMmap opens an MSF file by memory-mapping it, so that stream reads
+// are served from the mapping instead of one ReadAt call per block.
+func OpenMmap(path string) (*MSF, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := fi.Size()
+	if size == 0 || int64(int(size)) != size {
+		return nil, fmt.Errorf("cannot map file of size %d", size)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mmap file: %w", err)
+	}
+
+	r := &mmapReader{data: data}
+	msf, err := OpenReaderAt(r, size)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return msf, nil
+}
@@ -2,6 +2,7 @@ package msf
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -10,31 +11,74 @@ import (
 
 // MSF represents an opened MSF (Multi-Stream Format) file.
 type MSF struct {
-	file       *os.File
+	reader     io.ReaderAt
+	closer     io.Closer
+	size       int64
 	superBlock *SuperBlock
 	directory  *StreamDirectory
 	streams    []*Stream
+
+	directoryBlocks []uint32 // Blocks holding the stream directory
 }
 
 // Open opens an MSF file and parses its structure.
 func Open(path string) (*MSF, error) {
+	return OpenContext(context.Background(), path)
+}
+
+// OpenContext is like Open but stops with ctx's error if ctx is done
+// before the structure is parsed.
+func OpenContext(ctx context.Context, path string) (*MSF, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
-	msf := &MSF{file: f}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
 
-	// Read SuperBlock
-	msf.superBlock, err = ReadSuperBlock(f)
+	msf, err := OpenReaderAtContext(ctx, f, fi.Size())
 	if err != nil {
 		f.Close()
+		return nil, err
+	}
+
+	return msf, nil
+}
+
+// OpenReaderAt parses an MSF file from an io.ReaderAt of the given size.
+// If r also implements io.Closer, Close closes it.
+func OpenReaderAt(r io.ReaderAt, size int64) (*MSF, error) {
+	return OpenReaderAtContext(context.Background(), r, size)
+}
+
+// OpenReaderAtContext is like OpenReaderAt but checks ctx between reading
+// the SuperBlock and the stream directory.
+func OpenReaderAtContext(ctx context.Context, r io.ReaderAt, size int64) (*MSF, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	msf := &MSF{reader: r, size: size}
+	if c, ok := r.(io.Closer); ok {
+		msf.closer = c
+	}
+
+	// Read SuperBlock
+	var err error
+	msf.superBlock, err = ReadSuperBlock(io.NewSectionReader(r, 0, size))
+	if err != nil {
 		return nil, fmt.Errorf("failed to read superblock: %w", err)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Read stream directory
 	if err := msf.readStreamDirectory(); err != nil {
-		f.Close()
 		return nil, fmt.Errorf("failed to read stream directory: %w", err)
 	}
 
@@ -44,10 +88,10 @@ func Open(path string) (*MSF, error) {
 	return msf, nil
 }
 
-// Close closes the MSF file.
+// Close closes the underlying reader if it implements io.Closer.
 func (m *MSF) Close() error {
-	if m.file != nil {
-		return m.file.Close()
+	if m.closer != nil {
+		return m.closer.Close()
 	}
 	return nil
 }
@@ -62,6 +106,25 @@ func (m *MSF) NumStreams() int {
 	return int(m.directory.NumStreams)
 }
 
+// StreamInfo returns the size and block list the stream directory records
+// for the stream at index. Unused slots have size NilStreamSize and no
+// blocks. ok is false if index is out of range. The block list must not be
+// modified.
+func (m *MSF) StreamInfo(index int) (size uint32, blocks []uint32, ok bool) {
+	if index < 0 || index >= len(m.directory.StreamSizes) {
+		return 0, nil, false
+	}
+	return m.directory.StreamSizes[index], m.directory.StreamBlocks[index], true
+}
+
+// StreamSizes returns the size of every stream as recorded in the stream
+// directory, with NilStreamSize for unused slots.
+func (m *MSF) StreamSizes() []uint32 {
+	sizes := make([]uint32, len(m.directory.StreamSizes))
+	copy(sizes, m.directory.StreamSizes)
+	return sizes
+}
+
 // Stream returns the stream at the given index.
 func (m *MSF) Stream(index int) (*Stream, error) {
 	if index < 0 || index >= len(m.streams) {
@@ -79,9 +142,11 @@ func (m *MSF) StreamReader(index int) (*StreamReader, error) {
 	return NewStreamReader(s), nil
 }
 
-// readAt reads data from the file at the given offset.
+// readAt reads data from the underlying reader at the given offset.
+// It keeps no state, so concurrent stream reads are safe as long as the
+// reader's ReadAt is (as it is for *os.File and the mmap backend).
 func (m *MSF) readAt(p []byte, off int64) (int, error) {
-	return m.file.ReadAt(p, off)
+	return m.reader.ReadAt(p, off)
 }
 
 // readStreamDirectory reads and parses the stream directory.
@@ -91,26 +156,31 @@ func (m *MSF) readStreamDirectory() error {
 	// Read the block map (list of blocks containing the stream directory)
 	blockMapOffset := int64(m.superBlock.BlockMapAddr) * int64(blockSize)
 	numDirBlocks := m.superBlock.NumDirectoryBlocks()
+	if int64(m.superBlock.NumDirectoryBytes) > m.size {
+		return fmt.Errorf("directory size %d exceeds file size %d", m.superBlock.NumDirectoryBytes, m.size)
+	}
 
 	// Read block map entries
 	blockMap := make([]uint32, numDirBlocks)
-	if _, err := m.file.Seek(blockMapOffset, io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek to block map: %w", err)
-	}
-	if err := binary.Read(m.file, binary.LittleEndian, blockMap); err != nil {
+	blockMapReader := io.NewSectionReader(m.reader, blockMapOffset, m.size-blockMapOffset)
+	if err := binary.Read(blockMapReader, binary.LittleEndian, blockMap); err != nil {
 		return fmt.Errorf("failed to read block map: %w", err)
 	}
+	m.directoryBlocks = blockMap
 
 	// Read the stream directory data from the block map blocks
 	dirData := make([]byte, m.superBlock.NumDirectoryBytes)
 	bytesRead := 0
 	for _, blockIdx := range blockMap {
+		if err := m.superBlock.ValidateBlock(blockIdx); err != nil {
+			return fmt.Errorf("invalid directory block: %w", err)
+		}
 		offset := int64(blockIdx) * int64(blockSize)
 		toRead := int(blockSize)
 		if bytesRead+toRead > len(dirData) {
 			toRead = len(dirData) - bytesRead
 		}
-		if _, err := m.file.ReadAt(dirData[bytesRead:bytesRead+toRead], offset); err != nil {
+		if _, err := m.reader.ReadAt(dirData[bytesRead:bytesRead+toRead], offset); err != nil {
 			return fmt.Errorf("failed to read directory block %d: %w", blockIdx, err)
 		}
 		bytesRead += toRead
@@ -130,6 +200,9 @@ func (m *MSF) parseStreamDirectory(data []byte) error {
 	}
 
 	// Read stream sizes
+	if uint64(numStreams)*4 > uint64(r.Len()) {
+		return fmt.Errorf("stream count %d exceeds directory size %d", numStreams, len(data))
+	}
 	streamSizes := make([]uint32, numStreams)
 	for i := uint32(0); i < numStreams; i++ {
 		if err := binary.Read(r, binary.LittleEndian, &streamSizes[i]); err != nil {
@@ -143,11 +216,14 @@ func (m *MSF) parseStreamDirectory(data []byte) error {
 	for i := uint32(0); i < numStreams; i++ {
 		size := streamSizes[i]
 		// Size of 0xFFFFFFFF indicates an unused/deleted stream
-		if size == 0xFFFFFFFF {
+		if size == NilStreamSize {
 			streamBlocks[i] = nil
 			continue
 		}
-		numBlocks := (size + blockSize - 1) / blockSize
+		numBlocks := uint32((uint64(size) + uint64(blockSize) - 1) / uint64(blockSize))
+		if uint64(numBlocks)*4 > uint64(r.Len()) {
+			return fmt.Errorf("block list of stream %d exceeds directory size %d", i, len(data))
+		}
 		blocks := make([]uint32, numBlocks)
 		for j := uint32(0); j < numBlocks; j++ {
 			if err := binary.Read(r, binary.LittleEndian, &blocks[j]); err != nil {
@@ -171,7 +247,7 @@ func (m *MSF) buildStreams() {
 	m.streams = make([]*Stream, m.directory.NumStreams)
 	for i := uint32(0); i < m.directory.NumStreams; i++ {
 		size := m.directory.StreamSizes[i]
-		if size == 0xFFFFFFFF {
+		if size == NilStreamSize {
 			// Unused stream
 			m.streams[i] = &Stream{
 				msf:    m,
@@ -184,6 +260,18 @@ func (m *MSF) buildStreams() {
 				size:   size,
 				blocks: m.directory.StreamBlocks[i],
 			}
+			// A stream referencing a reserved or out-of-range block is
+			// unreadable; reads report the error instead of corrupt data
+			if int64(size) > m.size {
+				m.streams[i].err = fmt.Errorf("stream %d: size %d exceeds file size %d", i, size, m.size)
+				continue
+			}
+			for _, blockIdx := range m.streams[i].blocks {
+				if err := m.superBlock.ValidateBlock(blockIdx); err != nil {
+					m.streams[i].err = fmt.Errorf("stream %d: %w", i, err)
+					break
+				}
+			}
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package msf
 
 import (
+	"fmt"
 	"io"
 )
 
@@ -10,6 +11,7 @@ type Stream struct {
 	msf    *MSF
 	size   uint32
 	blocks []uint32
+	err    error // Set if the block list is invalid
 }
 
 // Size returns the size of the stream in bytes.
@@ -17,6 +19,11 @@ func (s *Stream) Size() uint32 {
 	return s.size
 }
 
+// Err returns the validation error of the stream's block list, if any.
+func (s *Stream) Err() error {
+	return s.err
+}
+
 // Blocks returns the block indices that make up this stream.
 func (s *Stream) Blocks() []uint32 {
 	return s.blocks
@@ -43,6 +50,9 @@ func NewStreamReader(s *Stream) *StreamReader {
 
 // Read implements io.Reader for streaming data from non-contiguous blocks.
 func (sr *StreamReader) Read(p []byte) (int, error) {
+	if sr.stream.err != nil {
+		return 0, sr.stream.err
+	}
 	if sr.offset >= int64(sr.stream.size) {
 		return 0, io.EOF
 	}
@@ -71,6 +81,10 @@ func (sr *StreamReader) Read(p []byte) (int, error) {
 		if err != nil && err != io.EOF {
 			return totalRead, err
 		}
+		if n < toRead {
+			// The block lies past the end of the file
+			return totalRead + n, io.ErrUnexpectedEOF
+		}
 
 		totalRead += n
 		sr.offset += int64(n)
@@ -114,6 +128,52 @@ func (sr *StreamReader) Seek(offset int64, whence int) (int64, error) {
 	return sr.offset, nil
 }
 
+// ReadAt implements io.ReaderAt, reading len(p) bytes starting at stream
+// offset off. Reads may span blocks. It keeps no state, so it is safe for
+// concurrent use, unlike StreamReader.
+func (s *Stream) ReadAt(p []byte, off int64) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("negative stream offset: %d", off)
+	}
+	if off >= int64(s.size) {
+		return 0, io.EOF
+	}
+
+	blockSize := int64(s.msf.superBlock.BlockSize)
+	totalRead := 0
+	for len(p) > 0 && off < int64(s.size) {
+		posInBlock := off % blockSize
+		toRead := int64(len(p))
+		if toRead > blockSize-posInBlock {
+			toRead = blockSize - posInBlock
+		}
+		if toRead > int64(s.size)-off {
+			toRead = int64(s.size) - off
+		}
+
+		fileOffset := int64(s.blocks[off/blockSize])*blockSize + posInBlock
+		n, err := s.msf.readAt(p[:toRead], fileOffset)
+		totalRead += n
+		off += int64(n)
+		p = p[n:]
+		if err != nil && err != io.EOF {
+			return totalRead, err
+		}
+		if int64(n) < toRead {
+			return totalRead, io.ErrUnexpectedEOF
+		}
+	}
+
+	// Like io.ReaderAt, a short read must report why
+	if len(p) > 0 {
+		return totalRead, io.EOF
+	}
+	return totalRead, nil
+}
+
 // ReadAll reads the entire stream contents into a byte slice.
 func (s *Stream) ReadAll() ([]byte, error) {
 	data := make([]byte, s.size)
@@ -125,6 +185,10 @@ func (s *Stream) ReadAll() ([]byte, error) {
 	return data, nil
 }
 
+// NilStreamSize is the size the stream directory records for an unused
+// stream slot.
+const NilStreamSize = 0xFFFFFFFF
+
 // StreamDirectory represents the directory of all streams in the MSF file.
 type StreamDirectory struct {
 	NumStreams   uint32
@@ -0,0 +1,80 @@
+package msf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestStreamReadAt(t *testing.T) {
+	const bs = 512
+	data := pattern(3*bs+100, 9)
+	m := openMSF(t, writeMSF(t, bs, data))
+	s, err := m.Stream(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		off  int64
+		n    int
+		want int
+		err  error
+	}{
+		{"within a block", 10, 20, 20, nil},
+		{"to a block end", bs - 8, 8, 8, nil},
+		{"across one boundary", bs - 8, 16, 16, nil},
+		{"across two boundaries", bs - 1, bs + 2, bs + 2, nil},
+		{"whole stream", 0, len(data), len(data), nil},
+		{"into the last block", 3*bs - 4, 104, 104, nil},
+		{"short at the end", int64(len(data)) - 10, 20, 10, io.EOF},
+		{"at the end", int64(len(data)), 1, 0, io.EOF},
+		{"past the end", int64(len(data)) + bs, 1, 0, io.EOF},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := make([]byte, tc.n)
+			n, err := s.ReadAt(p, tc.off)
+			if n != tc.want || err != tc.err {
+				t.Fatalf("ReadAt(%d bytes, %d) = %d, %v; want %d, %v", tc.n, tc.off, n, err, tc.want, tc.err)
+			}
+			if n > 0 && !bytes.Equal(p[:n], data[tc.off:tc.off+int64(n)]) {
+				t.Error("content mismatch")
+			}
+		})
+	}
+
+	if _, err := s.ReadAt(make([]byte, 1), -1); err == nil {
+		t.Error("ReadAt at a negative offset succeeded")
+	}
+}
+
+func TestStreamReaderSeek(t *testing.T) {
+	const bs = 512
+	data := pattern(2*bs+7, 4)
+	m := openMSF(t, writeMSF(t, bs, data))
+	sr, err := m.StreamReader(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sr.Seek(bs-3, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 6)
+	if _, err := io.ReadFull(sr, p); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p, data[bs-3:bs+3]) {
+		t.Error("read after Seek crossed the block boundary wrongly")
+	}
+
+	if pos, _ := sr.Seek(-2, io.SeekEnd); pos != int64(len(data))-2 {
+		t.Errorf("Seek(-2, SeekEnd) = %d", pos)
+	}
+	rest, err := io.ReadAll(sr)
+	if err != nil || !bytes.Equal(rest, data[len(data)-2:]) {
+		t.Errorf("ReadAll after Seek = %x, %v", rest, err)
+	}
+}
@@ -4,6 +4,7 @@ package msf
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -11,6 +12,14 @@ import (
 // MSF 7.00 magic signature
 var MSFMagic = []byte("Microsoft C/C++ MSF 7.00\r\n\x1aDS\x00\x00\x00")
 
+// MSF 2.00 magic signature, used by Visual C++ 6.0 and earlier
+var MSF2Magic = []byte("Microsoft C/C++ program database 2.00\r\n\x1aJG\x00\x00")
+
+// ErrMSF2Unsupported is returned for files in the MSF 2.00 container
+// format, which has 16-bit page numbers and a different directory layout.
+// Such PDBs are valid but not readable by this package.
+var ErrMSF2Unsupported = errors.New("MSF 2.00 PDB (Visual C++ 6.0 or earlier) is not supported")
+
 // SuperBlock is the header structure at the beginning of an MSF file.
 // It contains metadata needed to navigate the file's stream structure.
 type SuperBlock struct {
@@ -38,7 +47,11 @@ func ReadSuperBlock(r io.Reader) (*SuperBlock, error) {
 		return nil, fmt.Errorf("failed to read magic: %w", err)
 	}
 
-	// Validate magic
+	// Validate magic. The older format's magic is longer, so only its
+	// first bytes are compared.
+	if bytes.Equal(sb.Magic[:], MSF2Magic[:len(sb.Magic)]) {
+		return nil, ErrMSF2Unsupported
+	}
 	if !bytes.Equal(sb.Magic[:], MSFMagic) {
 		return nil, fmt.Errorf("invalid MSF magic: not a valid PDB file")
 	}
@@ -73,12 +86,17 @@ func ReadSuperBlock(r io.Reader) (*SuperBlock, error) {
 		return nil, fmt.Errorf("invalid FreeBlockMapBlock: %d (must be 1 or 2)", sb.FreeBlockMapBlock)
 	}
 
+	// Validate the directory block map location
+	if err := sb.ValidateBlock(sb.BlockMapAddr); err != nil {
+		return nil, fmt.Errorf("invalid BlockMapAddr: %w", err)
+	}
+
 	return &sb, nil
 }
 
 // NumDirectoryBlocks returns the number of blocks needed to store the stream directory.
 func (sb *SuperBlock) NumDirectoryBlocks() uint32 {
-	return (sb.NumDirectoryBytes + sb.BlockSize - 1) / sb.BlockSize
+	return uint32((uint64(sb.NumDirectoryBytes) + uint64(sb.BlockSize) - 1) / uint64(sb.BlockSize))
 }
 
 // FileSize returns the expected file size based on block count.
@@ -86,6 +104,29 @@ func (sb *SuperBlock) FileSize() int64 {
 	return int64(sb.NumBlocks) * int64(sb.BlockSize)
 }
 
+// IsFPMBlock reports whether a block index falls on one of the two
+// free-page-map blocks reserved at positions 1 and 2 of every interval of
+// BlockSize blocks.
+func (sb *SuperBlock) IsFPMBlock(index uint32) bool {
+	pos := index % sb.BlockSize
+	return pos == 1 || pos == 2
+}
+
+// ValidateBlock checks that a block index can hold stream or directory
+// data: it must lie within the file and not alias the SuperBlock or a
+// free-page-map block.
+func (sb *SuperBlock) ValidateBlock(index uint32) error {
+	switch {
+	case index >= sb.NumBlocks:
+		return fmt.Errorf("block %d out of range [0, %d)", index, sb.NumBlocks)
+	case index == 0:
+		return fmt.Errorf("block %d is the superblock", index)
+	case sb.IsFPMBlock(index):
+		return fmt.Errorf("block %d is a reserved free page map block", index)
+	}
+	return nil
+}
+
 func isValidBlockSize(size uint32) bool {
 	for _, valid := range ValidBlockSizes {
 		if size == valid {
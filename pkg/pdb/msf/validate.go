@@ -0,0 +1,174 @@
+package msf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DirectoryStream is the stream number Validate reports for blocks that
+// hold the stream directory or its block map.
+const DirectoryStream = -1
+
+// IssueKind classifies a problem found by Validate.
+type IssueKind int
+
+// Validation issue kinds
+const (
+	IssueBlockOutOfRange IssueKind = iota // Block index >= NumBlocks
+	IssueReservedBlock                    // Block aliases the SuperBlock or a free page map block
+	IssueDuplicateBlock                   // Block claimed by more than one stream
+	IssueFreeBlock                        // Block in use but marked free in the free page map
+	IssueFileSize                         // Block count or stream sizes disagree with the file length
+)
+
+// String returns a short name for the issue kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueBlockOutOfRange:
+		return "block_out_of_range"
+	case IssueReservedBlock:
+		return "reserved_block"
+	case IssueDuplicateBlock:
+		return "duplicate_block"
+	case IssueFreeBlock:
+		return "free_block"
+	case IssueFileSize:
+		return "file_size"
+	default:
+		return fmt.Sprintf("issue_%d", int(k))
+	}
+}
+
+// Issue is a single structural problem in an MSF file.
+type Issue struct {
+	Kind   IssueKind
+	Stream int    // Stream claiming the block, or DirectoryStream
+	Block  uint32 // Offending block index (unused for IssueFileSize)
+	Other  int    // Stream that claimed the block first (IssueDuplicateBlock)
+	Detail string
+}
+
+// String describes the issue.
+func (i Issue) String() string {
+	return i.Detail
+}
+
+// ValidationError is returned by Validate when the container's block
+// layout is inconsistent. It lists every problem found, not just the
+// first.
+type ValidationError struct {
+	Issues []Issue
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return "invalid MSF: " + e.Issues[0].Detail
+	}
+	details := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		details[i] = issue.Detail
+	}
+	return fmt.Sprintf("invalid MSF: %d problems: %s", len(e.Issues), strings.Join(details, "; "))
+}
+
+// Validate checks the block layout of the container: every stream and
+// directory block must lie within NumBlocks, avoid the reserved blocks,
+// be claimed by a single stream and be marked in use by the active free
+// page map, and the file must be large enough for the blocks it declares.
+// Problems are returned as a *ValidationError. Opening a file does not
+// validate it; damaged streams are otherwise only reported when read.
+func (m *MSF) Validate() error {
+	sb := m.superBlock
+	var issues []Issue
+
+	if m.size < sb.FileSize() {
+		issues = append(issues, Issue{
+			Kind:   IssueFileSize,
+			Stream: DirectoryStream,
+			Detail: fmt.Sprintf("file is %d bytes but %d blocks of %d bytes need %d", m.size, sb.NumBlocks, sb.BlockSize, sb.FileSize()),
+		})
+	}
+
+	fpm := m.readFreePageMap()
+	owner := make(map[uint32]int)
+	claim := func(stream int, block uint32) {
+		what := "directory"
+		if stream != DirectoryStream {
+			what = fmt.Sprintf("stream %d", stream)
+		}
+		if err := sb.ValidateBlock(block); err != nil {
+			kind := IssueReservedBlock
+			if block >= sb.NumBlocks {
+				kind = IssueBlockOutOfRange
+			}
+			issues = append(issues, Issue{Kind: kind, Stream: stream, Block: block, Detail: fmt.Sprintf("%s: %v", what, err)})
+			return
+		}
+		if first, ok := owner[block]; ok {
+			other := "the directory"
+			if first != DirectoryStream {
+				other = fmt.Sprintf("stream %d", first)
+			}
+			issues = append(issues, Issue{
+				Kind: IssueDuplicateBlock, Stream: stream, Block: block, Other: first,
+				Detail: fmt.Sprintf("%s: block %d already used by %s", what, block, other),
+			})
+			return
+		}
+		owner[block] = stream
+		if fpm != nil && isBitSet(fpm, block) {
+			issues = append(issues, Issue{Kind: IssueFreeBlock, Stream: stream, Block: block, Detail: fmt.Sprintf("%s: block %d is marked free", what, block)})
+		}
+	}
+
+	claim(DirectoryStream, sb.BlockMapAddr)
+	for _, block := range m.directoryBlocks {
+		claim(DirectoryStream, block)
+	}
+
+	var total int64
+	for i, blocks := range m.directory.StreamBlocks {
+		for _, block := range blocks {
+			claim(i, block)
+		}
+		total += int64(len(blocks)) * int64(sb.BlockSize)
+	}
+	if total > m.size {
+		issues = append(issues, Issue{
+			Kind:   IssueFileSize,
+			Stream: DirectoryStream,
+			Detail: fmt.Sprintf("streams reference %d bytes of blocks but the file is %d bytes", total, m.size),
+		})
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// readFreePageMap reads the active free page map as one bit vector, one
+// bit per block, set for free blocks. Its bytes are spread over the FPM
+// block at the same position in each interval of BlockSize blocks. It
+// returns nil if the map cannot be read.
+func (m *MSF) readFreePageMap() []byte {
+	sb := m.superBlock
+	fpm := make([]byte, (sb.NumBlocks+7)/8)
+	for off := uint32(0); off < uint32(len(fpm)); off += sb.BlockSize {
+		block := off/sb.BlockSize*sb.BlockSize + sb.FreeBlockMapBlock
+		if block >= sb.NumBlocks {
+			return nil
+		}
+		end := min(off+sb.BlockSize, uint32(len(fpm)))
+		if _, err := m.readAt(fpm[off:end], int64(block)*int64(sb.BlockSize)); err != nil {
+			return nil
+		}
+	}
+	return fpm
+}
+
+// isBitSet reports whether bit i of a little-endian bit vector is set.
+func isBitSet(bits []byte, i uint32) bool {
+	return i/8 < uint32(len(bits)) && bits[i/8]&(1<<(i%8)) != 0
+}
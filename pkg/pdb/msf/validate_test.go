@@ -0,0 +1,89 @@
+package msf
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+// msfFile is the raw content of an MSF file, for corrupting a container
+// written by Writer.
+type msfFile []byte
+
+func (f msfFile) u32(off int) uint32       { return binary.LittleEndian.Uint32(f[off:]) }
+func (f msfFile) setU32(off int, v uint32) { binary.LittleEndian.PutUint32(f[off:], v) }
+
+func (f msfFile) blockSize() int { return int(f.u32(32)) }
+
+// streamBlock returns the file offset of the directory entry holding the
+// i-th block of a stream. The directory must fit in one block.
+func (f msfFile) streamBlock(stream, i int) int {
+	bs := f.blockSize()
+	dir := int(f.u32(int(f.u32(52))*bs)) * bs
+	numStreams := int(f.u32(dir))
+	off := dir + 4 + 4*numStreams
+	for s := 0; s < stream; s++ {
+		size := int(f.u32(dir + 4 + 4*s))
+		off += 4 * ((size + bs - 1) / bs)
+	}
+	return off + 4*i
+}
+
+func TestValidateIssues(t *testing.T) {
+	const bs = 512
+	tests := []struct {
+		name  string
+		patch func(f msfFile)
+		kind  IssueKind
+	}{
+		{"block out of range", func(f msfFile) {
+			f.setU32(f.streamBlock(1, 0), f.u32(40)+10)
+		}, IssueBlockOutOfRange},
+		{"block shared by two streams", func(f msfFile) {
+			f.setU32(f.streamBlock(2, 1), f.u32(f.streamBlock(1, 0)))
+		}, IssueDuplicateBlock},
+		{"block marked free", func(f msfFile) {
+			block := f.u32(f.streamBlock(1, 0))
+			f[int(f.u32(36))*bs+int(block/8)] |= 1 << (block % 8)
+		}, IssueFreeBlock},
+		{"superblock", func(f msfFile) {
+			f.setU32(f.streamBlock(1, 0), 0)
+		}, IssueReservedBlock},
+		{"free page map block", func(f msfFile) {
+			f.setU32(f.streamBlock(2, 0), f.u32(36))
+		}, IssueReservedBlock},
+		{"inflated block count", func(f msfFile) {
+			f.setU32(40, f.u32(40)+100)
+		}, IssueFileSize},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeMSF(t, bs, nil, pattern(bs+10, 1), pattern(3*bs, 2))
+			if err := openMSF(t, path).Validate(); err != nil {
+				t.Fatalf("Validate before corruption: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.patch(msfFile(data))
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err = openMSF(t, path).Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate = %v, want a *ValidationError", err)
+			}
+			for _, issue := range verr.Issues {
+				if issue.Kind == tc.kind {
+					return
+				}
+			}
+			t.Errorf("Validate issues = %v, want one of kind %v", verr.Issues, tc.kind)
+		})
+	}
+}
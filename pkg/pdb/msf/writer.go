@@ -0,0 +1,178 @@
+package msf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Writer builds an MSF file from whole streams, for creating small PDBs
+// such as test fixtures. Streams are added in index order with AddStream;
+// Finish lays them out and writes the file. Only the container is written:
+// the caller supplies the content of each stream, for a PDB the info
+// stream at index 1, the TPI stream at index 2 and so on.
+type Writer struct {
+	path      string
+	blockSize uint32
+	streams   [][]byte
+	finished  bool
+}
+
+// errWriterFinished is returned by a Writer used after Finish.
+var errWriterFinished = errors.New("msf writer already finished")
+
+// NewWriter returns a Writer that creates the file at path when Finish is
+// called. blockSize must be one of ValidBlockSizes.
+func NewWriter(path string, blockSize uint32) (*Writer, error) {
+	if !isValidBlockSize(blockSize) {
+		return nil, fmt.Errorf("invalid block size: %d", blockSize)
+	}
+	return &Writer{path: path, blockSize: blockSize}, nil
+}
+
+// AddStream appends a stream holding a copy of data and returns its index.
+func (w *Writer) AddStream(data []byte) (int, error) {
+	if w.finished {
+		return 0, errWriterFinished
+	}
+	if uint64(len(data)) >= NilStreamSize {
+		return 0, fmt.Errorf("stream size %d too large", len(data))
+	}
+	w.streams = append(w.streams, append([]byte{}, data...))
+	return len(w.streams) - 1, nil
+}
+
+// Finish lays out the streams and writes the file: the SuperBlock, both
+// free page maps at every interval of BlockSize blocks, the stream data,
+// the stream directory and its block map. Stream blocks are allocated in
+// order, skipping the free page map blocks. The Writer cannot be used
+// afterwards. On error the partially written file is removed.
+func (w *Writer) Finish() error {
+	if w.finished {
+		return errWriterFinished
+	}
+	w.finished = true
+
+	bs := w.blockSize
+	sb := &SuperBlock{BlockSize: bs, FreeBlockMapBlock: 1}
+	copy(sb.Magic[:], MSFMagic)
+
+	// Block 0 is the SuperBlock; 1 and 2 are the first free page maps
+	next := uint32(3)
+	alloc := func(size int) []uint32 {
+		blocks := make([]uint32, (uint64(size)+uint64(bs)-1)/uint64(bs))
+		for i := range blocks {
+			for sb.IsFPMBlock(next) {
+				next++
+			}
+			blocks[i] = next
+			next++
+		}
+		return blocks
+	}
+
+	streamBlocks := make([][]uint32, len(w.streams))
+	for i, data := range w.streams {
+		streamBlocks[i] = alloc(len(data))
+	}
+
+	dir := binary.LittleEndian.AppendUint32(nil, uint32(len(w.streams)))
+	for _, data := range w.streams {
+		dir = binary.LittleEndian.AppendUint32(dir, uint32(len(data)))
+	}
+	for _, blocks := range streamBlocks {
+		for _, block := range blocks {
+			dir = binary.LittleEndian.AppendUint32(dir, block)
+		}
+	}
+
+	dirBlocks := alloc(len(dir))
+	if uint64(len(dirBlocks))*4 > uint64(bs) {
+		return fmt.Errorf("stream directory of %d bytes does not fit a single block map block", len(dir))
+	}
+	blockMap := make([]byte, 0, len(dirBlocks)*4)
+	for _, block := range dirBlocks {
+		blockMap = binary.LittleEndian.AppendUint32(blockMap, block)
+	}
+
+	sb.BlockMapAddr = alloc(1)[0]
+	sb.NumBlocks = next
+	sb.NumDirectoryBytes = uint32(len(dir))
+
+	f, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := w.write(f, sb, streamBlocks, dir, dirBlocks, blockMap); err != nil {
+		f.Close()
+		os.Remove(w.path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(w.path)
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return nil
+}
+
+// write writes the laid-out file to f.
+func (w *Writer) write(f *os.File, sb *SuperBlock, streamBlocks [][]uint32, dir []byte, dirBlocks []uint32, blockMap []byte) error {
+	bs := int64(sb.BlockSize)
+
+	// writeBlocks writes data over the given blocks, one block at a time
+	writeBlocks := func(data []byte, blocks []uint32) error {
+		for i, block := range blocks {
+			chunk := data[int64(i)*bs : min(int64(i+1)*bs, int64(len(data)))]
+			if _, err := f.WriteAt(chunk, int64(block)*bs); err != nil {
+				return fmt.Errorf("failed to write block %d: %w", block, err)
+			}
+		}
+		return nil
+	}
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, sb)
+	if _, err := f.WriteAt(header.Bytes(), 0); err != nil {
+		return fmt.Errorf("failed to write superblock: %w", err)
+	}
+
+	// Every block in the file is in use. The map has one bit per block,
+	// set for free blocks, and its bytes are spread over the free page map
+	// block of each interval; bits past the end of the file are free.
+	intervals := (sb.NumBlocks + sb.BlockSize - 1) / sb.BlockSize
+	fpm := bytes.Repeat([]byte{0xFF}, int(intervals)*int(bs))
+	for i := uint32(0); i < sb.NumBlocks; i++ {
+		fpm[i/8] &^= 1 << (i % 8)
+	}
+	for k := uint32(0); k < intervals; k++ {
+		chunk := fpm[int64(k)*bs : int64(k+1)*bs]
+		for _, block := range []uint32{k*sb.BlockSize + 1, k*sb.BlockSize + 2} {
+			if block >= sb.NumBlocks {
+				continue
+			}
+			if _, err := f.WriteAt(chunk, int64(block)*bs); err != nil {
+				return fmt.Errorf("failed to write free page map block %d: %w", block, err)
+			}
+		}
+	}
+
+	for i, data := range w.streams {
+		if err := writeBlocks(data, streamBlocks[i]); err != nil {
+			return fmt.Errorf("stream %d: %w", i, err)
+		}
+	}
+	if err := writeBlocks(dir, dirBlocks); err != nil {
+		return fmt.Errorf("stream directory: %w", err)
+	}
+	if _, err := f.WriteAt(blockMap, int64(sb.BlockMapAddr)*bs); err != nil {
+		return fmt.Errorf("failed to write block map: %w", err)
+	}
+
+	// Pad the last block
+	if err := f.Truncate(int64(sb.NumBlocks) * bs); err != nil {
+		return fmt.Errorf("failed to size file: %w", err)
+	}
+	return nil
+}
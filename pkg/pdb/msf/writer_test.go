@@ -0,0 +1,215 @@
+package msf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMSF writes streams into a new MSF file in a temporary directory and
+// returns its path.
+func writeMSF(tb testing.TB, blockSize uint32, streams ...[]byte) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "test.pdb")
+	w, err := NewWriter(path, blockSize)
+	if err != nil {
+		tb.Fatalf("NewWriter: %v", err)
+	}
+	for _, data := range streams {
+		if _, err := w.AddStream(data); err != nil {
+			tb.Fatalf("AddStream: %v", err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		tb.Fatalf("Finish: %v", err)
+	}
+	return path
+}
+
+// openMSF opens path and closes it when the test ends.
+func openMSF(tb testing.TB, path string) *MSF {
+	tb.Helper()
+	m, err := Open(path)
+	if err != nil {
+		tb.Fatalf("Open: %v", err)
+	}
+	tb.Cleanup(func() { m.Close() })
+	return m
+}
+
+// pattern returns n bytes that differ from block to block, so misplaced
+// blocks show up as content mismatches.
+func pattern(n int, seed byte) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7+i/251) ^ seed
+	}
+	return data
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	for _, bs := range ValidBlockSizes {
+		streams := [][]byte{
+			nil,
+			pattern(1, 1),
+			pattern(int(bs), 2),
+			pattern(int(bs)*3+17, 3),
+			{},
+		}
+		path := writeMSF(t, bs, streams...)
+		m := openMSF(t, path)
+
+		if err := m.Validate(); err != nil {
+			t.Errorf("block size %d: Validate: %v", bs, err)
+		}
+		if got := m.BlockSize(); got != bs {
+			t.Errorf("block size %d: BlockSize() = %d", bs, got)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := m.SuperBlock().FileSize(); fi.Size() != want {
+			t.Errorf("block size %d: file is %d bytes, want %d", bs, fi.Size(), want)
+		}
+		if got := m.NumStreams(); got != len(streams) {
+			t.Fatalf("block size %d: NumStreams() = %d, want %d", bs, got, len(streams))
+		}
+		for i, want := range streams {
+			s, err := m.Stream(i)
+			if err != nil {
+				t.Fatalf("block size %d: Stream(%d): %v", bs, i, err)
+			}
+			got, err := s.ReadAll()
+			if err != nil {
+				t.Fatalf("block size %d: stream %d: ReadAll: %v", bs, i, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("block size %d: stream %d: content mismatch (%d bytes, want %d)", bs, i, len(got), len(want))
+			}
+		}
+	}
+}
+
+func TestWriterZeroLengthStream(t *testing.T) {
+	m := openMSF(t, writeMSF(t, 512, []byte{}))
+	size, blocks, ok := m.StreamInfo(0)
+	if !ok || size != 0 || len(blocks) != 0 {
+		t.Fatalf("StreamInfo(0) = %d, %v, %v; want 0, [], true", size, blocks, ok)
+	}
+	s, err := m.Stream(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.ReadAll()
+	if err != nil || len(data) != 0 {
+		t.Errorf("ReadAll() = %d bytes, %v; want 0 bytes, nil", len(data), err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestWriterSpansFreePageMapInterval(t *testing.T) {
+	// Enough blocks that the stream crosses the reserved blocks of the
+	// second interval and the free page map itself needs a second block.
+	const bs = 512
+	data := pattern((bs*8+100)*bs, 5)
+	m := openMSF(t, writeMSF(t, bs, data))
+	sb := m.SuperBlock()
+
+	_, blocks, _ := m.StreamInfo(0)
+	for _, block := range blocks {
+		if sb.IsFPMBlock(block) {
+			t.Fatalf("stream uses free page map block %d", block)
+		}
+	}
+	if last := blocks[len(blocks)-1]; last < 2*bs {
+		t.Fatalf("stream ends at block %d, want past the second interval", last)
+	}
+	if sb.NumBlocks <= bs*8 {
+		t.Fatalf("NumBlocks = %d, want more than one free page map block covers", sb.NumBlocks)
+	}
+
+	// Bits of in-use blocks are clear, the rest of the map is set
+	fpm := make([]byte, (sb.NumBlocks+7)/8)
+	for off := uint32(0); off < uint32(len(fpm)); off += bs {
+		end := min(off+bs, uint32(len(fpm)))
+		if _, err := m.readAt(fpm[off:end], int64(off/bs*bs+1)*bs); err != nil {
+			t.Fatalf("reading free page map: %v", err)
+		}
+	}
+	for i := uint32(0); i < sb.NumBlocks; i++ {
+		if isBitSet(fpm, i) {
+			t.Fatalf("block %d is marked free", i)
+		}
+	}
+	tail := make([]byte, bs)
+	if _, err := m.readAt(tail, int64(bs+2)*bs); err != nil {
+		t.Fatal(err)
+	}
+	for i := sb.NumBlocks % (bs * 8); i < bs*8; i++ {
+		if !isBitSet(tail, i) {
+			t.Fatalf("bit for block %d past the end of the file is clear", bs*8+i)
+		}
+	}
+
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	s, err := m.Stream(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("stream content mismatch")
+	}
+}
+
+func TestWriterDirectoryTooLarge(t *testing.T) {
+	// A 512-byte block map block holds 128 directory blocks, 64KiB of
+	// directory; each empty stream adds four bytes.
+	path := filepath.Join(t.TempDir(), "big.pdb")
+	w, err := NewWriter(path, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 512*128/4; i++ {
+		if _, err := w.AddStream(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Finish()
+	if err == nil || !strings.Contains(err.Error(), "does not fit a single block map block") {
+		t.Fatalf("Finish() error = %v, want directory size error", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file exists after failed Finish: %v", err)
+	}
+}
+
+func TestWriterInvalidUse(t *testing.T) {
+	if _, err := NewWriter(filepath.Join(t.TempDir(), "x.pdb"), 8192); err == nil {
+		t.Error("NewWriter accepted block size 8192")
+	}
+
+	w, err := NewWriter(filepath.Join(t.TempDir(), "x.pdb"), 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.AddStream(nil); err != errWriterFinished {
+		t.Errorf("AddStream after Finish: %v, want errWriterFinished", err)
+	}
+	if err := w.Finish(); err != errWriterFinished {
+		t.Errorf("second Finish: %v, want errWriterFinished", err)
+	}
+}
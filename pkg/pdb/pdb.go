@@ -1,7 +1,19 @@
 package pdb
 
 import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/jtang613/gopdb/pkg/pdb/codeview"
 	"github.com/jtang613/gopdb/pkg/pdb/msf"
@@ -16,484 +28,3338 @@ const (
 	StreamIPI = 4 // ID info stream
 )
 
-// PDB represents an opened PDB file.
+// errStopWalk ends a symbol walk early without signalling a failure.
+var errStopWalk = errors.New("stop walk")
+
+// PDB represents an opened PDB file. Its methods are safe for concurrent
+// use by multiple goroutines.
 type PDB struct {
 	msf            *msf.MSF
 	pdbInfo        *streams.PDBInfo
 	tpi            *streams.TPIStream
+	ipi            *streams.TPIStream
 	dbi            *streams.DBIStream
 	resolver       *codeview.TypeResolver
+	idResolver     *codeview.IDResolver
+	names          *streams.NamesTable
 	sectionHeaders []streams.PESectionHeader
+	origHeaders    []streams.PESectionHeader // Pre-OMAP section layout
+	omapFromSrc    []streams.OMAPEntry
+	omapToSrc      []streams.OMAPEntry // Final to original layout, if present
+	rawTypes       bool                // Set by WithRawTypes
+	skipTPI        bool                // Set by WithoutTPI
+	noDemangle     atomic.Bool         // Set by SetDemangle(false)
 
 	// Cached results
 	functions []Function
 	variables []Variable
 	publics   []PublicSymbol
+	constants []Constant
+	udts      []UDT
+	udtIndex  map[string]int // UDT name to index into udts
+	typeSrc   map[uint32]typeSource
 	sections  []SectionInfo
+	imageSecs []ImageSection
+	lines     []LineInfo
+	rvaIndex  []int // Indices into functions, sorted by RVA
+	contribs  []Contribution
+	lineIndex []int // Indices into lines, sorted by RVA
+	fpo       []streams.FPOData
+	frameData []streams.FrameData
+	pdata     []streams.RuntimeFunction
+	pdataBase uint32 // RVA of pdata[0], 0 if unknown
+	xdata     []byte // .xdata copy, without its header
+	xdataBase uint32 // RVA of xdata[0]
+	thunks    []Thunk
+	labels    []Label
+	sepCode   []SeparatedCode // Sorted by RVA
+	annots    []Annotation
+	linkInfo  *LinkInfo
+	callGraph map[string][]string
+	heapSites []HeapAllocSite
+	exports   []Export
+	typeSrvs  []TypeServerRef
+	modHdrs   []moduleHeader // By module index
+
+	// Guards for the lazily built caches above
+	functionsOnce cacheOnce
+	variablesOnce cacheOnce
+	publicsOnce   cacheOnce
+	constantsOnce sync.Once
+	udtsOnce      sync.Once
+	typeSrcOnce   sync.Once
+	sectionsOnce  sync.Once
+	imageSecsOnce sync.Once
+	linesOnce     cacheOnce
+	rvaIndexOnce  sync.Once
+	contribsOnce  sync.Once
+	lineIndexOnce sync.Once
+	namesOnce     sync.Once
+	fpoOnce       sync.Once
+	frameDataOnce sync.Once
+	pdataOnce     sync.Once
+	xdataOnce     sync.Once
+	thunksOnce    sync.Once
+	labelsOnce    sync.Once
+	sepCodeOnce   sync.Once
+	annotsOnce    sync.Once
+	linkInfoOnce  sync.Once
+	callGraphOnce sync.Once
+	heapSiteOnce  sync.Once
+	exportsOnce   sync.Once
+	typeSrvsOnce  sync.Once
+	modHdrsOnce   sync.Once
+
+	// External type servers set by SetTypeServerResolver
+	extMu    sync.RWMutex
+	extTypes []extTypeServer
+
+	// Parse failures
+	warnMu       sync.Mutex
+	warnings     []error
+	tpiErr       error
+	dbiErr       error
+	functionsErr error
+	variablesErr error
+}
+
+// Option configures how a PDB is opened.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	lazyTypes    bool
+	validateMSF  bool
+	rawTypes     bool
+	skipTPI      bool
+	skipDBI      bool
+	skipSections bool
+}
+
+// WithLazyTypes reads TPI type records on demand instead of parsing the
+// whole stream when the PDB is opened. Lookups of single types, such as
+// ResolveType and FindType, then touch only the records they need; listing
+// every type still reads the full stream.
+func WithLazyTypes() Option {
+	return func(o *options) { o.lazyTypes = true }
+}
+
+// WithMSFValidation checks the block layout of the MSF container before
+// parsing any stream, and fails to open files whose streams reference
+// out-of-range, reserved, free or shared blocks or whose size disagrees
+// with the block count. The error wraps an *msf.ValidationError listing
+// every problem.
+func WithMSFValidation() Option {
+	return func(o *options) { o.validateMSF = true }
+}
+
+// WithRawTypes keeps the undecoded record of each type in TypeInfo.Raw and
+// TypeInfo.RawKind, for tools that decode leaf kinds this package does not.
+func WithRawTypes() Option {
+	return func(o *options) { o.rawTypes = true }
+}
+
+// WithoutTPI skips the TPI and IPI streams, for callers that need no
+// types, such as a symbol server lookup by GUID and age. Type names and
+// signatures then render built-in types by name and others as type_0xNNNN,
+// ResolveType returns a TypeInfo holding only that name, and Types is
+// empty.
+func WithoutTPI() Option {
+	return func(o *options) { o.skipTPI = true }
+}
+
+// WithoutDBI skips the DBI stream and with it the section headers, leaving
+// no modules, symbols or line information. Info still reports the PDB
+// info stream.
+func WithoutDBI() Option {
+	return func(o *options) { o.skipDBI = true }
+}
+
+// WithoutSections skips the section header and OMAP streams. Addresses
+// are then mapped through the S_SECTION records or the DBI section map,
+// when present.
+func WithoutSections() Option {
+	return func(o *options) { o.skipSections = true }
+}
+
+// Options selects the parts of a PDB that OpenWith skips, as a struct for
+// callers that build the choice from configuration. The zero value parses
+// everything, as Open does.
+type Options struct {
+	SkipTPI      bool // See WithoutTPI
+	SkipDBI      bool // See WithoutDBI
+	SkipSections bool // See WithoutSections
+	Lazy         bool // See WithLazyTypes
+}
+
+// optionList returns the Option values equivalent to o.
+func (o Options) optionList() []Option {
+	var opts []Option
+	if o.SkipTPI {
+		opts = append(opts, WithoutTPI())
+	}
+	if o.SkipDBI {
+		opts = append(opts, WithoutDBI())
+	}
+	if o.SkipSections {
+		opts = append(opts, WithoutSections())
+	}
+	if o.Lazy {
+		opts = append(opts, WithLazyTypes())
+	}
+	return opts
 }
 
 // Open opens a PDB file and parses its core structures.
-func Open(path string) (*PDB, error) {
-	m, err := msf.Open(path)
+func Open(path string, opts ...Option) (*PDB, error) {
+	return OpenContext(context.Background(), path, opts...)
+}
+
+// OpenWith is like Open with the options given as an Options struct.
+func OpenWith(path string, opts Options) (*PDB, error) {
+	return Open(path, opts.optionList()...)
+}
+
+// OpenContext is like Open but checks ctx between parse phases and
+// returns ctx's error if it is done before the PDB is open.
+func OpenContext(ctx context.Context, path string, opts ...Option) (*PDB, error) {
+	m, err := msf.OpenContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MSF: %w", err)
+	}
+
+	pdb, err := newPDB(ctx, m, opts)
+	if err != nil {
+		m.Close()
+	}
+	return pdb, err
+}
+
+// OpenMmap opens a PDB file by memory-mapping it. This avoids a read
+// system call per block on large files; on platforms without mmap support
+// it behaves like Open.
+func OpenMmap(path string, opts ...Option) (*PDB, error) {
+	m, err := msf.OpenMmap(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MSF: %w", err)
+	}
+
+	pdb, err := newPDB(context.Background(), m, opts)
+	if err != nil {
+		m.Close()
+	}
+	return pdb, err
+}
+
+// OpenReaderAt parses a PDB from an io.ReaderAt of the given size.
+// If r also implements io.Closer, Close closes it. Concurrent use of the
+// PDB requires r to support concurrent ReadAt calls.
+func OpenReaderAt(r io.ReaderAt, size int64, opts ...Option) (*PDB, error) {
+	m, err := msf.OpenReaderAt(r, size)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open MSF: %w", err)
 	}
 
-	pdb := &PDB{msf: m}
+	return newPDB(context.Background(), m, opts)
+}
+
+// newPDB parses the core PDB structures from an opened MSF container.
+// Failures to parse individual streams are not fatal; they are recorded
+// and reported by Warnings; only a failed MSF validation or a done ctx is.
+func newPDB(ctx context.Context, m *msf.MSF, opts []Option) (*PDB, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.validateMSF {
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to validate MSF: %w", err)
+		}
+	}
+	pdb := &PDB{msf: m, rawTypes: o.rawTypes, skipTPI: o.skipTPI}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Parse PDB info stream
 	if m.NumStreams() > StreamPDB {
 		reader, err := m.StreamReader(StreamPDB)
 		if err == nil {
-			pdb.pdbInfo, _ = streams.ReadPDBInfo(reader)
+			pdb.pdbInfo, err = streams.ReadPDBInfo(reader)
 		}
+		if err != nil {
+			pdb.warnf("failed to parse PDB info stream: %w", err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// Parse TPI stream
-	if m.NumStreams() > StreamTPI {
-		stream, err := m.Stream(StreamTPI)
-		if err == nil && stream.Size() > 0 {
-			data, err := stream.ReadAll()
-			if err == nil {
-				pdb.tpi, _ = streams.ReadTPIStream(data)
+	if o.skipTPI {
+		pdb.resolver = codeview.NewTypeResolver(nil)
+	} else if m.NumStreams() > StreamTPI {
+		var err error
+		if o.lazyTypes {
+			err = pdb.openLazyTPI()
+		} else {
+			var data []byte
+			data, err = pdb.readStream(StreamTPI)
+			if err == nil && len(data) > 0 {
+				pdb.tpi, err = streams.ReadTPIStream(data)
 				pdb.resolver = codeview.NewTypeResolver(pdb.tpi)
 			}
 		}
+		if err != nil {
+			pdb.tpiErr = fmt.Errorf("failed to parse TPI stream: %w", err)
+			pdb.warnings = append(pdb.warnings, pdb.tpiErr)
+		} else if pdb.tpi != nil {
+			pdb.loadTPIHash()
+			if bad := pdb.tpi.BadRecords(); len(bad) > 0 {
+				pdb.warnf("TPI stream has %d malformed records, the first at type 0x%x", len(bad), bad[0])
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Parse IPI stream
+	if !o.skipTPI && m.NumStreams() > StreamIPI {
+		data, err := pdb.readStream(StreamIPI)
+		if err == nil && len(data) > 0 {
+			pdb.ipi, err = streams.ReadIPIStream(data)
+		}
+		if err != nil {
+			pdb.warnf("failed to parse IPI stream: %w", err)
+		}
+	}
+	pdb.idResolver = codeview.NewIDResolver(pdb.ipi, pdb.resolver)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// Parse DBI stream
-	if m.NumStreams() > StreamDBI {
-		stream, err := m.Stream(StreamDBI)
-		if err == nil && stream.Size() > 0 {
-			data, err := stream.ReadAll()
-			if err == nil {
-				pdb.dbi, _ = streams.ReadDBIStream(data)
+	if !o.skipDBI && m.NumStreams() > StreamDBI {
+		data, err := pdb.readStream(StreamDBI)
+		if err == nil && len(data) > 0 {
+			pdb.dbi, err = streams.ReadDBIStream(data)
+		}
+		if err != nil {
+			pdb.dbiErr = fmt.Errorf("failed to parse DBI stream: %w", err)
+			pdb.warnings = append(pdb.warnings, pdb.dbiErr)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Load section headers from optional debug header stream
+	if !o.skipSections && pdb.dbi != nil && pdb.dbi.DebugHeader != nil {
+		secHdrStream := int(pdb.dbi.DebugHeader.SectionHdr)
+		if secHdrStream != 0xFFFF {
+			data, err := pdb.readStream(secHdrStream)
+			if err != nil {
+				pdb.warnf("failed to read section header stream: %w", err)
+			} else {
+				pdb.sectionHeaders = streams.ParseSectionHeaders(data)
 			}
 		}
+		pdb.loadOMAP()
+	}
+
+	return pdb, nil
+}
+
+// openLazyTPI opens the TPI stream for on-demand record reads.
+func (p *PDB) openLazyTPI() error {
+	stream, err := p.msf.Stream(StreamTPI)
+	if err != nil || stream.Size() == 0 {
+		return err
+	}
+	p.tpi, err = streams.OpenTPIStreamLazy(stream, int64(stream.Size()))
+	p.resolver = codeview.NewTypeResolver(p.tpi)
+	return err
+}
+
+// loadTPIHash loads the TPI hash stream used for name lookups. Without it
+// lookups fall back to scanning the type records.
+func (p *PDB) loadTPIHash() {
+	index := int(p.tpi.Header.HashStreamIndex)
+	if index == 0xFFFF {
+		return
+	}
+
+	data, err := p.readStream(index)
+	if err == nil {
+		err = p.tpi.LoadHashStream(data)
+	}
+	if err != nil {
+		p.warnf("failed to load TPI hash stream: %w", err)
+	}
+}
+
+// loadOMAP loads the OMAP table and original section headers of binaries
+// whose code was reordered after linking. Symbol addresses in such PDBs
+// refer to the original layout.
+func (p *PDB) loadOMAP() {
+	hdr := p.dbi.DebugHeader
+	if hdr.OmapFromSrc == 0xFFFF || hdr.SectionHdrOrig == 0xFFFF {
+		return
+	}
+
+	data, err := p.readStream(int(hdr.OmapFromSrc))
+	if err != nil {
+		p.warnf("failed to read OMAP stream: %w", err)
+		return
+	}
+	omap := streams.ParseOMAP(data)
+
+	data, err = p.readStream(int(hdr.SectionHdrOrig))
+	if err != nil {
+		p.warnf("failed to read original section header stream: %w", err)
+		return
+	}
+	origHeaders := streams.ParseSectionHeaders(data)
+
+	if len(omap) > 0 && len(origHeaders) > 0 {
+		p.omapFromSrc = omap
+		p.origHeaders = origHeaders
+	}
+
+	// The reverse table is only needed by RVAToSegmentOffset
+	if p.omapFromSrc != nil && hdr.OmapToSrc != 0xFFFF {
+		data, err := p.readStream(int(hdr.OmapToSrc))
+		if err != nil {
+			p.warnf("failed to read OMAP to source stream: %w", err)
+			return
+		}
+		p.omapToSrc = streams.ParseOMAP(data)
+	}
+}
+
+// readStream reads the full contents of the stream at the given index.
+func (p *PDB) readStream(index int) ([]byte, error) {
+	stream, err := p.msf.Stream(index)
+	if err != nil {
+		return nil, err
+	}
+	if stream.Size() == 0 {
+		return nil, nil
+	}
+	data, err := stream.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %d: %w", index, err)
+	}
+	return data, nil
+}
+
+// Stream returns the MSF stream at the given index, for reading its raw
+// contents. Valid indices run from 0 to Info().Streams-1; the error for
+// any other index names that range.
+func (p *PDB) Stream(index int) (*msf.Stream, error) {
+	return p.msf.Stream(index)
+}
+
+// StreamData returns the raw contents of the stream at the given index.
+// Like MSF, it is a low-level escape hatch for streams the rest of the API
+// does not model.
+func (p *PDB) StreamData(index int) ([]byte, error) {
+	return p.readStream(index)
+}
+
+// NamedStream returns the stream registered under name in the PDB info
+// stream, such as "/LinkInfo" or "/src/headerblock". The second return is
+// false if there is no such stream.
+func (p *PDB) NamedStream(name string) (*msf.Stream, bool) {
+	if p.pdbInfo == nil {
+		return nil, false
+	}
+	idx, ok := p.pdbInfo.NamedStreams[name]
+	if !ok {
+		return nil, false
+	}
+	stream, err := p.msf.Stream(int(idx))
+	if err != nil {
+		return nil, false
+	}
+	return stream, true
+}
+
+// NamedStreamData reads the full contents of the stream registered under
+// name in the PDB info stream.
+func (p *PDB) NamedStreamData(name string) ([]byte, error) {
+	if p.pdbInfo == nil {
+		return nil, fmt.Errorf("named stream %q not found: no PDB info stream", name)
+	}
+	idx, ok := p.pdbInfo.NamedStreams[name]
+	if !ok {
+		return nil, fmt.Errorf("named stream %q not found", name)
+	}
+	data, err := p.readStream(int(idx))
+	if err != nil {
+		return nil, fmt.Errorf("named stream %q: %w", name, err)
+	}
+	return data, nil
+}
+
+// EmbeddedSources returns the source files injected into the PDB, as
+// indexed by the /src/headerblock stream. Content is decompressed for the
+// uncompressed and deflate schemes; for other schemes it holds the stored
+// bytes.
+func (p *PDB) EmbeddedSources() []EmbeddedSource {
+	data, err := p.NamedStreamData("/src/headerblock")
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	entries, err := streams.ReadSrcHeaderBlock(data)
+	if err != nil {
+		p.warnf("failed to parse /src/headerblock: %w", err)
+	}
+
+	names := p.namesTable()
+	sources := make([]EmbeddedSource, 0, len(entries))
+	for _, entry := range entries {
+		src := EmbeddedSource{
+			Name:        names.Get(entry.FileName),
+			ObjectName:  names.Get(entry.ObjName),
+			Compression: streams.SourceCompressionName(entry.Compression),
+			IsVirtual:   entry.IsVirtual,
+		}
+
+		vname := names.Get(entry.VirtualFileName)
+		content, err := p.NamedStreamData("/src/files/" + strings.ToLower(vname))
+		if err != nil {
+			p.warnf("embedded source %s: %w", src.Name, err)
+			continue
+		}
+		src.Content, err = decompressSource(entry.Compression, content)
+		if err != nil {
+			p.warnf("embedded source %s: %w", src.Name, err)
+			src.Content = content
+		}
+		sources = append(sources, src)
+	}
+
+	return sources
+}
+
+// LinkInfo returns the linker's working directory and command line from
+// the /LinkInfo stream, or nil if the PDB has none, as is common for
+// release builds.
+func (p *PDB) LinkInfo() *LinkInfo {
+	p.linkInfoOnce.Do(p.loadLinkInfo)
+	return p.linkInfo
+}
+
+// loadLinkInfo parses the /LinkInfo stream, if present.
+func (p *PDB) loadLinkInfo() {
+	if _, ok := p.NamedStream("/LinkInfo"); !ok {
+		return
+	}
+	data, err := p.NamedStreamData("/LinkInfo")
+	if err != nil {
+		p.warnf("failed to read /LinkInfo: %w", err)
+		return
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	info, err := streams.ParseLinkInfo(data)
+	if err != nil {
+		p.warnf("failed to parse /LinkInfo: %w", err)
+		return
+	}
+	p.linkInfo = &LinkInfo{
+		CWD:        info.CWD,
+		Command:    info.Command,
+		OutputFile: info.OutputFile,
+		LibPaths:   info.Libs,
+	}
+}
+
+// decompressSource decodes the contents of an injected source stream.
+// Schemes other than none and deflate are returned as stored.
+func decompressSource(compression uint8, data []byte) ([]byte, error) {
+	if compression != streams.SourceCompressionDotNet {
+		return data, nil
+	}
+
+	// A zero uncompressed size marks contents stored as-is
+	if len(data) < 4 {
+		return nil, fmt.Errorf("deflate source too small: %d bytes", len(data))
+	}
+	size := binary.LittleEndian.Uint32(data)
+	if size == 0 {
+		return data[4:], nil
+	}
+
+	content, err := io.ReadAll(flate.NewReader(bytes.NewReader(data[4:])))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate source: %w", err)
+	}
+	return content, nil
+}
+
+// warnf records a non-fatal parse failure.
+func (p *PDB) warnf(format string, args ...interface{}) {
+	p.warnMu.Lock()
+	defer p.warnMu.Unlock()
+	p.warnings = append(p.warnings, fmt.Errorf(format, args...))
+}
+
+// Warnings returns the non-fatal parse failures encountered while opening
+// the PDB or lazily loading its streams.
+func (p *PDB) Warnings() []error {
+	p.warnMu.Lock()
+	defer p.warnMu.Unlock()
+	return append([]error(nil), p.warnings...)
+}
+
+// namesTable returns the /names string table, loading it on first use.
+// It returns nil if the PDB has no /names stream.
+func (p *PDB) namesTable() *streams.NamesTable {
+	p.namesOnce.Do(p.loadNames)
+	return p.names
+}
+
+// loadNames reads and parses the /names stream.
+func (p *PDB) loadNames() {
+	if p.pdbInfo == nil {
+		return
+	}
+	idx, ok := p.pdbInfo.NamedStreams["/names"]
+	if !ok {
+		return
+	}
+
+	data, err := p.readStream(int(idx))
+	if err == nil && len(data) > 0 {
+		p.names, err = streams.ReadNamesStream(data)
+	}
+	if err != nil {
+		p.warnf("failed to parse /names stream: %w", err)
+	}
+}
+
+// Close closes the PDB file, or the reader passed to OpenReaderAt if it
+// implements io.Closer.
+func (p *PDB) Close() error {
+	if p.msf != nil {
+		return p.msf.Close()
+	}
+	return nil
+}
+
+// Info returns basic PDB file information.
+func (p *PDB) Info() *PDBInfo {
+	info := &PDBInfo{
+		Streams: p.msf.NumStreams(),
+	}
+
+	if p.pdbInfo != nil {
+		info.GUID = p.pdbInfo.GUIDString()
+		info.Age = p.pdbInfo.Age
+		info.Version = p.pdbInfo.Version
+		info.NamedStreams = p.pdbInfo.NamedStreams
+	}
+
+	if p.dbi != nil {
+		info.Machine = streams.MachineTypeName(p.dbi.Header.Machine)
+		info.Toolset = streams.BuildNumberString(p.dbi.Header.BuildNumber)
+		info.IncrementallyLinked = p.dbi.Header.Flags&streams.DBIFlagIncrementallyLinked != 0
+		info.PrivateSymbolsStripped = p.dbi.Header.Flags&streams.DBIFlagPrivateSymbolsStripped != 0
+		info.HasConflictingTypes = p.dbi.Header.Flags&streams.DBIFlagConflictingTypes != 0
+	}
+
+	return info
+}
+
+// MSF returns the underlying MSF container, for low-level access to its
+// superblock and streams, such as the optional debug streams or
+// "/LinkInfo". This is a low-level API: the msf package follows the file
+// format rather than this package's abstractions and may change between
+// releases. The container is shared with p and must not be closed.
+func (p *PDB) MSF() *msf.MSF {
+	return p.msf
+}
+
+// Streams lists every stream of the MSF container with its size and block
+// count, naming the streams whose role is known from the PDB info, TPI and
+// DBI streams.
+func (p *PDB) Streams() []StreamInfo {
+	names := p.streamNames()
+	result := make([]StreamInfo, p.msf.NumStreams())
+	for i := range result {
+		size, blocks, _ := p.msf.StreamInfo(i)
+		result[i] = StreamInfo{Index: i, Size: size, Blocks: len(blocks), Name: names[i]}
+		if size == msf.NilStreamSize {
+			result[i].Size = 0
+			result[i].Unused = true
+		}
+	}
+	return result
+}
+
+// streamNames maps stream indices to a short description of their content.
+func (p *PDB) streamNames() map[int]string {
+	names := map[int]string{
+		0:         "old directory",
+		StreamPDB: "PDB info",
+		StreamTPI: "TPI",
+		StreamDBI: "DBI",
+		StreamIPI: "IPI",
+	}
+	set := func(index uint16, name string) {
+		if index != 0xFFFF && index != 0 {
+			names[int(index)] = name
+		}
+	}
+
+	if p.pdbInfo != nil {
+		for name, index := range p.pdbInfo.NamedStreams {
+			names[int(index)] = name
+		}
+	}
+	if p.tpi != nil {
+		set(p.tpi.Header.HashStreamIndex, "TPI hash")
+		set(p.tpi.Header.HashAuxStreamIndex, "TPI aux hash")
+	}
+	if p.ipi != nil {
+		set(p.ipi.Header.HashStreamIndex, "IPI hash")
+		set(p.ipi.Header.HashAuxStreamIndex, "IPI aux hash")
+	}
+	if p.dbi != nil {
+		set(p.dbi.Header.GlobalStreamIndex, "globals")
+		set(p.dbi.Header.PublicStreamIndex, "publics")
+		set(p.dbi.Header.SymRecordStream, "symbol records")
+		if h := p.dbi.DebugHeader; h != nil {
+			set(h.FPO, "FPO")
+			set(h.Exception, "exception data")
+			set(h.Fixup, "fixups")
+			set(h.OmapToSrc, "OMAP to source")
+			set(h.OmapFromSrc, "OMAP from source")
+			set(h.SectionHdr, "section headers")
+			set(h.TokenRidMap, "token RID map")
+			set(h.Xdata, "xdata")
+			set(h.Pdata, "pdata")
+			set(h.NewFPO, "frame data")
+			set(h.SectionHdrOrig, "original section headers")
+		}
+		for _, mod := range p.dbi.Modules {
+			set(mod.ModuleSymStream, "module "+mod.ModuleName)
+		}
+	}
+	return names
+}
+
+// Identity returns the raw GUID, age and signature recorded in the PDB
+// info stream, or zero values if it could not be parsed.
+func (p *PDB) Identity() (guid [16]byte, age uint32, signature uint32) {
+	if p.pdbInfo == nil {
+		return guid, 0, 0
+	}
+	return p.pdbInfo.GUID, p.pdbInfo.Age, p.pdbInfo.Signature
+}
+
+// Matches reports whether the PDB belongs to an executable whose CodeView
+// debug record carries the given GUID and age. The GUID is compared as raw
+// bytes and the age against the PDB info stream, which is authoritative
+// for matching.
+func (p *PDB) Matches(guid [16]byte, age uint32) bool {
+	if p.pdbInfo == nil {
+		return false
+	}
+	return p.pdbInfo.GUID == guid && p.pdbInfo.Age == age
+}
+
+// SymbolServerPath returns the relative symbol-server path of the PDB,
+// "<name>/<GUID><Age>/<name>", as used by symsrv and symchk. The GUID is
+// formatted with its first three fields as little-endian integers, and the
+// age is appended in unpadded uppercase hex. As for Identity and Matches,
+// the age is the PDB info stream's; the DBI header keeps a copy of it that
+// is not updated when tools rewrite the info stream, so it can lag behind
+// and is not used. Directory components of pdbName are dropped. Returns ""
+// if the PDB info stream could not be parsed.
+func (p *PDB) SymbolServerPath(pdbName string) string {
+	if p.pdbInfo == nil {
+		return ""
+	}
+
+	if i := strings.LastIndexAny(pdbName, `/\`); i >= 0 {
+		pdbName = pdbName[i+1:]
+	}
+
+	return fmt.Sprintf("%s/%s%X/%s", pdbName, p.pdbInfo.GUIDString(), p.pdbInfo.Age, pdbName)
+}
+
+// Functions returns all functions found in the PDB.
+// Parse failures are dropped; use FunctionsE to observe them.
+func (p *PDB) Functions() []Function {
+	functions, _ := p.FunctionsE()
+	return functions
+}
+
+// FunctionsE returns all functions found in the PDB, along with any errors
+// encountered while reading symbol streams. The returned slice holds
+// everything that could be parsed, even when the error is non-nil.
+func (p *PDB) FunctionsE() ([]Function, error) {
+	p.functionsOnce.do(context.Background(), p.loadFunctions)
+	return p.functions, p.functionsErr
+}
+
+// loadFunctions parses the procedure symbols of all symbol streams into the
+// function cache. It returns ctx's error, leaving the cache unbuilt, if ctx
+// is done first.
+func (p *PDB) loadFunctions(ctx context.Context) error {
+	functions := make([]Function, 0)
+	err := p.walkFunctions(cancelCheck(ctx), func(fn *Function) error {
+		functions = append(functions, *fn)
+		return nil
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	p.functions, p.functionsErr = functions, err
+	return nil
+}
+
+// WalkFunctions calls fn for each function of the symbol streams, in the
+// order Functions lists them, without building the function cache. This
+// keeps memory flat for callers that consume functions one at a time. If
+// fn returns an error the walk stops and that error is returned; otherwise
+// the result is the same as the error from FunctionsE.
+func (p *PDB) WalkFunctions(fn func(*Function) error) error {
+	return p.walkFunctions(func() error { return nil }, fn)
+}
+
+// walkFunctions implements WalkFunctions. check is consulted before each
+// symbol; a non-nil result stops the walk like an error from fn.
+func (p *PDB) walkFunctions(check func() error, fn func(*Function) error) error {
+	var errs []error
+
+	// A module procedure is held back until its S_FRAMEPROC, or the next
+	// procedure, has been seen.
+	var pending *Function
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		f := pending
+		pending = nil
+		return fn(f)
+	}
+
+	var stop error
+	walkErr := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if stop = check(); stop != nil {
+			return stop
+		}
+		switch {
+		case codeview.IsProcSymbol(sym.Kind):
+			if stop = flush(); stop != nil {
+				return stop
+			}
+			f, err := p.newFunction(sym, module)
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			if module == "" {
+				stop = fn(f)
+				return stop
+			}
+			pending = f
+		case sym.Kind == codeview.S_FRAMEPROC && pending != nil:
+			if frame, err := codeview.ParseFrameProcSym(sym.Data); err == nil {
+				pending.Frame = p.frameInfo(frame)
+			}
+			stop = flush()
+			return stop
+		}
+		return nil
+	})
+	if stop != nil {
+		return stop
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	errs = append(errs, walkErr)
+
+	return errors.Join(errs...)
+}
+
+// newFunction builds a Function from a procedure symbol of module.
+func (p *PDB) newFunction(sym codeview.SymbolRecord, module string) (*Function, error) {
+	proc, err := codeview.ParseProcSymKind(sym.Kind, sym.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", codeview.SymbolKindName(sym.Kind), err)
+	}
+	fn := &Function{
+		Name:      proc.Name,
+		Offset:    proc.Offset,
+		Segment:   proc.Segment,
+		RVA:       p.SegmentToRVA(proc.Segment, proc.Offset),
+		Length:    proc.Length,
+		TypeIndex: proc.TypeIndex,
+		IsGlobal:  codeview.IsGlobalSymbol(sym.Kind),
+		Module:    module,
+	}
+	if demangled := p.demangle(proc.Name); demangled.Name != proc.Name {
+		fn.DemangledName = demangled.Name
+		fn.Prototype = demangled.Prototype
+	}
+	fn.Signature = p.procSignature(sym.Kind, proc.TypeIndex)
+	return fn, nil
+}
+
+// frameInfo converts a parsed S_FRAMEPROC record into a FrameInfo.
+func (p *PDB) frameInfo(frame *codeview.FrameProcSym) *FrameInfo {
+	var machine uint16
+	if p.dbi != nil {
+		machine = p.dbi.Header.Machine
+	}
+
+	return &FrameInfo{
+		TotalFrameBytes:         frame.TotalFrameBytes,
+		PaddingBytes:            frame.PaddingBytes,
+		PaddingOffset:           frame.PaddingOffset,
+		CalleeSavesBytes:        frame.CalleeSavesBytes,
+		ExceptionHandlerOffset:  frame.ExceptionHandlerOffset,
+		ExceptionHandlerSection: frame.ExceptionHandlerSection,
+		Flags:                   frame.Flags,
+		HasSEH:                  frame.Flags&codeview.FrameHasSEH != 0,
+		HasAsyncEH:              frame.Flags&codeview.FrameAsyncEH != 0,
+		Naked:                   frame.Flags&codeview.FrameNaked != 0,
+		LocalBasePointer:        codeview.BasePointerName(frame.LocalBasePointer(), machine),
+		ParamBasePointer:        codeview.BasePointerName(frame.ParamBasePointer(), machine),
+	}
+}
+
+// Thunks returns the thunks (S_THUNK32) of all module symbol streams:
+// incremental link stubs, this-adjustors, vcall thunks and the like.
+func (p *PDB) Thunks() []Thunk {
+	p.thunksOnce.Do(p.loadThunks)
+	return p.thunks
+}
+
+// loadThunks builds the thunk cache.
+func (p *PDB) loadThunks() {
+	p.thunks = make([]Thunk, 0)
+
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if sym.Kind != codeview.S_THUNK32 {
+			return nil
+		}
+		thunk, err := codeview.ParseThunkSym(sym.Data)
+		if err != nil {
+			return nil
+		}
+		p.thunks = append(p.thunks, Thunk{
+			Name:          thunk.Name,
+			Kind:          codeview.ThunkOrdinalName(thunk.Ordinal),
+			Ordinal:       thunk.Ordinal,
+			Offset:        thunk.Offset,
+			Segment:       thunk.Segment,
+			RVA:           p.SegmentToRVA(thunk.Segment, thunk.Offset),
+			Length:        thunk.Length,
+			Module:        module,
+			AdjustorDelta: thunk.AdjustorDelta,
+			Target:        thunk.AdjustorTarget,
+			VCallOffset:   thunk.VCallOffset,
+		})
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read thunk symbols: %w", err)
+	}
+}
+
+// Labels returns the code labels (S_LABEL32) of all module symbol
+// streams: jump targets the compiler named and labels of __asm blocks.
+func (p *PDB) Labels() []Label {
+	p.labelsOnce.Do(p.loadLabels)
+	return p.labels
+}
+
+// loadLabels builds the label cache.
+func (p *PDB) loadLabels() {
+	p.labels = make([]Label, 0)
+
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if sym.Kind != codeview.S_LABEL32 && sym.Kind != codeview.S_LABEL32_ST {
+			return nil
+		}
+		label, err := codeview.ParseLabelSymKind(sym.Kind, sym.Data)
+		if err != nil {
+			return nil
+		}
+		p.labels = append(p.labels, Label{
+			Name:       label.Name,
+			Offset:     label.Offset,
+			Segment:    label.Segment,
+			RVA:        p.SegmentToRVA(label.Segment, label.Offset),
+			Flags:      label.Flags,
+			NoReturn:   label.Flags&codeview.ProcNeverReturn != 0,
+			NotReached: label.Flags&codeview.ProcNotReached != 0,
+			FarReturn:  label.Flags&codeview.ProcFarReturn != 0,
+			Module:     module,
+		})
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read label symbols: %w", err)
+	}
+}
+
+// SeparatedCode returns the separated code ranges (S_SEPCODE) of all
+// module symbol streams, sorted by RVA. Each range is a piece of a function
+// moved away from it by hot/cold splitting or folding, such as /OPT:ICF or
+// PGO builds produce.
+func (p *PDB) SeparatedCode() []SeparatedCode {
+	p.sepCodeOnce.Do(p.loadSepCode)
+	return p.sepCode
+}
+
+// loadSepCode builds the separated code cache.
+func (p *PDB) loadSepCode() {
+	p.sepCode = make([]SeparatedCode, 0)
+
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if sym.Kind != codeview.S_SEPCODE {
+			return nil
+		}
+		sep, err := codeview.ParseSepCodeSym(sym.Data)
+		if err != nil {
+			return nil
+		}
+		p.sepCode = append(p.sepCode, SeparatedCode{
+			Offset:          sep.Offset,
+			Segment:         sep.Segment,
+			RVA:             p.SegmentToRVA(sep.Segment, sep.Offset),
+			Length:          sep.Length,
+			ParentOffset:    sep.ParentOffset,
+			ParentSegment:   sep.ParentSegment,
+			ParentRVA:       p.SegmentToRVA(sep.ParentSegment, sep.ParentOffset),
+			Module:          module,
+			IsLexicalScope:  sep.Flags&codeview.SepCodeIsLexicalScope != 0,
+			ReturnsToParent: sep.Flags&codeview.SepCodeReturnsToParent != 0,
+		})
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read separated code symbols: %w", err)
+	}
+
+	for i := range p.sepCode {
+		sep := &p.sepCode[i]
+		if fn := p.functionAtRVA(sep.ParentRVA); fn != nil && fn.RVA == sep.ParentRVA {
+			sep.Parent = fn.Name
+		}
+	}
+	sort.SliceStable(p.sepCode, func(a, b int) bool {
+		return p.sepCode[a].RVA < p.sepCode[b].RVA
+	})
+}
+
+// SeparatedCodeAtRVA returns the separated code range containing the
+// given RVA, or nil if there is none.
+func (p *PDB) SeparatedCodeAtRVA(rva uint32) *SeparatedCode {
+	ranges := p.SeparatedCode()
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].RVA > rva
+	}) - 1
+	if i < 0 {
+		return nil
+	}
+
+	sep := &ranges[i]
+	if sep.RVA == 0 || rva-sep.RVA >= sep.Length {
+		return nil
+	}
+	return sep
+}
+
+// Annotations returns the __annotation() strings (S_ANNOTATION) of all
+// module symbol streams. Records only reachable through an S_ANNOTATIONREF
+// in the global symbol stream are included as well.
+func (p *PDB) Annotations() []Annotation {
+	p.annotsOnce.Do(p.loadAnnotations)
+	return p.annots
+}
+
+// loadAnnotations builds the annotation cache.
+func (p *PDB) loadAnnotations() {
+	p.annots = make([]Annotation, 0)
+
+	seen := make(map[string]bool)
+	add := func(sym codeview.SymbolRecord, module string) {
+		annotation, err := codeview.ParseAnnotationSym(sym.Data)
+		if err != nil {
+			return
+		}
+		key := fmt.Sprintf("%s\x00%d:%d\x00%s", module, annotation.Segment, annotation.Offset, strings.Join(annotation.Strings, "\x00"))
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		p.annots = append(p.annots, Annotation{
+			Offset:  annotation.Offset,
+			Segment: annotation.Segment,
+			RVA:     p.SegmentToRVA(annotation.Segment, annotation.Offset),
+			Strings: annotation.Strings,
+			Module:  module,
+		})
+	}
+
+	var refs []*codeview.RefSym
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		switch sym.Kind {
+		case codeview.S_ANNOTATION:
+			add(sym, module)
+		case codeview.S_ANNOTATIONREF:
+			if ref, err := codeview.ParseRefSym(sym.Data); err == nil {
+				refs = append(refs, ref)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read annotation symbols: %w", err)
+	}
+
+	// References normally point at records found above; following them
+	// still recovers records past a malformed one, which ends a module walk
+	modData := make(map[uint16][]byte)
+	for _, ref := range refs {
+		if p.dbi == nil || ref.Module == 0 || int(ref.Module) > len(p.dbi.Modules) {
+			continue
+		}
+		mod := &p.dbi.Modules[ref.Module-1]
+		data, ok := modData[ref.Module]
+		if !ok {
+			data, _ = p.moduleSymbolData(mod)
+			modData[ref.Module] = data
+		}
+		sym, err := codeview.SymbolAt(data, ref.SymOffset)
+		if err == nil && sym.Kind == codeview.S_ANNOTATION {
+			add(sym, mod.ModuleName)
+		}
+	}
+}
+
+// Exports returns the DLL exports recorded by the linker (S_EXPORT).
+func (p *PDB) Exports() []Export {
+	p.exportsOnce.Do(p.loadExports)
+	return p.exports
+}
+
+// loadExports builds the export cache.
+func (p *PDB) loadExports() {
+	p.exports = make([]Export, 0)
+
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if sym.Kind != codeview.S_EXPORT {
+			return nil
+		}
+		export, err := codeview.ParseExportSym(sym.Data)
+		if err != nil {
+			return nil
+		}
+		p.exports = append(p.exports, Export{
+			Ordinal:     export.Ordinal,
+			Name:        export.Name,
+			IsForwarder: export.Flags&codeview.ExportForwarder != 0,
+			IsConstant:  export.Flags&codeview.ExportConstant != 0,
+			IsData:      export.Flags&codeview.ExportData != 0,
+			IsPrivate:   export.Flags&codeview.ExportPrivate != 0,
+			IsNoName:    export.Flags&codeview.ExportNoName != 0,
+		})
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read export symbols: %w", err)
+	}
+}
+
+// HeapAllocSites returns the heap allocation call sites
+// (S_HEAPALLOCSITE) of all module symbol streams, each with the type of
+// the object it allocates.
+func (p *PDB) HeapAllocSites() []HeapAllocSite {
+	p.heapSiteOnce.Do(p.loadHeapAllocSites)
+	return p.heapSites
+}
+
+// loadHeapAllocSites builds the heap allocation site cache.
+func (p *PDB) loadHeapAllocSites() {
+	p.heapSites = make([]HeapAllocSite, 0)
+
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if sym.Kind != codeview.S_HEAPALLOCSITE {
+			return nil
+		}
+		site, err := codeview.ParseHeapAllocSiteSym(sym.Data)
+		if err != nil {
+			return nil
+		}
+
+		hs := HeapAllocSite{
+			Offset:            site.Offset,
+			Segment:           site.Segment,
+			RVA:               p.SegmentToRVA(site.Segment, site.Offset),
+			InstructionLength: site.InstructionLength,
+			TypeIndex:         site.TypeIndex,
+			Module:            module,
+		}
+		if p.resolver != nil {
+			hs.TypeName = p.resolver.ResolveType(site.TypeIndex)
+		} else {
+			hs.TypeName = streams.GetBuiltinTypeName(site.TypeIndex)
+		}
+		p.heapSites = append(p.heapSites, hs)
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read heap allocation site symbols: %w", err)
+	}
+}
+
+// CallGraph returns the call edges recorded by S_CALLEES and S_CALLERS
+// records, mapping each caller's name to the sorted names of its callees.
+// These records are only emitted for whole-program optimized builds.
+func (p *PDB) CallGraph() map[string][]string {
+	p.callGraphOnce.Do(p.loadCallGraph)
+	return p.callGraph
+}
+
+// loadCallGraph builds the call graph cache.
+func (p *PDB) loadCallGraph() {
+	edges := make(map[string]map[string]bool)
+	addEdge := func(caller, callee string) {
+		if edges[caller] == nil {
+			edges[caller] = make(map[string]bool)
+		}
+		edges[caller][callee] = true
+	}
+
+	current := "" // Procedure owning the next function list
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		switch {
+		case codeview.IsProcSymbol(sym.Kind):
+			current = ""
+			if proc, err := codeview.ParseProcSymKind(sym.Kind, sym.Data); err == nil {
+				current = proc.Name
+			}
+		case (sym.Kind == codeview.S_CALLEES || sym.Kind == codeview.S_CALLERS) && current != "":
+			list, err := codeview.ParseFunctionListSym(sym.Data)
+			if err != nil {
+				return nil
+			}
+			for _, id := range list.Functions {
+				name := p.idResolver.ResolveID(id)
+				if sym.Kind == codeview.S_CALLEES {
+					addEdge(current, name)
+				} else {
+					addEdge(name, current)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read call graph symbols: %w", err)
+	}
+
+	p.callGraph = make(map[string][]string, len(edges))
+	for caller, callees := range edges {
+		names := make([]string, 0, len(callees))
+		for callee := range callees {
+			names = append(names, callee)
+		}
+		sort.Strings(names)
+		p.callGraph[caller] = names
+	}
+}
+
+// LocalsForFunction returns the local variables and parameters of a function,
+// recovered from the S_LOCAL and S_DEFRANGE_* records between the function's
+// procedure symbol and its matching S_END. Locals of inlined callees are
+// not included.
+func (p *PDB) LocalsForFunction(fn *Function) []LocalVar {
+	if fn == nil || p.dbi == nil {
+		return nil
+	}
+
+	for i := range p.dbi.Modules {
+		mod := &p.dbi.Modules[i]
+		if mod.ModuleName != fn.Module {
+			continue
+		}
+
+		symbols, _ := p.moduleSymbols(mod)
+		if j := findProc(symbols, fn); j >= 0 {
+			return p.collectLocals(symbols[j+1:])
+		}
+	}
+
+	return nil
+}
+
+// findProc returns the index of the procedure symbol describing fn, or -1.
+func findProc(symbols []codeview.SymbolRecord, fn *Function) int {
+	for j, sym := range symbols {
+		if !codeview.IsProcSymbol(sym.Kind) {
+			continue
+		}
+		proc, err := codeview.ParseProcSymKind(sym.Kind, sym.Data)
+		if err != nil || proc.Segment != fn.Segment || proc.Offset != fn.Offset || proc.Name != fn.Name {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// Blocks returns the lexical blocks (S_BLOCK32) nested in a function, in
+// the order they appear. Each block names its enclosing block, so the
+// result forms a scope tree; LocalVar.Block indexes into it. Blocks of
+// inlined callees are not included.
+func (p *PDB) Blocks(fn *Function) []Block {
+	if fn == nil || p.dbi == nil {
+		return nil
+	}
+
+	for i := range p.dbi.Modules {
+		mod := &p.dbi.Modules[i]
+		if mod.ModuleName != fn.Module {
+			continue
+		}
+
+		symbols, _ := p.moduleSymbols(mod)
+		if j := findProc(symbols, fn); j >= 0 {
+			return p.collectBlocks(symbols[j+1:])
+		}
+	}
+
+	return nil
+}
+
+// collectBlocks walks the symbols following a procedure symbol up to its
+// matching scope end, nesting blocks by their matching S_END.
+func (p *PDB) collectBlocks(symbols []codeview.SymbolRecord) []Block {
+	const inlineScope = -2
+
+	var blocks []Block
+	var stack []int // Index into blocks of each open scope, -1 or inlineScope
+	inlineDepth := 0
+
+	for _, sym := range symbols {
+		switch {
+		case codeview.IsScopeEnd(sym.Kind):
+			if len(stack) == 0 {
+				return blocks
+			}
+			if stack[len(stack)-1] == inlineScope {
+				inlineDepth--
+			}
+			stack = stack[:len(stack)-1]
+
+		case sym.Kind == codeview.S_INLINESITE || sym.Kind == codeview.S_INLINESITE2:
+			inlineDepth++
+			stack = append(stack, inlineScope)
+
+		case codeview.IsScopeStart(sym.Kind):
+			if sym.Kind != codeview.S_BLOCK32 || inlineDepth > 0 {
+				stack = append(stack, -1)
+				continue
+			}
+
+			parent := -1
+			for k := len(stack) - 1; k >= 0; k-- {
+				if stack[k] >= 0 {
+					parent = stack[k]
+					break
+				}
+			}
+			stack = append(stack, len(blocks))
+
+			block := Block{Parent: parent}
+			if b, err := codeview.ParseBlockSym(sym.Data); err == nil {
+				block.Name = b.Name
+				block.RVA = p.SegmentToRVA(b.Segment, b.Offset)
+				block.Length = b.Length
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks
+}
+
+// InlineSitesForFunction returns the call sites inlined into a function,
+// recovered from the S_INLINESITE records nested in its scope. Line
+// ranges are decoded from each site's binary annotations, starting at the
+// inlinee's definition line from the module's DEBUG_S_INLINEELINES.
+func (p *PDB) InlineSitesForFunction(fn *Function) []InlineSite {
+	if fn == nil || p.dbi == nil {
+		return nil
+	}
+
+	for i := range p.dbi.Modules {
+		mod := &p.dbi.Modules[i]
+		if mod.ModuleName != fn.Module {
+			continue
+		}
+
+		symbols, _ := p.moduleSymbols(mod)
+		if j := findProc(symbols, fn); j >= 0 {
+			return p.collectInlineSites(mod, fn, symbols[j+1:])
+		}
+	}
+
+	return nil
+}
+
+// collectInlineSites walks the symbols following a procedure symbol up to
+// its matching scope end and decodes each inline site.
+func (p *PDB) collectInlineSites(mod *streams.ModuleInfo, fn *Function, symbols []codeview.SymbolRecord) []InlineSite {
+	var checksums map[uint32]codeview.FileChecksum
+	inlinees := make(map[uint32]codeview.InlineeSourceLine)
+	for _, sub := range p.moduleSubsections(mod) {
+		switch sub.Kind {
+		case codeview.DEBUG_S_FILECHECKSUMS:
+			checksums = codeview.ParseFileChecksums(sub.Data)
+		case codeview.DEBUG_S_INLINEELINES:
+			parsed, err := codeview.ParseInlineeLines(sub.Data)
+			if err != nil {
+				continue
+			}
+			for id, line := range parsed {
+				inlinees[id] = line
+			}
+		}
+	}
+
+	fileName := func(fileID uint32) string {
+		if fc, ok := checksums[fileID]; ok {
+			return p.namesTable().Get(fc.FileNameOffset)
+		}
+		return ""
+	}
+
+	var sites []InlineSite
+	var stack []int // Index into sites of each open scope, or -1
+
+	for _, sym := range symbols {
+		switch {
+		case codeview.IsScopeEnd(sym.Kind):
+			if len(stack) == 0 {
+				return sites
+			}
+			stack = stack[:len(stack)-1]
+
+		case sym.Kind == codeview.S_INLINESITE || sym.Kind == codeview.S_INLINESITE2:
+			parent := -1
+			for k := len(stack) - 1; k >= 0; k-- {
+				if stack[k] >= 0 {
+					parent = stack[k]
+					break
+				}
+			}
+			stack = append(stack, len(sites))
+
+			var site *codeview.InlineSiteSym
+			var err error
+			if sym.Kind == codeview.S_INLINESITE2 {
+				site, err = codeview.ParseInlineSite2Sym(sym.Data)
+			} else {
+				site, err = codeview.ParseInlineSiteSym(sym.Data)
+			}
+			if err != nil {
+				sites = append(sites, InlineSite{Parent: parent})
+				continue
+			}
+
+			is := InlineSite{
+				Name:    p.idResolver.ResolveID(site.Inlinee),
+				Inlinee: site.Inlinee,
+				Parent:  parent,
+			}
+			src := inlinees[site.Inlinee]
+			// A malformed stream still yields the annotations before the error
+			annotations, _ := codeview.ParseBinaryAnnotations(site.BinaryAnnotations)
+			for _, entry := range codeview.InlineLines(annotations, src.FileID, src.SourceLine) {
+				is.Lines = append(is.Lines, InlineLineInfo{
+					RVA:        fn.RVA + entry.CodeOffset,
+					Length:     entry.Length,
+					FileName:   fileName(entry.FileID),
+					LineNumber: entry.LineNumber,
+				})
+			}
+			sites = append(sites, is)
+
+		case codeview.IsScopeStart(sym.Kind):
+			stack = append(stack, -1)
+		}
+	}
+
+	return sites
+}
+
+// localScope is an open scope while collecting locals.
+type localScope struct {
+	kind  uint16
+	block *codeview.BlockSym
+	index int // Index of the block in collectBlocks order, or -1
+}
+
+// collectLocals walks the symbols following a procedure symbol up to its
+// matching scope end and gathers S_LOCAL records with their def-ranges,
+// and the S_REGREL32 and S_REGISTER records that carry their own location.
+func (p *PDB) collectLocals(symbols []codeview.SymbolRecord) []LocalVar {
+	var locals []LocalVar
+	var stack []localScope
+	inlineDepth := 0
+	numBlocks := 0
+	var current *LocalVar
+
+	for _, sym := range symbols {
+		switch {
+		case codeview.IsScopeEnd(sym.Kind):
+			current = nil
+			if len(stack) == 0 {
+				return locals
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top.kind == codeview.S_INLINESITE || top.kind == codeview.S_INLINESITE2 {
+				inlineDepth--
+			}
+
+		case codeview.IsScopeStart(sym.Kind):
+			current = nil
+			sc := localScope{kind: sym.Kind, index: -1}
+			if sym.Kind == codeview.S_BLOCK32 {
+				sc.block, _ = codeview.ParseBlockSym(sym.Data)
+				if inlineDepth == 0 {
+					sc.index = numBlocks
+					numBlocks++
+				}
+			}
+			if sym.Kind == codeview.S_INLINESITE || sym.Kind == codeview.S_INLINESITE2 {
+				inlineDepth++
+			}
+			stack = append(stack, sc)
+
+		case sym.Kind == codeview.S_LOCAL:
+			current = nil
+			if inlineDepth > 0 {
+				continue
+			}
+			local, err := codeview.ParseLocalSym(sym.Data)
+			if err != nil {
+				continue
+			}
+			lv := p.newLocal(local.Name, local.TypeIndex, stack)
+			lv.IsParam = local.Flags&codeview.LocalIsParam != 0
+			lv.Flags = local.Flags
+			locals = append(locals, lv)
+			current = &locals[len(locals)-1]
+
+		case sym.Kind == codeview.S_REGREL32:
+			current = nil
+			if inlineDepth > 0 {
+				continue
+			}
+			rel, err := codeview.ParseRegRel32Sym(sym.Data)
+			if err != nil {
+				continue
+			}
+			lv := p.newLocal(rel.Name, rel.TypeIndex, stack)
+			lv.Locations = []LocalLocation{{
+				Kind:         "register_relative",
+				Register:     rel.Register,
+				RegisterName: p.registerName(rel.Register),
+				Offset:       rel.Offset,
+				FullScope:    true,
+			}}
+			locals = append(locals, lv)
+
+		case sym.Kind == codeview.S_REGISTER_NEW:
+			current = nil
+			if inlineDepth > 0 {
+				continue
+			}
+			reg, err := codeview.ParseRegisterSym(sym.Data)
+			if err != nil {
+				continue
+			}
+			lv := p.newLocal(reg.Name, reg.TypeIndex, stack)
+			lv.Locations = []LocalLocation{{
+				Kind:         "register",
+				Register:     reg.Register,
+				RegisterName: p.registerName(reg.Register),
+				FullScope:    true,
+			}}
+			locals = append(locals, lv)
+
+		case codeview.IsDefRangeSymbol(sym.Kind):
+			if current == nil {
+				continue
+			}
+			def, err := codeview.ParseDefRangeSym(sym.Kind, sym.Data)
+			if err != nil {
+				continue
+			}
+			current.Locations = append(current.Locations, p.localLocation(def))
+		}
+	}
+
+	return locals
+}
+
+// newLocal returns a LocalVar with its type resolved and its enclosing
+// block taken from the innermost block on the scope stack.
+func (p *PDB) newLocal(name string, typeIndex uint32, stack []localScope) LocalVar {
+	lv := LocalVar{
+		Name:      name,
+		TypeIndex: typeIndex,
+		Block:     -1,
+	}
+	if p.resolver != nil {
+		lv.TypeName = p.resolver.ResolveType(typeIndex)
+	}
+	for k := len(stack) - 1; k >= 0; k-- {
+		if b := stack[k].block; b != nil {
+			lv.BlockRVA = p.SegmentToRVA(b.Segment, b.Offset)
+			lv.BlockLength = b.Length
+			lv.Block = stack[k].index
+			break
+		}
+	}
+	return lv
+}
+
+// registerName returns the name of a CodeView register on the PDB's
+// target machine.
+func (p *PDB) registerName(reg uint16) string {
+	var machine uint16
+	if p.dbi != nil {
+		machine = p.dbi.Header.Machine
+	}
+	return codeview.RegisterName(reg, machine)
+}
+
+// localLocation converts a parsed def-range into a LocalLocation.
+func (p *PDB) localLocation(def *codeview.DefRangeSym) LocalLocation {
+	loc := LocalLocation{
+		Register:     def.Register,
+		Offset:       def.Offset,
+		OffsetParent: def.OffsetParent,
+		FullScope:    def.FullScope,
+	}
+
+	switch def.Kind {
+	case codeview.S_DEFRANGE_REGISTER:
+		loc.Kind = "register"
+	case codeview.S_DEFRANGE_SUBFIELD_REGISTER:
+		loc.Kind = "subfield_register"
+	case codeview.S_DEFRANGE_FRAMEPOINTER_REL, codeview.S_DEFRANGE_FRAMEPOINTER_REL_FULL_SCOPE:
+		loc.Kind = "frame_relative"
+	case codeview.S_DEFRANGE_REGISTER_REL:
+		loc.Kind = "register_relative"
+	default:
+		loc.Kind = "program"
+	}
+	if loc.Kind == "register" || loc.Kind == "subfield_register" || loc.Kind == "register_relative" {
+		loc.RegisterName = p.registerName(def.Register)
+	}
+
+	if !def.FullScope {
+		loc.RVA = p.SegmentToRVA(def.Range.Segment, def.Range.Offset)
+		loc.Length = def.Range.Length
+		for _, gap := range def.Gaps {
+			loc.Gaps = append(loc.Gaps, Gap{Offset: gap.Offset, Length: gap.Length})
+		}
+	}
+
+	return loc
+}
+
+// globalSymbols parses the global symbol record stream.
+func (p *PDB) globalSymbols() ([]codeview.SymbolRecord, error) {
+	data, err := p.globalSymbolData()
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := codeview.ParseSymbols(data)
+	if err != nil {
+		return symbols, fmt.Errorf("global symbols: %w", err)
+	}
+	return symbols, nil
+}
+
+// globalSymbolData reads the raw global symbol record stream.
+func (p *PDB) globalSymbolData() ([]byte, error) {
+	if p.dbi == nil {
+		return nil, p.dbiErr
+	}
+	if p.dbi.Header.SymRecordStream == 0xFFFF {
+		return nil, nil
+	}
+
+	data, err := p.readStream(int(p.dbi.Header.SymRecordStream))
+	if err != nil {
+		return nil, fmt.Errorf("global symbols: %w", err)
+	}
+	return data, nil
+}
+
+// SymbolsForModule returns the symbol records of a single module's symbol
+// stream, indexed as in Modules. Only that stream is read. It is an error
+// for the index to be out of range or for the module to have no symbol
+// stream; as with the other accessors, a parse error is returned along with
+// the records read before it.
+func (p *PDB) SymbolsForModule(modIndex int) ([]codeview.SymbolRecord, error) {
+	if p.dbi == nil || modIndex < 0 || modIndex >= len(p.dbi.Modules) {
+		return nil, fmt.Errorf("module index %d out of range", modIndex)
+	}
+
+	mod := &p.dbi.Modules[modIndex]
+	if mod.ModuleSymStream == 0xFFFF {
+		return nil, fmt.Errorf("module %s has no symbol stream", mod.ModuleName)
+	}
+	return p.moduleSymbols(mod)
+}
+
+// moduleSymbols parses the symbol records of a module's symbol stream.
+func (p *PDB) moduleSymbols(mod *streams.ModuleInfo) ([]codeview.SymbolRecord, error) {
+	data, err := p.moduleSymbolData(mod)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := codeview.ParseSymbols(data)
+	if err != nil {
+		return symbols, fmt.Errorf("module %s: %w", mod.ModuleName, err)
+	}
+	return symbols, nil
+}
+
+// moduleSymbolData reads the symbol portion of a module's symbol stream.
+func (p *PDB) moduleSymbolData(mod *streams.ModuleInfo) ([]byte, error) {
+	if !mod.HasSymbols() {
+		return nil, nil
+	}
+
+	data, err := p.readStream(int(mod.ModuleSymStream))
+	if err != nil {
+		return nil, fmt.Errorf("module %s: %w", mod.ModuleName, err)
+	}
+
+	// Only read SymByteSize bytes for symbols
+	if uint32(len(data)) > mod.SymByteSize {
+		data = data[:mod.SymByteSize]
+	}
+	return data, nil
+}
+
+// WalkSymbols calls fn for every record of the global symbol stream and of
+// each module symbol stream, in that order, without collecting them. module
+// is "" for global records. Records are only valid for the duration of the
+// call. Walking stops as soon as fn returns an error, which is returned.
+// Streams that fail to read or parse are skipped and their errors joined
+// into the result.
+func (p *PDB) WalkSymbols(fn func(sym codeview.SymbolRecord, module string) error) error {
+	var errs []error
+	var stop error
+
+	walk := func(data []byte, module string) error {
+		return codeview.WalkSymbols(data, func(sym codeview.SymbolRecord) error {
+			if err := fn(sym, module); err != nil {
+				stop = err
+				return err
+			}
+			return nil
+		})
+	}
+
+	data, err := p.globalSymbolData()
+	if err != nil {
+		errs = append(errs, err)
+	} else if err := walk(data, ""); err != nil {
+		if stop != nil {
+			return stop
+		}
+		errs = append(errs, fmt.Errorf("global symbols: %w", err))
+	}
+
+	if p.dbi != nil {
+		for i := range p.dbi.Modules {
+			mod := &p.dbi.Modules[i]
+			data, err := p.moduleSymbolData(mod)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := walk(data, mod.ModuleName); err != nil {
+				if stop != nil {
+					return stop
+				}
+				errs = append(errs, fmt.Errorf("module %s: %w", mod.ModuleName, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// procSignature resolves the signature of a procedure symbol. The _ID
+// variants carry an IPI index which is dereferenced to the TPI type first.
+func (p *PDB) procSignature(kind uint16, typeIndex uint32) string {
+	if codeview.IsIDProcSymbol(kind) {
+		return p.idResolver.ResolveSignature(typeIndex)
+	}
+	if p.resolver != nil {
+		return p.resolver.ResolveType(typeIndex)
+	}
+	return ""
+}
+
+// SymbolAtRVA returns the function that contains the given RVA, or nil if
+// no function encloses it. Functions with no recorded length are treated as
+// extending up to the start of the next function or the end of the section
+// contribution holding them, whichever comes first, and code separated from
+// its function (see SeparatedCode) is attributed to the parent function.
+// The RVA is in the final image layout; function RVAs are already
+// OMAP-translated.
+func (p *PDB) SymbolAtRVA(rva uint32) *Function {
+	if sep := p.SeparatedCodeAtRVA(rva); sep != nil {
+		if fn := p.functionAtRVA(sep.ParentRVA); fn != nil {
+			return fn
+		}
+	}
+	return p.functionAtRVA(rva)
+}
+
+// functionAtRVA returns the function whose own range contains the RVA.
+func (p *PDB) functionAtRVA(rva uint32) *Function {
+	index := p.functionRVAIndex()
+	i := searchRVAIndex(p.functions, index, rva)
+	if i < 0 {
+		return nil
+	}
+
+	fn := &p.functions[index[i]]
+	if fn.Length > 0 {
+		if rva-fn.RVA < fn.Length {
+			return fn
+		}
+		return nil
+	}
+
+	// No length: enclosed up to the next function's start, but never past
+	// the end of the contribution or section holding the function
+	end, ok := p.extentEnd(fn.RVA)
+	for j := i + 1; j < len(index); j++ {
+		next := &p.functions[index[j]]
+		if next.RVA != fn.RVA {
+			if !ok || next.RVA < end {
+				end, ok = next.RVA, true
+			}
+			break
+		}
+	}
+	if !ok {
+		// Nothing bounds it; only its start is known to be in it
+		if rva == fn.RVA {
+			return fn
+		}
+		return nil
+	}
+	if rva < end {
+		return fn
+	}
+	return nil
+}
+
+// extentEnd returns the end RVA of the section contribution containing
+// the RVA or, failing that, of the image section containing it.
+func (p *PDB) extentEnd(rva uint32) (uint32, bool) {
+	contribs := p.SectionContributions()
+	i := sort.Search(len(contribs), func(i int) bool {
+		return contribs[i].RVA > rva
+	}) - 1
+	if i >= 0 && rva-contribs[i].RVA < contribs[i].Size {
+		return contribs[i].RVA + contribs[i].Size, true
+	}
+
+	for _, hdr := range p.sectionHeaders {
+		if rva-hdr.VirtualAddress < hdr.VirtualSize {
+			return hdr.VirtualAddress + hdr.VirtualSize, true
+		}
+	}
+	for _, sec := range p.ImageSections() {
+		if rva-sec.RVA < sec.Length {
+			return sec.RVA + sec.Length, true
+		}
+	}
+	return 0, false
+}
+
+// NearestSymbol returns the function starting at or before the given RVA
+// and the offset of the RVA into that function, or nil if there is none.
+func (p *PDB) NearestSymbol(rva uint32) (*Function, uint32) {
+	index := p.functionRVAIndex()
+	i := searchRVAIndex(p.functions, index, rva)
+	if i < 0 {
+		return nil, 0
+	}
+
+	fn := &p.functions[index[i]]
+	return fn, rva - fn.RVA
+}
+
+// functionRVAIndex lazily builds the RVA-sorted function index.
+// Functions without a resolvable RVA are left out.
+func (p *PDB) functionRVAIndex() []int {
+	p.rvaIndexOnce.Do(p.loadRVAIndex)
+	return p.rvaIndex
+}
+
+// loadRVAIndex builds the RVA-sorted function index.
+func (p *PDB) loadRVAIndex() {
+	functions := p.Functions()
+	p.rvaIndex = make([]int, 0, len(functions))
+	for i, fn := range functions {
+		if fn.RVA != 0 {
+			p.rvaIndex = append(p.rvaIndex, i)
+		}
+	}
+	sort.SliceStable(p.rvaIndex, func(a, b int) bool {
+		return functions[p.rvaIndex[a]].RVA < functions[p.rvaIndex[b]].RVA
+	})
+}
+
+// searchRVAIndex returns the position in index of the last function whose
+// RVA is <= rva, or -1 if there is none.
+func searchRVAIndex(functions []Function, index []int, rva uint32) int {
+	i := sort.Search(len(index), func(i int) bool {
+		return functions[index[i]].RVA > rva
+	})
+	return i - 1
+}
+
+// Variables returns all global/static variables found in the PDB.
+// Parse failures are dropped; use VariablesE to observe them.
+func (p *PDB) Variables() []Variable {
+	variables, _ := p.VariablesE()
+	return variables
+}
+
+// VariablesE returns all global/static variables found in the PDB, along
+// with any errors encountered while reading symbol streams. The returned
+// slice holds everything that could be parsed, even when the error is non-nil.
+func (p *PDB) VariablesE() ([]Variable, error) {
+	p.variablesOnce.do(context.Background(), p.loadVariables)
+	return p.variables, p.variablesErr
+}
+
+// loadVariables parses the data symbols of all symbol streams into the
+// variable cache. Like loadFunctions it stops early if ctx is done.
+func (p *PDB) loadVariables(ctx context.Context) error {
+	variables := make([]Variable, 0)
+	err := p.walkVariables(cancelCheck(ctx), func(v *Variable) error {
+		variables = append(variables, *v)
+		return nil
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	p.variables, p.variablesErr = variables, err
+	return nil
+}
+
+// WalkVariables calls fn for each variable of the symbol streams, in the
+// order Variables lists them, without building the variable cache. Errors
+// are handled as in WalkFunctions.
+func (p *PDB) WalkVariables(fn func(*Variable) error) error {
+	return p.walkVariables(func() error { return nil }, fn)
+}
+
+// walkVariables implements WalkVariables with the cancellation check of
+// walkFunctions.
+func (p *PDB) walkVariables(check func() error, fn func(*Variable) error) error {
+	var errs []error
+
+	var stop error
+	walkErr := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		if stop = check(); stop != nil {
+			return stop
+		}
+		if !codeview.IsDataSymbol(sym.Kind) {
+			return nil
+		}
+		v, err := p.newVariable(sym, module)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		stop = fn(v)
+		return stop
+	})
+	if stop != nil {
+		return stop
+	}
+	errs = append(errs, walkErr)
+
+	return errors.Join(errs...)
+}
+
+// newVariable builds a Variable from a data symbol of module.
+func (p *PDB) newVariable(sym codeview.SymbolRecord, module string) (*Variable, error) {
+	dataSym, err := codeview.ParseDataSymKind(sym.Kind, sym.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", codeview.SymbolKindName(sym.Kind), err)
+	}
+	v := &Variable{
+		Name:          dataSym.Name,
+		Offset:        dataSym.Offset,
+		Segment:       dataSym.Segment,
+		TypeIndex:     dataSym.TypeIndex,
+		IsGlobal:      codeview.IsGlobalSymbol(sym.Kind),
+		IsThreadLocal: codeview.IsThreadLocalSymbol(sym.Kind),
+		Module:        module,
+	}
+	// A TLS offset indexes each thread's copy of the TLS template, not the
+	// image, so it has no meaningful RVA
+	if !v.IsThreadLocal {
+		v.RVA = p.SegmentToRVA(dataSym.Segment, dataSym.Offset)
+	}
+	if demangled := p.demangle(dataSym.Name); demangled.Name != dataSym.Name {
+		v.DemangledName = demangled.Name
+		v.Prototype = demangled.Prototype
+	}
+	if p.resolver != nil {
+		v.TypeName = p.resolver.ResolveType(dataSym.TypeIndex)
+	}
+	return v, nil
+}
+
+// FindFunctions returns the functions whose name or demangled name matches
+// the regular expression pattern. The pattern is unanchored unless it
+// anchors itself with ^ or $.
+func (p *PDB) FindFunctions(pattern string) ([]Function, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	matches := make([]Function, 0)
+	for _, fn := range p.Functions() {
+		if matchName(re, fn.Name, fn.DemangledName) {
+			matches = append(matches, fn)
+		}
+	}
+	return matches, nil
+}
+
+// FindVariables returns the variables whose name or demangled name matches
+// the regular expression pattern.
+func (p *PDB) FindVariables(pattern string) ([]Variable, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	matches := make([]Variable, 0)
+	for _, v := range p.Variables() {
+		if matchName(re, v.Name, v.DemangledName) {
+			matches = append(matches, v)
+		}
+	}
+	return matches, nil
+}
+
+// FindPublicSymbols returns the public symbols whose name or demangled name
+// matches the regular expression pattern.
+func (p *PDB) FindPublicSymbols(pattern string) ([]PublicSymbol, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	matches := make([]PublicSymbol, 0)
+	for _, pub := range p.PublicSymbols() {
+		if matchName(re, pub.Name, pub.DemangledName) {
+			matches = append(matches, pub)
+		}
+	}
+	return matches, nil
+}
+
+// matchName reports whether re matches a symbol's name or its demangled
+// name, if any.
+func matchName(re *regexp.Regexp, name, demangled string) bool {
+	return re.MatchString(name) || (demangled != "" && re.MatchString(demangled))
+}
+
+// PublicSymbols returns all public symbols.
+func (p *PDB) PublicSymbols() []PublicSymbol {
+	p.publicsOnce.do(context.Background(), p.loadPublics)
+	return p.publics
+}
+
+// loadPublics parses the S_PUB32 records into the public symbol cache.
+// Legacy S_PUB32_16t records carry no flags.
+func (p *PDB) loadPublics(ctx context.Context) error {
+	p.publics = make([]PublicSymbol, 0)
+
+	check := cancelCheck(ctx)
+	symbols, _ := p.globalSymbols()
+	for _, sym := range symbols {
+		if err := check(); err != nil {
+			return err
+		}
+		if sym.Kind == codeview.S_PUB32 || sym.Kind == codeview.S_PUB32_16t {
+			pub, err := codeview.ParsePubSymKind(sym.Kind, sym.Data)
+			if err == nil {
+				ps := PublicSymbol{
+					Name:       pub.Name,
+					Offset:     pub.Offset,
+					Segment:    pub.Segment,
+					RVA:        p.SegmentToRVA(pub.Segment, pub.Offset),
+					Flags:      pub.Flags,
+					IsCode:     pub.Flags&codeview.PubCode != 0,
+					IsFunction: pub.Flags&codeview.PubFunction != 0,
+					IsManaged:  pub.Flags&codeview.PubManaged != 0,
+					IsMSIL:     pub.Flags&codeview.PubMSIL != 0,
+				}
+				if demangled := p.demangle(pub.Name); demangled.Name != pub.Name {
+					ps.DemangledName = demangled.Name
+					ps.Prototype = demangled.Prototype
+				}
+				p.publics = append(p.publics, ps)
+			}
+		}
+	}
+	return nil
+}
+
+// Symbols returns the functions, variables, thunks and public symbols in
+// one list sorted by RVA. A public symbol at the RVA of a function,
+// variable or thunk is dropped in favour of that richer record. Thread-local
+// variables and symbols that do not map to an image section have RVA 0 and
+// sort first. Constants are not included, since they have no address.
+func (p *PDB) Symbols() []Symbol {
+	var syms []Symbol
+	for _, fn := range p.Functions() {
+		syms = append(syms, Symbol{
+			Name:          fn.Name,
+			DemangledName: fn.DemangledName,
+			RVA:           fn.RVA,
+			Kind:          SymbolFunction,
+			TypeName:      fn.Signature,
+			Module:        fn.Module,
+		})
+	}
+	for _, v := range p.Variables() {
+		kind := SymbolData
+		if v.IsThreadLocal {
+			kind = SymbolThread
+		}
+		syms = append(syms, Symbol{
+			Name:          v.Name,
+			DemangledName: v.DemangledName,
+			RVA:           v.RVA,
+			Kind:          kind,
+			TypeName:      v.TypeName,
+			Module:        v.Module,
+		})
+	}
+	for _, t := range p.Thunks() {
+		syms = append(syms, Symbol{
+			Name:   t.Name,
+			RVA:    t.RVA,
+			Kind:   SymbolThunk,
+			Module: t.Module,
+		})
+	}
+
+	// Publics duplicate most of the symbols above. RVA 0 marks symbols
+	// without an address, which cannot be matched.
+	covered := make(map[uint32]bool, len(syms))
+	for _, sym := range syms {
+		if sym.RVA != 0 {
+			covered[sym.RVA] = true
+		}
+	}
+	for _, pub := range p.PublicSymbols() {
+		if covered[pub.RVA] {
+			continue
+		}
+		syms = append(syms, Symbol{
+			Name:          pub.Name,
+			DemangledName: pub.DemangledName,
+			RVA:           pub.RVA,
+			Kind:          SymbolPublic,
+		})
+	}
+
+	sort.SliceStable(syms, func(i, j int) bool {
+		return syms[i].RVA < syms[j].RVA
+	})
+	return syms
+}
+
+// Constants returns the named constants of the global symbol stream.
+func (p *PDB) Constants() []Constant {
+	p.constantsOnce.Do(p.loadConstants)
+	return p.constants
+}
+
+// loadConstants builds the constant cache.
+func (p *PDB) loadConstants() {
+	p.constants = make([]Constant, 0)
+
+	symbols, _ := p.globalSymbols()
+	for _, sym := range symbols {
+		if sym.Kind != codeview.S_CONSTANT_NEW {
+			continue
+		}
+		constant, err := codeview.ParseConstantSym(sym.Data)
+		if err != nil {
+			continue
+		}
+
+		c := Constant{
+			Name:      constant.Name,
+			TypeIndex: constant.TypeIndex,
+		}
+		if demangled := p.demangle(constant.Name); demangled.Name != constant.Name {
+			c.DemangledName = demangled.Name
+		}
+		if p.resolver != nil {
+			c.TypeName = p.resolver.ResolveType(constant.TypeIndex)
+		} else {
+			c.TypeName = streams.GetBuiltinTypeName(constant.TypeIndex)
+		}
+		switch {
+		case constant.Numeric.IsFloat || constant.Numeric.Raw != nil:
+			c.Value = constant.Numeric.String()
+		case codeview.IsUnsignedTypeName(c.TypeName):
+			c.Value = strconv.FormatUint(constant.Value, 10)
+		default:
+			c.Value = strconv.FormatInt(int64(constant.Value), 10)
+		}
+		p.constants = append(p.constants, c)
+	}
+}
+
+// UDTs returns the user-defined type names of the global symbol stream,
+// deduplicated by name. When a name is recorded more than once, an entry
+// naming a complete definition is preferred over a forward declaration.
+func (p *PDB) UDTs() []UDT {
+	p.udtsOnce.Do(p.loadUDTs)
+	return p.udts
+}
+
+// loadUDTs builds the UDT cache and its name index.
+func (p *PDB) loadUDTs() {
+	p.udts = make([]UDT, 0)
+	p.udtIndex = make(map[string]int)
+
+	symbols, _ := p.globalSymbols()
+	for _, sym := range symbols {
+		if sym.Kind != codeview.S_UDT_NEW {
+			continue
+		}
+		udt, err := codeview.ParseUDTSym(sym.Data)
+		if err != nil || udt.Name == "" {
+			continue
+		}
+
+		if i, ok := p.udtIndex[udt.Name]; ok {
+			if p.resolver == nil || !p.resolver.IsForwardRef(p.udts[i].TypeIndex) || p.resolver.IsForwardRef(udt.TypeIndex) {
+				continue
+			}
+			p.udts[i].TypeIndex = udt.TypeIndex
+			p.udts[i].TypeName = p.resolver.ResolveType(udt.TypeIndex)
+			continue
+		}
+
+		u := UDT{
+			Name:      udt.Name,
+			TypeIndex: udt.TypeIndex,
+		}
+		if p.resolver != nil {
+			u.TypeName = p.resolver.ResolveType(udt.TypeIndex)
+		} else {
+			u.TypeName = streams.GetBuiltinTypeName(udt.TypeIndex)
+		}
+		p.udtIndex[udt.Name] = len(p.udts)
+		p.udts = append(p.udts, u)
+	}
+}
+
+// attachSource fills in where a type was defined, from the
+// LF_UDT_SRC_LINE / LF_UDT_MOD_SRC_LINE records.
+func (p *PDB) attachSource(ti *TypeInfo) {
+	p.typeSrcOnce.Do(p.loadTypeSources)
+
+	src, ok := p.typeSrc[ti.Index]
+	if !ok && p.resolver != nil {
+		src, ok = p.typeSrc[p.resolver.CompleteType(ti.Index)]
+	}
+	if ok {
+		ti.SourceFile = src.SourceFile
+		ti.SourceLine = src.Line
+		ti.SourceModule = src.Module
+	}
+}
+
+// loadTypeSources maps UDT type indices to their definition sites.
+// LF_UDT_SRC_LINE names the file by an LF_STRING_ID index, while
+// LF_UDT_MOD_SRC_LINE uses a /names offset and a module index.
+func (p *PDB) loadTypeSources() {
+	p.typeSrc = make(map[uint32]typeSource)
+
+	for _, stream := range []*streams.TPIStream{p.ipi, p.tpi} {
+		// Scanning a lazily opened TPI would defeat on-demand loading
+		if stream == nil || stream.IsLazy() {
+			continue
+		}
+		for i := range stream.TypeRecords {
+			rec := &stream.TypeRecords[i]
+			if rec.Kind != streams.LF_UDT_SRC_LINE && rec.Kind != streams.LF_UDT_MOD_SRC_LINE {
+				continue
+			}
+			line, err := codeview.ParseUDTSrcLine(rec.Data)
+			if err != nil {
+				continue
+			}
+
+			src := typeSource{Line: line.LineNumber}
+			if rec.Kind == streams.LF_UDT_SRC_LINE {
+				src.SourceFile = p.idResolver.ResolveID(line.SourceFile)
+			} else {
+				src.SourceFile = p.namesTable().Get(line.SourceFile)
+				if p.dbi != nil && int(line.Module) < len(p.dbi.Modules) {
+					src.Module = p.dbi.Modules[line.Module].ModuleName
+				}
+			}
+			if _, ok := p.typeSrc[line.UDT]; !ok {
+				p.typeSrc[line.UDT] = src
+			}
+		}
+	}
+}
+
+// FindType resolves a C type name, such as a typedef or a struct tag, to
+// its full definition. S_UDT records are consulted first, since they are
+// the only link from a typedef to its target; otherwise the TPI stream is
+// searched for a struct/class/union/enum of that name. Returns nil if the
+// name is unknown.
+func (p *PDB) FindType(name string) *TypeInfo {
+	if p.resolver == nil {
+		return nil
+	}
+
+	p.udtsOnce.Do(p.loadUDTs)
+	if i, ok := p.udtIndex[name]; ok {
+		return p.ResolveType(p.resolver.CompleteType(p.udts[i].TypeIndex))
+	}
+	if typeIdx, ok := p.resolver.FindType(name); ok {
+		return p.ResolveType(typeIdx)
+	}
+	return nil
+}
+
+// MemberAtOffset returns the data member of t whose [Offset, Offset+Size)
+// range contains off, or nil if off falls in padding or outside t. Members
+// of unknown size only match their own offset. Static members and virtual
+// bases are skipped, and a base class only matches if no member declared
+// in t does, as happens for empty bases. It does not descend into nested
+// aggregates; see PDB.FieldAtOffset.
+func (t *TypeInfo) MemberAtOffset(off uint64) *Member {
+	var base *Member
+	for i := range t.Members {
+		m := &t.Members[i]
+		if m.IsStatic || m.IsVirtualBase || off < m.Offset {
+			continue
+		}
+		if off-m.Offset >= max(m.Size, 1) {
+			continue
+		}
+		if m.Name != "(base)" {
+			return m
+		}
+		if base == nil {
+			base = m
+		}
+	}
+	return base
+}
+
+// FieldAtOffset finds the field at byte offset off of the type named
+// typeName, as resolved by FindType. It descends through members and base
+// classes that are themselves structures, classes or unions, and returns
+// the innermost member containing the offset. The returned Member's Offset
+// is relative to typeName, and its Name is the access path from it, such
+// as "hdr.len"; fields inherited from a base class are named as if they
+// were declared in the derived type.
+func (p *PDB) FieldAtOffset(typeName string, off uint64) (*Member, error) {
+	ti := p.FindType(typeName)
+	if ti == nil {
+		return nil, fmt.Errorf("type %q not found", typeName)
+	}
+	if ti.Kind == "enum" || len(ti.Members) == 0 {
+		return nil, fmt.Errorf("type %q has no data members", typeName)
+	}
+	if ti.Size > 0 && off >= ti.Size {
+		return nil, fmt.Errorf("offset %d is outside %s (%d bytes)", off, typeName, ti.Size)
+	}
+
+	m := ti.MemberAtOffset(off)
+	if m == nil {
+		return nil, fmt.Errorf("no member of %s at offset %d", typeName, off)
+	}
+	field := *m
+	if field.Name == "(base)" {
+		field.Name = ""
+	}
+
+	visited := map[uint32]bool{ti.Index: true}
+	for field.TypeIndex != 0 && !visited[field.TypeIndex] {
+		visited[field.TypeIndex] = true
+		inner := p.ResolveType(field.TypeIndex)
+		if inner == nil || inner.Kind == "enum" || len(inner.Members) == 0 {
+			break
+		}
+		sub := inner.MemberAtOffset(off - field.Offset)
+		if sub == nil {
+			break
+		}
+
+		name := field.Name
+		if sub.Name != "(base)" {
+			if name != "" {
+				name += "."
+			}
+			name += sub.Name
+		}
+		offset := field.Offset + sub.Offset
+		field = *sub
+		field.Name, field.Offset = name, offset
+	}
+
+	if field.Name == "" {
+		field.Name = "(base)"
+	}
+	return &field, nil
+}
+
+// Types returns all named types from the TPI stream.
+// Parse failures are dropped; use TypesE to observe them.
+func (p *PDB) Types() []TypeInfo {
+	types, _ := p.TypesE()
+	return types
+}
+
+// TypesE returns all named types from the TPI stream, along with any errors
+// encountered while parsing type records. The returned slice holds
+// everything that could be parsed, even when the error is non-nil.
+func (p *PDB) TypesE() ([]TypeInfo, error) {
+	return p.TypesContext(context.Background())
+}
+
+// TypesContext is like TypesE but returns ctx's error and no types if ctx
+// is done before all records are parsed.
+func (p *PDB) TypesContext(ctx context.Context) ([]TypeInfo, error) {
+	var types []TypeInfo
+	var errs []error
+
+	if p.tpi == nil {
+		return types, p.tpiErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	records, err := p.tpi.Records()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	check := cancelCheck(ctx)
+	for _, rec := range records {
+		if err := check(); err != nil {
+			return nil, err
+		}
+		switch rec.Kind {
+		case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
+			streams.LF_CLASS, streams.LF_CLASS_newformat,
+			streams.LF_UNION, streams.LF_UNION_newformat:
+			parsed := p.resolver.ParseStructureType(&rec)
+			if parsed == nil {
+				errs = append(errs, fmt.Errorf("type 0x%x: %s record too small", rec.Index, streams.LeafKindName(rec.Kind)))
+			} else if parsed.Name != "" {
+				ti := TypeInfo{
+					Index:           parsed.Index,
+					Kind:            parsed.KindName,
+					Name:            parsed.Name,
+					Size:            parsed.Size,
+					Signature:       parsed.Signature,
+					TrailingPadding: parsed.TrailingPadding,
+					VTableShape:     vtableShape(parsed.VTShape),
+					IsForwardRef:    parsed.IsForwardRef,
+				}
+				for _, m := range parsed.Members {
+					ti.Members = append(ti.Members, Member{
+						Name:          m.Name,
+						TypeName:      m.TypeName,
+						TypeIndex:     m.TypeIdx,
+						Offset:        m.Offset,
+						Size:          memberSize(p.resolver, m),
+						IsStatic:      m.IsStatic,
+						IsVirtualBase: m.IsVirtualBase,
+						PaddingBefore: m.PaddingBefore,
+						BitWidth:      m.BitWidth,
+						BitPosition:   m.BitPosition,
+					})
+				}
+				for _, m := range parsed.Methods {
+					ti.Methods = append(ti.Methods, Method{
+						Name:         m.Name,
+						TypeIndex:    m.TypeIndex,
+						TypeName:     p.resolver.ResolveType(m.TypeIndex),
+						Signature:    m.Signature,
+						Attributes:   m.Attributes,
+						VtableOffset: m.VtableOffset,
+					})
+				}
+				for _, n := range parsed.NestedTypes {
+					ti.NestedTypes = append(ti.NestedTypes, NestedType{
+						Name:      n.Name,
+						TypeIndex: n.TypeIndex,
+						TypeName:  n.TypeName,
+					})
+				}
+				p.attachSource(&ti)
+				p.attachRaw(&ti, &rec)
+				types = append(types, ti)
+			}
+
+		case streams.LF_ENUM, streams.LF_ENUM_newformat:
+			parsed := p.resolver.ParseEnumType(&rec)
+			if parsed == nil {
+				errs = append(errs, fmt.Errorf("type 0x%x: %s record too small", rec.Index, streams.LeafKindName(rec.Kind)))
+			} else if parsed.Name != "" {
+				ti := TypeInfo{
+					Index:          parsed.Index,
+					Kind:           "enum",
+					Name:           parsed.Name,
+					Signature:      parsed.Signature,
+					UnderlyingType: parsed.UnderlyingType,
+				}
+				for _, m := range parsed.Members {
+					ti.Members = append(ti.Members, Member{
+						Name:     m.Name,
+						TypeName: m.TypeName,
+						Offset:   m.Offset,
+					})
+				}
+				p.attachSource(&ti)
+				p.attachRaw(&ti, &rec)
+				types = append(types, ti)
+			}
+		}
+	}
+
+	return types, errors.Join(errs...)
+}
+
+// WalkTypes calls fn for every record of the TPI stream, in index order,
+// until fn returns false. Each record is resolved as by ResolveType, so
+// only structures and enums carry members, and is annotated with its leaf
+// kind and the type indices it refers to.
+func (p *PDB) WalkTypes(fn func(ti *TypeInfo) bool) error {
+	if p.tpi == nil {
+		return p.tpiErr
+	}
+
+	records, err := p.tpi.Records()
+	for i := range records {
+		ti := p.ResolveType(records[i].Index)
+		if ti == nil {
+			continue
+		}
+		ti.Leaf = streams.LeafKindName(records[i].Kind)
+		ti.References = p.resolver.TypeReferences(&records[i])
+		if !fn(ti) {
+			break
+		}
+	}
+
+	return err
+}
+
+// AllTypes returns every record of the TPI stream, including pointers,
+// arrays, procedures, modifiers and field lists. See WalkTypes.
+func (p *PDB) AllTypes() []TypeInfo {
+	var types []TypeInfo
+	p.WalkTypes(func(ti *TypeInfo) bool {
+		types = append(types, *ti)
+		return true
+	})
+	return types
+}
+
+// ResolveType resolves a type index to a TypeInfo. Indices missing from
+// the TPI stream are looked up in the type servers supplied through
+// SetTypeServerResolver. For a PDB opened WithoutTPI, the TypeInfo holds
+// only the name type signatures use for the index.
+func (p *PDB) ResolveType(index uint32) *TypeInfo {
+	if p.tpi == nil && !p.skipTPI {
+		return nil
+	}
+
+	// Built-in type. A stream whose records start below TypeIndexBegin
+	// claims the indices from its own begin; indices between
+	// TypeIndexBegin and a higher begin are left to the type servers.
+	if p.resolver.IsBuiltin(index) {
+		return &TypeInfo{
+			Index:     index,
+			Kind:      "builtin",
+			Name:      streams.GetBuiltinTypeName(index),
+			Signature: streams.GetBuiltinTypeName(index),
+		}
+	}
+
+	if p.skipTPI {
+		name := p.resolver.ResolveType(index)
+		return &TypeInfo{Index: index, Name: name, Signature: name}
+	}
+
+	r, local := p.resolver, true
+	rec := p.tpi.GetType(index)
+	if rec == nil {
+		if r, rec = p.externalType(index); rec == nil {
+			return nil
+		}
+		local = false
+	}
+
+	switch rec.Kind {
+	case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
+		streams.LF_CLASS, streams.LF_CLASS_newformat,
+		streams.LF_UNION, streams.LF_UNION_newformat:
+		parsed := r.ParseStructureType(rec)
+		if parsed != nil {
+			ti := &TypeInfo{
+				Index:           parsed.Index,
+				Kind:            parsed.KindName,
+				Name:            parsed.Name,
+				Size:            parsed.Size,
+				Signature:       parsed.Signature,
+				TrailingPadding: parsed.TrailingPadding,
+				VTableShape:     vtableShape(parsed.VTShape),
+				IsForwardRef:    parsed.IsForwardRef,
+			}
+			for _, m := range parsed.Members {
+				ti.Members = append(ti.Members, Member{
+					Name:          m.Name,
+					TypeName:      m.TypeName,
+					TypeIndex:     m.TypeIdx,
+					Offset:        m.Offset,
+					Size:          memberSize(r, m),
+					IsStatic:      m.IsStatic,
+					IsVirtualBase: m.IsVirtualBase,
+					PaddingBefore: m.PaddingBefore,
+					BitWidth:      m.BitWidth,
+					BitPosition:   m.BitPosition,
+				})
+			}
+			for _, m := range parsed.Methods {
+				ti.Methods = append(ti.Methods, Method{
+					Name:         m.Name,
+					TypeIndex:    m.TypeIndex,
+					TypeName:     r.ResolveType(m.TypeIndex),
+					Signature:    m.Signature,
+					Attributes:   m.Attributes,
+					VtableOffset: m.VtableOffset,
+				})
+			}
+			for _, n := range parsed.NestedTypes {
+				ti.NestedTypes = append(ti.NestedTypes, NestedType{
+					Name:      n.Name,
+					TypeIndex: n.TypeIndex,
+					TypeName:  n.TypeName,
+				})
+			}
+			if local {
+				p.attachSource(ti)
+			}
+			p.attachRaw(ti, rec)
+			return ti
+		}
+
+	case streams.LF_ENUM, streams.LF_ENUM_newformat:
+		parsed := r.ParseEnumType(rec)
+		if parsed != nil {
+			ti := &TypeInfo{
+				Index:          parsed.Index,
+				Kind:           "enum",
+				Name:           parsed.Name,
+				Signature:      parsed.Signature,
+				UnderlyingType: parsed.UnderlyingType,
+			}
+			for _, m := range parsed.Members {
+				ti.Members = append(ti.Members, Member{
+					Name:     m.Name,
+					TypeName: m.TypeName,
+					Offset:   m.Offset,
+				})
+			}
+			if local {
+				p.attachSource(ti)
+			}
+			p.attachRaw(ti, rec)
+			return ti
+		}
+	}
+
+	// For other types, return basic info
+	ti := &TypeInfo{
+		Index:     index,
+		Kind:      streams.LeafKindName(rec.Kind),
+		Signature: r.ResolveType(index),
+	}
+	p.attachRaw(ti, rec)
+	return ti
+}
+
+// attachRaw copies the undecoded record of ti when the PDB was opened
+// WithRawTypes. The data is copied, as the record may point into a
+// memory-mapped file.
+func (p *PDB) attachRaw(ti *TypeInfo, rec *streams.TypeRecord) {
+	if p.rawTypes {
+		ti.RawKind = rec.Kind
+		ti.Raw = append([]byte(nil), rec.Data...)
+	}
+}
+
+// memberSize returns the size of a data member's type, or 0 for static
+// members, virtual bases and types of unknown size.
+func memberSize(r *codeview.TypeResolver, m codeview.ParsedMember) uint64 {
+	if m.IsStatic || m.IsVirtualBase {
+		return 0
+	}
+	size, _ := r.SizeOf(m.TypeIdx)
+	return size
+}
+
+// vtableShape converts the slots of an LF_VTSHAPE record to their names.
+func vtableShape(entries []codeview.VTShapeEntry) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.String()
+	}
+	return names
+}
+
+// Modules returns information about compiled modules.
+func (p *PDB) Modules() []ModuleInfo {
+	if p.dbi == nil {
+		return nil
+	}
+
+	modules := make([]ModuleInfo, len(p.dbi.Modules))
+	for i := range p.dbi.Modules {
+		modules[i] = p.moduleInfo(i)
+	}
+	return modules
+}
+
+// moduleInfo converts a DBI module entry into a ModuleInfo, adding the
+// S_OBJNAME and S_ENVBLOCK records from the head of its symbol stream.
+func (p *PDB) moduleInfo(index int) ModuleInfo {
+	mod := &p.dbi.Modules[index]
+	hdr := p.moduleHeader(index)
+	return ModuleInfo{
+		Name:         mod.ModuleName,
+		ObjectFile:   mod.ObjFileName,
+		SymbolStream: mod.ModuleSymStream,
+		SymbolSize:   mod.SymByteSize,
+		SourceFiles:  mod.SourceFileCount,
+		FileNames:    mod.SourceFiles,
+
+		SourceFileName: mod.SourceFileName,
+		PdbFilePath:    mod.PdbFilePath,
+
+		ObjNameSignature: hdr.objNameSignature,
+		BuildEnv:         hdr.buildEnv,
+	}
+}
+
+// moduleHeader holds what ModuleInfo takes from the records at the head of
+// a module's symbol stream.
+type moduleHeader struct {
+	once             sync.Once
+	objNameSignature uint32
+	buildEnv         map[string]string
+}
+
+// moduleHeader returns the header records of a module, reading them on
+// first use.
+func (p *PDB) moduleHeader(index int) *moduleHeader {
+	p.modHdrsOnce.Do(func() {
+		p.modHdrs = make([]moduleHeader, len(p.dbi.Modules))
+	})
+	hdr := &p.modHdrs[index]
+	hdr.once.Do(func() { p.loadModuleHeader(&p.dbi.Modules[index], hdr) })
+	return hdr
+}
+
+// loadModuleHeader reads the S_OBJNAME and S_ENVBLOCK records of a module.
+// Both precede the first scope, so records are read one at a time up to
+// it rather than reading the whole stream.
+func (p *PDB) loadModuleHeader(mod *streams.ModuleInfo, hdr *moduleHeader) {
+	if !mod.HasSymbols() {
+		return
+	}
+	stream, err := p.msf.Stream(int(mod.ModuleSymStream))
+	if err != nil {
+		return
+	}
+	end := int64(mod.SymByteSize)
+	if size := int64(stream.Size()); size < end {
+		end = size
+	}
+
+	var head [4]byte
+	offset := int64(0)
+	if _, err := stream.ReadAt(head[:], 0); err == nil && binary.LittleEndian.Uint32(head[:]) == 4 {
+		offset = 4 // CV_SIGNATURE_C13
+	}
+	for offset+4 <= end {
+		if _, err := stream.ReadAt(head[:], offset); err != nil {
+			return
+		}
+		recLen := int64(binary.LittleEndian.Uint16(head[:]))
+		kind := binary.LittleEndian.Uint16(head[2:])
+		if recLen < 2 || offset+2+recLen > end || codeview.IsScopeStart(kind) {
+			return
+		}
+
+		var data []byte
+		if kind == codeview.S_OBJNAME || kind == codeview.S_ENVBLOCK {
+			data = make([]byte, recLen-2)
+			if _, err := stream.ReadAt(data, offset+4); err != nil {
+				return
+			}
+		}
+		switch kind {
+		case codeview.S_OBJNAME:
+			if obj, err := codeview.ParseObjNameSym(data); err == nil {
+				hdr.objNameSignature = obj.Signature
+			}
+		case codeview.S_ENVBLOCK:
+			if env, err := codeview.ParseEnvBlockSym(data); err == nil && len(env.Env) > 0 {
+				hdr.buildEnv = env.Env
+			}
+		}
+		offset += 2 + recLen
+	}
+}
+
+// SectionContributions returns the section contributions of all modules,
+// sorted by RVA. Contributions that do not map to an RVA are omitted.
+func (p *PDB) SectionContributions() []Contribution {
+	p.contribsOnce.Do(p.loadContribs)
+	return p.contribs
+}
+
+// loadContribs converts the DBI section contributions into the RVA-sorted
+// contribution cache.
+func (p *PDB) loadContribs() {
+	p.contribs = make([]Contribution, 0)
+	if p.dbi == nil {
+		return
+	}
+
+	for _, sc := range p.dbi.SectionContribs {
+		if sc.Size <= 0 || sc.Offset < 0 {
+			continue
+		}
+		rva := p.SegmentToRVA(sc.Section, uint32(sc.Offset))
+		if rva == 0 {
+			continue
+		}
+		c := Contribution{
+			ModuleIndex:     sc.ModuleIndex,
+			RVA:             rva,
+			Size:            uint32(sc.Size),
+			Characteristics: sc.Characteristics,
+		}
+		if int(sc.ModuleIndex) < len(p.dbi.Modules) {
+			c.ModuleName = p.dbi.Modules[sc.ModuleIndex].ModuleName
+		}
+		p.contribs = append(p.contribs, c)
+	}
+
+	sort.SliceStable(p.contribs, func(i, j int) bool {
+		return p.contribs[i].RVA < p.contribs[j].RVA
+	})
+}
+
+// DebugStreams returns the optional debug streams the DBI stream's debug
+// header references, keyed by name as by OptionalDebugHeader.Present, or
+// nil if the PDB has no debug header. A present "FPO", "NewFPO" or "Pdata"
+// entry tells whether unwind data can be read.
+func (p *PDB) DebugStreams() map[string]uint16 {
+	if p.dbi == nil || p.dbi.DebugHeader == nil {
+		return nil
+	}
+	return p.dbi.DebugHeader.Present()
+}
+
+// FPOData returns the old-format frame pointer omission records used to
+// unwind 32-bit x86 stacks, or nil if the PDB has no FPO stream.
+func (p *PDB) FPOData() []streams.FPOData {
+	p.fpoOnce.Do(p.loadFPO)
+	return p.fpo
+}
+
+// loadFPO reads and parses the DBI FPO stream.
+func (p *PDB) loadFPO() {
+	if p.dbi == nil || p.dbi.DebugHeader == nil {
+		return
+	}
+	if data := p.debugStream(p.dbi.DebugHeader.FPO); data != nil {
+		p.fpo = streams.ParseFPO(data)
+	}
+}
+
+// FrameData returns the new-format frame data records used to unwind
+// 32-bit x86 stacks, or nil if the PDB has no NewFPO stream. FrameFunc
+// offsets refer to the /names table.
+func (p *PDB) FrameData() []streams.FrameData {
+	p.frameDataOnce.Do(p.loadFrameData)
+	return p.frameData
+}
+
+// loadFrameData reads and parses the DBI NewFPO stream.
+func (p *PDB) loadFrameData() {
+	if p.dbi == nil || p.dbi.DebugHeader == nil {
+		return
+	}
+	if data := p.debugStream(p.dbi.DebugHeader.NewFPO); data != nil {
+		p.frameData = streams.ParseFrameData(data)
+	}
+}
+
+// RuntimeFunctions returns the function table entries of an x64 image
+// from the copy of its .pdata section in the DBI Pdata stream, which the
+// linker sorts by BeginRVA. They give the bounds of every non-leaf
+// function, even where symbols are missing. It returns nil for other
+// machine types or if the PDB has no Pdata stream.
+func (p *PDB) RuntimeFunctions() []streams.RuntimeFunction {
+	p.pdataOnce.Do(p.loadPdata)
+	return p.pdata
+}
+
+// loadPdata reads and parses the DBI Pdata stream.
+func (p *PDB) loadPdata() {
+	if p.dbi == nil || p.dbi.DebugHeader == nil || p.dbi.Header.Machine != streams.MachineAMD64 {
+		return
+	}
+	if data := p.debugStream(p.dbi.DebugHeader.Pdata); data != nil {
+		hdr, data := streams.SplitDebugData(data)
+		if hdr != nil {
+			p.pdataBase = hdr.RVADataBase
+		}
+		p.pdata = streams.ParsePdata(data)
+	}
+}
+
+// UnwindInfo returns the unwind information of an x64 function table
+// entry, read from the copy of the .xdata section in the DBI Xdata stream.
+// The stream must carry the header giving its RVA, as those written by
+// recent linkers do. Entries that share another entry's unwind
+// information are followed to it, which needs the same header on the
+// Pdata stream.
+func (p *PDB) UnwindInfo(fn *streams.RuntimeFunction) (*streams.UnwindInfo, error) {
+	p.xdataOnce.Do(p.loadXdata)
+	if p.xdata == nil {
+		return nil, fmt.Errorf("no xdata stream with a known base RVA")
 	}
 
-	// Load section headers from optional debug header stream
-	if pdb.dbi != nil && pdb.dbi.DebugHeader != nil {
-		secHdrStream := int(pdb.dbi.DebugHeader.SectionHdr)
-		if secHdrStream != 0xFFFF && m.NumStreams() > secHdrStream {
-			stream, err := m.Stream(secHdrStream)
-			if err == nil && stream.Size() > 0 {
-				data, err := stream.ReadAll()
-				if err == nil {
-					pdb.sectionHeaders = streams.ParseSectionHeaders(data)
-				}
-			}
+	rva := fn.UnwindInfoRVA
+	if rva&1 != 0 {
+		shared := p.runtimeFunctionAt(rva &^ 1)
+		if shared == nil || shared.UnwindInfoRVA&1 != 0 {
+			return nil, fmt.Errorf("unwind info of 0x%x: no function table entry at 0x%x", fn.BeginRVA, rva&^1)
 		}
+		rva = shared.UnwindInfoRVA
 	}
 
-	return pdb, nil
+	if rva < p.xdataBase || rva-p.xdataBase >= uint32(len(p.xdata)) {
+		return nil, fmt.Errorf("unwind info of 0x%x at 0x%x is outside the xdata stream", fn.BeginRVA, rva)
+	}
+	return streams.ParseUnwindInfo(p.xdata[rva-p.xdataBase:])
 }
 
-// Close closes the PDB file.
-func (p *PDB) Close() error {
-	if p.msf != nil {
-		return p.msf.Close()
+// runtimeFunctionAt returns the function table entry stored at rva in the
+// .pdata section, or nil if the Pdata stream's RVA is unknown or no entry
+// starts there.
+func (p *PDB) runtimeFunctionAt(rva uint32) *streams.RuntimeFunction {
+	functions := p.RuntimeFunctions()
+	if p.pdataBase == 0 || rva < p.pdataBase || (rva-p.pdataBase)%12 != 0 {
+		return nil
+	}
+	if i := (rva - p.pdataBase) / 12; uint64(i) < uint64(len(functions)) {
+		return &functions[i]
 	}
 	return nil
 }
 
-// Info returns basic PDB file information.
-func (p *PDB) Info() *PDBInfo {
-	info := &PDBInfo{
-		Streams: p.msf.NumStreams(),
+// loadXdata reads the DBI Xdata stream, keeping it only if its header
+// gives the RVA it was copied from.
+func (p *PDB) loadXdata() {
+	if p.dbi == nil || p.dbi.DebugHeader == nil {
+		return
 	}
-
-	if p.pdbInfo != nil {
-		info.GUID = p.pdbInfo.GUIDString()
-		info.Age = p.pdbInfo.Age
-		info.Version = p.pdbInfo.Version
-		info.NamedStreams = p.pdbInfo.NamedStreams
+	if data := p.debugStream(p.dbi.DebugHeader.Xdata); data != nil {
+		if hdr, xdata := streams.SplitDebugData(data); hdr != nil {
+			p.xdata, p.xdataBase = xdata, hdr.RVADataBase
+		}
 	}
+}
 
-	if p.dbi != nil {
-		info.Machine = streams.MachineTypeName(p.dbi.Header.Machine)
+// debugStream reads a stream named by the DBI optional debug header, or
+// returns nil if it is absent or unreadable.
+func (p *PDB) debugStream(index uint16) []byte {
+	if index == 0xFFFF {
+		return nil
 	}
-
-	return info
+	data, err := p.readStream(int(index))
+	if err != nil {
+		p.warnf("failed to read debug stream %d: %w", index, err)
+		return nil
+	}
+	return data
 }
 
-// Functions returns all functions found in the PDB.
-func (p *PDB) Functions() []Function {
-	if p.functions != nil {
-		return p.functions
+// ModuleAtRVA returns the module whose section contribution contains the
+// given RVA, or nil if no contribution covers it.
+func (p *PDB) ModuleAtRVA(rva uint32) *ModuleInfo {
+	contribs := p.SectionContributions()
+
+	// Last contribution starting at or before rva
+	i := sort.Search(len(contribs), func(i int) bool {
+		return contribs[i].RVA > rva
+	}) - 1
+	if i < 0 {
+		return nil
 	}
 
-	p.functions = make([]Function, 0)
+	c := contribs[i]
+	if rva-c.RVA >= c.Size || int(c.ModuleIndex) >= len(p.dbi.Modules) {
+		return nil
+	}
 
-	// Parse global symbols stream
-	if p.dbi != nil && p.dbi.Header.SymRecordStream != 0xFFFF {
-		stream, err := p.msf.Stream(int(p.dbi.Header.SymRecordStream))
-		if err == nil && stream.Size() > 0 {
-			data, err := stream.ReadAll()
-			if err == nil {
-				symbols, _ := codeview.ParseSymbols(data)
-				for _, sym := range symbols {
-					if codeview.IsProcSymbol(sym.Kind) {
-						proc, err := codeview.ParseProcSym(sym.Data)
-						if err == nil {
-							fn := Function{
-								Name:      proc.Name,
-								Offset:    proc.Offset,
-								Segment:   proc.Segment,
-								RVA:       p.SegmentToRVA(proc.Segment, proc.Offset),
-								Length:    proc.Length,
-								TypeIndex: proc.TypeIndex,
-								IsGlobal:  codeview.IsGlobalSymbol(sym.Kind),
-							}
-							if demangled := DemangleFull(proc.Name); demangled.Name != proc.Name {
-								fn.DemangledName = demangled.Name
-								fn.Prototype = demangled.Prototype
-							}
-							if p.resolver != nil {
-								fn.Signature = p.resolver.ResolveType(proc.TypeIndex)
-							}
-							p.functions = append(p.functions, fn)
-						}
-					}
-				}
-			}
-		}
+	mod := p.moduleInfo(int(c.ModuleIndex))
+	return &mod
+}
+
+// BuildInfo returns the compiler information recorded at the head of each
+// module's symbol stream. Modules without an S_COMPILE2/S_COMPILE3 record
+// are omitted.
+func (p *PDB) BuildInfo() []CompileInfo {
+	if p.dbi == nil {
+		return nil
 	}
 
-	// Parse module symbols
-	if p.dbi != nil {
-		for _, mod := range p.dbi.Modules {
-			if !mod.HasSymbols() {
-				continue
-			}
+	var infos []CompileInfo
+	for i := range p.dbi.Modules {
+		mod := &p.dbi.Modules[i]
+		symbols, _ := p.moduleSymbols(mod)
 
-			stream, err := p.msf.Stream(int(mod.ModuleSymStream))
-			if err != nil || stream.Size() == 0 {
-				continue
+		for _, sym := range symbols {
+			var compile *codeview.CompileSym
+			switch sym.Kind {
+			case codeview.S_COMPILE3:
+				compile, _ = codeview.ParseCompile3Sym(sym.Data)
+			case codeview.S_COMPILE2:
+				compile, _ = codeview.ParseCompile2Sym(sym.Data)
 			}
-
-			data, err := stream.ReadAll()
-			if err != nil {
+			if compile == nil {
 				continue
 			}
 
-			// Only read SymByteSize bytes for symbols
-			symData := data
-			if uint32(len(data)) > mod.SymByteSize {
-				symData = data[:mod.SymByteSize]
-			}
-
-			symbols, _ := codeview.ParseSymbols(symData)
-			for _, sym := range symbols {
-				if codeview.IsProcSymbol(sym.Kind) {
-					proc, err := codeview.ParseProcSym(sym.Data)
-					if err == nil {
-						fn := Function{
-							Name:      proc.Name,
-							Offset:    proc.Offset,
-							Segment:   proc.Segment,
-							RVA:       p.SegmentToRVA(proc.Segment, proc.Offset),
-							Length:    proc.Length,
-							TypeIndex: proc.TypeIndex,
-							IsGlobal:  codeview.IsGlobalSymbol(sym.Kind),
-							Module:    mod.ModuleName,
-						}
-						if demangled := DemangleFull(proc.Name); demangled.Name != proc.Name {
-							fn.DemangledName = demangled.Name
-							fn.Prototype = demangled.Prototype
-						}
-						if p.resolver != nil {
-							fn.Signature = p.resolver.ResolveType(proc.TypeIndex)
-						}
-						p.functions = append(p.functions, fn)
-					}
-				}
-			}
+			fe, be := compile.FrontendVersion, compile.BackendVersion
+			infos = append(infos, CompileInfo{
+				Module:          mod.ModuleName,
+				Language:        codeview.LanguageName(compile.Language),
+				Machine:         codeview.MachineName(compile.Machine),
+				FrontendVersion: fmt.Sprintf("%d.%d.%d.%d", fe[0], fe[1], fe[2], fe[3]),
+				BackendVersion:  fmt.Sprintf("%d.%d.%d.%d", be[0], be[1], be[2], be[3]),
+				CompilerName:    compile.CompilerName,
+				Flags:           compile.Flags,
+			})
+			break
 		}
 	}
 
-	return p.functions
+	return infos
 }
 
-// Variables returns all global/static variables found in the PDB.
-func (p *PDB) Variables() []Variable {
-	if p.variables != nil {
-		return p.variables
+// CompileCommands returns the compiler invocation of each module, taken
+// from the LF_BUILDINFO record its S_BUILDINFO symbol references in the IPI
+// stream. Modules without build information, such as those of the linker
+// or of import libraries, are omitted.
+func (p *PDB) CompileCommands() []CompileCommand {
+	if p.dbi == nil || p.ipi == nil {
+		return nil
 	}
 
-	p.variables = make([]Variable, 0)
+	var cmds []CompileCommand
+	for i := range p.dbi.Modules {
+		mod := &p.dbi.Modules[i]
+		data, err := p.moduleSymbolData(mod)
+		if err != nil {
+			continue
+		}
 
-	// Parse global symbols stream
-	if p.dbi != nil && p.dbi.Header.SymRecordStream != 0xFFFF {
-		stream, err := p.msf.Stream(int(p.dbi.Header.SymRecordStream))
-		if err == nil && stream.Size() > 0 {
-			data, err := stream.ReadAll()
-			if err == nil {
-				symbols, _ := codeview.ParseSymbols(data)
-				for _, sym := range symbols {
-					if codeview.IsDataSymbol(sym.Kind) {
-						dataSym, err := codeview.ParseDataSym(sym.Data)
-						if err == nil {
-							v := Variable{
-								Name:      dataSym.Name,
-								Offset:    dataSym.Offset,
-								Segment:   dataSym.Segment,
-								RVA:       p.SegmentToRVA(dataSym.Segment, dataSym.Offset),
-								TypeIndex: dataSym.TypeIndex,
-								IsGlobal:  codeview.IsGlobalSymbol(sym.Kind),
-							}
-							if demangled := DemangleFull(dataSym.Name); demangled.Name != dataSym.Name {
-								v.DemangledName = demangled.Name
-								v.Prototype = demangled.Prototype
-							}
-							if p.resolver != nil {
-								v.TypeName = p.resolver.ResolveType(dataSym.TypeIndex)
-							}
-							p.variables = append(p.variables, v)
-						}
-					}
+		// S_BUILDINFO follows S_COMPILE3 at the head of the stream
+		var buildID uint32
+		codeview.WalkSymbols(data, func(sym codeview.SymbolRecord) error {
+			switch {
+			case sym.Kind == codeview.S_BUILDINFO:
+				if bi, err := codeview.ParseBuildInfoSym(sym.Data); err == nil {
+					buildID = bi.BuildID
 				}
+				return errStopWalk
+			case codeview.IsScopeStart(sym.Kind):
+				return errStopWalk
 			}
+			return nil
+		})
+		if buildID == 0 {
+			continue
 		}
-	}
 
-	// Parse module symbols for static variables
-	if p.dbi != nil {
-		for _, mod := range p.dbi.Modules {
-			if !mod.HasSymbols() {
-				continue
+		args, ok := p.idResolver.ResolveBuildInfo(buildID)
+		if !ok {
+			continue
+		}
+		arg := func(i int) string {
+			if i < len(args) {
+				return args[i]
 			}
+			return ""
+		}
+		cmds = append(cmds, CompileCommand{
+			Module:      mod.ModuleName,
+			Directory:   arg(codeview.BuildInfoCurrentDirectory),
+			Compiler:    arg(codeview.BuildInfoBuildTool),
+			SourceFile:  arg(codeview.BuildInfoSourceFile),
+			PDBFile:     arg(codeview.BuildInfoTypeServerPDB),
+			CommandLine: arg(codeview.BuildInfoCommandLine),
+		})
+	}
 
-			stream, err := p.msf.Stream(int(mod.ModuleSymStream))
-			if err != nil || stream.Size() == 0 {
-				continue
-			}
+	return cmds
+}
 
-			data, err := stream.ReadAll()
-			if err != nil {
-				continue
-			}
+// SourceFiles returns the names of all source files that contributed to
+// the PDB, deduplicated and sorted.
+func (p *PDB) SourceFiles() []string {
+	if p.dbi == nil {
+		return nil
+	}
 
-			symData := data
-			if uint32(len(data)) > mod.SymByteSize {
-				symData = data[:mod.SymByteSize]
-			}
-
-			symbols, _ := codeview.ParseSymbols(symData)
-			for _, sym := range symbols {
-				if codeview.IsDataSymbol(sym.Kind) {
-					dataSym, err := codeview.ParseDataSym(sym.Data)
-					if err == nil {
-						v := Variable{
-							Name:      dataSym.Name,
-							Offset:    dataSym.Offset,
-							Segment:   dataSym.Segment,
-							RVA:       p.SegmentToRVA(dataSym.Segment, dataSym.Offset),
-							TypeIndex: dataSym.TypeIndex,
-							IsGlobal:  codeview.IsGlobalSymbol(sym.Kind),
-							Module:    mod.ModuleName,
-						}
-						if demangled := DemangleFull(dataSym.Name); demangled.Name != dataSym.Name {
-							v.DemangledName = demangled.Name
-							v.Prototype = demangled.Prototype
-						}
-						if p.resolver != nil {
-							v.TypeName = p.resolver.ResolveType(dataSym.TypeIndex)
-						}
-						p.variables = append(p.variables, v)
-					}
-				}
+	seen := make(map[string]bool)
+	files := make([]string, 0)
+	for _, mod := range p.dbi.Modules {
+		for _, f := range mod.SourceFiles {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
 			}
 		}
 	}
+	sort.Strings(files)
 
-	return p.variables
+	return files
 }
 
-// PublicSymbols returns all public symbols.
-func (p *PDB) PublicSymbols() []PublicSymbol {
-	if p.publics != nil {
-		return p.publics
-	}
+// Lines returns the line-number information of all modules.
+func (p *PDB) Lines() []LineInfo {
+	p.linesOnce.do(context.Background(), p.loadLines)
+	return p.lines
+}
 
-	p.publics = make([]PublicSymbol, 0)
+// loadLines collects the line information of all modules, checking ctx
+// before each module.
+func (p *PDB) loadLines(ctx context.Context) error {
+	p.lines = make([]LineInfo, 0)
 
-	if p.dbi != nil && p.dbi.Header.SymRecordStream != 0xFFFF {
-		stream, err := p.msf.Stream(int(p.dbi.Header.SymRecordStream))
-		if err == nil && stream.Size() > 0 {
-			data, err := stream.ReadAll()
-			if err == nil {
-				symbols, _ := codeview.ParseSymbols(data)
-				for _, sym := range symbols {
-					if sym.Kind == codeview.S_PUB32 {
-						pub, err := codeview.ParsePubSym(sym.Data)
-						if err == nil {
-							ps := PublicSymbol{
-								Name:    pub.Name,
-								Offset:  pub.Offset,
-								Segment: pub.Segment,
-								RVA:     p.SegmentToRVA(pub.Segment, pub.Offset),
-							}
-							if demangled := DemangleFull(pub.Name); demangled.Name != pub.Name {
-								ps.DemangledName = demangled.Name
-								ps.Prototype = demangled.Prototype
-							}
-							p.publics = append(p.publics, ps)
-						}
-					}
-				}
+	if p.dbi != nil {
+		for i := range p.dbi.Modules {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
+			p.lines = append(p.lines, p.LinesForModule(i)...)
 		}
 	}
-
-	return p.publics
+	return nil
 }
 
-// Types returns all named types from the TPI stream.
-func (p *PDB) Types() []TypeInfo {
-	var types []TypeInfo
+// LineAtRVA returns the line entry covering the given RVA, or nil if the
+// address has no line information. The entry is the last one starting at
+// or before the RVA, provided it lies in the same function.
+func (p *PDB) LineAtRVA(rva uint32) *LineInfo {
+	p.lineIndexOnce.Do(p.loadLineIndex)
+	index := p.lineIndex
 
-	if p.tpi == nil {
-		return types
+	// Last entry starting at or before rva
+	i := sort.Search(len(index), func(i int) bool {
+		return p.lines[index[i]].RVA > rva
+	}) - 1
+	if i < 0 {
+		return nil
 	}
 
-	for _, rec := range p.tpi.TypeRecords {
-		switch rec.Kind {
-		case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
-			streams.LF_CLASS, streams.LF_CLASS_newformat,
-			streams.LF_UNION, streams.LF_UNION_newformat:
-			parsed := p.resolver.ParseStructureType(&rec)
-			if parsed != nil && parsed.Name != "" {
-				ti := TypeInfo{
-					Index:     parsed.Index,
-					Kind:      parsed.KindName,
-					Name:      parsed.Name,
-					Size:      parsed.Size,
-					Signature: parsed.Signature,
-				}
-				for _, m := range parsed.Members {
-					ti.Members = append(ti.Members, Member{
-						Name:     m.Name,
-						TypeName: m.TypeName,
-						Offset:   m.Offset,
-					})
-				}
-				types = append(types, ti)
-			}
-
-		case streams.LF_ENUM, streams.LF_ENUM_newformat:
-			parsed := p.resolver.ParseEnumType(&rec)
-			if parsed != nil && parsed.Name != "" {
-				ti := TypeInfo{
-					Index:     parsed.Index,
-					Kind:      "enum",
-					Name:      parsed.Name,
-					Signature: parsed.Signature,
-				}
-				for _, m := range parsed.Members {
-					ti.Members = append(ti.Members, Member{
-						Name:     m.Name,
-						TypeName: m.TypeName,
-						Offset:   m.Offset,
-					})
-				}
-				types = append(types, ti)
-			}
+	// The entry must not lie before the enclosing code range
+	line := &p.lines[index[i]]
+	if sep := p.SeparatedCodeAtRVA(rva); sep != nil {
+		if line.RVA < sep.RVA {
+			return nil
 		}
+	} else if fn := p.functionAtRVA(rva); fn != nil && line.RVA < fn.RVA {
+		return nil
 	}
+	return line
+}
 
-	return types
+// loadLineIndex builds the RVA-sorted line index. Entries without a
+// resolvable RVA are left out.
+func (p *PDB) loadLineIndex() {
+	lines := p.Lines()
+	p.lineIndex = make([]int, 0, len(lines))
+	for i := range lines {
+		if lines[i].RVA != 0 {
+			p.lineIndex = append(p.lineIndex, i)
+		}
+	}
+	sort.SliceStable(p.lineIndex, func(i, j int) bool {
+		return lines[p.lineIndex[i]].RVA < lines[p.lineIndex[j]].RVA
+	})
 }
 
-// ResolveType resolves a type index to a TypeInfo.
-func (p *PDB) ResolveType(index uint32) *TypeInfo {
-	if p.tpi == nil {
+// LinesForModule returns the line-number information of a single module,
+// decoded from the C13 DEBUG_S_LINES subsections of its symbol stream.
+// Modules with only C11 line info yield no entries.
+func (p *PDB) LinesForModule(modIndex int) []LineInfo {
+	if p.dbi == nil || modIndex < 0 || modIndex >= len(p.dbi.Modules) {
 		return nil
 	}
 
-	if index < streams.TypeIndexBegin {
-		// Built-in type
-		return &TypeInfo{
-			Index:     index,
-			Kind:      "builtin",
-			Name:      streams.GetBuiltinTypeName(index),
-			Signature: streams.GetBuiltinTypeName(index),
+	mod := &p.dbi.Modules[modIndex]
+	subsections := p.moduleSubsections(mod)
+
+	var checksums map[uint32]codeview.FileChecksum
+	for _, sub := range subsections {
+		if sub.Kind == codeview.DEBUG_S_FILECHECKSUMS {
+			checksums = codeview.ParseFileChecksums(sub.Data)
+			break
 		}
 	}
 
-	rec := p.tpi.GetType(index)
-	if rec == nil {
-		return nil
-	}
+	var lines []LineInfo
+	for _, sub := range subsections {
+		if sub.Kind != codeview.DEBUG_S_LINES {
+			continue
+		}
 
-	switch rec.Kind {
-	case streams.LF_STRUCTURE, streams.LF_STRUCTURE_newformat,
-		streams.LF_CLASS, streams.LF_CLASS_newformat,
-		streams.LF_UNION, streams.LF_UNION_newformat:
-		parsed := p.resolver.ParseStructureType(rec)
-		if parsed != nil {
-			ti := &TypeInfo{
-				Index:     parsed.Index,
-				Kind:      parsed.KindName,
-				Name:      parsed.Name,
-				Size:      parsed.Size,
-				Signature: parsed.Signature,
-			}
-			for _, m := range parsed.Members {
-				ti.Members = append(ti.Members, Member{
-					Name:     m.Name,
-					TypeName: m.TypeName,
-					Offset:   m.Offset,
-				})
-			}
-			return ti
+		parsed, err := codeview.ParseLinesSubsection(sub.Data)
+		if err != nil {
+			continue
 		}
 
-	case streams.LF_ENUM, streams.LF_ENUM_newformat:
-		parsed := p.resolver.ParseEnumType(rec)
-		if parsed != nil {
-			ti := &TypeInfo{
-				Index:     parsed.Index,
-				Kind:      "enum",
-				Name:      parsed.Name,
-				Signature: parsed.Signature,
+		for _, block := range parsed.Blocks {
+			fileName := ""
+			if fc, ok := checksums[block.FileID]; ok {
+				fileName = p.namesTable().Get(fc.FileNameOffset)
 			}
-			for _, m := range parsed.Members {
-				ti.Members = append(ti.Members, Member{
-					Name:     m.Name,
-					TypeName: m.TypeName,
-					Offset:   m.Offset,
+
+			for _, entry := range block.Lines {
+				offset := parsed.Offset + entry.Offset
+				lines = append(lines, LineInfo{
+					RVA:         p.SegmentToRVA(parsed.Segment, offset),
+					Segment:     parsed.Segment,
+					Offset:      offset,
+					FileName:    fileName,
+					LineNumber:  entry.LineNumber,
+					ColumnStart: entry.ColumnStart,
+					IsStatement: entry.IsStatement,
+					Module:      mod.ModuleName,
 				})
 			}
-			return ti
 		}
 	}
 
-	// For other types, return basic info
-	return &TypeInfo{
-		Index:     index,
-		Kind:      streams.LeafKindName(rec.Kind),
-		Signature: p.resolver.ResolveType(index),
-	}
+	return lines
 }
 
-// Modules returns information about compiled modules.
-func (p *PDB) Modules() []ModuleInfo {
-	if p.dbi == nil {
+// moduleSubsections returns the C13 debug subsections of a module.
+func (p *PDB) moduleSubsections(mod *streams.ModuleInfo) []codeview.DebugSubsection {
+	if mod.ModuleSymStream == 0xFFFF || mod.C13ByteSize == 0 {
 		return nil
 	}
 
-	modules := make([]ModuleInfo, len(p.dbi.Modules))
-	for i, mod := range p.dbi.Modules {
-		modules[i] = ModuleInfo{
-			Name:         mod.ModuleName,
-			ObjectFile:   mod.ObjFileName,
-			SymbolStream: mod.ModuleSymStream,
-			SymbolSize:   mod.SymByteSize,
-			SourceFiles:  mod.SourceFileCount,
-		}
+	stream, err := p.msf.Stream(int(mod.ModuleSymStream))
+	if err != nil || stream.Size() == 0 {
+		return nil
 	}
-	return modules
+
+	data, err := stream.ReadAll()
+	if err != nil {
+		return nil
+	}
+
+	// C13 line info follows the symbols and any C11 line info
+	start := uint64(mod.SymByteSize) + uint64(mod.C11ByteSize)
+	end := start + uint64(mod.C13ByteSize)
+	if end > uint64(len(data)) {
+		return nil
+	}
+
+	return codeview.ParseDebugSubsections(data[start:end])
+}
+
+// TranslateRVA maps an RVA in the original (pre-reordering) image layout
+// to the final image using the PDB's OMAP table. Addresses in eliminated
+// code map to 0. Without an OMAP table the RVA is returned unchanged.
+func (p *PDB) TranslateRVA(rva uint32) uint32 {
+	if len(p.omapFromSrc) == 0 {
+		return rva
+	}
+	return streams.TranslateOMAP(p.omapFromSrc, rva)
 }
 
 // TypeCount returns the number of types in the TPI stream.
@@ -505,12 +3371,15 @@ func (p *PDB) TypeCount() int {
 }
 
 // Sections returns the PE section information.
-// Uses PE section headers when available (more accurate), falls back to section map.
+// Uses PE section headers when available (more accurate), then the linker's
+// S_SECTION records, and falls back to the section map.
 func (p *PDB) Sections() []SectionInfo {
-	if p.sections != nil {
-		return p.sections
-	}
+	p.sectionsOnce.Do(p.loadSections)
+	return p.sections
+}
 
+// loadSections builds the section cache.
+func (p *PDB) loadSections() {
 	p.sections = make([]SectionInfo, 0)
 
 	// Prefer PE section headers (from debug stream) if available
@@ -523,12 +3392,25 @@ func (p *PDB) Sections() []SectionInfo {
 				Length: hdr.VirtualSize,
 			})
 		}
-		return p.sections
+		return
+	}
+
+	// Then the sections recorded by the linker
+	if images := p.ImageSections(); len(images) > 0 {
+		for _, sec := range images {
+			p.sections = append(p.sections, SectionInfo{
+				Index:  sec.Index,
+				Name:   sec.Name,
+				Offset: sec.RVA,
+				Length: sec.Length,
+			})
+		}
+		return
 	}
 
 	// Fall back to section map
 	if p.dbi == nil || len(p.dbi.SectionMap) == 0 {
-		return p.sections
+		return
 	}
 
 	for i, entry := range p.dbi.SectionMap {
@@ -542,14 +3424,94 @@ func (p *PDB) Sections() []SectionInfo {
 			Length: entry.SectionLength,
 		})
 	}
+}
 
-	return p.sections
+// ImageSections returns the sections of the linked image from the linker's
+// S_SECTION records, sorted by section number, each with the S_COFFGROUP
+// groups that fall in it. Unlike the section headers of the optional debug
+// header stream, these are present in most PDBs written by link.exe.
+func (p *PDB) ImageSections() []ImageSection {
+	p.imageSecsOnce.Do(p.loadImageSections)
+	return p.imageSecs
+}
+
+// loadImageSections builds the image section cache. Group RVAs are
+// computed from their section, as SegmentToRVA may itself depend on the
+// image sections.
+func (p *PDB) loadImageSections() {
+	p.imageSecs = make([]ImageSection, 0)
+
+	var groups []codeview.CoffGroupSym
+	err := p.WalkSymbols(func(sym codeview.SymbolRecord, module string) error {
+		switch sym.Kind {
+		case codeview.S_SECTION:
+			sec, err := codeview.ParseSectionSym(sym.Data)
+			if err != nil {
+				return nil
+			}
+			p.imageSecs = append(p.imageSecs, ImageSection{
+				Index:           sec.Section,
+				Name:            sec.Name,
+				RVA:             sec.RVA,
+				Length:          sec.Length,
+				Characteristics: sec.Characteristics,
+				Alignment:       1 << (sec.Alignment & 31),
+			})
+		case codeview.S_COFFGROUP:
+			if group, err := codeview.ParseCoffGroupSym(sym.Data); err == nil {
+				groups = append(groups, *group)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.warnf("failed to read image section symbols: %w", err)
+	}
+
+	sort.SliceStable(p.imageSecs, func(a, b int) bool {
+		return p.imageSecs[a].Index < p.imageSecs[b].Index
+	})
+	for _, group := range groups {
+		sec := findImageSection(p.imageSecs, group.Segment)
+		if sec == nil {
+			continue
+		}
+		sec.Groups = append(sec.Groups, CoffGroup{
+			Name:            group.Name,
+			Offset:          group.Offset,
+			RVA:             sec.RVA + group.Offset,
+			Length:          group.Length,
+			Characteristics: group.Characteristics,
+		})
+	}
+}
+
+// findImageSection returns the image section numbered index, or nil.
+func findImageSection(sections []ImageSection, index uint16) *ImageSection {
+	i := sort.Search(len(sections), func(i int) bool {
+		return sections[i].Index >= index
+	})
+	if i < len(sections) && sections[i].Index == index {
+		return &sections[i]
+	}
+	return nil
 }
 
 // SegmentToRVA converts a segment:offset pair to an RVA (Relative Virtual Address).
 // Segment is 1-based (as used in PDB symbols).
-// Returns 0 if the segment is invalid or section headers are not available.
+// Returns 0 if the segment is invalid or no section layout is available.
+// Sections come from the PE section headers, else from ImageSections.
+// When the PDB carries OMAP tables the result is translated to the final
+// image layout.
 func (p *PDB) SegmentToRVA(segment uint16, offset uint32) uint32 {
+	// Reordered binaries: map through the original layout and the OMAP
+	if len(p.omapFromSrc) > 0 {
+		if segment == 0 || int(segment) > len(p.origHeaders) {
+			return 0
+		}
+		return p.TranslateRVA(p.origHeaders[segment-1].VirtualAddress + offset)
+	}
+
 	// Prefer PE section headers (from debug stream) if available
 	if len(p.sectionHeaders) > 0 {
 		if segment == 0 || int(segment) > len(p.sectionHeaders) {
@@ -558,6 +3520,14 @@ func (p *PDB) SegmentToRVA(segment uint16, offset uint32) uint32 {
 		return p.sectionHeaders[segment-1].VirtualAddress + offset
 	}
 
+	// Then the sections recorded by the linker
+	if images := p.ImageSections(); len(images) > 0 {
+		if sec := findImageSection(images, segment); sec != nil {
+			return sec.RVA + offset
+		}
+		return 0
+	}
+
 	// Fall back to section map
 	if p.dbi == nil || len(p.dbi.SectionMap) == 0 {
 		return 0
@@ -571,3 +3541,54 @@ func (p *PDB) SegmentToRVA(segment uint16, offset uint32) uint32 {
 	entry := p.dbi.SectionMap[segment-1]
 	return entry.Offset + offset
 }
+
+// RVAToSegmentOffset is the inverse of SegmentToRVA: it returns the 1-based
+// segment and the offset within it of the section containing rva. ok is
+// false if no section contains the RVA. For binaries reordered after
+// linking, the RVA is first mapped back to the original layout, which needs
+// the OMAP to source table.
+func (p *PDB) RVAToSegmentOffset(rva uint32) (segment uint16, offset uint32, ok bool) {
+	if len(p.omapFromSrc) > 0 {
+		if rva = streams.TranslateOMAP(p.omapToSrc, rva); rva == 0 {
+			return 0, 0, false
+		}
+		return sectionContaining(p.origHeaders, rva)
+	}
+
+	if len(p.sectionHeaders) > 0 {
+		return sectionContaining(p.sectionHeaders, rva)
+	}
+
+	if images := p.ImageSections(); len(images) > 0 {
+		for _, sec := range images {
+			if rva >= sec.RVA && rva-sec.RVA < sec.Length {
+				return sec.Index, rva - sec.RVA, true
+			}
+		}
+		return 0, 0, false
+	}
+
+	// Fall back to section map
+	if p.dbi == nil {
+		return 0, 0, false
+	}
+	for i, entry := range p.dbi.SectionMap {
+		if rva >= entry.Offset && rva-entry.Offset < entry.SectionLength {
+			return uint16(i + 1), rva - entry.Offset, true
+		}
+	}
+	return 0, 0, false
+}
+
+// sectionContaining returns the 1-based index of the section header whose
+// virtual range contains rva, and the offset of rva in it.
+func sectionContaining(headers []streams.PESectionHeader, rva uint32) (uint16, uint32, bool) {
+	for i := range headers {
+		hdr := &headers[i]
+		size := max(hdr.VirtualSize, hdr.SizeOfRawData)
+		if rva >= hdr.VirtualAddress && rva-hdr.VirtualAddress < size {
+			return uint16(i + 1), rva - hdr.VirtualAddress, true
+		}
+	}
+	return 0, 0, false
+}
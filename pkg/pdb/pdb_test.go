@@ -0,0 +1,910 @@
+package pdb
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jtang613/gopdb/pkg/pdb/codeview"
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+func TestIDProcSignatures(t *testing.T) {
+	p := openPDB(t, &testPDB{
+		types: []bb{
+			leaf(streams.LF_ARGLIST).u32(1).u32(streams.T_INT4), // 0x1000
+			leaf(streams.LF_PROCEDURE).u32(streams.T_INT4).u8(0).u8(0).u16(1).u32(0x1000),
+			leaf(streams.LF_STRUCTURE_newformat).u16(0).u16(0).u32(0).u32(0).u32(0).u16(4).str("Foo"),
+			leaf(streams.LF_POINTER).u32(0x1002).u32(0x0C | 8<<13),
+			leaf(streams.LF_MFUNCTION).u32(streams.T_VOID).u32(0x1002).u32(0x1003).u8(0x0B).u8(0).u16(1).u32(0x1000).u32(0),
+		},
+		ids: []bb{
+			leaf(streams.LF_FUNC_ID).u32(0).u32(0x1001).str("add"),       // 0x1000
+			leaf(streams.LF_MFUNC_ID).u32(0x1002).u32(0x1004).str("set"), // 0x1001
+		},
+		modules: []testModule{{
+			name: "a.obj",
+			syms: bb(nil).
+				bytes(symbol(codeview.S_GPROC32_ID, procSym(0x1000, 0x10, 1, 0x10, "add"))).
+				bytes(symbol(codeview.S_END, nil)).
+				bytes(symbol(codeview.S_LPROC32_ID, procSym(0x1001, 0x20, 1, 0x10, "Foo::set"))).
+				bytes(symbol(codeview.S_END, nil)).
+				bytes(symbol(codeview.S_GPROC32, procSym(0x1001, 0x30, 1, 0x10, "sub"))).
+				bytes(symbol(codeview.S_END, nil)).
+				bytes(symbol(codeview.S_GPROC32_ID, procSym(0x1005, 0x40, 1, 0x10, "lost"))).
+				bytes(symbol(codeview.S_END, nil)),
+		}},
+	})
+
+	want := map[string]string{
+		// _ID procedures carry IPI indices, which are the same numbers as
+		// unrelated TPI types
+		"add":      "int32 __cdecl(int32)",
+		"Foo::set": "void (__thiscall Foo::)(int32)",
+		"sub":      "int32 __cdecl(int32)",
+		"lost":     "id_0x1005",
+	}
+	functions, err := p.FunctionsE()
+	if err != nil {
+		t.Fatalf("FunctionsE: %v", err)
+	}
+	if len(functions) != len(want) {
+		t.Fatalf("got %d functions, want %d", len(functions), len(want))
+	}
+	for _, fn := range functions {
+		if fn.Signature != want[fn.Name] {
+			t.Errorf("%s: Signature = %q, want %q", fn.Name, fn.Signature, want[fn.Name])
+		}
+	}
+}
+
+func TestModuleSourceFiles(t *testing.T) {
+	// The source info header's file count is written as 0, as for a PDB
+	// with 65536 files, so the per-module counts must be used
+	modules := []testModule{
+		{name: "a.obj", files: []string{`d:\src\a.cpp`, `d:\src\common.h`, `c:\sdk\windows.h`}},
+		{name: "b.obj", files: []string{`d:\src\b.cpp`, `d:\src\common.h`}},
+		{name: "* Linker *"},
+		{name: "c.obj", files: []string{`d:\src\c.cpp`}},
+	}
+	p := openPDB(t, &testPDB{modules: modules})
+
+	got := p.Modules()
+	if len(got) != len(modules) {
+		t.Fatalf("got %d modules, want %d", len(got), len(modules))
+	}
+	for i, mod := range got {
+		if int(mod.SourceFiles) != len(mod.FileNames) {
+			t.Errorf("%s: %d file names, SourceFileCount %d", mod.Name, len(mod.FileNames), mod.SourceFiles)
+		}
+		if want := modules[i].files; len(mod.FileNames) != len(want) || len(want) > 0 && !reflect.DeepEqual(mod.FileNames, want) {
+			t.Errorf("%s: FileNames = %q, want %q", mod.Name, mod.FileNames, want)
+		}
+	}
+
+	want := []string{`c:\sdk\windows.h`, `d:\src\a.cpp`, `d:\src\b.cpp`, `d:\src\c.cpp`, `d:\src\common.h`}
+	if files := p.SourceFiles(); !reflect.DeepEqual(files, want) {
+		t.Errorf("SourceFiles = %q, want %q", files, want)
+	}
+}
+
+func TestTruncatedDBIWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		dbiSize int
+		warning string
+	}{
+		{"one byte", 1, "failed to parse DBI stream: DBI stream too small: 1 bytes"},
+		{"partial header", 40, "failed to parse DBI stream: DBI stream too small: 40 bytes"},
+		{"header less a byte", 63, "failed to parse DBI stream: DBI stream too small: 63 bytes"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := openPDB(t, &testPDB{
+				globals: symbol(codeview.S_PUB32, pubSym(0, 0x10, 1, "main")),
+				dbiSize: tc.dbiSize,
+			})
+
+			warnings := p.Warnings()
+			if len(warnings) != 1 || warnings[0].Error() != tc.warning {
+				t.Fatalf("Warnings = %v, want [%s]", warnings, tc.warning)
+			}
+			// Accessors that need the DBI stream report the same failure
+			if _, err := p.FunctionsE(); err == nil || err.Error() != tc.warning {
+				t.Errorf("FunctionsE error = %v, want %q", err, tc.warning)
+			}
+			if _, err := p.VariablesE(); err == nil || err.Error() != tc.warning {
+				t.Errorf("VariablesE error = %v, want %q", err, tc.warning)
+			}
+		})
+	}
+}
+
+func TestOMAPRoundTrip(t *testing.T) {
+	// The original .text is reordered into four blocks: A and B swap, C is
+	// eliminated and D moves past A
+	p := openPDB(t, &testPDB{
+		origSections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000)},
+		sections:     []streams.PESectionHeader{section(".text", 0x1000, 0x1600)},
+		omapFromSrc: []streams.OMAPEntry{
+			{From: 0x1000, To: 0x1800}, // A
+			{From: 0x1100, To: 0x1000}, // B
+			{From: 0x1200, To: 0},      // C
+			{From: 0x1300, To: 0x1900}, // D
+		},
+		omapToSrc: []streams.OMAPEntry{
+			{From: 0x1000, To: 0x1100},
+			{From: 0x1100, To: 0},
+			{From: 0x1800, To: 0x1000},
+			{From: 0x1900, To: 0x1300},
+		},
+		modules: []testModule{{
+			name: "a.obj",
+			syms: symbol(codeview.S_GPROC32, procSym(0, 0x50, 1, 0x20, "inA")),
+		}},
+	})
+
+	tests := []struct {
+		orig, final uint32
+	}{
+		{0x1000, 0x1800},
+		{0x1050, 0x1850},
+		{0x1100, 0x1000},
+		{0x11FF, 0x10FF},
+		{0x1200, 0},
+		{0x1250, 0},
+		{0x1300, 0x1900},
+		{0x1FFF, 0x25FF},
+		{0x0FFF, 0},
+	}
+	for _, tc := range tests {
+		if got := p.TranslateRVA(tc.orig); got != tc.final {
+			t.Errorf("TranslateRVA(0x%x) = 0x%x, want 0x%x", tc.orig, got, tc.final)
+		}
+		if tc.orig < 0x1000 {
+			continue
+		}
+		if got := p.SegmentToRVA(1, tc.orig-0x1000); got != tc.final {
+			t.Errorf("SegmentToRVA(1, 0x%x) = 0x%x, want 0x%x", tc.orig-0x1000, got, tc.final)
+		}
+		if tc.final == 0 {
+			continue
+		}
+		seg, off, ok := p.RVAToSegmentOffset(tc.final)
+		if !ok || seg != 1 || off != tc.orig-0x1000 {
+			t.Errorf("RVAToSegmentOffset(0x%x) = %d:0x%x, %v; want 1:0x%x", tc.final, seg, off, ok, tc.orig-0x1000)
+		}
+	}
+
+	// Eliminated code has no original address
+	if seg, off, ok := p.RVAToSegmentOffset(0x1150); ok {
+		t.Errorf("RVAToSegmentOffset(0x1150) = %d:0x%x, want no section", seg, off)
+	}
+	if fn := p.SymbolAtRVA(0x1860); fn == nil || fn.Name != "inA" || fn.RVA != 0x1850 {
+		t.Errorf("SymbolAtRVA(0x1860) = %+v, want inA at 0x1850", fn)
+	}
+}
+
+func TestSymbolServerPath(t *testing.T) {
+	// {3A7C1B2D-4E5F-6789-ABCD-EF0123456789} as stored in the PDB: Data1,
+	// Data2 and Data3 little-endian, Data4 as bytes
+	guid := [16]byte{0x2D, 0x1B, 0x7C, 0x3A, 0x5F, 0x4E, 0x89, 0x67, 0xAB, 0xCD, 0xEF, 0x01, 0x23, 0x45, 0x67, 0x89}
+
+	tests := []struct {
+		name    string
+		age     uint32
+		dbiAge  uint32
+		pdbName string
+		want    string
+	}{
+		{"age 1", 1, 1, "foo.pdb", "foo.pdb/3A7C1B2D4E5F6789ABCDEF01234567891/foo.pdb"},
+		{"hex age", 0x2A, 0x2A, "foo.pdb", "foo.pdb/3A7C1B2D4E5F6789ABCDEF01234567892A/foo.pdb"},
+		// The DBI age is a copy that can lag behind the PDB info stream's
+		{"stale DBI age", 0x1B, 0x1A, "foo.pdb", "foo.pdb/3A7C1B2D4E5F6789ABCDEF01234567891B/foo.pdb"},
+		{"Windows path", 3, 3, `C:\build\Release\foo.pdb`, "foo.pdb/3A7C1B2D4E5F6789ABCDEF01234567893/foo.pdb"},
+		{"slash path", 0x100, 0x100, "out/foo.pdb", "foo.pdb/3A7C1B2D4E5F6789ABCDEF0123456789100/foo.pdb"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := openPDB(t, &testPDB{guid: guid, age: tc.age, dbiAge: tc.dbiAge})
+			if got := p.SymbolServerPath(tc.pdbName); got != tc.want {
+				t.Errorf("SymbolServerPath(%q) = %q, want %q", tc.pdbName, got, tc.want)
+			}
+			if !p.Matches(guid, tc.age) {
+				t.Error("Matches disagrees with the age in the path")
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// The DBI header's age differs, as after an incremental update; the
+	// PDB info stream's is authoritative
+	p := openPDB(t, &testPDB{age: 3, dbiAge: 2})
+
+	other := testGUID
+	other[15] ^= 0xFF
+	tests := []struct {
+		name string
+		guid [16]byte
+		age  uint32
+		want bool
+	}{
+		{"match", testGUID, 3, true},
+		{"older age", testGUID, 2, false},
+		{"newer age", testGUID, 4, false},
+		{"zero age", testGUID, 0, false},
+		{"other GUID", other, 3, false},
+		{"zero GUID", [16]byte{}, 3, false},
+	}
+	for _, tc := range tests {
+		if got := p.Matches(tc.guid, tc.age); got != tc.want {
+			t.Errorf("%s: Matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	guid, age, signature := p.Identity()
+	if guid != testGUID || age != 3 || signature != 0x5F3759DF {
+		t.Errorf("Identity = %x, %d, 0x%x", guid, age, signature)
+	}
+}
+
+func TestFindAnchoring(t *testing.T) {
+	const mangled = "?Init@MyClass@@QEAAXXZ" // MyClass::Init
+	names := []string{"Init", "MyInit", "Initialize", mangled}
+	var globals bb
+	for i, name := range names {
+		off := uint32(0x10 * (i + 1))
+		globals = globals.
+			bytes(symbol(codeview.S_GPROC32, procSym(0, off, 1, 0x10, name))).
+			bytes(symbol(codeview.S_GDATA32, dataSym(streams.T_INT4, off, 2, name))).
+			bytes(symbol(codeview.S_PUB32, pubSym(codeview.PubFunction, off, 1, name)))
+	}
+	p := openPDB(t, &testPDB{globals: globals})
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"Init", names},
+		{"nit", names},
+		{"^Init", []string{"Init", "Initialize"}},
+		{"Init$", []string{"Init", "MyInit", mangled}},
+		{"^Init$", []string{"Init"}},
+		{"MyClass::Init", []string{mangled}},
+		{"^MyClass::Init$", []string{mangled}},
+		{"^My", []string{"MyInit", mangled}},
+		{"(?i)^init", []string{"Init", "Initialize"}},
+		{"^nit", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			functions, err := p.FindFunctions(tc.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			variables, err := p.FindVariables(tc.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			publics, err := p.FindPublicSymbols(tc.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotFunctions, gotVariables, gotPublics []string
+			for _, fn := range functions {
+				gotFunctions = append(gotFunctions, fn.Name)
+			}
+			for _, v := range variables {
+				gotVariables = append(gotVariables, v.Name)
+			}
+			for _, pub := range publics {
+				gotPublics = append(gotPublics, pub.Name)
+			}
+			for kind, got := range map[string][]string{"FindFunctions": gotFunctions, "FindVariables": gotVariables, "FindPublicSymbols": gotPublics} {
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("%s(%q) = %q, want %q", kind, tc.pattern, got, tc.want)
+				}
+			}
+		})
+	}
+
+	if _, err := p.FindFunctions("("); err == nil {
+		t.Error("FindFunctions accepted an invalid pattern")
+	}
+}
+
+// annotationSym encodes the data of an S_ANNOTATION record.
+func annotationSym(offset uint32, segment uint16, strs ...string) bb {
+	data := bb(nil).u32(offset).u16(segment).u16(uint16(len(strs)))
+	for _, s := range strs {
+		data = data.str(s)
+	}
+	return data
+}
+
+func TestAnnotations(t *testing.T) {
+	a := symbol(codeview.S_ANNOTATION, annotationSym(0x120, 1, "build=1234", "branch=main"))
+	b := symbol(codeview.S_ANNOTATION, annotationSym(0x200, 1, "marker"))
+	bad := bb(nil).u16(1).u16(0) // Ends the walk of the module
+	c := symbol(codeview.S_ANNOTATION, annotationSym(0x8, 2, "late"))
+	// Offsets in the module stream count its signature
+	offA := uint32(4)
+	offC := offA + uint32(len(a)+len(b)+len(bad))
+
+	ref := func(offset uint32) bb {
+		return symbol(codeview.S_ANNOTATIONREF, bb(nil).u32(0).u32(offset).u16(1).str(""))
+	}
+	p := openPDB(t, &testPDB{
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000), section(".data", 0x3000, 0x1000)},
+		globals:  ref(offA).bytes(ref(offC)),
+		modules: []testModule{{
+			name: "a.obj",
+			syms: a.bytes(b).bytes(bad).bytes(c),
+		}},
+	})
+
+	want := []Annotation{
+		{Offset: 0x120, Segment: 1, RVA: 0x1120, Strings: []string{"build=1234", "branch=main"}, Module: "a.obj"},
+		{Offset: 0x200, Segment: 1, RVA: 0x1200, Strings: []string{"marker"}, Module: "a.obj"},
+		// Only reachable through its reference
+		{Offset: 0x8, Segment: 2, RVA: 0x3008, Strings: []string{"late"}, Module: "a.obj"},
+	}
+	if got := p.Annotations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Annotations = %+v, want %+v", got, want)
+	}
+}
+
+func TestSegmentOffsetRoundTrip(t *testing.T) {
+	headers := []streams.PESectionHeader{
+		section(".text", 0x1000, 0x1000),
+		section(".rdata", 0x3000, 0x80),
+		section(".data", 0x5000, 0x200),
+	}
+	var linker bb
+	for i, h := range headers {
+		linker = linker.bytes(symbol(codeview.S_SECTION, bb(nil).u16(uint16(i+1)).u8(12).u8(0).
+			u32(h.VirtualAddress).u32(h.VirtualSize).u32(0).str(h.SectionName())))
+	}
+	layouts := map[string]*testPDB{
+		"section headers": {sections: headers},
+		"image sections":  {modules: []testModule{{name: "* Linker *", syms: linker}}},
+	}
+
+	tests := []struct {
+		segment uint16
+		offset  uint32
+		rva     uint32
+	}{
+		{1, 0, 0x1000},
+		{1, 0x123, 0x1123},
+		{1, 0xFFF, 0x1FFF},
+		{2, 0, 0x3000},
+		{2, 0x7F, 0x307F},
+		{3, 0x10, 0x5010},
+		{3, 0x1FF, 0x51FF},
+	}
+	for name, layout := range layouts {
+		t.Run(name, func(t *testing.T) {
+			p := openPDB(t, layout)
+			for _, tc := range tests {
+				if rva := p.SegmentToRVA(tc.segment, tc.offset); rva != tc.rva {
+					t.Errorf("SegmentToRVA(%d, 0x%x) = 0x%x, want 0x%x", tc.segment, tc.offset, rva, tc.rva)
+				}
+				seg, off, ok := p.RVAToSegmentOffset(tc.rva)
+				if !ok || seg != tc.segment || off != tc.offset {
+					t.Errorf("RVAToSegmentOffset(0x%x) = %d:0x%x, %v; want %d:0x%x", tc.rva, seg, off, ok, tc.segment, tc.offset)
+				}
+			}
+
+			// Outside every section
+			for _, rva := range []uint32{0, 0xFFF, 0x2000, 0x3080, 0x5200, 0xFFFFFFFF} {
+				if seg, off, ok := p.RVAToSegmentOffset(rva); ok {
+					t.Errorf("RVAToSegmentOffset(0x%x) = %d:0x%x, want no section", rva, seg, off)
+				}
+			}
+			for _, seg := range []uint16{0, 4} {
+				if rva := p.SegmentToRVA(seg, 0); rva != 0 {
+					t.Errorf("SegmentToRVA(%d, 0) = 0x%x, want 0", seg, rva)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldAtOffset(t *testing.T) {
+	p := openPDB(t, &testPDB{types: []bb{
+		leaf(streams.LF_FIELDLIST).
+			bytes(member("tag", streams.T_USHORT, 0)).
+			bytes(member("len", streams.T_USHORT, 2)).
+			bytes(member("flags", streams.T_INT4, 4)), // 0x1000
+		structure("Header", 3, 0x1000, 8),
+		leaf(streams.LF_FIELDLIST).bytes(member("id", streams.T_INT4, 0)), // 0x1002
+		structure("Base", 1, 0x1002, 4),
+		leaf(streams.LF_FIELDLIST).
+			bytes(leaf(streams.LF_BCLASS).u16(3).u32(0x1003).u16(0).pad()).
+			bytes(member("hdr", 0x1001, 4)).
+			bytes(member("crc", streams.T_INT4, 12)).
+			bytes(member("tail", streams.T_CHAR, 16)), // 0x1004
+		structure("Packet", 4, 0x1004, 20),
+	}})
+
+	tests := []struct {
+		off    uint64
+		name   string
+		offset uint64
+		err    string
+	}{
+		{0, "id", 0, ""},
+		{3, "id", 0, ""},
+		{4, "hdr.tag", 4, ""},
+		{6, "hdr.len", 6, ""},
+		{7, "hdr.len", 6, ""},
+		{9, "hdr.flags", 8, ""},
+		{12, "crc", 12, ""},
+		{16, "tail", 16, ""},
+		{17, "", 0, "no member of Packet at offset 17"},
+		{20, "", 0, "offset 20 is outside Packet (20 bytes)"},
+	}
+	for _, tc := range tests {
+		m, err := p.FieldAtOffset("Packet", tc.off)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("FieldAtOffset(Packet, %d) error = %v, want %q", tc.off, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FieldAtOffset(Packet, %d): %v", tc.off, err)
+			continue
+		}
+		if m.Name != tc.name || m.Offset != tc.offset {
+			t.Errorf("FieldAtOffset(Packet, %d) = %s at %d, want %s at %d", tc.off, m.Name, m.Offset, tc.name, tc.offset)
+		}
+	}
+
+	if _, err := p.FieldAtOffset("Missing", 0); err == nil {
+		t.Error("FieldAtOffset found a field of an unknown type")
+	}
+}
+
+func TestThreadLocalVariables(t *testing.T) {
+	tests := []struct {
+		kind    uint16
+		name    string
+		global  bool
+		tls     bool
+		rva     uint32
+		symKind SymbolKind
+	}{
+		{codeview.S_GDATA32, "g_data", true, false, 0x3010, SymbolData},
+		{codeview.S_LDATA32, "s_data", false, false, 0x3010, SymbolData},
+		{codeview.S_GTHREAD32, "g_tls", true, true, 0, SymbolThread},
+		{codeview.S_LTHREAD32, "s_tls", false, true, 0, SymbolThread},
+	}
+	var globals bb
+	for _, tc := range tests {
+		globals = globals.bytes(symbol(tc.kind, dataSym(streams.T_INT4, 0x10, 2, tc.name)))
+	}
+	p := openPDB(t, &testPDB{
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000), section(".data", 0x3000, 0x1000)},
+		globals:  globals,
+	})
+
+	variables := p.Variables()
+	if len(variables) != len(tests) {
+		t.Fatalf("got %d variables, want %d", len(variables), len(tests))
+	}
+	symbols := make(map[string]Symbol)
+	for _, sym := range p.Symbols() {
+		symbols[sym.Name] = sym
+	}
+	for i, tc := range tests {
+		v := variables[i]
+		if v.Name != tc.name || v.IsGlobal != tc.global || v.IsThreadLocal != tc.tls || v.RVA != tc.rva {
+			t.Errorf("%s: got %s global %v, thread-local %v, RVA 0x%x; want global %v, thread-local %v, RVA 0x%x",
+				codeview.SymbolKindName(tc.kind), v.Name, v.IsGlobal, v.IsThreadLocal, v.RVA, tc.global, tc.tls, tc.rva)
+		}
+		if v.Offset != 0x10 || v.TypeName != "int32" {
+			t.Errorf("%s: Offset = 0x%x, TypeName = %q", tc.name, v.Offset, v.TypeName)
+		}
+		if sym := symbols[tc.name]; sym.Kind != tc.symKind {
+			t.Errorf("%s: Symbols kind = %v, want %v", tc.name, sym.Kind, tc.symKind)
+		}
+	}
+}
+
+func TestSymbolsCollapsePublics(t *testing.T) {
+	p := openPDB(t, &testPDB{
+		types: []bb{
+			leaf(streams.LF_ARGLIST).u32(0), // 0x1000
+			leaf(streams.LF_PROCEDURE).u32(streams.T_INT4).u8(0).u8(0).u16(0).u32(0x1000),
+		},
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000), section(".data", 0x3000, 0x1000)},
+		globals: bb(nil).
+			bytes(symbol(codeview.S_PUB32, pubSym(codeview.PubFunction, 0x10, 1, "main"))).
+			bytes(symbol(codeview.S_PUB32, pubSym(0, 0x20, 2, "g_count"))).
+			bytes(symbol(codeview.S_PUB32, pubSym(codeview.PubFunction, 0x80, 1, "exit"))).
+			bytes(symbol(codeview.S_PUB32, pubSym(0, 0x10, 9, "nowhere"))).
+			bytes(symbol(codeview.S_GPROC32, procSym(0x1001, 0x10, 1, 0x40, "main"))).
+			bytes(symbol(codeview.S_GDATA32, dataSym(streams.T_INT4, 0x20, 2, "g_count"))),
+	})
+
+	want := []Symbol{
+		{Name: "nowhere", RVA: 0, Kind: SymbolPublic},
+		{Name: "main", RVA: 0x1010, Kind: SymbolFunction, TypeName: "int32 __cdecl(void)"},
+		{Name: "exit", RVA: 0x1080, Kind: SymbolPublic},
+		{Name: "g_count", RVA: 0x3020, Kind: SymbolData, TypeName: "int32"},
+	}
+	if got := p.Symbols(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Symbols = %+v, want %+v", got, want)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	label := func(offset uint32, flags uint8, name string) bb {
+		return symbol(codeview.S_LABEL32, bb(nil).u32(offset).u16(1).u8(flags).str(name))
+	}
+	p := openPDB(t, &testPDB{
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000)},
+		modules: []testModule{{
+			name: "asm.obj",
+			syms: bb(nil).
+				bytes(symbol(codeview.S_GPROC32, procSym(0, 0x20, 1, 0x100, "copy"))).
+				bytes(label(0x40, 0, "loop_top")).
+				bytes(label(0x80, codeview.ProcNeverReturn, "fatal")).
+				bytes(label(0x90, codeview.ProcNotReached|codeview.ProcFarReturn, "far_exit")).
+				bytes(symbol(codeview.S_LABEL32_ST, bb(nil).u32(0xA0).u16(1).u8(0).u8(4).bytes([]byte("done")))).
+				bytes(symbol(codeview.S_END, nil)),
+		}},
+	})
+
+	want := []Label{
+		{Name: "loop_top", Offset: 0x40, Segment: 1, RVA: 0x1040, Module: "asm.obj"},
+		{Name: "fatal", Offset: 0x80, Segment: 1, RVA: 0x1080, Flags: codeview.ProcNeverReturn, NoReturn: true, Module: "asm.obj"},
+		{Name: "far_exit", Offset: 0x90, Segment: 1, RVA: 0x1090, Flags: codeview.ProcNotReached | codeview.ProcFarReturn,
+			NotReached: true, FarReturn: true, Module: "asm.obj"},
+		{Name: "done", Offset: 0xA0, Segment: 1, RVA: 0x10A0, Module: "asm.obj"},
+	}
+	if got := p.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels = %+v, want %+v", got, want)
+	}
+}
+
+// BenchmarkFunctions lists the functions of a PDB where many functions
+// share a few procedure types, whose parameters are deep pointer chains,
+// so that resolving signatures is dominated by shared types.
+func BenchmarkFunctions(b *testing.B) {
+	const (
+		numFunctions = 20000
+		numProcs     = 500
+		numChains    = 4
+		chainDepth   = 8
+	)
+	bases := []uint32{streams.T_INT4, streams.T_CHAR, streams.T_REAL64, streams.T_UINT8}
+	var types []bb
+	var chainEnds []uint32
+	for c := 0; c < numChains; c++ {
+		to := bases[c]
+		for d := 0; d < chainDepth; d++ {
+			types = append(types, leaf(streams.LF_POINTER).u32(to).u32(0x0C|8<<13))
+			to = streams.TypeIndexBegin + uint32(len(types)) - 1
+		}
+		chainEnds = append(chainEnds, to)
+	}
+	var procs []uint32
+	for i := 0; i < numProcs; i++ {
+		types = append(types, leaf(streams.LF_ARGLIST).u32(2).
+			u32(chainEnds[i%numChains]).u32(chainEnds[(i+1)%numChains]))
+		argList := streams.TypeIndexBegin + uint32(len(types)) - 1
+		types = append(types, leaf(streams.LF_PROCEDURE).u32(chainEnds[(i+2)%numChains]).
+			u8(0).u8(0).u16(2).u32(argList))
+		procs = append(procs, argList+1)
+	}
+
+	var syms bb
+	for i := 0; i < numFunctions; i++ {
+		syms = syms.bytes(symbol(codeview.S_GPROC32,
+			procSym(procs[i%numProcs], uint32(i)*0x10, 1, 0x10, fmt.Sprintf("f%d", i)))).
+			bytes(symbol(codeview.S_END, nil))
+	}
+	path := writePDB(b, &testPDB{
+		types:    types,
+		sections: []streams.PESectionHeader{section(".text", 0x1000, numFunctions*0x10)},
+		modules:  []testModule{{name: "a.obj", syms: syms}},
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Functions caches its result, so each iteration opens the PDB anew
+		b.StopTimer()
+		p, err := Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if funcs := p.Functions(); len(funcs) != numFunctions {
+			b.Fatalf("Functions returned %d functions, want %d", len(funcs), numFunctions)
+		}
+		b.StopTimer()
+		p.Close()
+		b.StartTimer()
+	}
+}
+
+func TestOpenWithoutTPI(t *testing.T) {
+	path := writePDB(t, &testPDB{
+		types: []bb{
+			leaf(streams.LF_ARGLIST).u32(0), // 0x1000
+			leaf(streams.LF_PROCEDURE).u32(streams.T_INT4).u8(0).u8(0).u16(0).u32(0x1000),
+		},
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000)},
+		modules: []testModule{{name: "a.obj", syms: bb(nil).
+			bytes(symbol(codeview.S_GPROC32, procSym(0x1001, 0x10, 1, 0x20, "main"))).
+			bytes(symbol(codeview.S_END, nil))}},
+	})
+	p, err := OpenWith(path, Options{SkipTPI: true, SkipSections: true})
+	if err != nil {
+		t.Fatalf("OpenWith: %v", err)
+	}
+	defer p.Close()
+
+	if secs := p.Sections(); len(secs) != 0 {
+		t.Errorf("Sections = %+v, want none", secs)
+	}
+	if types := p.Types(); len(types) != 0 {
+		t.Errorf("Types = %d types, want none", len(types))
+	}
+	if funcs := p.Functions(); len(funcs) != 1 || funcs[0].Signature != "type_0x1001" {
+		t.Errorf("Functions = %+v, want main with signature type_0x1001", funcs)
+	}
+
+	tests := []struct {
+		index uint32
+		want  TypeInfo
+	}{
+		{0x1001, TypeInfo{Index: 0x1001, Name: "type_0x1001", Signature: "type_0x1001"}},
+		{0x2000, TypeInfo{Index: 0x2000, Name: "type_0x2000", Signature: "type_0x2000"}},
+		{streams.T_INT4, TypeInfo{Index: streams.T_INT4, Kind: "builtin", Name: "int32", Signature: "int32"}},
+	}
+	for _, tc := range tests {
+		ti := p.ResolveType(tc.index)
+		if ti == nil {
+			t.Errorf("ResolveType(0x%x) = nil", tc.index)
+			continue
+		}
+		if !reflect.DeepEqual(*ti, tc.want) {
+			t.Errorf("ResolveType(0x%x) = %+v, want %+v", tc.index, *ti, tc.want)
+		}
+	}
+}
+
+func TestFrameData(t *testing.T) {
+	// cbProlog:8 cbRegs:3 fHasSEH:1 fUseBP:1 reserved:1 cbFrame:2
+	fpoBits := func(prolog, regs uint16, seh, bp bool, frame uint16) uint16 {
+		bits := prolog | regs<<8 | frame<<14
+		if seh {
+			bits |= 1 << 11
+		}
+		if bp {
+			bits |= 1 << 12
+		}
+		return bits
+	}
+	fpo := bb(nil).
+		u32(0x1000).u32(0x40).u32(3).u16(2).u16(fpoBits(6, 3, true, false, streams.FrameTypeFPO)).
+		u32(0x1040).u32(0x20).u32(0).u16(1).u16(fpoBits(0xFF, 7, false, true, streams.FrameTypeNonFPO))
+	// A relocation pointer precedes the records
+	frameData := bb(nil).u32(0xDEADBEEF).
+		u32(0x1000).u32(0x40).u32(0xC).u32(8).u32(0x100).u32(0).u16(6).u16(0xC).
+		u32(streams.FrameDataIsFunctionStart | streams.FrameDataHasSEH).
+		u32(0x1010).u32(0x30).u32(0x10).u32(0).u32(0x80).u32(1).u16(0).u16(4).
+		u32(streams.FrameDataHasEH)
+
+	p := openPDB(t, &testPDB{fpo: fpo, frameData: frameData})
+
+	wantFPO := []streams.FPOData{
+		{OffStart: 0x1000, ProcSize: 0x40, Locals: 3, Params: 2, Prolog: 6, Regs: 3, HasSEH: true, FrameType: streams.FrameTypeFPO},
+		{OffStart: 0x1040, ProcSize: 0x20, Params: 1, Prolog: 0xFF, Regs: 7, UseBP: true, FrameType: streams.FrameTypeNonFPO},
+	}
+	if got := p.FPOData(); !reflect.DeepEqual(got, wantFPO) {
+		t.Errorf("FPOData = %+v, want %+v", got, wantFPO)
+	}
+
+	wantFrames := []streams.FrameData{
+		{RVAStart: 0x1000, BlockSize: 0x40, LocalSize: 0xC, ParamsSize: 8, MaxStackSize: 0x100, PrologSize: 6, SavedRegsSize: 0xC,
+			Flags: streams.FrameDataIsFunctionStart | streams.FrameDataHasSEH},
+		{RVAStart: 0x1010, BlockSize: 0x30, LocalSize: 0x10, MaxStackSize: 0x80, FrameFunc: 1, SavedRegsSize: 4,
+			Flags: streams.FrameDataHasEH},
+	}
+	if got := p.FrameData(); !reflect.DeepEqual(got, wantFrames) {
+		t.Errorf("FrameData = %+v, want %+v", got, wantFrames)
+	}
+
+	// Without the streams there is nothing to unwind with
+	p = openPDB(t, &testPDB{})
+	if fpo, frames := p.FPOData(), p.FrameData(); fpo != nil || frames != nil {
+		t.Errorf("FPOData, FrameData = %+v, %+v; want nil", fpo, frames)
+	}
+}
+
+func TestSetDemangleOff(t *testing.T) {
+	const (
+		funcName = "?Init@MyClass@@QEAAXXZ"
+		varName  = "?g_count@@3HA"
+	)
+	p := openPDB(t, &testPDB{
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x1000), section(".data", 0x3000, 0x1000)},
+		globals: bb(nil).
+			bytes(symbol(codeview.S_PUB32, pubSym(codeview.PubFunction, 0x10, 1, funcName))).
+			bytes(symbol(codeview.S_GDATA32, dataSym(streams.T_INT4, 0x20, 2, varName))),
+		modules: []testModule{{name: "a.obj", syms: bb(nil).
+			bytes(symbol(codeview.S_GPROC32, procSym(streams.T_NOTYPE, 0x10, 1, 0x20, funcName))).
+			bytes(symbol(codeview.S_END, nil))}},
+	})
+	p.SetDemangle(false)
+
+	funcs := p.Functions()
+	if len(funcs) != 1 || funcs[0].Name != funcName || funcs[0].DemangledName != "" || funcs[0].Prototype != "" {
+		t.Errorf("Functions = %+v, want %s with no demangled name or prototype", funcs, funcName)
+	}
+	vars := p.Variables()
+	if len(vars) != 1 || vars[0].Name != varName || vars[0].DemangledName != "" || vars[0].Prototype != "" {
+		t.Errorf("Variables = %+v, want %s with no demangled name or prototype", vars, varName)
+	}
+	pubs := p.PublicSymbols()
+	if len(pubs) != 1 || pubs[0].Name != funcName || pubs[0].DemangledName != "" || pubs[0].Prototype != "" {
+		t.Errorf("PublicSymbols = %+v, want %s with no demangled name or prototype", pubs, funcName)
+	}
+}
+
+// BenchmarkFunctionsDemangle lists functions with mangled names, with
+// demangling on and off.
+func BenchmarkFunctionsDemangle(b *testing.B) {
+	const numFunctions = 20000
+	var syms bb
+	for i := 0; i < numFunctions; i++ {
+		name := fmt.Sprintf("?Method%d@Class%d@ns@@QEAAHPEBDAEAV?$vector@HV?$allocator@H@std@@@std@@@Z", i, i%100)
+		syms = syms.bytes(symbol(codeview.S_GPROC32, procSym(streams.T_NOTYPE, uint32(i)*0x10, 1, 0x10, name))).
+			bytes(symbol(codeview.S_END, nil))
+	}
+	path := writePDB(b, &testPDB{
+		sections: []streams.PESectionHeader{section(".text", 0x1000, numFunctions*0x10)},
+		modules:  []testModule{{name: "a.obj", syms: syms}},
+	})
+
+	for _, demangle := range []bool{true, false} {
+		name := "on"
+		if !demangle {
+			name = "off"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// Functions caches its result, so each iteration opens the PDB anew
+				b.StopTimer()
+				p, err := Open(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				p.SetDemangle(demangle)
+				b.StartTimer()
+				if funcs := p.Functions(); len(funcs) != numFunctions {
+					b.Fatalf("Functions returned %d functions, want %d", len(funcs), numFunctions)
+				}
+				b.StopTimer()
+				p.Close()
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func TestModuleBuildEnv(t *testing.T) {
+	env := func(pairs ...string) bb {
+		b := bb(nil).u8(0)
+		for _, s := range pairs {
+			b = b.str(s)
+		}
+		return b.u8(0)
+	}
+	p := openPDB(t, &testPDB{
+		modules: []testModule{
+			{name: "a.obj", syms: bb(nil).
+				bytes(symbol(codeview.S_OBJNAME, bb(nil).u32(0xC0FFEE).str(`C:\obj\a.obj`))).
+				bytes(symbol(codeview.S_ENVBLOCK, env("cwd", `C:\src`, "cmd", "-c -Zi"))).
+				bytes(symbol(codeview.S_GPROC32, procSym(streams.T_NOTYPE, 0x10, 1, 0x20, "main"))).
+				bytes(symbol(codeview.S_END, nil)).
+				// Records after the first scope are not header records
+				bytes(symbol(codeview.S_ENVBLOCK, env("cwd", "elsewhere")))},
+			{name: "b.obj", syms: bb(nil).
+				bytes(symbol(codeview.S_GPROC32, procSym(streams.T_NOTYPE, 0x30, 1, 0x20, "f"))).
+				bytes(symbol(codeview.S_END, nil)).
+				bytes(symbol(codeview.S_ENVBLOCK, env("cwd", "elsewhere")))},
+			{name: "c.obj"},
+		},
+	})
+
+	mods := p.Modules()
+	if len(mods) != 3 {
+		t.Fatalf("Modules = %d modules, want 3", len(mods))
+	}
+	wantEnv := map[string]string{"cwd": `C:\src`, "cmd": "-c -Zi"}
+	if mods[0].ObjNameSignature != 0xC0FFEE || !reflect.DeepEqual(mods[0].BuildEnv, wantEnv) {
+		t.Errorf("a.obj: ObjNameSignature, BuildEnv = %#x, %v; want 0xc0ffee, %v", mods[0].ObjNameSignature, mods[0].BuildEnv, wantEnv)
+	}
+	for _, mod := range mods[1:] {
+		if mod.ObjNameSignature != 0 || mod.BuildEnv != nil {
+			t.Errorf("%s: ObjNameSignature, BuildEnv = %#x, %v; want none", mod.Name, mod.ObjNameSignature, mod.BuildEnv)
+		}
+	}
+
+	// Later calls return the same header records
+	if again := p.Modules(); !reflect.DeepEqual(again, mods) {
+		t.Errorf("second Modules = %+v, want %+v", again, mods)
+	}
+}
+
+func TestMethodSignatures(t *testing.T) {
+	p := openPDB(t, &testPDB{types: []bb{
+		leaf(streams.LF_ARGLIST).u32(1).u32(streams.T_CHAR), // 0x1000
+		leaf(streams.LF_POINTER).u32(0x1005).u32(0x0C | 8<<13),
+		leaf(streams.LF_MFUNCTION).u32(streams.T_INT4).u32(0x1005).u32(0x1001).u8(0x0B).u8(0).u16(1).u32(0x1000).u32(0), // 0x1002
+		leaf(streams.LF_MFUNCTION).u32(streams.T_VOID).u32(0x1005).u32(streams.T_NOTYPE).u8(0).u8(0).u16(1).u32(0x1000).u32(0),
+		leaf(streams.LF_FIELDLIST).
+			bytes(leaf(streams.LF_ONEMETHOD_newformat).u16(3).u32(0x1002).str("bar").pad()).
+			bytes(leaf(streams.LF_ONEMETHOD_newformat).u16(3 | 2<<2).u32(0x1003).str("reset").pad()), // 0x1004
+		structure("Foo", 2, 0x1004, 1),
+	}})
+
+	ti := p.ResolveType(0x1005)
+	if ti == nil {
+		t.Fatal("ResolveType(0x1005) = nil")
+	}
+	want := []Method{
+		{Name: "bar", TypeIndex: 0x1002, TypeName: "int32 (__thiscall Foo::)(char)",
+			Signature: "int32 __thiscall Foo::bar(char)", Attributes: 3},
+		{Name: "reset", TypeIndex: 0x1003, TypeName: "static void (__cdecl Foo::)(char)",
+			Signature: "static void __cdecl Foo::reset(char)", Attributes: 3 | 2<<2},
+	}
+	if !reflect.DeepEqual(ti.Methods, want) {
+		t.Errorf("Methods = %+v, want %+v", ti.Methods, want)
+	}
+}
+
+func TestSymbolAtRVAZeroLength(t *testing.T) {
+	syms := bb(nil).
+		bytes(symbol(codeview.S_GPROC32, procSym(0, 0x10, 1, 0x20, "sized"))).
+		bytes(symbol(codeview.S_END, nil)).
+		bytes(symbol(codeview.S_GPROC32, procSym(0, 0x40, 1, 0, "middle"))).
+		bytes(symbol(codeview.S_END, nil)).
+		bytes(symbol(codeview.S_GPROC32, procSym(0, 0x60, 1, 0, "last"))).
+		bytes(symbol(codeview.S_END, nil))
+
+	tests := []struct {
+		rva  uint32
+		want string
+	}{
+		{0x1010, "sized"},
+		{0x1030, ""},
+		{0x1040, "middle"},
+		{0x105F, "middle"},
+		{0x1060, "last"},
+		{0x10FF, "last"},
+		{0x1100, ""}, // Past the end of .text
+		{0x5000, ""},
+	}
+	p := openPDB(t, &testPDB{
+		sections: []streams.PESectionHeader{section(".text", 0x1000, 0x100)},
+		modules:  []testModule{{name: "a.obj", syms: syms}},
+	})
+	for _, tc := range tests {
+		got := ""
+		if fn := p.SymbolAtRVA(tc.rva); fn != nil {
+			got = fn.Name
+		}
+		if got != tc.want {
+			t.Errorf("SymbolAtRVA(0x%x) = %q, want %q", tc.rva, got, tc.want)
+		}
+	}
+}
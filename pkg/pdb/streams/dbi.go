@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"sort"
 )
 
 // DBI Stream versions
@@ -22,9 +23,17 @@ const (
 	MachineIA64      = 0x0200
 	MachineAMD64     = 0x8664
 	MachineARM       = 0x01c0
+	MachineARMNT     = 0x01c4 // ARM Thumb-2, as on Windows on ARM
 	MachineARM64     = 0xAA64
 )
 
+// DBI header flags
+const (
+	DBIFlagIncrementallyLinked    = 0x0001 // Linked incrementally
+	DBIFlagPrivateSymbolsStripped = 0x0002 // Private symbols were stripped (/PDBSTRIPPED)
+	DBIFlagConflictingTypes       = 0x0004 // Linked with /DEBUG:CTYPES
+)
+
 // DBIHeader is the fixed header of the DBI stream (64 bytes).
 type DBIHeader struct {
 	VersionSignature       int32  // Always -1
@@ -44,7 +53,7 @@ type DBIHeader struct {
 	MFCTypeServerIndex     uint32
 	OptionalDbgHeaderSize  int32  // Size of optional debug header
 	ECSubstreamSize        int32  // Size of EC substream
-	Flags                  uint16
+	Flags                  uint16 // DBIFlag* bits
 	Machine                uint16 // CPU type
 	Padding                uint32
 }
@@ -56,6 +65,8 @@ type DBIStream struct {
 	SectionContribs []SectionContrib
 	SectionMap      []SectionMapEntry
 	DebugHeader     *OptionalDebugHeader
+	ECNames         *NamesTable  // Edit-and-Continue name table, nil if absent
+	TypeServers     []TypeServer // Entries of the type server map substream
 }
 
 // SectionMapHeader precedes the section map entries.
@@ -94,8 +105,11 @@ type ModuleInfo struct {
 	Unused2           uint32
 	SourceFileNameIndex uint32
 	PdbFilePathNameIndex uint32
+	SourceFileName    string // Resolved from SourceFileNameIndex via the EC names
+	PdbFilePath       string // Resolved from PdbFilePathNameIndex via the EC names
 	ModuleName        string // Object file name
 	ObjFileName       string // Archive or object file path
+	SourceFiles       []string // Source files contributing to this module
 }
 
 // SectionContrib describes a section contribution from a module.
@@ -109,8 +123,15 @@ type SectionContrib struct {
 	Padding2        uint16
 	DataCrc         uint32
 	RelocCrc        uint32
+	ISectCoff       uint32 // COFF section index (V2 entries only)
 }
 
+// Section contribution substream versions
+const (
+	SectionContribVer60 = 0xeffe0000 + 19970605
+	SectionContribV2    = 0xeffe0000 + 20140516
+)
+
 // ReadDBIStream parses the DBI stream.
 func ReadDBIStream(data []byte) (*DBIStream, error) {
 	if len(data) < 64 {
@@ -129,6 +150,16 @@ func ReadDBIStream(data []byte) (*DBIStream, error) {
 		return nil, fmt.Errorf("invalid DBI version signature: %d", header.VersionSignature)
 	}
 
+	// Substream sizes are signed; a negative one would yield offsets
+	// outside the stream
+	for _, size := range []int32{header.ModInfoSize, header.SectionContributionSize,
+		header.SectionMapSize, header.SourceInfoSize, header.TypeServerMapSize,
+		header.OptionalDbgHeaderSize, header.ECSubstreamSize} {
+		if size < 0 {
+			return nil, fmt.Errorf("invalid DBI substream size: %d", size)
+		}
+	}
+
 	dbi := &DBIStream{
 		Header: header,
 	}
@@ -137,7 +168,7 @@ func ReadDBIStream(data []byte) (*DBIStream, error) {
 	modInfoOffset := 64
 	secContribOffset := modInfoOffset + int(header.ModInfoSize)
 	secMapOffset := secContribOffset + int(header.SectionContributionSize)
-	// sourceInfoOffset := secMapOffset + int(header.SectionMapSize)
+	sourceInfoOffset := secMapOffset + int(header.SectionMapSize)
 
 	// Parse module info substream
 	if header.ModInfoSize > 0 {
@@ -175,6 +206,48 @@ func ReadDBIStream(data []byte) (*DBIStream, error) {
 		}
 	}
 
+	// Parse source info
+	if header.SourceInfoSize > 0 {
+		sourceInfoEnd := sourceInfoOffset + int(header.SourceInfoSize)
+		if sourceInfoEnd <= len(data) {
+			fileLists, err := parseSourceInfo(data[sourceInfoOffset:sourceInfoEnd])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse source info: %w", err)
+			}
+			for i := range dbi.Modules {
+				if i < len(fileLists) {
+					dbi.Modules[i].SourceFiles = fileLists[i]
+				}
+			}
+		}
+	}
+
+	// Parse type server map
+	if header.TypeServerMapSize > 0 {
+		tsMapOffset := sourceInfoOffset + int(header.SourceInfoSize)
+		tsMapEnd := tsMapOffset + int(header.TypeServerMapSize)
+		if tsMapEnd <= len(data) {
+			dbi.TypeServers = ParseTypeServerMap(data[tsMapOffset:tsMapEnd])
+		}
+	}
+
+	// Parse EC substream, a name table in the /names format. A damaged
+	// table only costs the module file names.
+	if header.ECSubstreamSize > 0 {
+		ecOffset := sourceInfoOffset + int(header.SourceInfoSize) + int(header.TypeServerMapSize)
+		ecEnd := ecOffset + int(header.ECSubstreamSize)
+		if ecEnd <= len(data) {
+			if names, err := ReadNamesStream(data[ecOffset:ecEnd]); err == nil {
+				dbi.ECNames = names
+				for i := range dbi.Modules {
+					mod := &dbi.Modules[i]
+					mod.SourceFileName = names.Get(mod.SourceFileNameIndex)
+					mod.PdbFilePath = names.Get(mod.PdbFilePathNameIndex)
+				}
+			}
+		}
+	}
+
 	// Parse optional debug header
 	if header.OptionalDbgHeaderSize > 0 {
 		// Calculate offset: after all other substreams
@@ -283,6 +356,61 @@ func parseModuleInfo(data []byte) ([]ModuleInfo, error) {
 	return modules, nil
 }
 
+// parseSourceInfo parses the source info substream and returns the list of
+// source file names for each module.
+func parseSourceInfo(data []byte) ([][]string, error) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+
+	numModules := int(binary.LittleEndian.Uint16(data[0:]))
+	// data[2:4] holds NumSourceFiles, which is truncated to 16 bits and
+	// unreliable; the real count is the sum of the per-module counts.
+	offset := 4
+
+	// Skip the module index array (unused)
+	offset += numModules * 2
+
+	// Some PDBs have truncated source info, just parse what we can
+	if offset+numModules*2 > len(data) {
+		return nil, nil
+	}
+	fileCounts := make([]int, numModules)
+	numFiles := 0
+	for i := 0; i < numModules; i++ {
+		fileCounts[i] = int(binary.LittleEndian.Uint16(data[offset:]))
+		numFiles += fileCounts[i]
+		offset += 2
+	}
+
+	if offset+numFiles*4 > len(data) {
+		numFiles = (len(data) - offset) / 4
+	}
+	nameOffsets := make([]uint32, numFiles)
+	for i := 0; i < numFiles; i++ {
+		nameOffsets[i] = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+	}
+
+	names := data[offset:]
+	fileLists := make([][]string, numModules)
+	fileIdx := 0
+	for i := 0; i < numModules; i++ {
+		files := make([]string, 0, fileCounts[i])
+		for j := 0; j < fileCounts[i] && fileIdx < numFiles; j++ {
+			nameOffset := nameOffsets[fileIdx]
+			fileIdx++
+			if nameOffset >= uint32(len(names)) {
+				continue
+			}
+			files = append(files, extractCString(names[nameOffset:]))
+		}
+		fileLists[i] = files
+	}
+
+	return fileLists, nil
+}
+
 // parseSectionContribs parses the section contribution substream.
 func parseSectionContribs(data []byte) ([]SectionContrib, error) {
 	if len(data) < 4 {
@@ -299,7 +427,7 @@ func parseSectionContribs(data []byte) ([]SectionContrib, error) {
 
 	// Determine entry size based on version
 	entrySize := 28 // Ver60 size
-	if version == 0xeffe0000+20140516 {
+	if version == SectionContribV2 {
 		entrySize = 32 // V2 adds ISectCoff
 	}
 
@@ -337,10 +465,10 @@ func parseSectionContribs(data []byte) ([]SectionContrib, error) {
 			break
 		}
 
-		// Skip extra field in V2
 		if entrySize == 32 {
-			var dummy uint32
-			binary.Read(r, binary.LittleEndian, &dummy)
+			if err := binary.Read(r, binary.LittleEndian, &contrib.ISectCoff); err != nil {
+				break
+			}
 		}
 
 		contribs = append(contribs, contrib)
@@ -412,6 +540,8 @@ func MachineTypeName(machine uint16) string {
 		return "x64"
 	case MachineARM:
 		return "ARM"
+	case MachineARMNT:
+		return "ARMNT"
 	case MachineARM64:
 		return "ARM64"
 	case MachineIA64:
@@ -421,6 +551,66 @@ func MachineTypeName(machine uint16) string {
 	}
 }
 
+// DBI BuildNumber fields
+const (
+	BuildNumberNewFormat  = 0x8000 // Set when major/minor are encoded
+	BuildNumberMajorShift = 8
+	BuildNumberMajorMask  = 0x7F
+	BuildNumberMinorMask  = 0xFF
+)
+
+// BuildNumberString decodes a DBI BuildNumber into the linker version, such
+// as "14.29", followed by the Visual Studio release for well-known versions,
+// as in "14.29 (VS2019 16.x)". Returns "" for the old format, which does not
+// encode a version.
+func BuildNumberString(bn uint16) string {
+	if bn&BuildNumberNewFormat == 0 {
+		return ""
+	}
+	major := int(bn>>BuildNumberMajorShift) & BuildNumberMajorMask
+	minor := int(bn) & BuildNumberMinorMask
+
+	version := fmt.Sprintf("%d.%02d", major, minor)
+	if name := toolsetName(major, minor); name != "" {
+		return version + " (" + name + ")"
+	}
+	return version
+}
+
+// toolsetName returns the Visual Studio release that ships the given
+// linker version, or "" if unknown.
+func toolsetName(major, minor int) string {
+	switch major {
+	case 7:
+		if minor >= 10 {
+			return "VS2003"
+		}
+		return "VS2002"
+	case 8:
+		return "VS2005"
+	case 9:
+		return "VS2008"
+	case 10:
+		return "VS2010"
+	case 11:
+		return "VS2012"
+	case 12:
+		return "VS2013"
+	case 14:
+		switch {
+		case minor < 10:
+			return "VS2015"
+		case minor < 20:
+			return "VS2017 15.x"
+		case minor < 30:
+			return "VS2019 16.x"
+		case minor < 50:
+			return "VS2022 17.x"
+		}
+	}
+	return ""
+}
+
 // HasSymbols returns true if the module has symbol information.
 func (m *ModuleInfo) HasSymbols() bool {
 	return m.ModuleSymStream != 0xFFFF && m.SymByteSize > 0
@@ -441,6 +631,34 @@ type OptionalDebugHeader struct {
 	SectionHdrOrig   uint16 // Original section header stream
 }
 
+// Present returns the stream indices of the header that are set, keyed by
+// field name ("FPO", "Pdata", "SectionHdr", ...). 0xFFFF marks an absent
+// stream.
+func (h *OptionalDebugHeader) Present() map[string]uint16 {
+	present := make(map[string]uint16)
+	for _, f := range []struct {
+		name  string
+		index uint16
+	}{
+		{"FPO", h.FPO},
+		{"Exception", h.Exception},
+		{"Fixup", h.Fixup},
+		{"OmapToSrc", h.OmapToSrc},
+		{"OmapFromSrc", h.OmapFromSrc},
+		{"SectionHdr", h.SectionHdr},
+		{"TokenRidMap", h.TokenRidMap},
+		{"Xdata", h.Xdata},
+		{"Pdata", h.Pdata},
+		{"NewFPO", h.NewFPO},
+		{"SectionHdrOrig", h.SectionHdrOrig},
+	} {
+		if f.index != 0xFFFF {
+			present[f.name] = f.index
+		}
+	}
+	return present
+}
+
 // PESectionHeader represents a PE section header from the debug stream.
 type PESectionHeader struct {
 	Name                 [8]byte
@@ -499,6 +717,44 @@ func ParseSectionHeaders(data []byte) []PESectionHeader {
 	return headers
 }
 
+// OMAPEntry maps an address range start in one image layout to the
+// other. A To of 0 means the code at From was eliminated.
+type OMAPEntry struct {
+	From uint32
+	To   uint32
+}
+
+// ParseOMAP parses an OMAP stream (OmapFromSrc or OmapToSrc) into entries
+// sorted by From.
+func ParseOMAP(data []byte) []OMAPEntry {
+	entries := make([]OMAPEntry, 0, len(data)/8)
+	for i := 0; i+8 <= len(data); i += 8 {
+		entries = append(entries, OMAPEntry{
+			From: binary.LittleEndian.Uint32(data[i:]),
+			To:   binary.LittleEndian.Uint32(data[i+4:]),
+		})
+	}
+
+	// Linkers emit sorted tables; sort defensively for the binary search
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].From < entries[j].From
+	})
+	return entries
+}
+
+// TranslateOMAP maps an address through an OMAP table. It finds the last
+// entry whose From is at or before addr and applies its delta. Addresses
+// before the first entry or in eliminated ranges map to 0.
+func TranslateOMAP(entries []OMAPEntry, addr uint32) uint32 {
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].From > addr
+	}) - 1
+	if i < 0 || entries[i].To == 0 {
+		return 0
+	}
+	return entries[i].To + (addr - entries[i].From)
+}
+
 // SectionName returns the section name as a string.
 func (h *PESectionHeader) SectionName() string {
 	// Find null terminator or use full 8 bytes
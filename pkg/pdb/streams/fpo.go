@@ -0,0 +1,94 @@
+package streams
+
+import (
+	"encoding/binary"
+)
+
+// FPO frame types (FPO_DATA cbFrame)
+const (
+	FrameTypeFPO    = 0 // Frame pointer omitted
+	FrameTypeTrap   = 1 // Kernel trap frame
+	FrameTypeTSS    = 2 // Task state segment
+	FrameTypeNonFPO = 3 // Standard EBP frame
+)
+
+// FRAMEDATA flags
+const (
+	FrameDataHasSEH          = 0x1 // Function has structured exception handling
+	FrameDataHasEH           = 0x2 // Function has C++ exception handling
+	FrameDataIsFunctionStart = 0x4 // Block starts the function
+)
+
+// FPOData is an old-format frame pointer omission record (FPO_DATA) from
+// the DBI FPO stream. Sizes in DWORDs are as stored.
+type FPOData struct {
+	OffStart  uint32 // Offset of the first byte of the function
+	ProcSize  uint32 // Function size in bytes
+	Locals    uint32 // Size of locals in DWORDs
+	Params    uint16 // Size of parameters in DWORDs
+	Prolog    uint8  // Prolog size in bytes
+	Regs      uint8  // Number of registers saved
+	HasSEH    bool   // Function uses structured exception handling
+	UseBP     bool   // EBP has been allocated
+	FrameType uint8  // FrameType* constant
+}
+
+// FrameData is a new-format frame data record (FRAMEDATA) from the DBI
+// NewFPO stream.
+type FrameData struct {
+	RVAStart      uint32 // RVA of the first byte of the block
+	BlockSize     uint32 // Block size in bytes
+	LocalSize     uint32 // Size of locals in bytes
+	ParamsSize    uint32 // Size of parameters in bytes
+	MaxStackSize  uint32 // Maximum stack size in bytes
+	FrameFunc     uint32 // /names offset of the unwind program
+	PrologSize    uint16 // Prolog size in bytes
+	SavedRegsSize uint16 // Size of saved registers in bytes
+	Flags         uint32 // FrameData* flags
+}
+
+// ParseFPO parses the records of a DBI FPO stream. A trailing partial
+// record is ignored.
+func ParseFPO(data []byte) []FPOData {
+	records := make([]FPOData, 0, len(data)/16)
+	for i := 0; i+16 <= len(data); i += 16 {
+		// cbProlog:8 cbRegs:3 fHasSEH:1 fUseBP:1 reserved:1 cbFrame:2
+		bits := binary.LittleEndian.Uint16(data[i+14:])
+		records = append(records, FPOData{
+			OffStart:  binary.LittleEndian.Uint32(data[i:]),
+			ProcSize:  binary.LittleEndian.Uint32(data[i+4:]),
+			Locals:    binary.LittleEndian.Uint32(data[i+8:]),
+			Params:    binary.LittleEndian.Uint16(data[i+12:]),
+			Prolog:    uint8(bits),
+			Regs:      uint8(bits>>8) & 0x7,
+			HasSEH:    bits&(1<<11) != 0,
+			UseBP:     bits&(1<<12) != 0,
+			FrameType: uint8(bits>>14) & 0x3,
+		})
+	}
+	return records
+}
+
+// ParseFrameData parses the records of a DBI NewFPO stream. The stream may
+// start with a 4-byte relocation pointer, which is skipped.
+func ParseFrameData(data []byte) []FrameData {
+	if len(data)%32 == 4 {
+		data = data[4:]
+	}
+
+	records := make([]FrameData, 0, len(data)/32)
+	for i := 0; i+32 <= len(data); i += 32 {
+		records = append(records, FrameData{
+			RVAStart:      binary.LittleEndian.Uint32(data[i:]),
+			BlockSize:     binary.LittleEndian.Uint32(data[i+4:]),
+			LocalSize:     binary.LittleEndian.Uint32(data[i+8:]),
+			ParamsSize:    binary.LittleEndian.Uint32(data[i+12:]),
+			MaxStackSize:  binary.LittleEndian.Uint32(data[i+16:]),
+			FrameFunc:     binary.LittleEndian.Uint32(data[i+20:]),
+			PrologSize:    binary.LittleEndian.Uint16(data[i+24:]),
+			SavedRegsSize: binary.LittleEndian.Uint16(data[i+26:]),
+			Flags:         binary.LittleEndian.Uint32(data[i+28:]),
+		})
+	}
+	return records
+}
@@ -0,0 +1,119 @@
+package streams
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// fpoRecord encodes an FPO_DATA record.
+func fpoRecord(offStart, procSize, locals uint32, params, bits uint16) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, offStart)
+	b = binary.LittleEndian.AppendUint32(b, procSize)
+	b = binary.LittleEndian.AppendUint32(b, locals)
+	b = binary.LittleEndian.AppendUint16(b, params)
+	return binary.LittleEndian.AppendUint16(b, bits)
+}
+
+// frameDataRecord encodes a FRAMEDATA record whose fields are all
+// rvaStart apart from the flags.
+func frameDataRecord(rvaStart, flags uint32) []byte {
+	var b []byte
+	for i := 0; i < 6; i++ {
+		b = binary.LittleEndian.AppendUint32(b, rvaStart)
+	}
+	b = binary.LittleEndian.AppendUint16(b, uint16(rvaStart))
+	b = binary.LittleEndian.AppendUint16(b, uint16(rvaStart))
+	return binary.LittleEndian.AppendUint32(b, flags)
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func TestParseFPO(t *testing.T) {
+	a := fpoRecord(0x1000, 0x40, 3, 2, 0xCB06)
+	b := fpoRecord(0x1040, 0x20, 0, 1, 0x37FF)
+
+	tests := []struct {
+		name string
+		data []byte
+		want []uint32 // OffStart of each record
+	}{
+		{"empty", nil, nil},
+		{"partial record", a[:15], nil},
+		{"one record", a, []uint32{0x1000}},
+		{"trailing partial record", concat(a, b[:8]), []uint32{0x1000}},
+		{"two records", concat(a, b), []uint32{0x1000, 0x1040}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseFPO(tc.data)
+			if len(got) != len(tc.want) {
+				t.Fatalf("ParseFPO = %d records, want %d", len(got), len(tc.want))
+			}
+			for i, r := range got {
+				if r.OffStart != tc.want[i] {
+					t.Errorf("record %d: OffStart = 0x%x, want 0x%x", i, r.OffStart, tc.want[i])
+				}
+			}
+		})
+	}
+
+	// The bitfields of the second word, with reserved bit 13 set in b
+	got := ParseFPO(concat(a, b))
+	want := []FPOData{
+		{OffStart: 0x1000, ProcSize: 0x40, Locals: 3, Params: 2, Prolog: 6, Regs: 3, HasSEH: true, FrameType: FrameTypeNonFPO},
+		{OffStart: 0x1040, ProcSize: 0x20, Params: 1, Prolog: 0xFF, Regs: 7, UseBP: true, FrameType: FrameTypeFPO},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseFrameData(t *testing.T) {
+	a := frameDataRecord(0x1000, FrameDataIsFunctionStart)
+	b := frameDataRecord(0x2000, FrameDataHasSEH|FrameDataHasEH)
+	reloc := []byte{0xEF, 0xBE, 0xAD, 0xDE}
+
+	tests := []struct {
+		name string
+		data []byte
+		want []uint32 // RVAStart of each record
+	}{
+		{"empty", nil, nil},
+		{"reloc only", reloc, nil},
+		{"partial record", a[:31], nil},
+		{"one record", a, []uint32{0x1000}},
+		{"two records", concat(a, b), []uint32{0x1000, 0x2000}},
+		{"reloc prefix", concat(reloc, a, b), []uint32{0x1000, 0x2000}},
+		{"trailing partial record", concat(a, b[:20]), []uint32{0x1000}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseFrameData(tc.data)
+			if len(got) != len(tc.want) {
+				t.Fatalf("ParseFrameData = %d records, want %d", len(got), len(tc.want))
+			}
+			for i, r := range got {
+				if r.RVAStart != tc.want[i] {
+					t.Errorf("record %d: RVAStart = 0x%x, want 0x%x", i, r.RVAStart, tc.want[i])
+				}
+			}
+		})
+	}
+
+	got := ParseFrameData(concat(reloc, b))
+	want := FrameData{
+		RVAStart: 0x2000, BlockSize: 0x2000, LocalSize: 0x2000, ParamsSize: 0x2000, MaxStackSize: 0x2000,
+		FrameFunc: 0x2000, PrologSize: 0x2000, SavedRegsSize: 0x2000, Flags: FrameDataHasSEH | FrameDataHasEH,
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("ParseFrameData = %+v, want [%+v]", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package streams
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func FuzzReadTPIStream(f *testing.F) {
+	structure := binary.LittleEndian.AppendUint32([]byte{1, 0, 0, 0}, 0x1001)
+	structure = append(structure, make([]byte, 8)...)
+	structure = append(structure, 4, 0, 'S', 0)
+	fields := []byte{0x0d, 0x15, 3, 0, 0x74, 0, 0, 0, 0, 0, 'x', 0}
+	valid := tpiBytes(TypeIndexBegin, tpiRecord(LF_STRUCTURE, structure), tpiRecord(LF_FIELDLIST, fields))
+
+	f.Add(valid)
+	// Truncated header and records
+	f.Add(valid[:40])
+	f.Add(valid[:len(valid)-3])
+	// Record lengths that underflow, and one that overruns the data
+	f.Add(tpiBytes(TypeIndexBegin, []byte{0, 0, 0x05, 0x15}))
+	f.Add(tpiBytes(TypeIndexBegin, []byte{1, 0, 0x05, 0x15}))
+	f.Add(tpiBytes(TypeIndexBegin, []byte{0xFF, 0xFF, 0x05, 0x15}))
+	// More declared records than present, and an index range that wraps
+	f.Add(tpiBytes(0xFFFFFFFF, tpiRecord(LF_STRUCTURE, structure)))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if tpi, err := ReadTPIStream(data); err == nil {
+			begin, end := tpi.IndexRange()
+			for i := begin; i < end && i-begin < 64; i++ {
+				if rec := tpi.GetType(i); rec != nil {
+					ParseNumericLeaf(rec.Data)
+				}
+			}
+			tpi.LoadHashStream(data)
+			tpi.LookupByName("S")
+		}
+
+		if tpi, err := OpenTPIStreamLazy(bytes.NewReader(data), int64(len(data))); err == nil {
+			begin, _ := tpi.IndexRange()
+			tpi.GetType(begin)
+			tpi.LoadHashStream(data)
+			tpi.GetType(begin + 1)
+			tpi.Records()
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package streams
+
+import "encoding/binary"
+
+// tpiRecord encodes a type record: its length, kind and data.
+func tpiRecord(kind uint16, data []byte) []byte {
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+	rec := binary.LittleEndian.AppendUint16(nil, uint16(len(data)+2))
+	rec = binary.LittleEndian.AppendUint16(rec, kind)
+	return append(rec, data...)
+}
+
+// tpiBytes encodes a V80 TPI stream holding records encoded by tpiRecord,
+// numbered from begin.
+func tpiBytes(begin uint32, records ...[]byte) []byte {
+	var body []byte
+	for _, rec := range records {
+		body = append(body, rec...)
+	}
+	h := make([]byte, 56)
+	binary.LittleEndian.PutUint32(h[0:], TPIStreamVersionV80)
+	binary.LittleEndian.PutUint32(h[4:], 56)
+	binary.LittleEndian.PutUint32(h[8:], begin)
+	binary.LittleEndian.PutUint32(h[12:], begin+uint32(len(records)))
+	binary.LittleEndian.PutUint32(h[16:], uint32(len(body)))
+	binary.LittleEndian.PutUint16(h[20:], 0xFFFF)
+	binary.LittleEndian.PutUint16(h[22:], 0xFFFF)
+	return append(h, body...)
+}
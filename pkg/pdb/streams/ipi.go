@@ -0,0 +1,9 @@
+package streams
+
+// ReadIPIStream parses the IPI (ID Info) stream from raw bytes.
+// The IPI stream shares its header layout and record framing with the TPI
+// stream, so the result is returned as a TPIStream. Its records are LF_FUNC_ID,
+// LF_MFUNC_ID, LF_STRING_ID, LF_BUILDINFO and similar ID leaves.
+func ReadIPIStream(data []byte) (*TPIStream, error) {
+	return ReadTPIStream(data)
+}
@@ -0,0 +1,72 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// linkInfoHeaderSize is the size of the header of the /LinkInfo stream.
+const linkInfoHeaderSize = 24
+
+// LinkInfo is the parsed /LinkInfo stream: the working directory and
+// command line of the link that produced the PDB.
+type LinkInfo struct {
+	Version    uint32
+	CWD        string   // Working directory of the linker
+	Command    string   // Linker command line
+	OutputFile string   // Output file name, if recorded
+	Libs       []string // Library search paths
+}
+
+// ParseLinkInfo parses the /LinkInfo stream. Its header holds the size of
+// the whole structure, a version and the offsets of the null-terminated
+// working directory, command and library strings that follow it. The
+// output file name is stored at an index past the start of the command;
+// the libraries run until an empty string.
+func ParseLinkInfo(data []byte) (*LinkInfo, error) {
+	if len(data) < linkInfoHeaderSize {
+		return nil, fmt.Errorf("link info too small: %d bytes", len(data))
+	}
+
+	size := binary.LittleEndian.Uint32(data[0:])
+	if size < linkInfoHeaderSize || uint64(size) > uint64(len(data)) {
+		return nil, fmt.Errorf("link info size %d invalid for %d byte stream", size, len(data))
+	}
+	data = data[:size]
+
+	// str reads the string at off, or "" if off is unset or out of range
+	str := func(off uint64) string {
+		if off < linkInfoHeaderSize || off >= uint64(len(data)) {
+			return ""
+		}
+		s, _ := ParseString(data[off:])
+		return s
+	}
+
+	cwdOffset := uint64(binary.LittleEndian.Uint32(data[8:]))
+	commandOffset := uint64(binary.LittleEndian.Uint32(data[12:]))
+	outputIndex := uint64(binary.LittleEndian.Uint32(data[16:]))
+	libsOffset := uint64(binary.LittleEndian.Uint32(data[20:]))
+
+	info := &LinkInfo{
+		Version: binary.LittleEndian.Uint32(data[4:]),
+		CWD:     str(cwdOffset),
+		Command: str(commandOffset),
+	}
+	if commandOffset != 0 && outputIndex != 0 {
+		info.OutputFile = str(commandOffset + outputIndex)
+	}
+
+	if libsOffset >= linkInfoHeaderSize {
+		for pos := libsOffset; pos < uint64(len(data)); {
+			lib, n := ParseString(data[pos:])
+			if lib == "" {
+				break
+			}
+			info.Libs = append(info.Libs, lib)
+			pos += uint64(n)
+		}
+	}
+
+	return info, nil
+}
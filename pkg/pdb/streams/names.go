@@ -0,0 +1,76 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// NamesSignature is the magic value at the start of the /names stream.
+const NamesSignature = 0xEFFEEFFE
+
+// /names hash versions
+const (
+	NamesHashVersionV1 = 1 // LHashPbCb
+	NamesHashVersionV2 = 2 // LHashPbCbV2
+)
+
+// NamesTable represents the /names stream (named string table).
+// Other streams refer to strings in it by byte offset.
+type NamesTable struct {
+	Signature   uint32
+	HashVersion uint32
+	Buffer      []byte   // Raw string buffer (null-terminated strings)
+	Offsets     []uint32 // Hash buckets of string offsets (0 = empty)
+	NumNames    uint32   // Number of strings in the table
+}
+
+// ReadNamesStream parses the /names stream from raw bytes.
+func ReadNamesStream(data []byte) (*NamesTable, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("names stream too small: %d bytes", len(data))
+	}
+
+	nt := &NamesTable{
+		Signature:   binary.LittleEndian.Uint32(data[0:]),
+		HashVersion: binary.LittleEndian.Uint32(data[4:]),
+	}
+	if nt.Signature != NamesSignature {
+		return nil, fmt.Errorf("invalid names stream signature: 0x%08x", nt.Signature)
+	}
+	if nt.HashVersion != NamesHashVersionV1 && nt.HashVersion != NamesHashVersionV2 {
+		return nil, fmt.Errorf("unsupported names stream hash version: %d", nt.HashVersion)
+	}
+
+	byteSize := binary.LittleEndian.Uint32(data[8:])
+	if uint64(12)+uint64(byteSize) > uint64(len(data)) {
+		return nil, fmt.Errorf("names buffer size %d exceeds stream size", byteSize)
+	}
+	nt.Buffer = data[12 : 12+byteSize]
+
+	// Offset index: bucket count, buckets, then the name count
+	offset := 12 + int(byteSize)
+	if offset+4 > len(data) {
+		return nil, fmt.Errorf("names stream offset index truncated")
+	}
+	numBuckets := binary.LittleEndian.Uint32(data[offset:])
+	offset += 4
+	if uint64(offset)+uint64(numBuckets)*4+4 > uint64(len(data)) {
+		return nil, fmt.Errorf("names stream offset index truncated: %d buckets", numBuckets)
+	}
+	nt.Offsets = make([]uint32, numBuckets)
+	for i := range nt.Offsets {
+		nt.Offsets[i] = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+	}
+	nt.NumNames = binary.LittleEndian.Uint32(data[offset:])
+
+	return nt, nil
+}
+
+// Get returns the string at the given byte offset, or "" if out of range.
+func (nt *NamesTable) Get(offset uint32) string {
+	if nt == nil || offset >= uint32(len(nt.Buffer)) {
+		return ""
+	}
+	return extractCString(nt.Buffer[offset:])
+}
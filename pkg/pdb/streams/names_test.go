@@ -0,0 +1,76 @@
+package streams
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// namesBytes encodes a /names stream holding strs, each null-terminated
+// after the empty string at offset 0, with the given hash buckets.
+func namesBytes(version uint32, buckets []uint32, strs ...string) []byte {
+	buf := []byte{0}
+	for _, s := range strs {
+		buf = append(append(buf, s...), 0)
+	}
+	data := binary.LittleEndian.AppendUint32(nil, NamesSignature)
+	data = binary.LittleEndian.AppendUint32(data, version)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(buf)))
+	data = append(data, buf...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(buckets)))
+	for _, b := range buckets {
+		data = binary.LittleEndian.AppendUint32(data, b)
+	}
+	return binary.LittleEndian.AppendUint32(data, uint32(len(strs)))
+}
+
+func TestReadNamesStream(t *testing.T) {
+	const (
+		mainCpp = `d:\src\app\main.cpp`
+		fooH    = `d:\src\app\include\foo.h`
+	)
+	data := namesBytes(NamesHashVersionV1, []uint32{0, 1, 0, 21}, mainCpp, fooH)
+	nt, err := ReadNamesStream(data)
+	if err != nil {
+		t.Fatalf("ReadNamesStream: %v", err)
+	}
+	if nt.NumNames != 2 || len(nt.Offsets) != 4 || nt.Offsets[3] != 21 {
+		t.Errorf("NumNames = %d, Offsets = %v", nt.NumNames, nt.Offsets)
+	}
+
+	for offset, want := range map[uint32]string{
+		0:                           "",
+		1:                           mainCpp,
+		uint32(2 + len(mainCpp)):    fooH,
+		uint32(len(nt.Buffer)):      "",
+		uint32(len(nt.Buffer) + 10): "",
+	} {
+		if got := nt.Get(offset); got != want {
+			t.Errorf("Get(%d) = %q, want %q", offset, got, want)
+		}
+	}
+	if got := nt.Get(1); !strings.HasSuffix(got, ".cpp") {
+		t.Errorf("Get(1) = %q, want a .cpp path", got)
+	}
+	if (*NamesTable)(nil).Get(1) != "" {
+		t.Error("Get on a nil table returned a string")
+	}
+
+	bad := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"too small", data[:8], "too small"},
+		{"signature", append([]byte{0, 0, 0, 0}, data[4:]...), "signature"},
+		{"hash version", namesBytes(3, nil, mainCpp), "hash version"},
+		{"buffer", data[:20], "exceeds stream size"},
+		{"bucket count", data[:12+len(nt.Buffer)+2], "truncated"},
+		{"buckets", data[:len(data)-8], "truncated: 4 buckets"},
+	}
+	for _, tc := range bad {
+		if _, err := ReadNamesStream(tc.data); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// debugDataHeaderSize is the size of DebugDataHeader.
+const debugDataHeaderSize = 32
+
+// DebugDataHeader is the header (DbgRvaVaBlob) that newer linkers put
+// before the .pdata and .xdata copies of the DBI Pdata and Xdata streams.
+type DebugDataHeader struct {
+	Version     uint32
+	HeaderSize  uint32 // Size of the header, including fields added later
+	DataSize    uint32 // Size of the data following the header
+	RVADataBase uint32 // RVA of the first byte of the data
+	ImageBase   uint64 // Preferred load address of the image
+}
+
+// SplitDebugData separates the DebugDataHeader of a Pdata or Xdata stream
+// from its data. The header is recognised by its sizes adding up to the
+// stream size; streams without one are returned whole with a nil header.
+func SplitDebugData(data []byte) (*DebugDataHeader, []byte) {
+	if len(data) < debugDataHeaderSize {
+		return nil, data
+	}
+
+	hdr := &DebugDataHeader{
+		Version:     binary.LittleEndian.Uint32(data[0:]),
+		HeaderSize:  binary.LittleEndian.Uint32(data[4:]),
+		DataSize:    binary.LittleEndian.Uint32(data[8:]),
+		RVADataBase: binary.LittleEndian.Uint32(data[12:]),
+		ImageBase:   binary.LittleEndian.Uint64(data[16:]),
+	}
+	if hdr.HeaderSize < debugDataHeaderSize || uint64(hdr.HeaderSize)+uint64(hdr.DataSize) != uint64(len(data)) {
+		return nil, data
+	}
+	return hdr, data[hdr.HeaderSize:]
+}
+
+// RuntimeFunction is an x64 function table entry (RUNTIME_FUNCTION) from
+// the image's .pdata section.
+type RuntimeFunction struct {
+	BeginRVA uint32 // First byte of the function or fragment
+	EndRVA   uint32 // Byte past the end of the function or fragment
+
+	// UnwindInfoRVA locates the UNWIND_INFO of the function. If bit 0 is
+	// set, the remaining bits instead give the RVA of another
+	// RUNTIME_FUNCTION whose unwind information is shared.
+	UnwindInfoRVA uint32
+}
+
+// ParsePdata parses the RUNTIME_FUNCTION entries of an x64 .pdata
+// section. A trailing partial entry is ignored.
+func ParsePdata(data []byte) []RuntimeFunction {
+	functions := make([]RuntimeFunction, 0, len(data)/12)
+	for i := 0; i+12 <= len(data); i += 12 {
+		functions = append(functions, RuntimeFunction{
+			BeginRVA:      binary.LittleEndian.Uint32(data[i:]),
+			EndRVA:        binary.LittleEndian.Uint32(data[i+4:]),
+			UnwindInfoRVA: binary.LittleEndian.Uint32(data[i+8:]),
+		})
+	}
+	return functions
+}
+
+// UNWIND_INFO flags
+const (
+	UnwindFlagEHandler  = 0x1 // Has an exception handler
+	UnwindFlagUHandler  = 0x2 // Has a termination handler
+	UnwindFlagChainInfo = 0x4 // Continues the unwind information of Chained
+)
+
+// UnwindInfo is the x64 unwind information of a function (UNWIND_INFO).
+// The unwind codes are kept as raw slots: an operation takes one to three
+// of them, depending on its code.
+type UnwindInfo struct {
+	Version       uint8
+	Flags         uint8 // UnwindFlag* bits
+	PrologSize    uint8 // Prolog size in bytes
+	CodeCount     uint8 // Number of unwind code slots
+	FrameRegister uint8 // Frame pointer register (x64 numbering), 0 if none
+	FrameOffset   uint8 // Offset of the frame pointer from RSP, in 16-byte units
+	Codes         []uint16
+
+	HandlerRVA uint32           // Language-specific handler (EHandler/UHandler only)
+	Chained    *RuntimeFunction // Entry continued by this one (ChainInfo only)
+}
+
+// ParseUnwindInfo parses the UNWIND_INFO at the start of data.
+func ParseUnwindInfo(data []byte) (*UnwindInfo, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("unwind info too small: %d bytes", len(data))
+	}
+
+	info := &UnwindInfo{
+		Version:       data[0] & 0x7,
+		Flags:         data[0] >> 3,
+		PrologSize:    data[1],
+		CodeCount:     data[2],
+		FrameRegister: data[3] & 0xF,
+		FrameOffset:   data[3] >> 4,
+	}
+
+	// The code array is padded to an even number of slots
+	pos := 4
+	end := pos + 2*(int(info.CodeCount)+int(info.CodeCount)&1)
+	if pos+2*int(info.CodeCount) > len(data) {
+		return nil, fmt.Errorf("unwind info has %d codes but only %d bytes", info.CodeCount, len(data))
+	}
+	info.Codes = make([]uint16, info.CodeCount)
+	for i := range info.Codes {
+		info.Codes[i] = binary.LittleEndian.Uint16(data[pos+2*i:])
+	}
+	pos = end
+
+	switch {
+	case info.Flags&UnwindFlagChainInfo != 0:
+		if pos+12 > len(data) {
+			return nil, fmt.Errorf("unwind info truncated before chained entry")
+		}
+		chained := ParsePdata(data[pos : pos+12])[0]
+		info.Chained = &chained
+	case info.Flags&(UnwindFlagEHandler|UnwindFlagUHandler) != 0:
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("unwind info truncated before handler")
+		}
+		info.HandlerRVA = binary.LittleEndian.Uint32(data[pos:])
+	}
+
+	return info, nil
+}
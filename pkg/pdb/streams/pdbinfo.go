@@ -1,4 +1,7 @@
 // Package streams provides parsers for the various PDB streams.
+//
+// Stream parsers never panic on malformed input. Sizes and counts read from
+// a stream are checked against its length before anything is allocated.
 package streams
 
 import (
@@ -65,8 +68,8 @@ func ReadPDBInfo(r io.Reader) (*PDBInfo, error) {
 	}
 
 	// Read string buffer
-	strBuf := make([]byte, strBufSize)
-	if _, err := io.ReadFull(r, strBuf); err != nil {
+	strBuf, err := readBytes(r, uint64(strBufSize))
+	if err != nil {
 		return info, nil
 	}
 
@@ -87,8 +90,8 @@ func ReadPDBInfo(r io.Reader) (*PDBInfo, error) {
 	if err := binary.Read(r, binary.LittleEndian, &presentWordsCount); err != nil {
 		return info, nil
 	}
-	presentWords := make([]uint32, presentWordsCount)
-	if err := binary.Read(r, binary.LittleEndian, presentWords); err != nil {
+	presentWords, err := readWords(r, presentWordsCount)
+	if err != nil {
 		return info, nil
 	}
 
@@ -97,13 +100,12 @@ func ReadPDBInfo(r io.Reader) (*PDBInfo, error) {
 	if err := binary.Read(r, binary.LittleEndian, &deletedWordsCount); err != nil {
 		return info, nil
 	}
-	deletedWords := make([]uint32, deletedWordsCount)
-	if err := binary.Read(r, binary.LittleEndian, deletedWords); err != nil {
+	if _, err := readWords(r, deletedWordsCount); err != nil {
 		return info, nil
 	}
 
 	// Read key-value pairs for present buckets
-	for i := uint32(0); i < hashCapacity; i++ {
+	for i := uint32(0); i < hashCapacity && i/32 < uint32(len(presentWords)); i++ {
 		if !isBitSet(presentWords, i) {
 			continue
 		}
@@ -137,6 +139,29 @@ func (p *PDBInfo) GUIDString() string {
 		p.GUID[12], p.GUID[13], p.GUID[14], p.GUID[15])
 }
 
+// readBytes reads n bytes from r. The buffer grows as data arrives, so a
+// corrupt size fails at the end of the stream instead of allocating it.
+func readBytes(r io.Reader, n uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readWords reads a vector of count little-endian uint32 words from r.
+func readWords(r io.Reader, count uint32) ([]uint32, error) {
+	data, err := readBytes(r, uint64(count)*4)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]uint32, count)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+	return words, nil
+}
+
 // isBitSet checks if bit n is set in the bit vector.
 func isBitSet(words []uint32, n uint32) bool {
 	wordIdx := n / 32
@@ -0,0 +1,116 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// SrcHeaderBlockVersion is the version of the /src/headerblock stream.
+const SrcHeaderBlockVersion = 19980827
+
+// Source compression schemes (PDB_SourceCompression)
+const (
+	SourceCompressionNone    = 0
+	SourceCompressionRLE     = 1
+	SourceCompressionHuffman = 2
+	SourceCompressionLZ      = 3
+	SourceCompressionDotNet  = 101 // Deflate, preceded by the uncompressed size
+)
+
+// srcHeaderBlockHeaderSize is the size of the header preceding the
+// injected source hash table.
+const srcHeaderBlockHeaderSize = 64
+
+// srcHeaderEntrySize is the size of a SrcHeaderBlockEntry.
+const srcHeaderEntrySize = 40
+
+// SrcHeaderEntry describes an injected source file (SrcHeaderBlockEntry).
+// Names are offsets into the /names string table.
+type SrcHeaderEntry struct {
+	Version         uint32
+	CRC             uint32 // CRC of the original file contents
+	FileSize        uint32 // Size of the original file
+	FileName        uint32 // File name
+	ObjName         uint32 // Object file name
+	VirtualFileName uint32 // Name the contents are stored under
+	Compression     uint8  // SourceCompression* constant
+	IsVirtual       bool   // Injected file with no file on disk
+}
+
+// ReadSrcHeaderBlock parses the /src/headerblock stream: a fixed header
+// followed by a serialized hash table of entries keyed by file name.
+func ReadSrcHeaderBlock(data []byte) ([]SrcHeaderEntry, error) {
+	if len(data) < srcHeaderBlockHeaderSize+8 {
+		return nil, fmt.Errorf("source header block too small: %d bytes", len(data))
+	}
+	if version := binary.LittleEndian.Uint32(data[0:]); version != SrcHeaderBlockVersion {
+		return nil, fmt.Errorf("unsupported source header block version %d", version)
+	}
+
+	offset := srcHeaderBlockHeaderSize
+	// size := binary.LittleEndian.Uint32(data[offset:])
+	capacity := binary.LittleEndian.Uint32(data[offset+4:])
+	offset += 8
+
+	var present []uint32
+	for i := 0; i < 2; i++ { // Present, then deleted bit vector
+		if offset+4 > len(data) {
+			return nil, fmt.Errorf("source header block bit vector truncated")
+		}
+		words := int(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+		if words > (len(data)-offset)/4 {
+			return nil, fmt.Errorf("source header block bit vector of %d words exceeds stream", words)
+		}
+		if i == 0 {
+			present = make([]uint32, words)
+			for j := range present {
+				present[j] = binary.LittleEndian.Uint32(data[offset+j*4:])
+			}
+		}
+		offset += words * 4
+	}
+
+	var entries []SrcHeaderEntry
+	for i := uint32(0); i < capacity && i/32 < uint32(len(present)); i++ {
+		if !isBitSet(present, i) {
+			continue
+		}
+		if offset+4+srcHeaderEntrySize > len(data) {
+			return entries, fmt.Errorf("source header block entry %d truncated", len(entries))
+		}
+		e := data[offset+4:] // Skip the key, which repeats FileName
+		entries = append(entries, SrcHeaderEntry{
+			Version:         binary.LittleEndian.Uint32(e[4:]),
+			CRC:             binary.LittleEndian.Uint32(e[8:]),
+			FileSize:        binary.LittleEndian.Uint32(e[12:]),
+			FileName:        binary.LittleEndian.Uint32(e[16:]),
+			ObjName:         binary.LittleEndian.Uint32(e[20:]),
+			VirtualFileName: binary.LittleEndian.Uint32(e[24:]),
+			Compression:     e[28],
+			IsVirtual:       e[29] != 0,
+		})
+		offset += 4 + srcHeaderEntrySize
+	}
+
+	return entries, nil
+}
+
+// SourceCompressionName returns a short name for a source compression
+// scheme.
+func SourceCompressionName(compression uint8) string {
+	switch compression {
+	case SourceCompressionNone:
+		return "none"
+	case SourceCompressionRLE:
+		return "rle"
+	case SourceCompressionHuffman:
+		return "huffman"
+	case SourceCompressionLZ:
+		return "lz"
+	case SourceCompressionDotNet:
+		return "deflate"
+	default:
+		return fmt.Sprintf("compression_%d", compression)
+	}
+}
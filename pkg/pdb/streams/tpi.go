@@ -5,6 +5,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
+	"sort"
+	"strconv"
 )
 
 // TPI Stream versions
@@ -38,11 +41,16 @@ type TPIHeader struct {
 	HashAdjBufferLength  uint32
 }
 
-// TPIStream represents the parsed TPI (Type Info) stream.
+// TPIStream represents the parsed TPI (Type Info) stream. Streams opened
+// lazily leave TypeRecords empty; use Records to list every record.
 type TPIStream struct {
 	Header      TPIHeader
 	TypeRecords []TypeRecord
 	typeMap     map[uint32]*TypeRecord // Type index to record
+	hashBuckets map[uint32][]uint32    // Hash bucket to type indices (see LoadHashStream)
+	lazy        *lazyRecords           // Set for streams opened by OpenTPIStreamLazy
+	badRecords  []uint32               // Indices of malformed records (see BadRecords)
+	recordData  []byte                 // Kept after a malformed record, to resynchronize in LoadHashStream
 }
 
 // TypeRecord represents a single type record.
@@ -56,83 +64,166 @@ type TypeRecord struct {
 func ReadTPIStream(data []byte) (*TPIStream, error) {
 	r := bytes.NewReader(data)
 
-	var header TPIHeader
-	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
-		return nil, fmt.Errorf("failed to read TPI header: %w", err)
-	}
-
-	// Validate version
-	if header.Version != TPIStreamVersionV80 && header.Version != TPIStreamVersionV70 {
-		return nil, fmt.Errorf("unsupported TPI version: %d", header.Version)
+	header, err := readTPIHeader(r, data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Read type records
+	if int64(header.TypeRecordBytes) > int64(r.Len()) {
+		return nil, fmt.Errorf("TPI record data size %d exceeds stream size", header.TypeRecordBytes)
+	}
 	recordData := make([]byte, header.TypeRecordBytes)
 	if _, err := io.ReadFull(r, recordData); err != nil {
 		return nil, fmt.Errorf("failed to read type records: %w", err)
 	}
 
-	tpi := &TPIStream{
-		Header:  header,
-		typeMap: make(map[uint32]*TypeRecord),
+	tpi := &TPIStream{Header: header}
+	tpi.TypeRecords, tpi.typeMap, tpi.badRecords = parseTypeRecords(header, recordData, nil)
+	if len(tpi.badRecords) > 0 {
+		tpi.recordData = recordData
+	}
+	return tpi, nil
+}
+
+// readTPIHeader reads and validates the TPI header from r, which is
+// positioned at the start of data.
+func readTPIHeader(r io.Reader, data []byte) (TPIHeader, error) {
+	if len(data) >= 4 && IsLegacyTPIVersion(binary.LittleEndian.Uint32(data)) {
+		return readTPIHeader16t(r)
 	}
 
-	// Parse individual type records
+	var header TPIHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return header, fmt.Errorf("failed to read TPI header: %w", err)
+	}
+
+	// Validate version
+	if header.Version != TPIStreamVersionV80 && header.Version != TPIStreamVersionV70 {
+		return header, fmt.Errorf("unsupported TPI version: %d", header.Version)
+	}
+	return header, nil
+}
+
+// parseTypeRecords splits the record data of a TPI stream into records
+// and indexes them by type index. A record whose length is too short for
+// its kind or overruns the data is returned in bad, and parsing resumes at
+// the next entry of offsets past it; without one, parsing stops there.
+func parseTypeRecords(header TPIHeader, recordData []byte, offsets []TypeIndexOffset) (records []TypeRecord, typeMap map[uint32]*TypeRecord, bad []uint32) {
+	legacy := IsLegacyTPIVersion(header.Version)
+
 	offset := 0
 	typeIndex := header.TypeIndexBegin
 	for offset < len(recordData) && typeIndex < header.TypeIndexEnd {
-		if offset+2 > len(recordData) {
-			break
+		// Read record length (2 bytes); the kind is part of the record
+		recLen := -1
+		if offset+2 <= len(recordData) {
+			recLen = int(binary.LittleEndian.Uint16(recordData[offset:]))
 		}
 
-		// Read record length (2 bytes)
-		recLen := binary.LittleEndian.Uint16(recordData[offset:])
-		offset += 2
-
-		if offset+int(recLen) > len(recordData) {
-			break
-		}
-
-		// Read record kind (2 bytes, part of the record)
-		if recLen < 2 {
-			typeIndex++
+		if recLen < 2 || offset+2+recLen > len(recordData) {
+			bad = append(bad, typeIndex)
+			next, ok := nextIndexOffset(offsets, typeIndex, offset)
+			if !ok {
+				break
+			}
+			typeIndex, offset = next.TypeIndex, int(next.Offset)
 			continue
 		}
 
-		recKind := binary.LittleEndian.Uint16(recordData[offset:])
+		offset += 2
+		records = append(records, newTypeRecord(typeIndex, recordData[offset:offset+recLen], legacy))
 
-		record := TypeRecord{
-			Index: typeIndex,
-			Kind:  recKind,
-			Data:  make([]byte, recLen-2),
-		}
-		copy(record.Data, recordData[offset+2:offset+int(recLen)])
+		offset += recLen
+		typeIndex++
+	}
 
-		tpi.TypeRecords = append(tpi.TypeRecords, record)
-		tpi.typeMap[typeIndex] = &tpi.TypeRecords[len(tpi.TypeRecords)-1]
+	typeMap = make(map[uint32]*TypeRecord, len(records))
+	for i := range records {
+		typeMap[records[i].Index] = &records[i]
+	}
+	return records, typeMap, bad
+}
 
-		offset += int(recLen)
-		typeIndex++
+// nextIndexOffset returns the first entry of offsets, which is sorted by
+// type index, that lies past the record typeIndex at offset.
+func nextIndexOffset(offsets []TypeIndexOffset, typeIndex uint32, offset int) (TypeIndexOffset, bool) {
+	i := sort.Search(len(offsets), func(i int) bool {
+		return offsets[i].TypeIndex > typeIndex
+	})
+	for ; i < len(offsets); i++ {
+		if int64(offsets[i].Offset) > int64(offset) {
+			return offsets[i], true
+		}
 	}
+	return TypeIndexOffset{}, false
+}
 
-	return tpi, nil
+// newTypeRecord builds the record with the given index from its kind and
+// data, copying the data and widening legacy 16-bit records.
+func newTypeRecord(index uint32, rec []byte, legacy bool) TypeRecord {
+	record := TypeRecord{
+		Index: index,
+		Kind:  binary.LittleEndian.Uint16(rec),
+		Data:  make([]byte, len(rec)-2),
+	}
+	copy(record.Data, rec[2:])
+	if legacy {
+		record.Kind, record.Data = widenTypeRecord(record.Kind, record.Data)
+	}
+	return record
 }
 
 // GetType returns the type record for the given type index.
 func (t *TPIStream) GetType(index uint32) *TypeRecord {
+	if t.lazy != nil {
+		return t.lazyGetType(index)
+	}
 	return t.typeMap[index]
 }
 
-// NumTypes returns the number of type records.
+// NumTypes returns the number of type records. For a lazily loaded stream
+// this is the count declared by the header.
 func (t *TPIStream) NumTypes() int {
+	if t.lazy != nil {
+		return int(t.TypeCount())
+	}
 	return len(t.TypeRecords)
 }
 
+// BadRecords returns the indices of the malformed records at which parsing
+// lost track of record boundaries. Once LoadHashStream has supplied the
+// index offset buffer, parsing resumes at its next entry, so the records
+// between a bad one and that entry are missing but not listed; before
+// that, or without a later entry, every record after the first bad one is
+// missing. A lazily opened stream reports bad records once Records has
+// read it in full.
+func (t *TPIStream) BadRecords() []uint32 {
+	if t.lazy != nil {
+		t.lazy.mu.Lock()
+		defer t.lazy.mu.Unlock()
+	}
+	return t.badRecords
+}
+
 // TypeCount returns the number of types (TypeIndexEnd - TypeIndexBegin).
 func (t *TPIStream) TypeCount() uint32 {
 	return t.Header.TypeIndexEnd - t.Header.TypeIndexBegin
 }
 
+// IndexRange returns the type indices the header declares: records are
+// numbered from begin up to, but not including, end. begin is normally
+// TypeIndexBegin but is not guaranteed to be.
+func (t *TPIStream) IndexRange() (begin, end uint32) {
+	return t.Header.TypeIndexBegin, t.Header.TypeIndexEnd
+}
+
+// IsValidIndex reports whether i lies within the header's index range. It
+// does not report whether the record for i could be parsed.
+func (t *TPIStream) IsValidIndex(i uint32) bool {
+	return i >= t.Header.TypeIndexBegin && i < t.Header.TypeIndexEnd
+}
+
 // LF_* type leaf constants
 const (
 	// Leaf types for type records
@@ -195,19 +286,9 @@ const (
 	LF_UNION_ST     = 0x1006
 	LF_ENUM_ST      = 0x1007
 
-	LF_POINTER_16t  = 0x0100
-	LF_ARRAY_16t    = 0x0003
-	LF_CLASS_16t    = 0x0004
-	LF_STRUCTURE_16t= 0x0005
-	LF_UNION_16t    = 0x0006
-	LF_ENUM_16t     = 0x0007
-	LF_PROCEDURE_16t= 0x0008
-	LF_MFUNCTION_16t= 0x0009
-	LF_ARGLIST_16t  = 0x0001
-	LF_FIELDLIST_16t= 0x0003
-
 	// More leaf types
 	LF_TYPESERVER   = 0x1016
+	LF_TYPESERVER2  = 0x1515
 	LF_ENUMERATE_ST = 0x1403
 	LF_ARRAY_newformat      = 0x1503
 	LF_CLASS_newformat      = 0x1504
@@ -307,6 +388,53 @@ const (
 	T_CHAR8     = 0x007c
 )
 
+// GetBuiltinTypeSize returns the size in bytes of a built-in type index,
+// or 0 if it is unknown or has no size (such as void).
+func GetBuiltinTypeSize(typeIdx uint32) uint64 {
+	if typeIdx >= TypeIndexBegin {
+		return 0
+	}
+
+	// Pointer modes determine the size regardless of the pointee
+	switch (typeIdx >> 8) & 0xF {
+	case TM_DIRECT:
+	case TM_NPTR:
+		return 2
+	case TM_FPTR, TM_HPTR, TM_NPTR32:
+		return 4
+	case TM_FPTR32:
+		return 6
+	case TM_NPTR64:
+		return 8
+	case TM_NPTR128:
+		return 16
+	default:
+		return 0
+	}
+
+	switch typeIdx & 0xFF {
+	case T_CHAR, T_UCHAR, T_BOOL08, T_INT1, T_UINT1, T_RCHAR, T_CHAR8:
+		return 1
+	case T_SHORT, T_USHORT, T_BOOL16, T_WCHAR, T_INT2, T_UINT2, T_CHAR16, T_REAL16:
+		return 2
+	case T_LONG, T_ULONG, T_BOOL32, T_REAL32, T_INT4, T_UINT4, T_CHAR32, T_HRESULT, T_BOOL32FF:
+		return 4
+	case T_REAL48:
+		return 6
+	case T_QUAD, T_UQUAD, T_BOOL64, T_REAL64, T_INT8, T_UINT8, T_CPLX32, T_CURRENCY:
+		return 8
+	case T_REAL80:
+		return 10
+	case T_OCT, T_UOCT, T_REAL128, T_INT16, T_UINT16, T_CPLX64:
+		return 16
+	case T_CPLX80:
+		return 20
+	case T_CPLX128:
+		return 32
+	}
+	return 0
+}
+
 // GetBuiltinTypeName returns the name of a built-in type index.
 func GetBuiltinTypeName(typeIdx uint32) string {
 	if typeIdx >= TypeIndexBegin {
@@ -320,8 +448,20 @@ func GetBuiltinTypeName(typeIdx uint32) string {
 	switch kind {
 	case T_NOTYPE:
 		baseName = "<no type>"
+	case T_ABS:
+		baseName = "<absolute>"
+	case T_SEGMENT:
+		baseName = "<segment>"
 	case T_VOID:
 		baseName = "void"
+	case T_CURRENCY:
+		baseName = "CURRENCY"
+	case T_NBASICSTR:
+		baseName = "<near basic string>"
+	case T_FBASICSTR:
+		baseName = "<far basic string>"
+	case T_NOTTRANS:
+		baseName = "<not translated>"
 	case T_CHAR:
 		baseName = "char"
 	case T_SHORT:
@@ -330,6 +470,8 @@ func GetBuiltinTypeName(typeIdx uint32) string {
 		baseName = "long"
 	case T_QUAD:
 		baseName = "int64"
+	case T_OCT:
+		baseName = "int128"
 	case T_UCHAR:
 		baseName = "unsigned char"
 	case T_USHORT:
@@ -338,16 +480,44 @@ func GetBuiltinTypeName(typeIdx uint32) string {
 		baseName = "unsigned long"
 	case T_UQUAD:
 		baseName = "uint64"
+	case T_UOCT:
+		baseName = "uint128"
 	case T_BOOL08:
 		baseName = "bool"
+	case T_BOOL16:
+		baseName = "bool16"
 	case T_BOOL32:
 		baseName = "BOOL"
+	case T_BOOL64:
+		baseName = "bool64"
 	case T_REAL32:
 		baseName = "float"
 	case T_REAL64:
 		baseName = "double"
 	case T_REAL80:
 		baseName = "long double"
+	case T_REAL128:
+		baseName = "__float128"
+	case T_REAL48:
+		baseName = "real48"
+	case T_REAL32PP:
+		baseName = "float (partial precision)"
+	case T_REAL16:
+		baseName = "half"
+	case T_CPLX32:
+		baseName = "_Complex float"
+	case T_CPLX64:
+		baseName = "_Complex double"
+	case T_CPLX80:
+		baseName = "_Complex long double"
+	case T_CPLX128:
+		baseName = "_Complex __float128"
+	case T_BIT:
+		baseName = "bit"
+	case T_PASCHAR:
+		baseName = "pascal char"
+	case T_BOOL32FF:
+		baseName = "bool32ff"
 	case T_INT1:
 		baseName = "int8"
 	case T_UINT1:
@@ -368,6 +538,16 @@ func GetBuiltinTypeName(typeIdx uint32) string {
 		baseName = "int64"
 	case T_UINT8:
 		baseName = "uint64"
+	case T_INT16:
+		baseName = "int128"
+	case T_UINT16:
+		baseName = "uint128"
+	case T_CHAR16:
+		baseName = "char16_t"
+	case T_CHAR32:
+		baseName = "char32_t"
+	case T_CHAR8:
+		baseName = "char8_t"
 	case T_HRESULT:
 		baseName = "HRESULT"
 	default:
@@ -436,63 +616,238 @@ func LeafKindName(kind uint16) string {
 		return "LF_STRING_ID"
 	case LF_UDT_SRC_LINE:
 		return "LF_UDT_SRC_LINE"
+	case LF_TYPESERVER:
+		return "LF_TYPESERVER"
+	case LF_TYPESERVER2:
+		return "LF_TYPESERVER2"
 	default:
 		return fmt.Sprintf("LF_0x%04x", kind)
 	}
 }
 
-// ParseNumeric parses a numeric leaf value from the data.
-// Returns the value and the number of bytes consumed.
-func ParseNumeric(data []byte) (uint64, int) {
+// Numeric leaf kinds. A value below LF_NUMERIC is stored directly in the
+// leading uint16; larger values use one of these leaves.
+const (
+	LF_NUMERIC    = 0x8000
+	LF_CHAR       = 0x8000
+	LF_SHORT      = 0x8001
+	LF_USHORT     = 0x8002
+	LF_LONG       = 0x8003
+	LF_ULONG      = 0x8004
+	LF_REAL32     = 0x8005
+	LF_REAL64     = 0x8006
+	LF_REAL80     = 0x8007
+	LF_REAL128    = 0x8008
+	LF_QUADWORD   = 0x8009
+	LF_UQUADWORD  = 0x800a
+	LF_REAL48     = 0x800b
+	LF_COMPLEX32  = 0x800c
+	LF_COMPLEX64  = 0x800d
+	LF_COMPLEX80  = 0x800e
+	LF_COMPLEX128 = 0x800f
+	LF_VARSTRING  = 0x8010
+	LF_OCTWORD    = 0x8017
+	LF_UOCTWORD   = 0x8018
+	LF_DECIMAL    = 0x8019
+	LF_DATE       = 0x801a
+	LF_UTF8STRING = 0x801b
+	LF_REAL16     = 0x801c
+)
+
+// numericSizes gives the payload size of fixed-size numeric leaves.
+var numericSizes = map[uint16]int{
+	LF_CHAR: 1, LF_SHORT: 2, LF_USHORT: 2, LF_LONG: 4, LF_ULONG: 4,
+	LF_REAL32: 4, LF_REAL64: 8, LF_REAL80: 10, LF_REAL128: 16,
+	LF_QUADWORD: 8, LF_UQUADWORD: 8, LF_REAL48: 6,
+	LF_COMPLEX32: 8, LF_COMPLEX64: 16, LF_COMPLEX80: 20, LF_COMPLEX128: 32,
+	LF_OCTWORD: 16, LF_UOCTWORD: 16, LF_DECIMAL: 16, LF_DATE: 8, LF_REAL16: 2,
+}
+
+// Numeric is a decoded numeric leaf.
+type Numeric struct {
+	Kind    uint16  // LF_* numeric leaf, or 0 for a value stored directly
+	Value   uint64  // Integer value, sign-extended for signed leaves; low 64 bits of LF_OCTWORD/LF_UOCTWORD
+	Float   float64 // Value of LF_REAL16/32/64/80/128 leaves
+	IsFloat bool    // Float holds the value
+	Raw     []byte  // Payload of leaves that do not fit in Value or Float
+}
+
+// ParseNumericLeaf parses a numeric leaf and returns it with the number
+// of bytes consumed. Truncated leaves consume the rest of the data and
+// unknown leaf kinds consume only the kind, so that callers walking a
+// sequence of records always make progress.
+//
+// This is the only numeric-leaf decoder: the symbol and type parsers in
+// codeview use it too, through ParseNumeric or directly.
+func ParseNumericLeaf(data []byte) (Numeric, int) {
 	if len(data) < 2 {
-		return 0, 0
+		return Numeric{}, len(data)
 	}
 
-	val := binary.LittleEndian.Uint16(data)
-	if val < 0x8000 {
-		return uint64(val), 2
+	kind := binary.LittleEndian.Uint16(data)
+	if kind < LF_NUMERIC {
+		return Numeric{Value: uint64(kind)}, 2
 	}
+	n := Numeric{Kind: kind}
 
-	// Encoded numeric follows
-	switch val {
-	case 0x8000: // LF_CHAR
-		if len(data) < 3 {
-			return 0, 0
-		}
-		return uint64(int8(data[2])), 3
-	case 0x8001: // LF_SHORT
-		if len(data) < 4 {
-			return 0, 0
-		}
-		return uint64(int16(binary.LittleEndian.Uint16(data[2:]))), 4
-	case 0x8002: // LF_USHORT
+	// Variable-length leaves
+	switch kind {
+	case LF_VARSTRING:
 		if len(data) < 4 {
-			return 0, 0
+			return n, len(data)
 		}
-		return uint64(binary.LittleEndian.Uint16(data[2:])), 4
-	case 0x8003: // LF_LONG
-		if len(data) < 6 {
-			return 0, 0
+		length := int(binary.LittleEndian.Uint16(data[2:]))
+		if 4+length > len(data) {
+			n.Raw = data[4:]
+			return n, len(data)
 		}
-		return uint64(int32(binary.LittleEndian.Uint32(data[2:]))), 6
-	case 0x8004: // LF_ULONG
-		if len(data) < 6 {
-			return 0, 0
+		n.Raw = data[4 : 4+length]
+		return n, 4 + length
+	case LF_UTF8STRING:
+		s, consumed := ParseString(data[2:])
+		n.Raw = []byte(s)
+		return n, 2 + consumed
+	}
+
+	size, ok := numericSizes[kind]
+	if !ok {
+		return n, 2
+	}
+	if len(data) < 2+size {
+		return n, len(data)
+	}
+	payload := data[2 : 2+size]
+
+	switch kind {
+	case LF_CHAR:
+		n.Value = uint64(int8(payload[0]))
+	case LF_SHORT:
+		n.Value = uint64(int16(binary.LittleEndian.Uint16(payload)))
+	case LF_USHORT:
+		n.Value = uint64(binary.LittleEndian.Uint16(payload))
+	case LF_LONG:
+		n.Value = uint64(int32(binary.LittleEndian.Uint32(payload)))
+	case LF_ULONG:
+		n.Value = uint64(binary.LittleEndian.Uint32(payload))
+	case LF_QUADWORD, LF_UQUADWORD:
+		n.Value = binary.LittleEndian.Uint64(payload)
+	case LF_OCTWORD, LF_UOCTWORD:
+		n.Value = binary.LittleEndian.Uint64(payload)
+		n.Raw = payload
+	case LF_REAL16:
+		n.Float, n.IsFloat = float16(binary.LittleEndian.Uint16(payload)), true
+	case LF_REAL32:
+		n.Float, n.IsFloat = float64(math.Float32frombits(binary.LittleEndian.Uint32(payload))), true
+	case LF_REAL64:
+		n.Float, n.IsFloat = math.Float64frombits(binary.LittleEndian.Uint64(payload)), true
+	case LF_REAL80:
+		n.Float, n.IsFloat = float80(payload), true
+	case LF_REAL128:
+		n.Float, n.IsFloat = float128(payload), true
+	default:
+		n.Raw = payload
+	}
+
+	return n, 2 + size
+}
+
+// ParseNumeric parses a numeric leaf value from the data.
+// Returns the integer value and the number of bytes consumed. Leaves that
+// are not integers yield 0; use ParseNumericLeaf to decode them.
+func ParseNumeric(data []byte) (uint64, int) {
+	n, consumed := ParseNumericLeaf(data)
+	return n.Value, consumed
+}
+
+// String renders the value: integers in decimal, signed for signed
+// leaves, floating-point values in the shortest exact form, strings
+// quoted, and other leaves as hex.
+func (n Numeric) String() string {
+	switch {
+	case n.IsFloat:
+		return strconv.FormatFloat(n.Float, 'g', -1, 64)
+	case n.Kind == LF_VARSTRING || n.Kind == LF_UTF8STRING:
+		return strconv.Quote(string(n.Raw))
+	case n.Kind == LF_OCTWORD || n.Kind == LF_UOCTWORD:
+		// Little-endian payload, printed most significant byte first
+		digits := make([]byte, len(n.Raw))
+		for i, b := range n.Raw {
+			digits[len(n.Raw)-1-i] = b
 		}
-		return uint64(binary.LittleEndian.Uint32(data[2:])), 6
-	case 0x8009: // LF_QUADWORD
-		if len(data) < 10 {
-			return 0, 0
+		return fmt.Sprintf("0x%x", digits)
+	case n.Raw != nil:
+		return fmt.Sprintf("0x%x", n.Raw)
+	case n.Kind == LF_CHAR || n.Kind == LF_SHORT || n.Kind == LF_LONG || n.Kind == LF_QUADWORD:
+		return strconv.FormatInt(int64(n.Value), 10)
+	}
+	return strconv.FormatUint(n.Value, 10)
+}
+
+// float16 converts an IEEE half-precision value.
+func float16(bits uint16) float64 {
+	sign := 1.0
+	if bits&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(bits>>10) & 0x1F
+	frac := float64(bits & 0x3FF)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1F:
+		if frac != 0 {
+			return math.NaN()
 		}
-		return binary.LittleEndian.Uint64(data[2:]), 10
-	case 0x800a: // LF_UQUADWORD
-		if len(data) < 10 {
-			return 0, 0
+		return math.Inf(int(sign))
+	}
+	return sign * math.Ldexp(frac+1024, exp-25)
+}
+
+// float80 converts an x87 extended-precision value, which has an explicit
+// integer bit, rounding to float64.
+func float80(b []byte) float64 {
+	mantissa := binary.LittleEndian.Uint64(b)
+	se := binary.LittleEndian.Uint16(b[8:])
+	sign := 1.0
+	if se&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(se & 0x7FFF)
+
+	switch {
+	case exp == 0x7FFF && mantissa<<1 != 0:
+		return math.NaN()
+	case exp == 0x7FFF:
+		return math.Inf(int(sign))
+	case exp == 0:
+		exp = 1 // Denormal
+	}
+	return sign * math.Ldexp(float64(mantissa), exp-16383-63)
+}
+
+// float128 converts an IEEE quadruple-precision value, keeping the top 64
+// bits of its fraction.
+func float128(b []byte) float64 {
+	lo := binary.LittleEndian.Uint64(b)
+	hi := binary.LittleEndian.Uint64(b[8:])
+	sign := 1.0
+	if hi>>63 != 0 {
+		sign = -1
+	}
+	exp := int(hi>>48) & 0x7FFF
+	frac := hi<<16 | lo>>48 // Top 64 of the 112 fraction bits
+
+	switch exp {
+	case 0x7FFF:
+		if frac != 0 {
+			return math.NaN()
 		}
-		return binary.LittleEndian.Uint64(data[2:]), 10
-	default:
-		return 0, 0
+		return math.Inf(int(sign))
+	case 0:
+		return sign * math.Ldexp(float64(frac), -16382-64)
 	}
+	return sign * (1 + math.Ldexp(float64(frac), -64)) * math.Ldexp(1, exp-16383)
 }
 
 // ParseString parses a null-terminated string from data.
@@ -504,3 +859,16 @@ func ParseString(data []byte) (string, int) {
 	}
 	return string(data[:idx]), idx + 1
 }
+
+// ParseLengthPrefixedString parses a string preceded by a length byte, as
+// used by pre-V70 records. Returns the string and number of bytes consumed.
+func ParseLengthPrefixedString(data []byte) (string, int) {
+	if len(data) == 0 {
+		return "", 0
+	}
+	n := int(data[0])
+	if 1+n > len(data) {
+		return string(data[1:]), len(data)
+	}
+	return string(data[1 : 1+n]), 1 + n
+}
@@ -0,0 +1,385 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// 16-bit type index leaf constants. These records predate V70 and store
+// type indices in two bytes and names as length-prefixed strings.
+const (
+	LF_MODIFIER_16t   = 0x0001
+	LF_POINTER_16t    = 0x0002
+	LF_ARRAY_16t      = 0x0003
+	LF_CLASS_16t      = 0x0004
+	LF_STRUCTURE_16t  = 0x0005
+	LF_UNION_16t      = 0x0006
+	LF_ENUM_16t       = 0x0007
+	LF_PROCEDURE_16t  = 0x0008
+	LF_MFUNCTION_16t  = 0x0009
+	LF_ARGLIST_16t    = 0x0201
+	LF_FIELDLIST_16t  = 0x0204
+	LF_BITFIELD_16t   = 0x0206
+	LF_METHODLIST_16t = 0x0207
+
+	LF_BCLASS_16t    = 0x0400
+	LF_VBCLASS_16t   = 0x0401
+	LF_IVBCLASS_16t  = 0x0402
+	LF_ENUMERATE_16t = 0x0403
+	LF_FRIENDFCN_16t = 0x0404
+	LF_INDEX_16t     = 0x0405
+	LF_MEMBER_16t    = 0x0406
+	LF_STMEMBER_16t  = 0x0407
+	LF_METHOD_16t    = 0x0408
+	LF_NESTTYPE_16t  = 0x0409
+	LF_VFUNCTAB_16t  = 0x040a
+	LF_FRIENDCLS_16t = 0x040b
+	LF_ONEMETHOD_16t = 0x040c
+	LF_VFUNCOFF_16t  = 0x040d
+)
+
+// IsLegacyTPIVersion returns true for TPI versions older than V70, whose
+// records may use 16-bit type indices.
+func IsLegacyTPIVersion(version uint32) bool {
+	switch version {
+	case TPIStreamVersion40, TPIStreamVersion41, TPIStreamVersion50:
+		return true
+	}
+	return false
+}
+
+// readTPIHeader16t reads the short header of a legacy TPI stream and
+// expands it into a TPIHeader. Legacy hash streams are not supported, so
+// the hash buffers are left empty.
+func readTPIHeader16t(r io.Reader) (TPIHeader, error) {
+	var raw struct {
+		Version         uint32
+		TypeIndexBegin  uint16
+		TypeIndexEnd    uint16
+		TypeRecordBytes uint32
+		HashStreamIndex uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return TPIHeader{}, fmt.Errorf("failed to read TPI header: %w", err)
+	}
+
+	return TPIHeader{
+		Version:            raw.Version,
+		HeaderSize:         uint32(binary.Size(raw)),
+		TypeIndexBegin:     uint32(raw.TypeIndexBegin),
+		TypeIndexEnd:       uint32(raw.TypeIndexEnd),
+		TypeRecordBytes:    raw.TypeRecordBytes,
+		HashStreamIndex:    0xFFFF,
+		HashAuxStreamIndex: 0xFFFF,
+	}, nil
+}
+
+// widenTypeRecord converts a 16-bit type record into the equivalent V70
+// record, so the rest of the library only deals with 32-bit type indices
+// and null-terminated names. Other records are returned unchanged, as are
+// records too short to convert.
+func widenTypeRecord(kind uint16, data []byte) (uint16, []byte) {
+	w := &recordWidener{data: data}
+	var newKind uint16
+	switch kind {
+	case LF_MODIFIER_16t:
+		newKind = LF_MODIFIER
+		attr := w.u16()
+		w.ti()
+		w.out = binary.LittleEndian.AppendUint16(w.out, attr)
+	case LF_POINTER_16t:
+		newKind = LF_POINTER
+		attr := w.u16()
+		w.ti()
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(attr&0x0FFF)|pointerSize16t(attr)<<13)
+		if mode := (attr >> 5) & 0x7; mode == 2 || mode == 3 {
+			// Pointer to member: containing class and representation
+			w.ti()
+			w.copy(2)
+		}
+		w.rest()
+	case LF_ARRAY_16t:
+		newKind = LF_ARRAY_newformat
+		w.ti()
+		w.ti()
+		w.numeric()
+		w.name()
+	case LF_CLASS_16t, LF_STRUCTURE_16t:
+		newKind = LF_STRUCTURE_newformat
+		if kind == LF_CLASS_16t {
+			newKind = LF_CLASS_newformat
+		}
+		count, field, property := w.u16(), w.u16(), w.u16()
+		derived, vshape := w.u16(), w.u16()
+		w.out = binary.LittleEndian.AppendUint16(w.out, count)
+		w.out = binary.LittleEndian.AppendUint16(w.out, property)
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(field))
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(derived))
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(vshape))
+		w.numeric()
+		w.name()
+	case LF_UNION_16t:
+		newKind = LF_UNION_newformat
+		count, field, property := w.u16(), w.u16(), w.u16()
+		w.out = binary.LittleEndian.AppendUint16(w.out, count)
+		w.out = binary.LittleEndian.AppendUint16(w.out, property)
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(field))
+		w.numeric()
+		w.name()
+	case LF_ENUM_16t:
+		newKind = LF_ENUM_newformat
+		count, utype, field, property := w.u16(), w.u16(), w.u16(), w.u16()
+		w.out = binary.LittleEndian.AppendUint16(w.out, count)
+		w.out = binary.LittleEndian.AppendUint16(w.out, property)
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(utype))
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(field))
+		w.name()
+	case LF_PROCEDURE_16t:
+		newKind = LF_PROCEDURE
+		w.ti()    // return type
+		w.copy(4) // calling convention, attributes, parameter count
+		w.ti()    // argument list
+	case LF_MFUNCTION_16t:
+		newKind = LF_MFUNCTION
+		w.ti()    // return type
+		w.ti()    // class type
+		w.ti()    // this type
+		w.copy(4) // calling convention, attributes, parameter count
+		w.ti()    // argument list
+		w.copy(4) // this adjustment
+	case LF_ARGLIST_16t:
+		newKind = LF_ARGLIST
+		count := w.u16()
+		w.out = binary.LittleEndian.AppendUint32(w.out, uint32(count))
+		for i := 0; i < int(count); i++ {
+			w.ti()
+		}
+	case LF_BITFIELD_16t:
+		newKind = LF_BITFIELD
+		length, position := w.u8(), w.u8()
+		w.ti()
+		w.out = append(w.out, length, position)
+	case LF_METHODLIST_16t:
+		newKind = LF_METHODLIST
+		for !w.failed && w.pos < len(w.data) {
+			attr := w.u16()
+			w.out = binary.LittleEndian.AppendUint16(w.out, attr)
+			w.out = binary.LittleEndian.AppendUint16(w.out, 0)
+			w.ti()
+			if isIntroVirtual16t(attr) {
+				w.copy(4)
+			}
+		}
+	case LF_FIELDLIST_16t:
+		newKind = LF_FIELDLIST
+		w.fieldList()
+	default:
+		return kind, data
+	}
+
+	if w.failed {
+		return kind, data
+	}
+	return newKind, w.out
+}
+
+// pointerSize16t returns the pointer size implied by a 16-bit pointer's
+// attributes, or 0 if unknown.
+func pointerSize16t(attr uint16) uint32 {
+	switch attr & 0x1F {
+	case 0x00: // Near
+		return 2
+	case 0x01, 0x02, 0x0a: // Far, huge, near 32-bit
+		return 4
+	case 0x0b: // Far 32-bit
+		return 6
+	case 0x0c: // 64-bit
+		return 8
+	}
+	return 0
+}
+
+// isIntroVirtual16t reports whether method attributes mark an introducing
+// virtual method, which carries a vtable offset.
+func isIntroVirtual16t(attr uint16) bool {
+	mprop := (attr >> 2) & 0x7
+	return mprop == 4 || mprop == 6
+}
+
+// recordWidener copies a 16-bit record into its 32-bit layout. Reads past
+// the end of the input set failed rather than panicking.
+type recordWidener struct {
+	data   []byte
+	pos    int
+	out    []byte
+	failed bool
+}
+
+func (w *recordWidener) need(n int) bool {
+	if w.failed || w.pos+n > len(w.data) {
+		w.failed = true
+		return false
+	}
+	return true
+}
+
+func (w *recordWidener) u8() uint8 {
+	if !w.need(1) {
+		return 0
+	}
+	v := w.data[w.pos]
+	w.pos++
+	return v
+}
+
+func (w *recordWidener) u16() uint16 {
+	if !w.need(2) {
+		return 0
+	}
+	v := binary.LittleEndian.Uint16(w.data[w.pos:])
+	w.pos += 2
+	return v
+}
+
+// ti widens a 16-bit type index.
+func (w *recordWidener) ti() {
+	ti := w.u16()
+	w.out = binary.LittleEndian.AppendUint32(w.out, uint32(ti))
+}
+
+// copy copies n bytes unchanged.
+func (w *recordWidener) copy(n int) {
+	if !w.need(n) {
+		return
+	}
+	w.out = append(w.out, w.data[w.pos:w.pos+n]...)
+	w.pos += n
+}
+
+// rest copies the remaining input unchanged.
+func (w *recordWidener) rest() {
+	if !w.failed {
+		w.copy(len(w.data) - w.pos)
+	}
+}
+
+// numeric copies a numeric leaf unchanged.
+func (w *recordWidener) numeric() {
+	if w.failed {
+		return
+	}
+	_, n := ParseNumeric(w.data[w.pos:])
+	if n == 0 {
+		w.failed = true
+		return
+	}
+	w.copy(n)
+}
+
+// name converts a length-prefixed name into a null-terminated one.
+func (w *recordWidener) name() {
+	if w.failed {
+		return
+	}
+	s, n := ParseLengthPrefixedString(w.data[w.pos:])
+	w.pos += n
+	w.out = append(w.out, s...)
+	w.out = append(w.out, 0)
+}
+
+// fieldList widens the sub-records of an LF_FIELDLIST_16t. Friend and
+// virtual function offset entries have no V70 counterpart the type resolver
+// understands and are dropped.
+func (w *recordWidener) fieldList() {
+	for !w.failed && w.pos < len(w.data) {
+		// Skip LF_PAD bytes between sub-records
+		if b := w.data[w.pos]; b >= 0xF0 {
+			if skip := int(b & 0x0F); skip > 0 {
+				w.pos += skip
+			} else {
+				w.pos++
+			}
+			continue
+		}
+
+		start := len(w.out)
+		switch leaf := w.u16(); leaf {
+		case LF_BCLASS_16t:
+			ti, attr := w.u16(), w.u16()
+			w.leaf(LF_BCLASS, attr, ti)
+			w.numeric()
+		case LF_VBCLASS_16t, LF_IVBCLASS_16t:
+			newLeaf := uint16(LF_VBCLASS)
+			if leaf == LF_IVBCLASS_16t {
+				newLeaf = LF_IVBCLASS
+			}
+			ti, vbptr, attr := w.u16(), w.u16(), w.u16()
+			w.leaf(newLeaf, attr, ti)
+			w.out = binary.LittleEndian.AppendUint32(w.out, uint32(vbptr))
+			w.numeric()
+			w.numeric()
+		case LF_ENUMERATE_16t:
+			w.out = binary.LittleEndian.AppendUint16(w.out, LF_ENUMERATE)
+			w.copy(2) // attributes
+			w.numeric()
+			w.name()
+		case LF_INDEX_16t:
+			w.leaf(LF_INDEX, 0, w.u16())
+		case LF_MEMBER_16t:
+			ti, attr := w.u16(), w.u16()
+			w.leaf(LF_MEMBER_newformat, attr, ti)
+			w.numeric()
+			w.name()
+		case LF_STMEMBER_16t:
+			ti, attr := w.u16(), w.u16()
+			w.leaf(LF_STMEMBER_newformat, attr, ti)
+			w.name()
+		case LF_METHOD_16t:
+			count, mlist := w.u16(), w.u16()
+			w.leaf(LF_METHOD_newformat, count, mlist)
+			w.name()
+		case LF_NESTTYPE_16t:
+			w.leaf(LF_NESTTYPE_newformat, 0, w.u16())
+			w.name()
+		case LF_VFUNCTAB_16t:
+			w.leaf(LF_VFUNCTAB, 0, w.u16())
+		case LF_ONEMETHOD_16t:
+			attr, ti := w.u16(), w.u16()
+			w.leaf(LF_ONEMETHOD_newformat, attr, ti)
+			if isIntroVirtual16t(attr) {
+				w.copy(4)
+			}
+			w.name()
+		case LF_FRIENDFCN_16t:
+			w.u16()
+			w.name()
+			continue
+		case LF_FRIENDCLS_16t:
+			w.u16()
+			continue
+		case LF_VFUNCOFF_16t:
+			w.u16()
+			if w.need(4) {
+				w.pos += 4
+			}
+			continue
+		default:
+			w.failed = true
+			return
+		}
+
+		// Pad each sub-record to 4 bytes as V70 field lists are
+		for pad := (4 - (len(w.out)-start)%4) % 4; pad > 0; pad-- {
+			w.out = append(w.out, byte(0xF0|pad))
+		}
+	}
+}
+
+// leaf writes a V70 sub-record prefix: leaf kind, a 16-bit word and a
+// widened type index.
+func (w *recordWidener) leaf(kind, word, ti uint16) {
+	w.out = binary.LittleEndian.AppendUint16(w.out, kind)
+	w.out = binary.LittleEndian.AppendUint16(w.out, word)
+	w.out = binary.LittleEndian.AppendUint32(w.out, uint32(ti))
+}
@@ -0,0 +1,169 @@
+package streams
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// numericLeaf encodes a numeric leaf of the given kind and payload.
+func numericLeaf(kind uint16, payload ...byte) []byte {
+	return append(binary.LittleEndian.AppendUint16(nil, kind), payload...)
+}
+
+func TestParseNumericParity(t *testing.T) {
+	f64 := binary.LittleEndian.AppendUint64(nil, math.Float64bits(-2.5))
+	f32 := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.75))
+	// 1.5 as an x87 extended and an IEEE quad
+	f80 := []byte{0, 0, 0, 0, 0, 0, 0, 0xC0, 0xFF, 0x3F}
+	f128 := append(make([]byte, 13), 0x80, 0xFF, 0x3F)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		value    uint64
+		float    float64
+		consumed int
+	}{
+		{"direct", []byte{0x34, 0x12}, 0x1234, 0, 2},
+		{"LF_CHAR", numericLeaf(LF_CHAR, 0xFE), math.MaxUint64 - 1, 0, 3},
+		{"LF_SHORT", numericLeaf(LF_SHORT, 0x00, 0x80), uint64(math.MaxUint64 - 0x7FFF), 0, 4},
+		{"LF_USHORT", numericLeaf(LF_USHORT, 0x00, 0x80), 0x8000, 0, 4},
+		{"LF_LONG", numericLeaf(LF_LONG, 0xFF, 0xFF, 0xFF, 0xFF), math.MaxUint64, 0, 6},
+		{"LF_ULONG", numericLeaf(LF_ULONG, 0xFF, 0xFF, 0xFF, 0xFF), math.MaxUint32, 0, 6},
+		{"LF_REAL32", numericLeaf(LF_REAL32, f32...), 0, 0.75, 6},
+		{"LF_REAL64", numericLeaf(LF_REAL64, f64...), 0, -2.5, 10},
+		{"LF_REAL80", numericLeaf(LF_REAL80, f80...), 0, 1.5, 12},
+		{"LF_REAL128", numericLeaf(LF_REAL128, f128...), 0, 1.5, 18},
+
+		// Truncated leaves consume what is there
+		{"empty", nil, 0, 0, 0},
+		{"one byte", []byte{0x80}, 0, 0, 1},
+		{"LF_CHAR truncated", numericLeaf(LF_CHAR), 0, 0, 2},
+		{"LF_SHORT truncated", numericLeaf(LF_SHORT, 1), 0, 0, 3},
+		{"LF_USHORT truncated", numericLeaf(LF_USHORT, 1), 0, 0, 3},
+		{"LF_LONG truncated", numericLeaf(LF_LONG, 1, 2, 3), 0, 0, 5},
+		{"LF_ULONG truncated", numericLeaf(LF_ULONG, 1, 2, 3), 0, 0, 5},
+		{"LF_REAL32 truncated", numericLeaf(LF_REAL32, f32[:3]...), 0, 0, 5},
+		{"LF_REAL64 truncated", numericLeaf(LF_REAL64, f64[:7]...), 0, 0, 9},
+		{"LF_REAL80 truncated", numericLeaf(LF_REAL80, f80[:9]...), 0, 0, 11},
+		{"LF_REAL128 truncated", numericLeaf(LF_REAL128, f128[:15]...), 0, 0, 17},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n, leafConsumed := ParseNumericLeaf(tc.data)
+			value, consumed := ParseNumeric(tc.data)
+
+			if value != n.Value || consumed != leafConsumed {
+				t.Errorf("ParseNumeric = %#x, %d; ParseNumericLeaf = %#x, %d", value, consumed, n.Value, leafConsumed)
+			}
+			if value != tc.value || consumed != tc.consumed {
+				t.Errorf("ParseNumeric = %#x, %d; want %#x, %d", value, consumed, tc.value, tc.consumed)
+			}
+			if n.Float != tc.float {
+				t.Errorf("ParseNumericLeaf float = %v, want %v", n.Float, tc.float)
+			}
+		})
+	}
+
+	// Every fixed-size leaf, whole and cut at each length
+	for kind, size := range numericSizes {
+		data := numericLeaf(kind, make([]byte, size)...)
+		for i := 2; i < len(data); i++ {
+			data[i] = byte(0x81 + i)
+		}
+		for end := 0; end <= len(data); end++ {
+			n, leafConsumed := ParseNumericLeaf(data[:end])
+			value, consumed := ParseNumeric(data[:end])
+			if value != n.Value || consumed != leafConsumed {
+				t.Errorf("kind %#x, %d bytes: ParseNumeric = %#x, %d; ParseNumericLeaf = %#x, %d", kind, end, value, consumed, n.Value, leafConsumed)
+			}
+		}
+	}
+}
+
+func TestGetBuiltinTypeName(t *testing.T) {
+	kinds := []struct {
+		name string
+		kind uint32
+	}{
+		{"T_NOTYPE", T_NOTYPE},
+		{"T_ABS", T_ABS},
+		{"T_SEGMENT", T_SEGMENT},
+		{"T_VOID", T_VOID},
+		{"T_CURRENCY", T_CURRENCY},
+		{"T_NBASICSTR", T_NBASICSTR},
+		{"T_FBASICSTR", T_FBASICSTR},
+		{"T_NOTTRANS", T_NOTTRANS},
+		{"T_HRESULT", T_HRESULT},
+		{"T_CHAR", T_CHAR},
+		{"T_SHORT", T_SHORT},
+		{"T_LONG", T_LONG},
+		{"T_QUAD", T_QUAD},
+		{"T_OCT", T_OCT},
+		{"T_UCHAR", T_UCHAR},
+		{"T_USHORT", T_USHORT},
+		{"T_ULONG", T_ULONG},
+		{"T_UQUAD", T_UQUAD},
+		{"T_UOCT", T_UOCT},
+		{"T_BOOL08", T_BOOL08},
+		{"T_BOOL16", T_BOOL16},
+		{"T_BOOL32", T_BOOL32},
+		{"T_BOOL64", T_BOOL64},
+		{"T_REAL32", T_REAL32},
+		{"T_REAL64", T_REAL64},
+		{"T_REAL80", T_REAL80},
+		{"T_REAL128", T_REAL128},
+		{"T_REAL48", T_REAL48},
+		{"T_REAL32PP", T_REAL32PP},
+		{"T_REAL16", T_REAL16},
+		{"T_CPLX32", T_CPLX32},
+		{"T_CPLX64", T_CPLX64},
+		{"T_CPLX80", T_CPLX80},
+		{"T_CPLX128", T_CPLX128},
+		{"T_BIT", T_BIT},
+		{"T_PASCHAR", T_PASCHAR},
+		{"T_BOOL32FF", T_BOOL32FF},
+		{"T_INT1", T_INT1},
+		{"T_UINT1", T_UINT1},
+		{"T_RCHAR", T_RCHAR},
+		{"T_WCHAR", T_WCHAR},
+		{"T_INT2", T_INT2},
+		{"T_UINT2", T_UINT2},
+		{"T_INT4", T_INT4},
+		{"T_UINT4", T_UINT4},
+		{"T_INT8", T_INT8},
+		{"T_UINT8", T_UINT8},
+		{"T_INT16", T_INT16},
+		{"T_UINT16", T_UINT16},
+		{"T_CHAR16", T_CHAR16},
+		{"T_CHAR32", T_CHAR32},
+		{"T_CHAR8", T_CHAR8},
+	}
+	for _, k := range kinds {
+		for _, mode := range []uint32{TM_DIRECT, TM_NPTR32, TM_NPTR64} {
+			index := mode<<8 | k.kind
+			if got := GetBuiltinTypeName(index); got == "" || strings.HasPrefix(got, "builtin_") {
+				t.Errorf("GetBuiltinTypeName(%s, mode %d) = %q", k.name, mode, got)
+			}
+		}
+	}
+
+	for index, want := range map[uint32]string{
+		T_CHAR8:                   "char8_t",
+		T_CHAR16:                  "char16_t",
+		T_CHAR32:                  "char32_t",
+		T_REAL128:                 "__float128",
+		T_CPLX32:                  "_Complex float",
+		T_INT16:                   "int128",
+		TM_NPTR64<<8 | T_HRESULT:  "HRESULT*",
+		TM_NPTR32<<8 | T_CURRENCY: "CURRENCY*",
+		TM_NPTR64<<8 | T_CHAR16:   "char16_t*",
+	} {
+		if got := GetBuiltinTypeName(index); got != want {
+			t.Errorf("GetBuiltinTypeName(0x%04x) = %q, want %q", index, got, want)
+		}
+	}
+}
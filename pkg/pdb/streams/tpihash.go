@@ -0,0 +1,180 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Structure property bits (CV_prop_t)
+const (
+	PropForwardRef    = 0x0080 // Forward declaration
+	PropScoped        = 0x0100 // Defined in a function or class scope
+	PropHasUniqueName = 0x0200 // Decorated unique name follows the name
+)
+
+// UDTNames returns the name and unique name (empty if absent) of a
+// struct/class/union/enum record, and whether it is a forward declaration.
+// Other records yield empty names.
+func UDTNames(rec *TypeRecord) (name, unique string, fwd bool) {
+	property, nameOffset, ok := udtNameOffset(rec)
+	if !ok {
+		return "", "", false
+	}
+
+	name, nameLen := ParseString(rec.Data[nameOffset:])
+	if property&PropHasUniqueName != 0 && nameOffset+nameLen < len(rec.Data) {
+		unique, _ = ParseString(rec.Data[nameOffset+nameLen:])
+	}
+	return name, unique, property&PropForwardRef != 0
+}
+
+// udtNameOffset returns the property bits of a struct/class/union/enum
+// record and the offset of its name.
+func udtNameOffset(rec *TypeRecord) (uint16, int, bool) {
+	var nameOffset int
+	hasSize := true
+	switch rec.Kind {
+	case LF_STRUCTURE, LF_STRUCTURE_newformat, LF_CLASS, LF_CLASS_newformat:
+		nameOffset = 16
+	case LF_UNION, LF_UNION_newformat:
+		nameOffset = 8
+	case LF_ENUM, LF_ENUM_newformat:
+		nameOffset = 12
+		hasSize = false
+	default:
+		return 0, 0, false
+	}
+	if len(rec.Data) < nameOffset+2 {
+		return 0, 0, false
+	}
+
+	property := binary.LittleEndian.Uint16(rec.Data[2:])
+	if hasSize {
+		_, consumed := ParseNumeric(rec.Data[nameOffset:])
+		nameOffset += consumed
+	}
+	if nameOffset >= len(rec.Data) {
+		return 0, 0, false
+	}
+	return property, nameOffset, true
+}
+
+// HashStringV1 computes the string hash MSVC uses for TPI name lookup and
+// the PDB's other V1 hash tables (LHashPbCb). The result is not yet reduced
+// modulo the bucket count.
+func HashStringV1(s string) uint32 {
+	var result uint32
+	b := []byte(s)
+
+	for len(b) >= 4 {
+		result ^= binary.LittleEndian.Uint32(b)
+		b = b[4:]
+	}
+	if len(b) >= 2 {
+		result ^= uint32(binary.LittleEndian.Uint16(b))
+		b = b[2:]
+	}
+	if len(b) == 1 {
+		result ^= uint32(b[0])
+	}
+
+	result |= 0x20202020 // Case-insensitive
+	result ^= result >> 11
+	return result ^ (result >> 16)
+}
+
+// LoadHashStream reads the hash values of the TPI hash stream, which hold
+// one bucket number per type record. Once loaded, LookupByName uses them
+// instead of scanning every record. Lazily opened streams also load the
+// index offset buffer used to locate records, and streams with bad records
+// use it to parse the records past them.
+func (t *TPIStream) LoadHashStream(data []byte) error {
+	h := t.Header
+	if (t.lazy != nil || t.recordData != nil) && h.IndexOffsetBufferLength > 0 {
+		if h.IndexOffsetBufferOffset < 0 || uint64(h.IndexOffsetBufferOffset)+uint64(h.IndexOffsetBufferLength) > uint64(len(data)) {
+			return fmt.Errorf("TPI index offset buffer exceeds hash stream size")
+		}
+		offsets := parseIndexOffsets(data[h.IndexOffsetBufferOffset : uint32(h.IndexOffsetBufferOffset)+h.IndexOffsetBufferLength])
+		if t.lazy != nil {
+			t.lazy.offsets = offsets
+		} else {
+			t.TypeRecords, t.typeMap, t.badRecords = parseTypeRecords(h, t.recordData, offsets)
+			t.recordData = nil
+		}
+	}
+
+	if h.NumHashBuckets == 0 {
+		return nil
+	}
+	if h.HashKeySize != 2 && h.HashKeySize != 4 {
+		return fmt.Errorf("unsupported TPI hash key size: %d", h.HashKeySize)
+	}
+	if h.HashValueBufferOffset < 0 || uint64(h.HashValueBufferOffset)+uint64(h.HashValueBufferLength) > uint64(len(data)) {
+		return fmt.Errorf("TPI hash value buffer exceeds hash stream size")
+	}
+
+	values := data[h.HashValueBufferOffset : uint32(h.HashValueBufferOffset)+h.HashValueBufferLength]
+	buckets := make(map[uint32][]uint32)
+	typeIndex := h.TypeIndexBegin
+	for i := 0; i+int(h.HashKeySize) <= len(values); i += int(h.HashKeySize) {
+		var bucket uint32
+		if h.HashKeySize == 4 {
+			bucket = binary.LittleEndian.Uint32(values[i:])
+		} else {
+			bucket = uint32(binary.LittleEndian.Uint16(values[i:]))
+		}
+		buckets[bucket] = append(buckets[bucket], typeIndex)
+		typeIndex++
+	}
+
+	t.hashBuckets = buckets
+	return nil
+}
+
+// HasHash reports whether hash values were loaded by LoadHashStream.
+func (t *TPIStream) HasHash() bool {
+	return t.hashBuckets != nil
+}
+
+// LookupByName returns the indices of the complete struct/class/union/enum
+// definitions named name, in type index order. As in MSVC's hash, types
+// defined in a scope are keyed by their unique (decorated) name and all
+// others by their name; forward declarations are not included. Without a
+// loaded hash stream every record is scanned.
+func (t *TPIStream) LookupByName(name string) []uint32 {
+	if name == "" {
+		return nil
+	}
+
+	var matches []uint32
+	if t.hashBuckets != nil {
+		bucket := HashStringV1(name) % t.Header.NumHashBuckets
+		for _, typeIndex := range t.hashBuckets[bucket] {
+			if rec := t.GetType(typeIndex); rec != nil && udtHashedAs(rec, name) {
+				matches = append(matches, typeIndex)
+			}
+		}
+		return matches
+	}
+
+	records, _ := t.Records()
+	for i := range records {
+		if udtHashedAs(&records[i], name) {
+			matches = append(matches, records[i].Index)
+		}
+	}
+	return matches
+}
+
+// udtHashedAs reports whether a record is a complete definition whose
+// hash key is name.
+func udtHashedAs(rec *TypeRecord, name string) bool {
+	recName, unique, fwd := UDTNames(rec)
+	if fwd || recName == "" {
+		return false
+	}
+	if property, _, _ := udtNameOffset(rec); property&PropScoped != 0 {
+		return unique == name
+	}
+	return recName == name
+}
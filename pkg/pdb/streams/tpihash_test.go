@@ -0,0 +1,123 @@
+package streams
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// udtRecord encodes a struct, union or enum record named name, with the
+// unique name appended when it is not empty.
+func udtRecord(kind, property uint16, name, unique string) []byte {
+	if unique != "" {
+		property |= PropHasUniqueName
+	}
+	data := binary.LittleEndian.AppendUint16(nil, 0)
+	data = binary.LittleEndian.AppendUint16(data, property)
+	switch kind {
+	case LF_STRUCTURE_newformat:
+		data = append(data, make([]byte, 12)...)
+		data = binary.LittleEndian.AppendUint16(data, 8)
+	case LF_UNION_newformat:
+		data = append(data, make([]byte, 4)...)
+		data = binary.LittleEndian.AppendUint16(data, 8)
+	case LF_ENUM_newformat:
+		data = binary.LittleEndian.AppendUint32(data, T_INT4)
+		data = append(data, make([]byte, 4)...)
+	}
+	data = append(append(data, name...), 0)
+	if unique != "" {
+		data = append(append(data, unique...), 0)
+	}
+	return tpiRecord(kind, data)
+}
+
+func TestLookupByNameHash(t *testing.T) {
+	type rec struct {
+		kind, property uint16
+		name, unique   string
+	}
+	recs := []rec{
+		{LF_STRUCTURE_newformat, 0, "Foo", ".?AUFoo@@"},                          // 0x1000
+		{LF_STRUCTURE_newformat, PropForwardRef, "Foo", ".?AUFoo@@"},             // 0x1001
+		{LF_STRUCTURE_newformat, 0, "FOO", ""},                                   // 0x1002
+		{LF_UNION_newformat, 0, "U", ""},                                         // 0x1003
+		{LF_ENUM_newformat, 0, "E", ""},                                          // 0x1004
+		{LF_STRUCTURE_newformat, PropScoped, "Inner", ".?AUInner@?1??f@@YAXXZ@"}, // 0x1005
+		{LF_STRUCTURE_newformat, 0, "Inner", ""},                                 // 0x1006
+		{LF_POINTER, 0, "", ""},                                                  // 0x1007
+		{LF_STRUCTURE_newformat, 0, "Foo", ".?AUFoo@@"},                          // 0x1008
+	}
+	const numBuckets = 5
+
+	var records [][]byte
+	var keys []string
+	for _, r := range recs {
+		if r.kind == LF_POINTER {
+			records = append(records, tpiRecord(LF_POINTER, make([]byte, 8)))
+		} else {
+			records = append(records, udtRecord(r.kind, r.property, r.name, r.unique))
+		}
+		// MSVC keys types defined in a scope by their unique name
+		key := r.name
+		if r.property&PropScoped != 0 {
+			key = r.unique
+		}
+		keys = append(keys, key)
+	}
+	data := tpiBytes(TypeIndexBegin, records...)
+
+	lookups := map[string][]uint32{
+		"Foo":                     {0x1000, 0x1008},
+		"FOO":                     {0x1002},
+		"foo":                     nil,
+		"U":                       {0x1003},
+		"E":                       {0x1004},
+		"Inner":                   {0x1006},
+		".?AUInner@?1??f@@YAXXZ@": {0x1005},
+		".?AUFoo@@":               nil,
+		"Missing":                 nil,
+		"":                        nil,
+	}
+
+	scan, err := ReadTPIStream(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keySize := range []uint32{2, 4} {
+		var values []byte
+		for _, key := range keys {
+			bucket := HashStringV1(key) % numBuckets
+			if keySize == 2 {
+				values = binary.LittleEndian.AppendUint16(values, uint16(bucket))
+			} else {
+				values = binary.LittleEndian.AppendUint32(values, bucket)
+			}
+		}
+		hashed := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(hashed[24:], keySize)
+		binary.LittleEndian.PutUint32(hashed[28:], numBuckets)
+		binary.LittleEndian.PutUint32(hashed[32:], 0)
+		binary.LittleEndian.PutUint32(hashed[36:], uint32(len(values)))
+		tpi, err := ReadTPIStream(hashed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tpi.LoadHashStream(values); err != nil {
+			t.Fatalf("key size %d: LoadHashStream: %v", keySize, err)
+		}
+		if !tpi.HasHash() {
+			t.Fatalf("key size %d: no hash loaded", keySize)
+		}
+
+		for name, want := range lookups {
+			got := tpi.LookupByName(name)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("key size %d: LookupByName(%q) = %#x, want %#x", keySize, name, got, want)
+			}
+			if brute := scan.LookupByName(name); !reflect.DeepEqual(got, brute) {
+				t.Errorf("key size %d: LookupByName(%q) = %#x with the hash, %#x by scanning", keySize, name, got, brute)
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package streams
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// TypeIndexOffset is an entry of the TPI hash stream's index offset
+// buffer: the byte offset of a type record within the record data. Entries
+// are spaced at intervals, giving a starting point for a forward scan.
+type TypeIndexOffset struct {
+	TypeIndex uint32
+	Offset    uint32
+}
+
+// lazyRecords holds the state of a TPI stream whose records are read on
+// demand.
+type lazyRecords struct {
+	r       io.ReaderAt
+	start   int64             // Offset of the record data in the stream
+	offsets []TypeIndexOffset // Sorted by type index
+
+	mu       sync.Mutex
+	loadOnce sync.Once
+	loadErr  error
+}
+
+// OpenTPIStreamLazy reads the TPI header from r but no records. GetType
+// then reads single records on demand, starting from the nearest entry of
+// the index offset buffer once LoadHashStream has been called. Records
+// reads and parses the whole stream.
+func OpenTPIStreamLazy(r io.ReaderAt, size int64) (*TPIStream, error) {
+	buf := make([]byte, binary.Size(TPIHeader{}))
+	if int64(len(buf)) > size {
+		buf = buf[:size]
+	}
+	if _, err := r.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read TPI header: %w", err)
+	}
+
+	hr := bytes.NewReader(buf)
+	header, err := readTPIHeader(hr, buf)
+	if err != nil {
+		return nil, err
+	}
+	start := int64(len(buf)) - int64(hr.Len())
+	if start+int64(header.TypeRecordBytes) > size {
+		return nil, fmt.Errorf("TPI record data size %d exceeds stream size", header.TypeRecordBytes)
+	}
+
+	return &TPIStream{
+		Header:  header,
+		typeMap: make(map[uint32]*TypeRecord),
+		lazy:    &lazyRecords{r: r, start: start},
+	}, nil
+}
+
+// IsLazy reports whether records are read on demand.
+func (t *TPIStream) IsLazy() bool {
+	return t.lazy != nil
+}
+
+// Records returns every type record in index order. A lazily opened
+// stream is read in full on the first call; the error reports a failed
+// read, in which case the records before the failure are returned.
+func (t *TPIStream) Records() ([]TypeRecord, error) {
+	if t.lazy == nil {
+		return t.TypeRecords, nil
+	}
+	t.lazy.loadOnce.Do(t.loadAllRecords)
+	return t.TypeRecords, t.lazy.loadErr
+}
+
+// loadAllRecords reads and parses the record data of a lazy stream.
+func (t *TPIStream) loadAllRecords() {
+	recordData := make([]byte, t.Header.TypeRecordBytes)
+	n, err := t.lazy.r.ReadAt(recordData, t.lazy.start)
+	if err != nil && !(err == io.EOF && n == len(recordData)) {
+		t.lazy.loadErr = fmt.Errorf("failed to read type records: %w", err)
+	}
+
+	records, typeMap, bad := parseTypeRecords(t.Header, recordData[:n], t.lazy.offsets)
+
+	t.lazy.mu.Lock()
+	t.TypeRecords, t.typeMap, t.badRecords = records, typeMap, bad
+	t.lazy.mu.Unlock()
+}
+
+// parseIndexOffsets parses the index offset buffer of the TPI hash
+// stream, sorted by type index.
+func parseIndexOffsets(data []byte) []TypeIndexOffset {
+	offsets := make([]TypeIndexOffset, 0, len(data)/8)
+	for i := 0; i+8 <= len(data); i += 8 {
+		offsets = append(offsets, TypeIndexOffset{
+			TypeIndex: binary.LittleEndian.Uint32(data[i:]),
+			Offset:    binary.LittleEndian.Uint32(data[i+4:]),
+		})
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i].TypeIndex < offsets[j].TypeIndex
+	})
+	return offsets
+}
+
+// lazyGetType returns the record for index, reading it on first use.
+func (t *TPIStream) lazyGetType(index uint32) *TypeRecord {
+	if index < t.Header.TypeIndexBegin || index >= t.Header.TypeIndexEnd {
+		return nil
+	}
+
+	t.lazy.mu.Lock()
+	defer t.lazy.mu.Unlock()
+	if rec, ok := t.typeMap[index]; ok {
+		return rec
+	}
+
+	rec := t.readRecord(index)
+	if rec != nil {
+		t.typeMap[index] = rec
+	}
+	return rec
+}
+
+// readRecord scans forward from the closest preceding index offset entry
+// to the record for index. The caller holds the lock.
+func (t *TPIStream) readRecord(index uint32) *TypeRecord {
+	typeIndex, offset := t.Header.TypeIndexBegin, uint32(0)
+	offsets := t.lazy.offsets
+	if i := sort.Search(len(offsets), func(i int) bool {
+		return offsets[i].TypeIndex > index
+	}) - 1; i >= 0 && offsets[i].TypeIndex >= typeIndex {
+		typeIndex, offset = offsets[i].TypeIndex, offsets[i].Offset
+	}
+
+	var lenBuf [2]byte
+	for offset+2 <= t.Header.TypeRecordBytes {
+		if _, err := t.lazy.r.ReadAt(lenBuf[:], t.lazy.start+int64(offset)); err != nil {
+			return nil
+		}
+		recLen := uint32(binary.LittleEndian.Uint16(lenBuf[:]))
+		offset += 2
+
+		// A malformed record loses track of the records after it. The
+		// scan started at the last index offset entry before index, so
+		// parseTypeRecords would not recover before index either.
+		if recLen < 2 || offset+recLen > t.Header.TypeRecordBytes {
+			return nil
+		}
+
+		if typeIndex == index {
+			rec := make([]byte, recLen)
+			if n, _ := t.lazy.r.ReadAt(rec, t.lazy.start+int64(offset)); n < len(rec) {
+				return nil
+			}
+			record := newTypeRecord(index, rec, IsLegacyTPIVersion(t.Header.Version))
+			return &record
+		}
+
+		offset += recLen
+		typeIndex++
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package streams
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TypeServer identifies an external PDB holding the types of modules
+// compiled with /Zi against a shared type server. It is the payload of an
+// LF_TYPESERVER or LF_TYPESERVER2 record.
+type TypeServer struct {
+	GUID [16]byte // PDB info GUID of the type server
+	Age  uint32   // PDB info age of the type server
+	Name string   // Path of the type server PDB as seen by the compiler
+}
+
+// IsTypeServerKind reports whether kind is a type server reference leaf.
+func IsTypeServerKind(kind uint16) bool {
+	return kind == LF_TYPESERVER || kind == LF_TYPESERVER2
+}
+
+// ParseTypeServer parses the data of an LF_TYPESERVER or LF_TYPESERVER2
+// record.
+func ParseTypeServer(data []byte) (*TypeServer, error) {
+	if len(data) < 20 {
+		return nil, fmt.Errorf("type server record too small: %d bytes", len(data))
+	}
+
+	ts := &TypeServer{Age: binary.LittleEndian.Uint32(data[16:])}
+	copy(ts.GUID[:], data[0:16])
+	ts.Name, _ = ParseString(data[20:])
+	return ts, nil
+}
+
+// ParseTypeServerMap parses the DBI type server map substream, a sequence
+// of type server records each preceded by its uint16 length, as in the TPI
+// record data. Records of other kinds are skipped and parsing stops at the
+// first truncated record.
+func ParseTypeServerMap(data []byte) []TypeServer {
+	var servers []TypeServer
+
+	offset := 0
+	for offset+4 <= len(data) {
+		recLen := int(binary.LittleEndian.Uint16(data[offset:]))
+		if recLen < 2 || offset+2+recLen > len(data) {
+			break
+		}
+		rec := data[offset+2 : offset+2+recLen]
+		offset += 2 + recLen
+
+		if !IsTypeServerKind(binary.LittleEndian.Uint16(rec)) {
+			continue
+		}
+		if ts, err := ParseTypeServer(rec[2:]); err == nil {
+			servers = append(servers, *ts)
+		}
+	}
+	return servers
+}
@@ -0,0 +1,35 @@
+#pragma once
+
+struct Node;
+
+struct Header {
+    unsigned int kind : 3;
+    unsigned int prio : 5;
+    unsigned short len;
+};
+
+struct Packet {
+    struct Header hdr;
+    struct Node *owner;
+    union {
+        unsigned int value;
+        float ratio;
+    } payload;
+    union {
+        unsigned int seq;
+        unsigned int ack;
+    };
+};
+
+struct Node {
+    struct Packet pkt;
+    struct Node *next;
+    /* int32 __thiscall Node::depth(void); */
+};
+
+#pragma pack(push, 1)
+struct Packed {
+    char c;
+    int i;
+};
+#pragma pack(pop)
@@ -1,4 +1,7 @@
 // Package pdb provides high-level access to Microsoft PDB debug files.
+//
+// Opening and querying a PDB is safe on untrusted files: corrupt data is
+// reported through errors and Warnings rather than panics.
 package pdb
 
 // Function represents a function/procedure symbol.
@@ -14,9 +17,29 @@ type Function struct {
 	Signature     string `json:"signature"`
 	IsGlobal      bool   `json:"is_global"`
 	Module        string `json:"module,omitempty"`
+
+	// Frame is the S_FRAMEPROC information, when the compiler emitted it.
+	Frame *FrameInfo `json:"frame,omitempty"`
+}
+
+// FrameInfo describes the stack frame layout of a function.
+type FrameInfo struct {
+	TotalFrameBytes         uint32 `json:"total_frame_bytes"`
+	PaddingBytes            uint32 `json:"padding_bytes,omitempty"`
+	PaddingOffset           uint32 `json:"padding_offset,omitempty"`
+	CalleeSavesBytes        uint32 `json:"callee_saves_bytes,omitempty"`
+	ExceptionHandlerOffset  uint32 `json:"exception_handler_offset,omitempty"`
+	ExceptionHandlerSection uint16 `json:"exception_handler_section,omitempty"`
+	Flags                   uint32 `json:"flags"`
+	HasSEH                  bool   `json:"has_seh,omitempty"`
+	HasAsyncEH              bool   `json:"has_async_eh,omitempty"`
+	Naked                   bool   `json:"naked,omitempty"`
+	LocalBasePointer        string `json:"local_base_pointer,omitempty"` // Register addressing locals
+	ParamBasePointer        string `json:"param_base_pointer,omitempty"` // Register addressing parameters
 }
 
-// Variable represents a data/variable symbol.
+// Variable represents a data/variable symbol. For thread-local variables
+// Offset is relative to the thread's TLS block and RVA is left zero.
 type Variable struct {
 	Name          string `json:"name"`
 	DemangledName string `json:"demangled_name,omitempty"`
@@ -27,9 +50,154 @@ type Variable struct {
 	TypeIndex     uint32 `json:"type_index"`
 	TypeName      string `json:"type_name"`
 	IsGlobal      bool   `json:"is_global"`
+	IsThreadLocal bool   `json:"is_thread_local,omitempty"` // S_GTHREAD32/S_LTHREAD32
 	Module        string `json:"module,omitempty"`
 }
 
+// Thunk represents a thunk symbol (S_THUNK32).
+type Thunk struct {
+	Name          string `json:"name"`
+	Kind          string `json:"kind"` // "notype", "adjustor", "vcall", "pcode", "load", ...
+	Ordinal       uint8  `json:"ordinal"`
+	Offset        uint32 `json:"offset"`
+	Segment       uint16 `json:"segment"`
+	RVA           uint32 `json:"rva"`
+	Length        uint16 `json:"length"`
+	Module        string `json:"module,omitempty"`
+	AdjustorDelta int16  `json:"adjustor_delta,omitempty"` // Adjustment applied to this (adjustor only)
+	Target        string `json:"target,omitempty"`         // Target function (adjustor only)
+	VCallOffset   uint16 `json:"vcall_offset,omitempty"`   // Vtable slot offset (vcall only)
+}
+
+// Label represents a code label (S_LABEL32), such as a jump target or a
+// label in an __asm block.
+type Label struct {
+	Name       string `json:"name"`
+	Offset     uint32 `json:"offset"`
+	Segment    uint16 `json:"segment"`
+	RVA        uint32 `json:"rva"`
+	Flags      uint8  `json:"flags"`                 // Raw CV_PROCFLAGS
+	NoReturn   bool   `json:"no_return,omitempty"`   // Code at the label does not return
+	NotReached bool   `json:"not_reached,omitempty"` // Label is not fallen into
+	FarReturn  bool   `json:"far_return,omitempty"`
+	Module     string `json:"module,omitempty"`
+}
+
+// SeparatedCode is a range of code split off from its parent function
+// (S_SEPCODE), such as the cold part of a hot/cold split function.
+type SeparatedCode struct {
+	Offset          uint32 `json:"offset"`
+	Segment         uint16 `json:"segment"`
+	RVA             uint32 `json:"rva"`
+	Length          uint32 `json:"length"`
+	ParentOffset    uint32 `json:"parent_offset"`
+	ParentSegment   uint16 `json:"parent_segment"`
+	ParentRVA       uint32 `json:"parent_rva"`
+	Parent          string `json:"parent,omitempty"` // Name of the parent function
+	Module          string `json:"module,omitempty"`
+	IsLexicalScope  bool   `json:"is_lexical_scope,omitempty"`
+	ReturnsToParent bool   `json:"returns_to_parent,omitempty"`
+}
+
+// Annotation holds the strings of an __annotation() call (S_ANNOTATION)
+// and the code address it was made at.
+type Annotation struct {
+	Offset  uint32   `json:"offset"`
+	Segment uint16   `json:"segment"`
+	RVA     uint32   `json:"rva"`
+	Strings []string `json:"strings"`
+	Module  string   `json:"module,omitempty"`
+}
+
+// Export represents a DLL export (S_EXPORT).
+type Export struct {
+	Ordinal     uint16 `json:"ordinal"`
+	Name        string `json:"name"`
+	IsForwarder bool   `json:"is_forwarder,omitempty"`
+	IsConstant  bool   `json:"is_constant,omitempty"`
+	IsData      bool   `json:"is_data,omitempty"`
+	IsPrivate   bool   `json:"is_private,omitempty"`
+	IsNoName    bool   `json:"is_no_name,omitempty"`
+}
+
+// EmbeddedSource represents a source file injected into the PDB.
+type EmbeddedSource struct {
+	Name        string `json:"name"`
+	ObjectName  string `json:"object_name,omitempty"`
+	Compression string `json:"compression"` // "none", "deflate", "rle", "huffman", "lz"
+	IsVirtual   bool   `json:"is_virtual,omitempty"`
+	Content     []byte `json:"-"`
+}
+
+// HeapAllocSite represents a heap allocation call site (S_HEAPALLOCSITE).
+type HeapAllocSite struct {
+	Offset            uint32 `json:"offset"`
+	Segment           uint16 `json:"segment"`
+	RVA               uint32 `json:"rva"` // RVA of the call instruction
+	InstructionLength uint16 `json:"instruction_length"`
+	TypeIndex         uint32 `json:"type_index"`
+	TypeName          string `json:"type_name"` // Type of the allocated object
+	Module            string `json:"module,omitempty"`
+}
+
+// LocalVar represents a local variable or parameter of a function. Variables
+// described by S_REGREL32 or S_REGISTER carry no parameter flag, so IsParam
+// is only set for S_LOCAL records.
+type LocalVar struct {
+	Name        string          `json:"name"`
+	TypeIndex   uint32          `json:"type_index"`
+	TypeName    string          `json:"type_name"`
+	IsParam     bool            `json:"is_param"`
+	Flags       uint16          `json:"flags"`
+	BlockRVA    uint32          `json:"block_rva,omitempty"`    // Start of the enclosing lexical block (0 for function scope)
+	BlockLength uint32          `json:"block_length,omitempty"` // Length of the enclosing lexical block
+	Block       int             `json:"block"`                  // Index of the enclosing block in Blocks(fn), or -1
+	Locations   []LocalLocation `json:"locations,omitempty"`
+}
+
+// LocalLocation describes where a local variable lives over a code range.
+type LocalLocation struct {
+	Kind         string `json:"kind"`                    // "register", "frame_relative", "register_relative", "subfield_register", "program"
+	Register     uint16 `json:"register,omitempty"`      // CodeView register number
+	RegisterName string `json:"register_name,omitempty"` // Register name for the target machine (e.g. "RSP")
+	Offset       int32  `json:"offset,omitempty"`        // Frame pointer or base register offset
+	OffsetParent uint16 `json:"offset_parent,omitempty"` // Offset within the parent variable (subfields)
+	FullScope    bool   `json:"full_scope,omitempty"`    // Valid for the whole function
+	RVA          uint32 `json:"rva,omitempty"`           // Start of the valid range
+	Length       uint16 `json:"length,omitempty"`        // Length of the valid range
+	Gaps         []Gap  `json:"gaps,omitempty"`          // Sub-ranges where the location is not valid
+}
+
+// Gap is a sub-range of a LocalLocation where it does not apply.
+type Gap struct {
+	Offset uint16 `json:"offset"` // Offset from the range start
+	Length uint16 `json:"length"`
+}
+
+// Block represents a lexical block (S_BLOCK32) within a function.
+type Block struct {
+	Name   string `json:"name,omitempty"`
+	RVA    uint32 `json:"rva"`
+	Length uint32 `json:"length"`
+	Parent int    `json:"parent"` // Index of the enclosing block, or -1
+}
+
+// InlineSite represents a call site inlined into a function.
+type InlineSite struct {
+	Name    string           `json:"name"`
+	Inlinee uint32           `json:"inlinee"` // ID index of the inlined function
+	Parent  int              `json:"parent"`  // Index of the enclosing inline site, or -1
+	Lines   []InlineLineInfo `json:"lines,omitempty"`
+}
+
+// InlineLineInfo maps a range of inlined code to a source line.
+type InlineLineInfo struct {
+	RVA        uint32 `json:"rva"`
+	Length     uint32 `json:"length,omitempty"`
+	FileName   string `json:"file_name"`
+	LineNumber uint32 `json:"line_number"`
+}
+
 // TypeInfo represents a parsed type.
 type TypeInfo struct {
 	Index     uint32   `json:"index"`
@@ -38,13 +206,82 @@ type TypeInfo struct {
 	Size      uint64   `json:"size,omitempty"`
 	Signature string   `json:"signature"`
 	Members   []Member `json:"members,omitempty"`
+	Methods   []Method `json:"methods,omitempty"`
+
+	// Types declared inside a struct, class or union
+	NestedTypes []NestedType `json:"nested_types,omitempty"`
+
+	// UnderlyingType is the integer type of an enum; Members then hold
+	// the enumerates, with TypeName their decimal value in that type
+	UnderlyingType string `json:"underlying_type,omitempty"`
+
+	// TrailingPadding is the number of unused bytes after the last member
+	TrailingPadding uint64 `json:"trailing_padding,omitempty"`
+
+	// VTableShape names the kind of each vtable slot of a class with a
+	// vfuncptr, such as "near32"; its length is the slot count
+	VTableShape []string `json:"vtable_shape,omitempty"`
+
+	// Set by WalkTypes and AllTypes only: the LF_* leaf kind, and the
+	// indices of the non-builtin types the record refers to
+	Leaf       string   `json:"leaf,omitempty"`
+	References []uint32 `json:"references,omitempty"`
+
+	// IsForwardRef is set when the type index names a forward declaration;
+	// the other fields then describe the complete definition, if found.
+	IsForwardRef bool `json:"is_forward_ref,omitempty"`
+
+	// Set only when the PDB is opened WithRawTypes: the LF_* kind and data
+	// of the record, excluding its length and kind prefix
+	RawKind uint16 `json:"raw_kind,omitempty"`
+	Raw     []byte `json:"raw,omitempty"`
+
+	// Definition site, from LF_UDT_SRC_LINE / LF_UDT_MOD_SRC_LINE
+	SourceFile   string `json:"source_file,omitempty"`
+	SourceLine   uint32 `json:"source_line,omitempty"`
+	SourceModule string `json:"source_module,omitempty"`
+}
+
+// typeSource records where a user-defined type was defined.
+type typeSource struct {
+	SourceFile string
+	Line       uint32
+	Module     string // Contributing module (LF_UDT_MOD_SRC_LINE only)
 }
 
 // Member represents a struct/class/union member.
 type Member struct {
-	Name     string `json:"name"`
-	TypeName string `json:"type_name"`
-	Offset   uint64 `json:"offset"`
+	Name          string `json:"name"`
+	TypeName      string `json:"type_name"`
+	TypeIndex     uint32 `json:"type_index,omitempty"`
+	Offset        uint64 `json:"offset"`
+	Size          uint64 `json:"size,omitempty"` // Size of the member's type, if known
+	IsStatic      bool   `json:"is_static,omitempty"`
+	IsVirtualBase bool   `json:"is_virtual_base,omitempty"`
+	PaddingBefore uint64 `json:"padding_before,omitempty"` // Unused bytes after the previous member
+
+	// Bitfield members only; Offset is the storage unit's offset
+	BitWidth    uint8 `json:"bit_width,omitempty"`
+	BitPosition uint8 `json:"bit_position,omitempty"`
+}
+
+// Method represents a member function of a struct/class/union.
+// Overloads appear as separate entries sharing a name.
+type Method struct {
+	Name         string `json:"name"`
+	TypeIndex    uint32 `json:"type_index"`
+	TypeName     string `json:"type_name"`
+	Signature    string `json:"signature"` // Type with the method name, e.g. "int32 __thiscall Foo::bar(char)"
+	Attributes   uint16 `json:"attributes"`
+	VtableOffset uint32 `json:"vtable_offset,omitempty"`
+}
+
+// NestedType is a type declared inside a struct, class or union, such as a
+// nested enum or a member typedef.
+type NestedType struct {
+	Name      string `json:"name"` // Name within the enclosing type
+	TypeIndex uint32 `json:"type_index"`
+	TypeName  string `json:"type_name"` // Resolved type, e.g. "A::E"
 }
 
 // PublicSymbol represents a public symbol from the public symbol stream.
@@ -55,6 +292,50 @@ type PublicSymbol struct {
 	Offset        uint32 `json:"offset"`
 	Segment       uint16 `json:"segment"`
 	RVA           uint32 `json:"rva"`
+	Flags         uint32 `json:"flags"` // Raw CV_PUBSYMFLAGS
+	IsCode        bool   `json:"is_code,omitempty"`
+	IsFunction    bool   `json:"is_function,omitempty"`
+	IsManaged     bool   `json:"is_managed,omitempty"`
+	IsMSIL        bool   `json:"is_msil,omitempty"`
+}
+
+// Constant represents a named constant symbol (S_CONSTANT).
+type Constant struct {
+	Name          string `json:"name"`
+	DemangledName string `json:"demangled_name,omitempty"`
+	TypeIndex     uint32 `json:"type_index"`
+	TypeName      string `json:"type_name"`
+	Value         string `json:"value"` // Decimal, signed unless the type is unsigned; other leaves per streams.Numeric
+}
+
+// SymbolKind classifies the entries of Symbols.
+type SymbolKind string
+
+// Symbol kinds
+const (
+	SymbolFunction SymbolKind = "function" // Function (S_GPROC32, S_LPROC32)
+	SymbolData     SymbolKind = "data"     // Variable (S_GDATA32, S_LDATA32)
+	SymbolThread   SymbolKind = "thread"   // Thread-local variable (S_GTHREAD32, S_LTHREAD32)
+	SymbolThunk    SymbolKind = "thunk"    // Thunk (S_THUNK32)
+	SymbolPublic   SymbolKind = "public"   // Public symbol with no other record at its RVA
+)
+
+// Symbol is an addressable symbol of any kind, as listed by Symbols.
+type Symbol struct {
+	Name          string     `json:"name"`
+	DemangledName string     `json:"demangled_name,omitempty"`
+	RVA           uint32     `json:"rva"` // Zero for thread-local variables
+	Kind          SymbolKind `json:"kind"`
+	TypeName      string     `json:"type_name,omitempty"` // Signature of functions, type of variables
+	Module        string     `json:"module,omitempty"`
+}
+
+// UDT associates a user-defined type name, including typedefs, with a
+// type index (S_UDT).
+type UDT struct {
+	Name      string `json:"name"`
+	TypeIndex uint32 `json:"type_index"`
+	TypeName  string `json:"type_name"`
 }
 
 // SectionInfo represents a PE section.
@@ -65,6 +346,41 @@ type SectionInfo struct {
 	Length uint32 `json:"length"`           // Section length in bytes
 }
 
+// ImageSection is a section of the linked image as the linker recorded it
+// in the symbols of its "* Linker *" module (S_SECTION).
+type ImageSection struct {
+	Index           uint16      `json:"index"` // 1-based section number
+	Name            string      `json:"name"`
+	RVA             uint32      `json:"rva"`
+	Length          uint32      `json:"length"`
+	Characteristics uint32      `json:"characteristics"` // IMAGE_SCN_* flags
+	Alignment       uint32      `json:"alignment"`       // In bytes
+	Groups          []CoffGroup `json:"groups,omitempty"`
+}
+
+// CoffGroup is a COFF group within an image section (S_COFFGROUP): the
+// merged contributions of like-named input sections, such as ".text$mn"
+// or ".CRT$XCU".
+type CoffGroup struct {
+	Name            string `json:"name"`
+	Offset          uint32 `json:"offset"` // Offset within the section
+	RVA             uint32 `json:"rva"`
+	Length          uint32 `json:"length"`
+	Characteristics uint32 `json:"characteristics"`
+}
+
+// LineInfo maps a code address to a source line.
+type LineInfo struct {
+	RVA         uint32 `json:"rva"`
+	Segment     uint16 `json:"segment"`
+	Offset      uint32 `json:"offset"`
+	FileName    string `json:"file_name"`
+	LineNumber  uint32 `json:"line_number"`
+	ColumnStart uint16 `json:"column_start,omitempty"`
+	IsStatement bool   `json:"is_statement"`
+	Module      string `json:"module,omitempty"`
+}
+
 // ModuleInfo represents information about a compiled module.
 type ModuleInfo struct {
 	Name          string `json:"name"`
@@ -72,6 +388,55 @@ type ModuleInfo struct {
 	SymbolStream  uint16 `json:"symbol_stream"`
 	SymbolSize    uint32 `json:"symbol_size"`
 	SourceFiles   uint16 `json:"source_files"`
+	FileNames     []string `json:"file_names,omitempty"`
+
+	// From the DBI Edit-and-Continue name table
+	SourceFileName string `json:"source_file_name,omitempty"` // Primary source file
+	PdbFilePath    string `json:"pdb_file_path,omitempty"`    // Compiler PDB, e.g. a type server
+
+	// From the module's S_OBJNAME and S_ENVBLOCK records
+	ObjNameSignature uint32            `json:"obj_name_signature,omitempty"`
+	BuildEnv         map[string]string `json:"build_env,omitempty"` // e.g. "cwd", "exe", "src", "cmd"
+}
+
+// Contribution is a range of an image contributed by a single module.
+type Contribution struct {
+	ModuleIndex     uint16 `json:"module_index"`
+	ModuleName      string `json:"module_name"`
+	RVA             uint32 `json:"rva"`
+	Size            uint32 `json:"size"`
+	Characteristics uint32 `json:"characteristics"` // IMAGE_SCN_* flags of the contribution
+}
+
+// CompileInfo describes the compiler that built a module.
+type CompileInfo struct {
+	Module          string `json:"module"`
+	Language        string `json:"language"`
+	Machine         string `json:"machine"`
+	FrontendVersion string `json:"frontend_version"`
+	BackendVersion  string `json:"backend_version"`
+	CompilerName    string `json:"compiler_name"`
+	Flags           uint32 `json:"flags"`
+}
+
+// CompileCommand is the compiler invocation of a module, from its
+// S_BUILDINFO record and the LF_BUILDINFO record it references.
+type CompileCommand struct {
+	Module      string `json:"module"`
+	Directory   string `json:"directory"`          // Working directory
+	Compiler    string `json:"compiler"`           // e.g. the path of cl.exe
+	SourceFile  string `json:"source_file"`        // Relative to Directory unless absolute
+	PDBFile     string `json:"pdb_file,omitempty"` // Compiler PDB (/Fd)
+	CommandLine string `json:"command_line"`       // Arguments, without the compiler or source file
+}
+
+// TypeServerRef identifies the external PDB that holds the types of
+// modules compiled with /Zi against a type server. GUID and Age are raw,
+// for comparison with the candidate's Identity or Matches.
+type TypeServerRef struct {
+	GUID [16]byte `json:"guid"`
+	Age  uint32   `json:"age"`
+	Name string   `json:"name"`
 }
 
 // PDBInfo contains basic PDB file information.
@@ -80,6 +445,29 @@ type PDBInfo struct {
 	Age       uint32            `json:"age"`
 	Version   uint32            `json:"version"`
 	Machine   string            `json:"machine"`
+	Toolset   string            `json:"toolset,omitempty"`
 	Streams   int               `json:"streams"`
 	NamedStreams map[string]uint32 `json:"named_streams,omitempty"`
+
+	// Decoded from the DBI header flags
+	IncrementallyLinked    bool `json:"incrementally_linked,omitempty"`
+	PrivateSymbolsStripped bool `json:"private_symbols_stripped,omitempty"`
+	HasConflictingTypes    bool `json:"has_conflicting_types,omitempty"`
+}
+
+// LinkInfo describes the link that produced the PDB (/LinkInfo stream).
+type LinkInfo struct {
+	CWD        string   `json:"cwd"`
+	Command    string   `json:"command"`
+	OutputFile string   `json:"output_file,omitempty"`
+	LibPaths   []string `json:"lib_paths,omitempty"`
+}
+
+// StreamInfo describes one stream of the MSF container.
+type StreamInfo struct {
+	Index  int    `json:"index"`
+	Size   uint32 `json:"size"` // 0 for unused slots
+	Blocks int    `json:"blocks"`
+	Name   string `json:"name,omitempty"`   // What the stream holds, if known
+	Unused bool   `json:"unused,omitempty"` // Slot is unused (size 0xFFFFFFFF)
 }
@@ -0,0 +1,106 @@
+package pdb
+
+import (
+	"github.com/jtang613/gopdb/pkg/pdb/codeview"
+	"github.com/jtang613/gopdb/pkg/pdb/streams"
+)
+
+// extTypeServer is the type stream of an external type server PDB.
+type extTypeServer struct {
+	tpi      *streams.TPIStream
+	resolver *codeview.TypeResolver
+}
+
+// TPI returns the parsed TPI stream, or nil if the PDB has none. A PDB
+// serving as another PDB's type server can supply it to
+// SetTypeServerResolver.
+func (p *PDB) TPI() *streams.TPIStream {
+	return p.tpi
+}
+
+// TypeServers returns the external type server PDBs referenced by the DBI
+// type server map and by LF_TYPESERVER records in the TPI stream, without
+// duplicates.
+func (p *PDB) TypeServers() []TypeServerRef {
+	p.typeSrvsOnce.Do(p.loadTypeServers)
+	return p.typeSrvs
+}
+
+// loadTypeServers collects the type server references into the cache.
+func (p *PDB) loadTypeServers() {
+	seen := make(map[TypeServerRef]bool)
+	add := func(ts streams.TypeServer) {
+		ref := TypeServerRef{GUID: ts.GUID, Age: ts.Age, Name: ts.Name}
+		if !seen[ref] {
+			seen[ref] = true
+			p.typeSrvs = append(p.typeSrvs, ref)
+		}
+	}
+
+	if p.dbi != nil {
+		for _, ts := range p.dbi.TypeServers {
+			add(ts)
+		}
+	}
+	if p.tpi != nil {
+		records, _ := p.tpi.Records()
+		for i := range records {
+			if !streams.IsTypeServerKind(records[i].Kind) {
+				continue
+			}
+			if ts, err := streams.ParseTypeServer(records[i].Data); err == nil {
+				add(*ts)
+			}
+		}
+	}
+}
+
+// SetTypeServerResolver lets type indices that have no record in this
+// PDB's TPI stream resolve against external type servers. fn is called
+// once for each reference returned by TypeServers and returns the type
+// server's TPI stream, or nil if it is unavailable. Names and signatures
+// from ResolveType and the symbol accessors then fall through to the
+// returned streams, tried in order; indices present in this PDB still
+// resolve locally. Passing nil removes the type servers.
+//
+// It is safe to call concurrently with the accessors, but results they
+// have already cached, such as Functions, are not recomputed, so call it
+// right after opening the PDB for the type servers to apply to every
+// result. It has no effect on a PDB without a TPI stream.
+func (p *PDB) SetTypeServerResolver(fn func(ref TypeServerRef) *streams.TPIStream) {
+	var extTypes []extTypeServer
+	var fallbacks []*codeview.TypeResolver
+	if fn != nil {
+		for _, ref := range p.TypeServers() {
+			tpi := fn(ref)
+			if tpi == nil {
+				continue
+			}
+			r := codeview.NewTypeResolver(tpi)
+			extTypes = append(extTypes, extTypeServer{tpi: tpi, resolver: r})
+			fallbacks = append(fallbacks, r)
+		}
+	}
+
+	p.extMu.Lock()
+	p.extTypes = extTypes
+	p.extMu.Unlock()
+	if p.resolver != nil {
+		p.resolver.SetFallbacks(fallbacks...)
+	}
+}
+
+// externalType returns the record of a type index from the first external
+// type server that has it, with that server's resolver.
+func (p *PDB) externalType(index uint32) (*codeview.TypeResolver, *streams.TypeRecord) {
+	p.extMu.RLock()
+	extTypes := p.extTypes
+	p.extMu.RUnlock()
+
+	for _, ts := range extTypes {
+		if rec := ts.tpi.GetType(index); rec != nil {
+			return ts.resolver, rec
+		}
+	}
+	return nil, nil
+}